@@ -0,0 +1,315 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/miner/conformance"
+	"github.com/ethereum/go-ethereum/miner/divergence"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	vectorsOutputFlag = &cli.StringFlag{
+		Name:  "output",
+		Usage: "Directory to write the vectors to, one JSON file each (default = stdout)",
+	}
+	poolDumpFlag = &cli.StringFlag{
+		Name:     "pool-dump",
+		Usage:    "JSON file with the pool content, as returned by txpool_content",
+		Required: true,
+	}
+	simBaseFeeFlag = &cli.StringFlag{
+		Name:  "basefee",
+		Usage: "Base fee of the simulated block in wei",
+		Value: strconv.FormatInt(params.GWei, 10),
+	}
+	simMinTipFlag = &cli.StringFlag{
+		Name:  "mintip",
+		Usage: "Comma separated minimum tips in wei to simulate",
+		Value: miner.DefaultConfig.GasPrice.String(),
+	}
+	simGasLimitFlag = &cli.StringFlag{
+		Name:  "gaslimit",
+		Usage: "Comma separated batch gas limits to simulate",
+		Value: strconv.FormatUint(miner.DefaultConfig.GasCeil, 10),
+	}
+	simLocalsFlag = &cli.StringFlag{
+		Name:  "locals",
+		Usage: "Comma separated accounts to treat as locals (nothing is forwarded without pending local txs)",
+	}
+	simExcludeFlag = &cli.StringFlag{
+		Name:  "exclude",
+		Usage: "Comma separated contracts whose txs aren't forwarded, nor the later txs of their senders",
+	}
+	diffOtherFlag = &cli.StringFlag{
+		Name:     "other",
+		Usage:    "Data directory of the executor to compare against",
+		Required: true,
+	}
+	executorCommand = &cli.Command{
+		Name:        "executor",
+		Usage:       "A set of commands for the consensus layer executor",
+		Description: "",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "vectors",
+				Usage:  "Generate conformance test vectors of the executor protocol",
+				Action: generateVectors,
+				Flags:  []cli.Flag{vectorsOutputFlag},
+				Description: `
+geth executor vectors [--output <dir>]
+generates golden test vectors for consensus layer implementations: canonically
+encoded ExecBlocks executed on top of a fixed genesis, along with the headers
+and receipts the executor derives from them.
+`,
+			},
+			{
+				Name:   "simulate-forwarding",
+				Usage:  "Run the tx forwarding policy against a pool snapshot",
+				Action: simulateForwarding,
+				Flags:  []cli.Flag{poolDumpFlag, simBaseFeeFlag, simMinTipFlag, simGasLimitFlag, simLocalsFlag, simExcludeFlag},
+				Description: `
+geth executor simulate-forwarding --pool-dump pool.json [--mintip <wei,...>] [--gaslimit <gas,...>]
+selects the txs the executor would forward to consensus from the pending txs of
+a pool snapshot, for every combination of the given minimum tips and gas limits,
+and prints the composition, gas utilization and fee revenue of each batch. The
+snapshot is the output of txpool_content, e.g. dumped with
+
+  geth attach --exec 'JSON.stringify(txpool.content)' > pool.json
+`,
+			},
+			{
+				Name:   "diff",
+				Usage:  "Locate the first block two executor databases diverge at",
+				Action: diffExecutors,
+				Flags:  flags.Merge([]cli.Flag{diffOtherFlag}, utils.DatabaseFlags),
+				Description: `
+geth executor diff --other <datadir>
+compares the canonical chain of the local executor database with the one of
+another executor, e.g. a copy of a replica's data directory, and reports the
+first block they diverge at: the header fields and receipts the blocks differ
+in and, if their state roots differ, the post state of the accounts the block
+touched. Both databases are opened read-only, the nodes must be stopped.
+`,
+			},
+		},
+	}
+)
+
+func generateVectors(ctx *cli.Context) error {
+	vectors, err := conformance.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate vectors: %v", err)
+	}
+	dir := ctx.String(vectorsOutputFlag.Name)
+	if dir == "" {
+		out, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, v := range vectors {
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, v.Name+".json")
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return err
+		}
+		log.Info("Wrote conformance vector", "name", v.Name, "blocks", len(v.Blocks), "path", path)
+	}
+	return nil
+}
+
+func diffExecutors(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	local := utils.MakeChainDatabase(ctx, stack, true)
+	defer local.Close()
+
+	otherStack, err := node.New(&node.Config{Name: clientIdentifier, DataDir: ctx.String(diffOtherFlag.Name)})
+	if err != nil {
+		return fmt.Errorf("failed to open other node: %v", err)
+	}
+	defer otherStack.Close()
+
+	other, err := otherStack.OpenDatabaseWithFreezer("chaindata", 0, 0, "", "", true)
+	if err != nil {
+		return fmt.Errorf("failed to open other database: %v", err)
+	}
+	defer other.Close()
+
+	report, err := divergence.Compare(local, other)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Heads: local #%d, other #%d\n", report.LocalHead, report.OtherHead)
+	if !report.Diverged {
+		fmt.Printf("No divergence up to block #%d\n", report.Common)
+		return nil
+	}
+	fmt.Printf("First divergent block: #%d (local %x, other %x)\n\n", report.Local.Number, report.Local.Hash(), report.Other.Hash())
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Header", "Local", "Other"})
+	for _, d := range report.Header {
+		table.Append([]string{d.Field, d.Local, d.Other})
+	}
+	table.Render()
+
+	if len(report.Receipts) > 0 {
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Receipt", "Tx", "Field", "Local", "Other"})
+		for _, r := range report.Receipts {
+			for _, d := range r.Diffs {
+				table.Append([]string{strconv.Itoa(r.Index), r.TxHash.Hex(), d.Field, d.Local, d.Other})
+			}
+		}
+		table.Render()
+	}
+	if report.StateErr != nil {
+		fmt.Printf("Post states not compared: %v\n", report.StateErr)
+	}
+	if len(report.Accounts) > 0 {
+		table = tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Account", "Field", "Local", "Other"})
+		for _, a := range report.Accounts {
+			for _, d := range a.Diffs {
+				table.Append([]string{a.Address.Hex(), d.Field, d.Local, d.Other})
+			}
+		}
+		table.Render()
+	}
+	return nil
+}
+
+// poolDump is the pool content as returned by txpool_content.
+type poolDump struct {
+	Pending map[common.Address]map[string]*types.Transaction `json:"pending"`
+}
+
+func simulateForwarding(ctx *cli.Context) error {
+	blob, err := os.ReadFile(ctx.String(poolDumpFlag.Name))
+	if err != nil {
+		return err
+	}
+	var dump poolDump
+	if err := json.Unmarshal(blob, &dump); err != nil {
+		return fmt.Errorf("invalid pool dump: %v", err)
+	}
+	var (
+		pending = make(map[common.Address]types.Transactions)
+		config  = *params.AllEthashProtocolChanges
+	)
+	for from, txs := range dump.Pending {
+		for _, tx := range txs {
+			pending[from] = append(pending[from], tx)
+			if tx.Protected() {
+				config.ChainID = tx.ChainId()
+			}
+		}
+		sort.Sort(types.TxByNonce(pending[from]))
+	}
+	baseFee, ok := new(big.Int).SetString(ctx.String(simBaseFeeFlag.Name), 10)
+	if !ok {
+		return fmt.Errorf("invalid base fee %q", ctx.String(simBaseFeeFlag.Name))
+	}
+	var locals []common.Address
+	for _, account := range splitList(ctx.String(simLocalsFlag.Name)) {
+		if !common.IsHexAddress(account) {
+			return fmt.Errorf("invalid local account %q", account)
+		}
+		locals = append(locals, common.HexToAddress(account))
+	}
+	var exclude []common.Address
+	for _, contract := range splitList(ctx.String(simExcludeFlag.Name)) {
+		if !common.IsHexAddress(contract) {
+			return fmt.Errorf("invalid excluded contract %q", contract)
+		}
+		exclude = append(exclude, common.HexToAddress(contract))
+	}
+	var policies []miner.ForwardingPolicy
+	for _, tip := range splitList(ctx.String(simMinTipFlag.Name)) {
+		minTip, ok := new(big.Int).SetString(tip, 10)
+		if !ok {
+			return fmt.Errorf("invalid minimum tip %q", tip)
+		}
+		for _, limit := range splitList(ctx.String(simGasLimitFlag.Name)) {
+			gasLimit, err := strconv.ParseUint(limit, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid gas limit %q: %v", limit, err)
+			}
+			policies = append(policies, miner.ForwardingPolicy{MinTip: minTip, GasLimit: gasLimit, Locals: locals, Exclude: exclude})
+		}
+	}
+	head := &types.Header{Number: common.Big1, BaseFee: baseFee}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Min tip", "Gas limit", "Txs", "Locals", "Senders", "Held", "Gas", "Utilization", "Fees (wei)"})
+	for _, policy := range policies {
+		report := miner.SimulateForwarding(&config, head, pending, policy)
+		table.Append([]string{
+			policy.MinTip.String(),
+			strconv.FormatUint(policy.GasLimit, 10),
+			strconv.Itoa(report.Txs),
+			strconv.Itoa(report.Locals),
+			strconv.Itoa(report.Senders),
+			strconv.Itoa(report.Held),
+			strconv.FormatUint(report.Gas, 10),
+			fmt.Sprintf("%.2f%%", 100*report.Utilization()),
+			report.Fees.String(),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
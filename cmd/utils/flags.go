@@ -292,6 +292,11 @@ var (
 		Usage:    "Disables price exemptions for locally submitted transactions",
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolNoGossipFlag = &cli.BoolFlag{
+		Name:     "txpool.nogossip",
+		Usage:    "Disables the exchange of transactions with peers (transactions travel via the consensus layer only)",
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolJournalFlag = &cli.StringFlag{
 		Name:     "txpool.journal",
 		Usage:    "Disk journal for local transaction to survive node restarts",
@@ -447,6 +452,11 @@ var (
 		Usage:    "0x prefixed public address for block mining rewards",
 		Category: flags.MinerCategory,
 	}
+	MinerDevEtherbaseFlag = &cli.BoolFlag{
+		Name:     "miner.devetherbase",
+		Usage:    "Use (or create) a keystore account as etherbase if none is configured, the account is not funded (testing only)",
+		Category: flags.MinerCategory,
+	}
 	MinerExtraDataFlag = &cli.StringFlag{
 		Name:     "miner.extradata",
 		Usage:    "Block extra data set by the miner (default = client version)",
@@ -464,6 +474,38 @@ var (
 		Value:    ethconfig.Defaults.Miner.NewPayloadTimeout,
 		Category: flags.MinerCategory,
 	}
+	MinerExecutorAddrFlag = &cli.StringFlag{
+		Name:     "miner.executor.addr",
+		Usage:    "Listening address of the executor gRPC API served to the consensus layer (host:port or unix:///path/to.sock)",
+		Value:    ethconfig.Defaults.Miner.ExecutorListenAddr,
+		Category: flags.MinerCategory,
+	}
+	MinerExecutorEndpointFlag = &cli.StringFlag{
+		Name:     "miner.executor.endpoint",
+		Usage:    "Node endpoint the executor gRPC API is served on instead of its own address (only \"auth\", requiring a JWT)",
+		Category: flags.MinerCategory,
+	}
+	MinerConsensusAddrFlag = &cli.StringFlag{
+		Name:     "miner.consensus.addr",
+		Usage:    "Address of the consensus layer gRPC API (host:port or unix:///path/to.sock)",
+		Value:    ethconfig.Defaults.Miner.ConsensusAddr,
+		Category: flags.MinerCategory,
+	}
+	MinerConsensusReplicasFlag = &cli.StringFlag{
+		Name:     "miner.consensus.replicas",
+		Usage:    "Comma separated addresses of further consensus replicas failed over to while the ones before are down",
+		Category: flags.MinerCategory,
+	}
+	MinerConsensusBalanceFlag = &cli.BoolFlag{
+		Name:     "miner.consensus.balance",
+		Usage:    "Spread the forwarded transactions round-robin over the healthy consensus replicas",
+		Category: flags.MinerCategory,
+	}
+	MinerDeterminismCheckFlag = &cli.BoolFlag{
+		Name:     "miner.determinism",
+		Usage:    "Refuse to start with VM settings altering the execution of the consensus blocks locally (tracers, extra EIPs, disabled base fee)",
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -1511,6 +1553,24 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerExecutorAddrFlag.Name) {
+		cfg.ExecutorListenAddr = ctx.String(MinerExecutorAddrFlag.Name)
+	}
+	if ctx.IsSet(MinerExecutorEndpointFlag.Name) {
+		cfg.ExecutorEndpoint = ctx.String(MinerExecutorEndpointFlag.Name)
+	}
+	if ctx.IsSet(MinerConsensusAddrFlag.Name) {
+		cfg.ConsensusAddr = ctx.String(MinerConsensusAddrFlag.Name)
+	}
+	if ctx.IsSet(MinerConsensusReplicasFlag.Name) {
+		cfg.ConsensusAddrs = SplitAndTrim(ctx.String(MinerConsensusReplicasFlag.Name))
+	}
+	if ctx.IsSet(MinerConsensusBalanceFlag.Name) {
+		cfg.ConsensusBalance = ctx.Bool(MinerConsensusBalanceFlag.Name)
+	}
+	if ctx.IsSet(MinerDeterminismCheckFlag.Name) {
+		cfg.DeterminismCheck = ctx.Bool(MinerDeterminismCheckFlag.Name)
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1641,6 +1701,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.IsSet(TxPoolNoGossipFlag.Name) {
+		cfg.NoTxGossip = ctx.Bool(TxPoolNoGossipFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.Bool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -1759,47 +1822,14 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.NetworkId = 1337
 		}
 		cfg.SyncMode = downloader.FullSync
-		// Create new developer account or reuse existing one
-		var (
-			developer  accounts.Account
-			passphrase string
-			err        error
-		)
-		if list := MakePasswordList(ctx); len(list) > 0 {
-			// Just take the first value. Although the function returns a possible multiple values and
-			// some usages iterate through them as attempts, that doesn't make sense in this setting,
-			// when we're definitely concerned with only one account.
-			passphrase = list[0]
-		}
 
-		// Unlock the developer account by local keystore.
-		var ks *keystore.KeyStore
-		if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
-			ks = keystores[0].(*keystore.KeyStore)
-		}
-		if ks == nil {
-			Fatalf("Keystore is not available")
-		}
-
-		// Figure out the dev account address.
+		// Create new developer account or reuse existing one.
 		// setEtherbase has been called above, configuring the miner address from command line flags.
-		if cfg.Miner.Etherbase != (common.Address{}) {
-			developer = accounts.Account{Address: cfg.Miner.Etherbase}
-		} else if accs := ks.Accounts(); len(accs) > 0 {
-			developer = ks.Accounts()[0]
-		} else {
-			developer, err = ks.NewAccount(passphrase)
-			if err != nil {
-				Fatalf("Failed to create developer account: %v", err)
-			}
-		}
+		developer := developerAccount(ctx, stack, cfg.Miner.Etherbase)
+
 		// Make sure the address is configured as fee recipient, otherwise
 		// the miner will fail to start.
 		cfg.Miner.Etherbase = developer.Address
-
-		if err := ks.Unlock(developer, passphrase); err != nil {
-			Fatalf("Failed to unlock developer account: %v", err)
-		}
 		log.Info("Using developer account", "address", developer.Address)
 
 		// Create a new developer genesis block or reuse existing one
@@ -1834,6 +1864,14 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			SetDNSDiscoveryDefaults(cfg, params.MainnetGenesisHash)
 		}
 	}
+	// Fall back to a developer account as etherbase if requested, sparing single
+	// node setups the manual account management. Unlike with --dev the account
+	// isn't funded in the genesis, it only collects the fees and rewards.
+	if ctx.Bool(MinerDevEtherbaseFlag.Name) && cfg.Miner.Etherbase == (common.Address{}) {
+		developer := developerAccount(ctx, stack, common.Address{})
+		cfg.Miner.Etherbase = developer.Address
+		log.Warn("Using developer account as etherbase", "address", developer.Address)
+	}
 	// Set any dangling config values
 	if ctx.String(CryptoKZGFlag.Name) != "gokzg" && ctx.String(CryptoKZGFlag.Name) != "ckzg" {
 		Fatalf("--%s flag must be 'gokzg' or 'ckzg'", CryptoKZGFlag.Name)
@@ -1844,6 +1882,48 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	}
 }
 
+// developerAccount returns the given account, or the first account of the local
+// keystore if none is given, creating one if the keystore is empty. The account
+// is unlocked with the first password of the password file.
+func developerAccount(ctx *cli.Context, stack *node.Node, address common.Address) accounts.Account {
+	var (
+		developer  accounts.Account
+		passphrase string
+		err        error
+	)
+	if list := MakePasswordList(ctx); len(list) > 0 {
+		// Just take the first value. Although the function returns a possible multiple values and
+		// some usages iterate through them as attempts, that doesn't make sense in this setting,
+		// when we're definitely concerned with only one account.
+		passphrase = list[0]
+	}
+
+	// Unlock the developer account by local keystore.
+	var ks *keystore.KeyStore
+	if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
+		ks = keystores[0].(*keystore.KeyStore)
+	}
+	if ks == nil {
+		Fatalf("Keystore is not available")
+	}
+
+	// Figure out the dev account address.
+	if address != (common.Address{}) {
+		developer = accounts.Account{Address: address}
+	} else if accs := ks.Accounts(); len(accs) > 0 {
+		developer = ks.Accounts()[0]
+	} else {
+		developer, err = ks.NewAccount(passphrase)
+		if err != nil {
+			Fatalf("Failed to create developer account: %v", err)
+		}
+	}
+	if err := ks.Unlock(developer, passphrase); err != nil {
+		Fatalf("Failed to unlock developer account: %v", err)
+	}
+	return developer
+}
+
 // SetDNSDiscoveryDefaults configures DNS discovery with the given URL if
 // no URLs are set.
 func SetDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
@@ -0,0 +1,80 @@
+// Package calldatafee implements the calldata fee market of the executor,
+// pricing the calldata of txs per byte separately from their gas.
+package calldatafee
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// excessSlot is the storage slot of the fee market account keeping the excess
+// calldata.
+var excessSlot = common.Hash{}
+
+// StateReader is the state the excess calldata is read from.
+type StateReader interface {
+	GetState(addr common.Address, slot common.Hash) common.Hash
+}
+
+// StateWriter is the state the excess calldata is written to.
+type StateWriter interface {
+	StateReader
+	GetNonce(addr common.Address) uint64
+	SetNonce(addr common.Address, nonce uint64)
+	SetState(addr common.Address, slot common.Hash, value common.Hash)
+}
+
+// ReadExcess returns the excess calldata left by the blocks up to the state.
+func ReadExcess(config *params.CalldataFee, db StateReader) uint64 {
+	return db.GetState(config.Address, excessSlot).Big().Uint64()
+}
+
+// WriteExcess stores the excess calldata left by a block. The account is given
+// a nonce, so it isn't removed as empty at the end of the block.
+func WriteExcess(config *params.CalldataFee, db StateWriter, excess uint64) {
+	if db.GetNonce(config.Address) == 0 {
+		db.SetNonce(config.Address, 1)
+	}
+	db.SetState(config.Address, excessSlot, common.BigToHash(new(big.Int).SetUint64(excess)))
+}
+
+// CalcExcess calculates the excess calldata after a block carrying the given
+// calldata bytes on top of the excess of its parent.
+func CalcExcess(config *params.CalldataFee, parentExcess uint64, used uint64) uint64 {
+	excess := parentExcess + used
+	if excess < config.Target {
+		return 0
+	}
+	return excess - config.Target
+}
+
+// CalcFee calculates the fee per calldata byte from the excess calldata.
+func CalcFee(config *params.CalldataFee, excess uint64) *big.Int {
+	minFee := common.Big1
+	if config.MinFee != nil && config.MinFee.Sign() > 0 {
+		minFee = config.MinFee
+	}
+	if config.UpdateFraction == 0 {
+		return new(big.Int).Set(minFee)
+	}
+	return fakeExponential(minFee, new(big.Int).SetUint64(excess), new(big.Int).SetUint64(config.UpdateFraction))
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using
+// Taylor expansion, the same way EIP-4844 prices blob gas.
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	var (
+		output = new(big.Int)
+		accum  = new(big.Int).Mul(factor, denominator)
+	)
+	for i := 1; accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(int64(i)))
+	}
+	return output.Div(output, denominator)
+}
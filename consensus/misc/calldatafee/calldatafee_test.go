@@ -0,0 +1,52 @@
+package calldatafee
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCalcExcess(t *testing.T) {
+	config := &params.CalldataFee{Target: 1000}
+	var tests = []struct {
+		excess uint64
+		used   uint64
+		want   uint64
+	}{
+		// Blocks at or below the target don't build up excess
+		{0, 0, 0},
+		{0, 1000, 0},
+		// Blocks over the target add however much they overshot
+		{0, 1001, 1},
+		{5, 1500, 505},
+		// Blocks below the target drain the excess, capped at zero
+		{600, 500, 100},
+		{400, 500, 0},
+	}
+	for i, tt := range tests {
+		if have := CalcExcess(config, tt.excess, tt.used); have != tt.want {
+			t.Errorf("test %d: excess calldata mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestCalcFee(t *testing.T) {
+	var tests = []struct {
+		config *params.CalldataFee
+		excess uint64
+		want   int64
+	}{
+		{&params.CalldataFee{UpdateFraction: 1000}, 0, 1},
+		{&params.CalldataFee{UpdateFraction: 1000, MinFee: big.NewInt(16)}, 0, 16},
+		{&params.CalldataFee{UpdateFraction: 1000, MinFee: big.NewInt(16)}, 1000, 43},
+		{&params.CalldataFee{UpdateFraction: 1000, MinFee: big.NewInt(16)}, 2000, 118},
+		// Without an update fraction the fee is fixed
+		{&params.CalldataFee{MinFee: big.NewInt(16)}, 2000, 16},
+	}
+	for i, tt := range tests {
+		if have := CalcFee(tt.config, tt.excess); have.Int64() != tt.want {
+			t.Errorf("test %d: calldata fee mismatch: have %v, want %d", i, have, tt.want)
+		}
+	}
+}
@@ -139,7 +139,22 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	}
 	// Validate the state root against the received state root and throw
 	// an error if they don't match.
-	if root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number)); header.Root != root {
+	root := statedb.IntermediateRoot(v.config.DeleteEmptyAccounts(header.Number))
+	if v.config.Executor.RootDepth(header.Number) > 0 {
+		// The header commits to the post state of an older block instead,
+		// whose state is known by now
+		parent := v.bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return consensus.ErrUnknownAncestor
+		}
+		if root, err := v.bc.DeferredRoot(parent); err != nil {
+			return err
+		} else if header.Root != root {
+			return fmt.Errorf("invalid deferred merkle root (remote: %x local: %x)", header.Root, root)
+		}
+		return statedb.Error()
+	}
+	if header.Root != root {
 		return fmt.Errorf("invalid merkle root (remote: %x local: %x) dberr: %w", header.Root, root, statedb.Error())
 	}
 	return nil
@@ -341,7 +341,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	// Make sure the state associated with the block is available, or log out
 	// if there is no available state, waiting for state sync.
 	head := bc.CurrentBlock()
-	if !bc.HasState(head.Root) {
+	if !bc.HasState(bc.PostStateRoot(head)) {
 		if head.Number.Uint64() == 0 {
 			// The genesis state is missing, which is only possible in the path-based
 			// scheme. This situation occurs when the initial state sync is not finished
@@ -447,7 +447,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 			NoBuild:    bc.cacheConfig.SnapshotNoBuild,
 			AsyncBuild: !bc.cacheConfig.SnapshotWait,
 		}
-		bc.snaps, _ = snapshot.New(snapconfig, bc.db, bc.triedb, head.Root)
+		bc.snaps, _ = snapshot.New(snapconfig, bc.db, bc.triedb, bc.PostStateRoot(head))
 	}
 
 	// Start future block processor.
@@ -670,10 +670,10 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 
 				for {
 					// If a root threshold was requested but not yet crossed, check
-					if root != (common.Hash{}) && !beyondRoot && newHeadBlock.Root() == root {
+					if root != (common.Hash{}) && !beyondRoot && bc.PostStateRoot(newHeadBlock.Header()) == root {
 						beyondRoot, rootNumber = true, newHeadBlock.NumberU64()
 					}
-					if !bc.HasState(newHeadBlock.Root()) && !bc.stateRecoverable(newHeadBlock.Root()) {
+					if headRoot := bc.PostStateRoot(newHeadBlock.Header()); !bc.HasState(headRoot) && !bc.stateRecoverable(headRoot) {
 						log.Trace("Block state missing, rewinding further", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
 						if pivot == nil || newHeadBlock.NumberU64() > *pivot {
 							parent := bc.GetBlock(newHeadBlock.ParentHash(), newHeadBlock.NumberU64()-1)
@@ -689,10 +689,10 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 						}
 					}
 					if beyondRoot || newHeadBlock.NumberU64() == 0 {
-						if !bc.HasState(newHeadBlock.Root()) && bc.stateRecoverable(newHeadBlock.Root()) {
+						if headRoot := bc.PostStateRoot(newHeadBlock.Header()); !bc.HasState(headRoot) && bc.stateRecoverable(headRoot) {
 							// Rewind to a block with recoverable state. If the state is
 							// missing, run the state recovery here.
-							if err := bc.triedb.Recover(newHeadBlock.Root()); err != nil {
+							if err := bc.triedb.Recover(headRoot); err != nil {
 								log.Crit("Failed to rollback state", "err", err) // Shouldn't happen
 							}
 							log.Debug("Rewound to block with state", "number", newHeadBlock.NumberU64(), "hash", newHeadBlock.Hash())
@@ -717,7 +717,7 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, time uint64, root common.Ha
 			// the pivot point. In this scenario, there is no possible recovery
 			// approach except for rerunning a snap sync. Do nothing here until the
 			// state syncer picks it up.
-			if !bc.HasState(newHeadBlock.Root()) {
+			if !bc.HasState(bc.PostStateRoot(newHeadBlock.Header())) {
 				log.Info("Chain is stateless, wait state sync", "number", newHeadBlock.Number(), "hash", newHeadBlock.Hash())
 			}
 		}
@@ -986,14 +986,14 @@ func (bc *BlockChain) Stop() {
 	var snapBase common.Hash
 	if bc.snaps != nil {
 		var err error
-		if snapBase, err = bc.snaps.Journal(bc.CurrentBlock().Root); err != nil {
+		if snapBase, err = bc.snaps.Journal(bc.PostStateRoot(bc.CurrentBlock())); err != nil {
 			log.Error("Failed to journal state snapshot", "err", err)
 		}
 		bc.snaps.Release()
 	}
 	if bc.triedb.Scheme() == rawdb.PathScheme {
 		// Ensure that the in-memory trie nodes are journaled to disk properly.
-		if err := bc.triedb.Journal(bc.CurrentBlock().Root); err != nil {
+		if err := bc.triedb.Journal(bc.PostStateRoot(bc.CurrentBlock())); err != nil {
 			log.Info("Failed to journal in-memory trie nodes", "err", err)
 		}
 	} else {
@@ -1007,10 +1007,11 @@ func (bc *BlockChain) Stop() {
 
 			for _, offset := range []uint64{0, 1, TriesInMemory - 1} {
 				if number := bc.CurrentBlock().Number.Uint64(); number > offset {
-					recent := bc.GetBlockByNumber(number - offset)
+					recent := bc.GetHeaderByNumber(number - offset)
+					root := bc.PostStateRoot(recent)
 
-					log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
-					if err := triedb.Commit(recent.Root(), true); err != nil {
+					log.Info("Writing cached state to disk", "block", recent.Number, "hash", recent.Hash(), "root", root)
+					if err := triedb.Commit(root, true); err != nil {
 						log.Error("Failed to commit recent state trie", "err", err)
 					}
 				}
@@ -1344,6 +1345,19 @@ func (bc *BlockChain) writeKnownBlock(block *types.Block) error {
 // writeBlockWithState writes block, metadata and corresponding state data to the
 // database.
 func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.Receipt, state *state.StateDB) error {
+	if err := bc.writeBlock(block, receipts, state.Preimages()); err != nil {
+		return err
+	}
+	// Commit all cached state changes into underlying memory database.
+	root, err := state.Commit(block.NumberU64(), bc.chainConfig.DeleteEmptyAccounts(block.Number()))
+	if err != nil {
+		return err
+	}
+	return bc.retainState(block, root)
+}
+
+// writeBlock writes a block along with its metadata, but without its state.
+func (bc *BlockChain) writeBlock(block *types.Block, receipts []*types.Receipt, preimages map[common.Hash][]byte) error {
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 	if ptd == nil {
@@ -1360,14 +1374,19 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
 	rawdb.WriteBlock(blockBatch, block)
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
-	rawdb.WritePreimages(blockBatch, state.Preimages())
+	rawdb.WritePreimages(blockBatch, preimages)
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
-	// Commit all cached state changes into underlying memory database.
-	root, err := state.Commit(block.NumberU64(), bc.chainConfig.IsEIP158(block.Number()))
-	if err != nil {
-		return err
+	return nil
+}
+
+// retainState keeps the committed state of a written block around, flushing and
+// garbage collecting the states of older blocks as the cache config mandates.
+func (bc *BlockChain) retainState(block *types.Block, root common.Hash) error {
+	// The header doesn't commit to the post state if the roots are deferred
+	if bc.chainConfig.Executor.RootDepth(block.Number()) > 0 {
+		rawdb.WritePostStateRoot(bc.db, block.Hash(), root)
 	}
 	// If node is running in path mode, skip explicit gc operation
 	// which is unnecessary in this mode.
@@ -1412,7 +1431,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 				log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", flushInterval, "optimum", float64(chosen-bc.lastWrite)/TriesInMemory)
 			}
 			// Flush an entire trie and restart the counters
-			bc.triedb.Commit(header.Root, true)
+			bc.triedb.Commit(bc.PostStateRoot(header), true)
 			bc.lastWrite = chosen
 			bc.gcproc = 0
 		}
@@ -1429,6 +1448,46 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	return nil
 }
 
+// WriteConsensusMeta stores the consensus metadata of a block delivered by the
+// consensus layer and indexes the block under its consensus epoch.
+func (bc *BlockChain) WriteConsensusMeta(hash common.Hash, number uint64, meta *types.ConsensusMeta) {
+	batch := bc.db.NewBatch()
+	rawdb.WriteConsensusMeta(batch, hash, meta)
+	rawdb.WriteConsensusEpochBlock(batch, meta.Epoch, number, hash)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write consensus metadata", "err", err)
+	}
+}
+
+// WriteExecutorInFlight stores the snapshot of the txs the executor forwarded
+// to consensus which weren't included yet.
+func (bc *BlockChain) WriteExecutorInFlight(data []byte) {
+	rawdb.WriteExecutorInFlight(bc.db, data)
+}
+
+// WriteExecutorWAL logs a consensus block acknowledged by the executor under
+// the given index.
+func (bc *BlockChain) WriteExecutorWAL(index uint64, data []byte) {
+	rawdb.WriteExecutorWAL(bc.db, index, data)
+}
+
+// DeleteExecutorWAL removes a consensus block executed by the executor from
+// its log.
+func (bc *BlockChain) DeleteExecutorWAL(index uint64) {
+	rawdb.DeleteExecutorWAL(bc.db, index)
+}
+
+// WriteExecutorQuarantine quarantines a consensus block the executor failed to
+// execute under the given id.
+func (bc *BlockChain) WriteExecutorQuarantine(id uint64, data []byte) {
+	rawdb.WriteExecutorQuarantine(bc.db, id, data)
+}
+
+// DeleteExecutorQuarantine removes a consensus block from the quarantine.
+func (bc *BlockChain) DeleteExecutorQuarantine(id uint64) {
+	rawdb.DeleteExecutorQuarantine(bc.db, id)
+}
+
 // WriteBlockAndSetHead writes the given block and all associated state to the database,
 // and applies the block as the new chain head.
 func (bc *BlockChain) WriteBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
@@ -1446,6 +1505,93 @@ func (bc *BlockChain) writeBlockAndSetHead(block *types.Block, receipts []*types
 	if err := bc.writeBlockWithState(block, receipts, state); err != nil {
 		return NonStatTy, err
 	}
+	return bc.updateHead(block, logs, emitHeadEvent)
+}
+
+// CommittedState is the post state of a block committed to the trie database
+// ahead of writing the block itself.
+type CommittedState struct {
+	root      common.Hash
+	preimages map[common.Hash][]byte
+}
+
+// CommitBlockState commits the post state of a block to the trie database
+// without writing the block, so the state can be opened to execute the next
+// block while this one is written by WriteCommittedBlockAndSetHead. The state
+// mustn't be committed while another block is being written, and is unusable
+// afterwards.
+func (bc *BlockChain) CommitBlockState(block *types.Block, state *state.StateDB) (*CommittedState, error) {
+	preimages := state.Preimages()
+	root, err := state.Commit(block.NumberU64(), bc.chainConfig.DeleteEmptyAccounts(block.Number()))
+	if err != nil {
+		return nil, err
+	}
+	if bc.chainConfig.Executor.RootDepth(block.Number()) == 0 && root != block.Root() {
+		return nil, fmt.Errorf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	return &CommittedState{root: root, preimages: preimages}, nil
+}
+
+// WriteCommittedBlockAndSetHead writes a block whose state was committed ahead
+// by CommitBlockState, and sets it as the head if the fork choice prefers it.
+// The blocks must be written in the order their states were committed.
+func (bc *BlockChain) WriteCommittedBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *CommittedState, emitHeadEvent bool) (status WriteStatus, err error) {
+	if !bc.chainmu.TryLock() {
+		return NonStatTy, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	if err := bc.writeBlock(block, receipts, state.preimages); err != nil {
+		return NonStatTy, err
+	}
+	if err := bc.retainState(block, state.root); err != nil {
+		return NonStatTy, err
+	}
+	return bc.updateHead(block, logs, emitHeadEvent)
+}
+
+// WriteDeferredBlockAndSetHead writes a block whose header commits to a deferred
+// state root without its post state, and sets it as the head if the fork choice
+// prefers it. The post state is committed afterwards by CommitDeferredState, the
+// state of the block can't be opened until then.
+func (bc *BlockChain) WriteDeferredBlockAndSetHead(block *types.Block, receipts []*types.Receipt, logs []*types.Log, emitHeadEvent bool) (status WriteStatus, err error) {
+	if bc.chainConfig.Executor.RootDepth(block.Number()) == 0 {
+		return NonStatTy, errors.New("state roots not deferred")
+	}
+	if !bc.chainmu.TryLock() {
+		return NonStatTy, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	if err := bc.writeBlock(block, receipts, nil); err != nil {
+		return NonStatTy, err
+	}
+	return bc.updateHead(block, logs, emitHeadEvent)
+}
+
+// CommitDeferredState commits the post state of a block written by
+// WriteDeferredBlockAndSetHead, returning its root. The states of the blocks
+// must be committed in the order the blocks were written.
+func (bc *BlockChain) CommitDeferredState(block *types.Block, state *state.StateDB) (common.Hash, error) {
+	// Hashing the tries is the expensive part, which is done without holding up
+	// the writes of the next blocks
+	preimages := state.Preimages()
+	root, err := state.Commit(block.NumberU64(), bc.chainConfig.DeleteEmptyAccounts(block.Number()))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !bc.chainmu.TryLock() {
+		return common.Hash{}, errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	rawdb.WritePreimages(bc.db, preimages)
+	return root, bc.retainState(block, root)
+}
+
+// updateHead sets a written block as the head if the fork choice prefers it,
+// emitting the chain events. This function expects the chain mutex to be held.
+func (bc *BlockChain) updateHead(block *types.Block, logs []*types.Log, emitHeadEvent bool) (status WriteStatus, err error) {
 	currentBlock := bc.CurrentBlock()
 	reorg, err := bc.forker.ReorgNeeded(currentBlock, block.Header())
 	if err != nil {
@@ -1736,7 +1882,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
-		statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
+		statedb, err := bc.StateAt(bc.PostStateRoot(parent))
 		if err != nil {
 			return it.index, err
 		}
@@ -1750,7 +1896,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, setHead bool) (int, error)
 		var followupInterrupt atomic.Bool
 		if !bc.cacheConfig.TrieCleanNoPrefetch {
 			if followup, err := it.peek(); followup != nil && err == nil {
-				throwaway, _ := state.New(parent.Root, bc.stateCache, bc.snaps)
+				throwaway, _ := bc.StateAt(bc.PostStateRoot(parent))
 
 				go func(start time.Time, followup *types.Block, throwaway *state.StateDB) {
 					bc.prefetcher.Prefetch(followup, throwaway, bc.vmConfig, &followupInterrupt)
@@ -1972,9 +2118,9 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 		numbers []uint64
 	)
 	parent := it.previous()
-	for parent != nil && !bc.HasState(parent.Root) {
-		if bc.stateRecoverable(parent.Root) {
-			if err := bc.triedb.Recover(parent.Root); err != nil {
+	for parent != nil && !bc.HasState(bc.PostStateRoot(parent)) {
+		if root := bc.PostStateRoot(parent); bc.stateRecoverable(root) {
+			if err := bc.triedb.Recover(root); err != nil {
 				return 0, err
 			}
 			break
@@ -2034,9 +2180,9 @@ func (bc *BlockChain) recoverAncestors(block *types.Block) (common.Hash, error)
 		numbers []uint64
 		parent  = block
 	)
-	for parent != nil && !bc.HasState(parent.Root()) {
-		if bc.stateRecoverable(parent.Root()) {
-			if err := bc.triedb.Recover(parent.Root()); err != nil {
+	for parent != nil && !bc.HasState(bc.PostStateRoot(parent.Header())) {
+		if root := bc.PostStateRoot(parent.Header()); bc.stateRecoverable(root) {
+			if err := bc.triedb.Recover(root); err != nil {
 				return common.Hash{}, err
 			}
 			break
@@ -2290,7 +2436,7 @@ func (bc *BlockChain) SetCanonical(head *types.Block) (common.Hash, error) {
 	defer bc.chainmu.Unlock()
 
 	// Re-execute the reorged chain in case the head state is missing.
-	if !bc.HasState(head.Root()) {
+	if !bc.HasState(bc.PostStateRoot(head.Header())) {
 		if latestValidHash, err := bc.recoverAncestors(head); err != nil {
 			return latestValidHash, err
 		}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -209,6 +210,48 @@ func (bc *BlockChain) GetBlocksFromHash(hash common.Hash, n int) (blocks []*type
 	return
 }
 
+// GetConsensusMeta retrieves the consensus metadata of a block, nil if the
+// block wasn't delivered by consensus.
+func (bc *BlockChain) GetConsensusMeta(hash common.Hash) *types.ConsensusMeta {
+	return rawdb.ReadConsensusMeta(bc.db, hash)
+}
+
+// GetConsensusEpochBlocks retrieves the canonical blocks decided in the given
+// consensus epoch, in ascending order.
+func (bc *BlockChain) GetConsensusEpochBlocks(epoch uint64) []*rawdb.NumberHash {
+	var blocks []*rawdb.NumberHash
+	for _, block := range rawdb.ReadConsensusEpochBlocks(bc.db, epoch) {
+		if bc.GetCanonicalHash(block.Number) == block.Hash {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// GetExecutorInFlight retrieves the snapshot of the txs the executor forwarded
+// to consensus which weren't included yet.
+func (bc *BlockChain) GetExecutorInFlight() []byte {
+	return rawdb.ReadExecutorInFlight(bc.db)
+}
+
+// GetExecutorWAL retrieves the consensus blocks the executor acknowledged
+// which weren't executed yet, along with their indices in ascending order.
+func (bc *BlockChain) GetExecutorWAL() ([]uint64, [][]byte) {
+	return rawdb.ReadExecutorWAL(bc.db)
+}
+
+// GetExecutorQuarantine retrieves the consensus blocks the executor failed to
+// execute irrecoverably, along with their ids in ascending order.
+func (bc *BlockChain) GetExecutorQuarantine() ([]uint64, [][]byte) {
+	return rawdb.ReadExecutorQuarantine(bc.db)
+}
+
+// GetExecutorQuarantined retrieves a quarantined consensus block by id, nil if
+// there's none.
+func (bc *BlockChain) GetExecutorQuarantined(id uint64) []byte {
+	return rawdb.ReadExecutorQuarantined(bc.db, id)
+}
+
 // GetReceiptsByHash retrieves the receipts for all transactions in a given block.
 func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if receipts, ok := bc.receiptsCache.Get(hash); ok {
@@ -305,6 +348,11 @@ func (bc *BlockChain) GetTd(hash common.Hash, number uint64) *big.Int {
 
 // HasState checks if state trie is fully present in the database or not.
 func (bc *BlockChain) HasState(hash common.Hash) bool {
+	// The zero hash stands for a deferred state not committed yet, not for
+	// the empty state
+	if hash == (common.Hash{}) {
+		return false
+	}
 	_, err := bc.stateCache.OpenTrie(hash)
 	return err == nil
 }
@@ -317,7 +365,7 @@ func (bc *BlockChain) HasBlockAndState(hash common.Hash, number uint64) bool {
 	if block == nil {
 		return false
 	}
-	return bc.HasState(block.Root())
+	return bc.HasState(bc.PostStateRoot(block.Header()))
 }
 
 // stateRecoverable checks if the specified state is recoverable.
@@ -348,11 +396,43 @@ func (bc *BlockChain) ContractCodeWithPrefix(hash common.Hash) ([]byte, error) {
 
 // State returns a new mutable state based on the current HEAD block.
 func (bc *BlockChain) State() (*state.StateDB, error) {
-	return bc.StateAt(bc.CurrentBlock().Root)
+	return bc.StateAt(bc.PostStateRoot(bc.CurrentBlock()))
+}
+
+// PostStateRoot returns the root of the post state of a block. That's the root
+// in its header, unless the chain defers the state roots in the headers. Then
+// it's recorded once the state of the block was committed, and the zero hash is
+// returned until then.
+func (bc *BlockChain) PostStateRoot(header *types.Header) common.Hash {
+	if bc.chainConfig.Executor.RootDepth(header.Number) == 0 || header.Number.Sign() == 0 {
+		return header.Root
+	}
+	return rawdb.ReadPostStateRoot(bc.db, header.Hash())
+}
+
+// DeferredRoot returns the state root the header of a child of the given block
+// commits to, if the chain defers the state roots in the headers: the post state
+// root of the block RootDepth blocks before the child.
+func (bc *BlockChain) DeferredRoot(parent *types.Header) (common.Hash, error) {
+	ancestor := parent
+	depth := bc.chainConfig.Executor.RootDepth(new(big.Int).Add(parent.Number, common.Big1))
+	for i := uint64(1); i < depth && ancestor.Number.Sign() > 0; i++ {
+		if ancestor = bc.GetHeader(ancestor.ParentHash, ancestor.Number.Uint64()-1); ancestor == nil {
+			return common.Hash{}, consensus.ErrUnknownAncestor
+		}
+	}
+	root := bc.PostStateRoot(ancestor)
+	if root == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("%w: block %d %x", ErrStateNotCommitted, ancestor.Number, ancestor.Hash())
+	}
+	return root, nil
 }
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	if root == (common.Hash{}) {
+		return nil, ErrStateNotCommitted
+	}
 	return state.New(root, bc.stateCache, bc.snaps)
 }
 
@@ -382,6 +462,13 @@ func (bc *BlockChain) StateCache() state.Database {
 	return bc.stateCache
 }
 
+// NewStateCache returns a caching database sharing the trie database of the
+// blockchain, with code caches of its own, for readers which shouldn't compete
+// with the block processing.
+func (bc *BlockChain) NewStateCache() state.Database {
+	return state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
+}
+
 // GasLimit returns the gas limit of the current HEAD block.
 func (bc *BlockChain) GasLimit() uint64 {
 	return bc.CurrentBlock().GasLimit
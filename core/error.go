@@ -32,6 +32,10 @@ var (
 	// ErrNoGenesis is returned when there is no Genesis Block.
 	ErrNoGenesis = errors.New("genesis not found in chain")
 
+	// ErrStateNotCommitted is returned if the state root a header commits to is
+	// deferred to a block whose state wasn't committed yet.
+	ErrStateNotCommitted = errors.New("deferred state not committed")
+
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
 )
 
@@ -110,4 +114,12 @@ var (
 
 	// ErrBlobTxCreate is returned if a blob transaction has no explicit to field.
 	ErrBlobTxCreate = errors.New("blob transaction of type create")
+
+	// ErrDepositsDisabled is returned if a deposit transaction is included in a
+	// chain without deposits.
+	ErrDepositsDisabled = errors.New("deposit transactions disabled")
+
+	// ErrDepositReplayed is returned if a deposit transaction mints a deposit
+	// which was already minted.
+	ErrDepositReplayed = errors.New("deposit already minted")
 )
@@ -162,6 +162,74 @@ func WriteHeaderNumber(db ethdb.KeyValueWriter, hash common.Hash, number uint64)
 	}
 }
 
+// ReadConsensusMeta retrieves the consensus metadata of the block with the
+// given hash, nil if the block wasn't delivered by consensus.
+func ReadConsensusMeta(db ethdb.KeyValueReader, hash common.Hash) *types.ConsensusMeta {
+	data, _ := db.Get(consensusMetaKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+	meta := new(types.ConsensusMeta)
+	if err := rlp.DecodeBytes(data, meta); err != nil {
+		log.Error("Invalid consensus metadata RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return meta
+}
+
+// WriteConsensusMeta stores the consensus metadata of a block.
+func WriteConsensusMeta(db ethdb.KeyValueWriter, hash common.Hash, meta *types.ConsensusMeta) {
+	data, err := rlp.EncodeToBytes(meta)
+	if err != nil {
+		log.Crit("Failed to RLP encode consensus metadata", "err", err)
+	}
+	if err := db.Put(consensusMetaKey(hash), data); err != nil {
+		log.Crit("Failed to store consensus metadata", "err", err)
+	}
+}
+
+// ReadConsensusEpochBlocks retrieves the blocks indexed under the given
+// consensus epoch by number. Blocks of forks rewound since are included.
+func ReadConsensusEpochBlocks(db ethdb.Iteratee, epoch uint64) []*NumberHash {
+	prefix := append(consensusEpochPrefix, encodeBlockNumber(epoch)...)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var blocks []*NumberHash
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 || len(it.Value()) != common.HashLength {
+			continue
+		}
+		blocks = append(blocks, &NumberHash{binary.BigEndian.Uint64(key[len(prefix):]), common.BytesToHash(it.Value())})
+	}
+	return blocks
+}
+
+// WriteConsensusEpochBlock indexes a block under the consensus epoch it was
+// decided in.
+func WriteConsensusEpochBlock(db ethdb.KeyValueWriter, epoch uint64, number uint64, hash common.Hash) {
+	if err := db.Put(consensusEpochKey(epoch, number), hash.Bytes()); err != nil {
+		log.Crit("Failed to store consensus epoch index", "err", err)
+	}
+}
+
+// ReadPostStateRoot retrieves the post state root of the block with the given
+// hash, whose header commits to a deferred state root. The zero hash is returned
+// if the state of the block wasn't committed.
+func ReadPostStateRoot(db ethdb.KeyValueReader, hash common.Hash) common.Hash {
+	data, _ := db.Get(postStateRootKey(hash))
+	return common.BytesToHash(data)
+}
+
+// WritePostStateRoot stores the post state root of a block whose header commits
+// to a deferred state root.
+func WritePostStateRoot(db ethdb.KeyValueWriter, hash common.Hash, root common.Hash) {
+	if err := db.Put(postStateRootKey(hash), root.Bytes()); err != nil {
+		log.Crit("Failed to store post state root", "err", err)
+	}
+}
+
 // DeleteHeaderNumber removes hash->number mapping.
 func DeleteHeaderNumber(db ethdb.KeyValueWriter, hash common.Hash) {
 	if err := db.Delete(headerNumberKey(hash)); err != nil {
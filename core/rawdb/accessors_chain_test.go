@@ -282,6 +282,39 @@ func TestTdStorage(t *testing.T) {
 }
 
 // Tests that canonical numbers can be mapped to hashes and retrieved.
+func TestConsensusMetaStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	hash := common.Hash{0: 0xcc}
+	if entry := ReadConsensusMeta(db, hash); entry != nil {
+		t.Fatalf("Non existent consensus metadata returned: %v", entry)
+	}
+	meta := &types.ConsensusMeta{BatchID: []byte{1, 2, 3}, Round: 7, Sequence: 42, Batches: []types.ConsensusBatch{}, Proposer: 3, Epoch: 2}
+	WriteConsensusMeta(db, hash, meta)
+	if entry := ReadConsensusMeta(db, hash); entry == nil {
+		t.Fatalf("Stored consensus metadata not found")
+	} else if !reflect.DeepEqual(entry, meta) {
+		t.Fatalf("Retrieved consensus metadata mismatch: have %v, want %v", entry, meta)
+	}
+}
+
+// Tests that blocks indexed by consensus epoch can be retrieved in order.
+func TestConsensusEpochStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	if entries := ReadConsensusEpochBlocks(db, 2); len(entries) != 0 {
+		t.Fatalf("Non existent epoch blocks returned: %v", entries)
+	}
+	WriteConsensusEpochBlock(db, 2, 11, common.Hash{0: 0x0b})
+	WriteConsensusEpochBlock(db, 2, 10, common.Hash{0: 0x0a})
+	WriteConsensusEpochBlock(db, 3, 12, common.Hash{0: 0x0c})
+
+	want := []*NumberHash{{10, common.Hash{0: 0x0a}}, {11, common.Hash{0: 0x0b}}}
+	if entries := ReadConsensusEpochBlocks(db, 2); !reflect.DeepEqual(entries, want) {
+		t.Fatalf("Retrieved epoch blocks mismatch: have %v, want %v", entries, want)
+	}
+}
+
 func TestCanonicalMappingStorage(t *testing.T) {
 	db := NewMemoryDatabase()
 
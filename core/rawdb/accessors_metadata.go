@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"time"
 
@@ -187,3 +188,98 @@ func WriteTransitionStatus(db ethdb.KeyValueWriter, data []byte) {
 		log.Crit("Failed to store the eth2 transition status", "err", err)
 	}
 }
+
+// ReadExecutorInFlight retrieves the snapshot of the txs awaiting inclusion
+// after being forwarded to consensus.
+func ReadExecutorInFlight(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(executorInFlightKey)
+	return data
+}
+
+// WriteExecutorInFlight stores the snapshot of the txs awaiting inclusion
+// after being forwarded to consensus.
+func WriteExecutorInFlight(db ethdb.KeyValueWriter, data []byte) {
+	if err := db.Put(executorInFlightKey, data); err != nil {
+		log.Crit("Failed to store the executor in-flight txs", "err", err)
+	}
+}
+
+// ReadExecutorWAL retrieves the consensus blocks logged by the executor before
+// acknowledging them, along with their indices in ascending order.
+func ReadExecutorWAL(db ethdb.Iteratee) ([]uint64, [][]byte) {
+	var (
+		indices []uint64
+		blocks  [][]byte
+	)
+	it := db.NewIterator(executorWALPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(executorWALPrefix)+8 {
+			continue
+		}
+		indices = append(indices, binary.BigEndian.Uint64(key[len(executorWALPrefix):]))
+		blocks = append(blocks, common.CopyBytes(it.Value()))
+	}
+	return indices, blocks
+}
+
+// WriteExecutorWAL logs a consensus block acknowledged by the executor under
+// the given index.
+func WriteExecutorWAL(db ethdb.KeyValueWriter, index uint64, data []byte) {
+	if err := db.Put(executorWALKey(index), data); err != nil {
+		log.Crit("Failed to store the executor WAL entry", "err", err)
+	}
+}
+
+// DeleteExecutorWAL removes a consensus block executed by the executor from
+// its log.
+func DeleteExecutorWAL(db ethdb.KeyValueWriter, index uint64) {
+	if err := db.Delete(executorWALKey(index)); err != nil {
+		log.Crit("Failed to delete the executor WAL entry", "err", err)
+	}
+}
+
+// ReadExecutorQuarantine retrieves the consensus blocks the executor failed to
+// execute irrecoverably, along with their ids in ascending order.
+func ReadExecutorQuarantine(db ethdb.Iteratee) ([]uint64, [][]byte) {
+	var (
+		ids    []uint64
+		blocks [][]byte
+	)
+	it := db.NewIterator(executorQuarantinePrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(executorQuarantinePrefix)+8 {
+			continue
+		}
+		ids = append(ids, binary.BigEndian.Uint64(key[len(executorQuarantinePrefix):]))
+		blocks = append(blocks, common.CopyBytes(it.Value()))
+	}
+	return ids, blocks
+}
+
+// ReadExecutorQuarantined retrieves a quarantined consensus block by id, nil if
+// there's none.
+func ReadExecutorQuarantined(db ethdb.KeyValueReader, id uint64) []byte {
+	data, _ := db.Get(executorQuarantineKey(id))
+	return data
+}
+
+// WriteExecutorQuarantine quarantines a consensus block the executor failed to
+// execute under the given id.
+func WriteExecutorQuarantine(db ethdb.KeyValueWriter, id uint64, data []byte) {
+	if err := db.Put(executorQuarantineKey(id), data); err != nil {
+		log.Crit("Failed to store the quarantined consensus block", "err", err)
+	}
+}
+
+// DeleteExecutorQuarantine removes a consensus block from the quarantine.
+func DeleteExecutorQuarantine(db ethdb.KeyValueWriter, id uint64) {
+	if err := db.Delete(executorQuarantineKey(id)); err != nil {
+		log.Crit("Failed to delete the quarantined consensus block", "err", err)
+	}
+}
@@ -96,6 +96,18 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// executorInFlightKey tracks the txs the executor forwarded to consensus
+	// which weren't included yet.
+	executorInFlightKey = []byte("ExecutorInFlight")
+
+	// executorWALPrefix tracks the consensus blocks the executor acknowledged
+	// which weren't executed yet.
+	executorWALPrefix = []byte("ExecutorWAL-") // executorWALPrefix + index (uint64 big endian) -> consensus block
+
+	// executorQuarantinePrefix tracks the consensus blocks the executor failed
+	// to execute irrecoverably, kept for the operator to retry.
+	executorQuarantinePrefix = []byte("ExecutorQuarantine-") // executorQuarantinePrefix + id (uint64 big endian) -> failed consensus block
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -112,6 +124,10 @@ var (
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 	skeletonHeaderPrefix  = []byte("S") // skeletonHeaderPrefix + num (uint64 big endian) -> header
 
+	consensusMetaPrefix  = []byte("x")  // consensusMetaPrefix + hash -> consensus metadata of the block
+	consensusEpochPrefix = []byte("ce") // consensusEpochPrefix + epoch (uint64 big endian) + num (uint64 big endian) -> hash of a block decided in the consensus epoch
+	postStateRootPrefix  = []byte("R")  // postStateRootPrefix + hash -> post state root of a block committing to a deferred root
+
 	// Path-based storage scheme of merkle patricia trie.
 	trieNodeAccountPrefix = []byte("A") // trieNodeAccountPrefix + hexPath -> trie node
 	trieNodeStoragePrefix = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
@@ -182,6 +198,31 @@ func headerNumberKey(hash common.Hash) []byte {
 	return append(headerNumberPrefix, hash.Bytes()...)
 }
 
+// executorWALKey = executorWALPrefix + index (uint64 big endian)
+func executorWALKey(index uint64) []byte {
+	return append(executorWALPrefix, encodeBlockNumber(index)...)
+}
+
+// executorQuarantineKey = executorQuarantinePrefix + id (uint64 big endian)
+func executorQuarantineKey(id uint64) []byte {
+	return append(executorQuarantinePrefix, encodeBlockNumber(id)...)
+}
+
+// consensusMetaKey = consensusMetaPrefix + hash
+func consensusMetaKey(hash common.Hash) []byte {
+	return append(consensusMetaPrefix, hash.Bytes()...)
+}
+
+// consensusEpochKey = consensusEpochPrefix + epoch (uint64 big endian) + num (uint64 big endian)
+func consensusEpochKey(epoch uint64, number uint64) []byte {
+	return append(append(consensusEpochPrefix, encodeBlockNumber(epoch)...), encodeBlockNumber(number)...)
+}
+
+// postStateRootKey = postStateRootPrefix + hash
+func postStateRootKey(hash common.Hash) []byte {
+	return append(postStateRootPrefix, hash.Bytes()...)
+}
+
 // blockBodyKey = blockBodyPrefix + num (uint64 big endian) + hash
 func blockBodyKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockBodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
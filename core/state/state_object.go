@@ -274,6 +274,9 @@ func (s *stateObject) finalise(prefetch bool) {
 // loading or updating of the trie, an error will be returned. Furthermore,
 // this function will return the mutated storage trie, or nil if there is no
 // storage change at all.
+//
+// The tries of different objects may be updated concurrently, the fields of the
+// state shared by them are only accessed under its hash lock.
 func (s *stateObject) updateTrie() (Trie, error) {
 	// Make sure all dirty slots are finalized into the pending storage area
 	s.finalise(false)
@@ -284,13 +287,26 @@ func (s *stateObject) updateTrie() (Trie, error) {
 	}
 	// Track the amount of time wasted on updating the storage trie
 	if metrics.EnabledExpensive {
-		defer func(start time.Time) { s.db.StorageUpdates += time.Since(start) }(time.Now())
+		defer func(start time.Time) {
+			s.db.hashLock.Lock()
+			s.db.StorageUpdates += time.Since(start)
+			s.db.hashLock.Unlock()
+		}(time.Now())
 	}
 	// The snapshot storage map for the object
 	var (
 		storage map[common.Hash][]byte
 		origin  map[common.Hash][]byte
+		hasher  = crypto.NewKeccakState()
+
+		updated, deleted int
 	)
+	defer func() {
+		s.db.hashLock.Lock()
+		s.db.StorageUpdated += updated
+		s.db.StorageDeleted += deleted
+		s.db.hashLock.Unlock()
+	}()
 	tr, err := s.getTrie()
 	if err != nil {
 		s.db.setError(err)
@@ -312,7 +328,7 @@ func (s *stateObject) updateTrie() (Trie, error) {
 				s.db.setError(err)
 				return nil, err
 			}
-			s.db.StorageDeleted += 1
+			deleted++
 		} else {
 			// Encoding []byte cannot fail, ok to ignore the error.
 			trimmed := common.TrimLeftZeroes(value[:])
@@ -321,25 +337,25 @@ func (s *stateObject) updateTrie() (Trie, error) {
 				s.db.setError(err)
 				return nil, err
 			}
-			s.db.StorageUpdated += 1
+			updated++
 		}
-		// Cache the mutated storage slots until commit
+		// Cache the mutated storage slots until commit, along with their
+		// original values
 		if storage == nil {
+			s.db.hashLock.Lock()
 			if storage = s.db.storages[s.addrHash]; storage == nil {
 				storage = make(map[common.Hash][]byte)
 				s.db.storages[s.addrHash] = storage
 			}
-		}
-		khash := crypto.HashData(s.db.hasher, key[:])
-		storage[khash] = encoded // encoded will be nil if it's deleted
-
-		// Cache the original value of mutated storage slots
-		if origin == nil {
 			if origin = s.db.storagesOrigin[s.address]; origin == nil {
 				origin = make(map[common.Hash][]byte)
 				s.db.storagesOrigin[s.address] = origin
 			}
+			s.db.hashLock.Unlock()
 		}
+		khash := crypto.HashData(hasher, key[:])
+		storage[khash] = encoded // encoded will be nil if it's deleted
+
 		// Track the original value of slot only if it's mutated first time
 		if _, ok := origin[khash]; !ok {
 			if prev == (common.Hash{}) {
@@ -371,7 +387,11 @@ func (s *stateObject) updateRoot() {
 	}
 	// Track the amount of time wasted on hashing the storage trie
 	if metrics.EnabledExpensive {
-		defer func(start time.Time) { s.db.StorageHashes += time.Since(start) }(time.Now())
+		defer func(start time.Time) {
+			s.db.hashLock.Lock()
+			s.db.StorageHashes += time.Since(start)
+			s.db.hashLock.Unlock()
+		}(time.Now())
 	}
 	s.data.Root = tr.Hash()
 }
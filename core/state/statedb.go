@@ -20,6 +20,8 @@ package state
 import (
 	"fmt"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -93,6 +95,11 @@ type StateDB struct {
 	// when accessing state of accounts.
 	dbErr error
 
+	// Number of goroutines the storage tries are updated and hashed by, along
+	// with the lock of the fields they share.
+	hashWorkers int
+	hashLock    sync.Mutex
+
 	// The refund counter, also used by state transitioning.
 	refund uint64
 
@@ -114,6 +121,7 @@ type StateDB struct {
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
+	journalTotal   int // Number of journal entries of the finalised changes
 	validRevisions []revision
 	nextRevisionId int
 
@@ -196,6 +204,9 @@ func (s *StateDB) StopPrefetcher() {
 
 // setError remembers the first non-nil error it is called with.
 func (s *StateDB) setError(err error) {
+	s.hashLock.Lock()
+	defer s.hashLock.Unlock()
+
 	if s.dbErr == nil {
 		s.dbErr = err
 	}
@@ -250,6 +261,20 @@ func (s *StateDB) Preimages() map[common.Hash][]byte {
 	return s.preimages
 }
 
+// WorkingSet returns the accounts loaded into the state, read or modified, along
+// with the storage slots loaded for each of them.
+func (s *StateDB) WorkingSet() map[common.Address][]common.Hash {
+	set := make(map[common.Address][]common.Hash, len(s.stateObjects))
+	for addr, obj := range s.stateObjects {
+		slots := make([]common.Hash, 0, len(obj.originStorage))
+		for key := range obj.originStorage {
+			slots = append(slots, key)
+		}
+		set[addr] = slots
+	}
+	return set
+}
+
 // AddRefund adds gas to the refund counter
 func (s *StateDB) AddRefund(gas uint64) {
 	s.journal.append(refundChange{prev: s.refund})
@@ -703,7 +728,9 @@ func (s *StateDB) Copy() *StateDB {
 		logSize:              s.logSize,
 		preimages:            make(map[common.Hash][]byte, len(s.preimages)),
 		journal:              newJournal(),
+		journalTotal:         s.journalTotal,
 		hasher:               crypto.NewKeccakState(),
+		hashWorkers:          s.hashWorkers,
 
 		// In order for the block producer to be able to use and make additions
 		// to the snapshot tree, we need to copy that as well. Otherwise, any
@@ -892,11 +919,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	// the account prefetcher. Instead, let's process all the storage updates
 	// first, giving the account prefetches just a few more milliseconds of time
 	// to pull useful data from disk.
-	for addr := range s.stateObjectsPending {
-		if obj := s.stateObjects[addr]; !obj.deleted {
-			obj.updateRoot()
-		}
-	}
+	s.updateStorageRoots()
 	// Now we're about to start to write changes to the trie. The trie is so far
 	// _untouched_. We can check with the prefetcher, if it can give us a trie
 	// which has the same root, but also has some content loaded into it.
@@ -929,6 +952,55 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	return s.trie.Hash()
 }
 
+// SetHashWorkers sets the number of goroutines the storage tries of the modified
+// accounts are updated and hashed by, serially if at most one.
+func (s *StateDB) SetHashWorkers(workers int) {
+	s.hashWorkers = workers
+}
+
+// updateStorageRoots flushes the pending storage changes of the modified
+// accounts into their tries and rehashes them. The tries are independent of
+// each other, they are spread across the hash workers if there's more than one.
+func (s *StateDB) updateStorageRoots() {
+	objs := make([]*stateObject, 0, len(s.stateObjectsPending))
+	for addr := range s.stateObjectsPending {
+		if obj := s.stateObjects[addr]; !obj.deleted {
+			objs = append(objs, obj)
+		}
+	}
+	workers := s.hashWorkers
+	if workers > len(objs) {
+		workers = len(objs)
+	}
+	if workers <= 1 {
+		for _, obj := range objs {
+			obj.updateRoot()
+		}
+		return
+	}
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := int(next.Add(1)) - 1; n < len(objs); n = int(next.Add(1)) - 1 {
+				objs[n].updateRoot()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// JournalEntries returns the number of state modifications journalled since
+// the state was created, a deterministic measure of the memory the execution
+// on top of it holds.
+func (s *StateDB) JournalEntries() int {
+	return s.journalTotal + s.journal.length()
+}
+
 // SetTxContext sets the current transaction hash and index which are
 // used when the EVM emits new state logs. It should be invoked before
 // transaction execution.
@@ -939,6 +1011,7 @@ func (s *StateDB) SetTxContext(thash common.Hash, ti int) {
 
 func (s *StateDB) clearJournalAndRefund() {
 	if len(s.journal.entries) > 0 {
+		s.journalTotal += len(s.journal.entries)
 		s.journal = newJournal()
 		s.refund = 0
 	}
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"math/rand"
 	"reflect"
 	"strings"
@@ -546,6 +547,55 @@ func TestTouchDelete(t *testing.T) {
 	}
 }
 
+// TestJournalEntries tests that the journalled modifications are counted across
+// finalised txs and reverts.
+func TestJournalEntries(t *testing.T) {
+	state, _ := New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	addr := common.HexToAddress("aaaa")
+
+	state.SetBalance(addr, uint256.NewInt(42)) // create + balance
+	snapshot := state.Snapshot()
+	state.SetNonce(addr, 1)
+	state.RevertToSnapshot(snapshot)
+	if have := state.JournalEntries(); have != 2 {
+		t.Fatalf("journal entries mismatch: have %d, want %d", have, 2)
+	}
+	state.Finalise(true)
+	state.SetNonce(addr, 1)
+	if have := state.JournalEntries(); have != 3 {
+		t.Fatalf("journal entries mismatch after finalise: have %d, want %d", have, 3)
+	}
+}
+
+// TestWorkingSet tests that both the read and the modified accounts and slots
+// are part of the working set.
+func TestWorkingSet(t *testing.T) {
+	var (
+		db      = NewDatabase(rawdb.NewMemoryDatabase())
+		written = common.HexToAddress("aaaa")
+		read    = common.HexToAddress("bbbb")
+		slot    = common.HexToHash("01")
+	)
+	state, _ := New(types.EmptyRootHash, db, nil)
+	state.SetBalance(read, uint256.NewInt(42))
+	root, _ := state.Commit(0, false)
+
+	state, _ = New(root, db, nil)
+	state.SetState(written, slot, common.HexToHash("02"))
+	state.GetBalance(read)
+
+	set := state.WorkingSet()
+	if len(set) != 2 {
+		t.Fatalf("working set size mismatch: have %d, want %d", len(set), 2)
+	}
+	if slots := set[written]; len(slots) != 1 || slots[0] != slot {
+		t.Errorf("slots mismatch: have %x, want [%x]", slots, slot)
+	}
+	if slots, ok := set[read]; !ok || len(slots) != 0 {
+		t.Errorf("read account mismatch: have %x, %v", slots, ok)
+	}
+}
+
 // TestCopyOfCopy tests that modified objects are carried over to the copy, and the copy of the copy.
 // See https://github.com/ethereum/go-ethereum/pull/15225#issuecomment-380191512
 func TestCopyOfCopy(t *testing.T) {
@@ -1188,3 +1238,70 @@ func TestDeleteStorage(t *testing.T) {
 		t.Fatalf("difference found:\nfast: %v\nslow: %v\n", fastRes, slowRes)
 	}
 }
+
+// newLargeUpdate commits a state of accounts with the given number of slots
+// each, and returns it reopened with every slot changed, a third deleted.
+func newLargeUpdate(t testing.TB, accounts, slots int) *StateDB {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(types.EmptyRootHash, db, nil)
+	for i := 0; i < accounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		state.SetNonce(addr, 1)
+		for j := 0; j < slots; j++ {
+			state.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(j+1))))
+		}
+	}
+	root, err := state.Commit(0, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db, nil)
+	for i := 0; i < accounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		for j := 0; j < slots; j++ {
+			value := common.BigToHash(big.NewInt(int64(i*slots + j + 2)))
+			if j%3 == 0 {
+				value = common.Hash{}
+			}
+			state.SetState(addr, common.BigToHash(big.NewInt(int64(j))), value)
+		}
+	}
+	return state
+}
+
+func TestParallelStorageRoots(t *testing.T) {
+	serial := newLargeUpdate(t, 64, 50)
+	want := serial.IntermediateRoot(false)
+
+	for _, workers := range []int{2, 8, 128} {
+		parallel := newLargeUpdate(t, 64, 50)
+		parallel.SetHashWorkers(workers)
+		if root := parallel.IntermediateRoot(false); root != want {
+			t.Fatalf("workers %d: root mismatch: have %x, want %x", workers, root, want)
+		}
+		if parallel.StorageUpdated != serial.StorageUpdated || parallel.StorageDeleted != serial.StorageDeleted {
+			t.Errorf("workers %d: slots mismatch: have %d/%d, want %d/%d", workers, parallel.StorageUpdated, parallel.StorageDeleted, serial.StorageUpdated, serial.StorageDeleted)
+		}
+		if !reflect.DeepEqual(parallel.storages, serial.storages) || !reflect.DeepEqual(parallel.storagesOrigin, serial.storagesOrigin) {
+			t.Errorf("workers %d: tracked slot changes mismatch", workers)
+		}
+		if root, err := parallel.Commit(1, false); err != nil || root != want {
+			t.Errorf("workers %d: commit mismatch: have %x, want %x, err %v", workers, root, want, err)
+		}
+	}
+}
+
+func BenchmarkIntermediateRootLarge(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				state := newLargeUpdate(b, 1000, 200)
+				state.SetHashWorkers(workers)
+				b.StartTimer()
+
+				state.IntermediateRoot(false)
+			}
+		})
+	}
+}
@@ -118,9 +118,9 @@ func applyTransaction(msg *Message, config *params.ChainConfig, gp *GasPool, sta
 	// Update the state with pending changes.
 	var root []byte
 	if config.IsByzantium(blockNumber) {
-		statedb.Finalise(true)
+		statedb.Finalise(config.DeleteEmptyAccounts(blockNumber))
 	} else {
-		root = statedb.IntermediateRoot(config.IsEIP158(blockNumber)).Bytes()
+		root = statedb.IntermediateRoot(config.DeleteEmptyAccounts(blockNumber)).Bytes()
 	}
 	*usedGas += result.UsedGas
 
@@ -187,5 +187,5 @@ func ProcessBeaconBlockRoot(beaconRoot common.Hash, vmenv *vm.EVM, statedb *stat
 	vmenv.Reset(NewEVMTxContext(msg), statedb)
 	statedb.AddAddressToAccessList(params.BeaconRootsStorageAddress)
 	_, _, _ = vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, 30_000_000, common.U2560)
-	statedb.Finalise(true)
+	statedb.Finalise(vmenv.ChainConfig().DeleteEmptyAccounts(vmenv.Context.BlockNumber))
 }
@@ -145,6 +145,12 @@ type Message struct {
 	// account nonce in state. It also disables checking that the sender is an EOA.
 	// This field will be set to true for operations like RPC eth_call.
 	SkipAccountChecks bool
+
+	// Deposit messages mint the funds bridged from another chain to the sender
+	// before the call, instead of paying for gas.
+	IsDeposit  bool
+	Mint       *big.Int
+	SourceHash common.Hash
 }
 
 // TransactionToMessage converts a transaction into a Message.
@@ -163,6 +169,9 @@ func TransactionToMessage(tx *types.Transaction, s types.Signer, baseFee *big.In
 		BlobHashes:        tx.BlobHashes(),
 		BlobGasFeeCap:     tx.BlobGasFeeCap(),
 	}
+	if tx.Type() == types.DepositTxType {
+		msg.IsDeposit, msg.Mint, msg.SourceHash = true, tx.Mint(), tx.SourceHash()
+	}
 	// If baseFee provided, set gasPrice to effectiveGasPrice.
 	if baseFee != nil {
 		msg.GasPrice = cmath.BigMin(msg.GasPrice.Add(msg.GasTipCap, baseFee), msg.GasFeeCap)
@@ -365,6 +374,10 @@ func (st *StateTransition) preCheck() error {
 // However if any consensus issue encountered, return the error directly with
 // nil evm execution result.
 func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
+	// Deposits follow their own rules, they're neither signed nor pay fees
+	if st.msg.IsDeposit {
+		return st.transitionDeposit()
+	}
 	// First check this message satisfies all consensus rules before
 	// applying the message. The rules include these clauses
 	//
@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// depositMinted is the value recording a minted deposit under its source hash.
+var depositMinted = common.BigToHash(common.Big1)
+
+// transitionDeposit applies a deposit message: the bridged funds are minted to
+// the sender, which then makes the call of the deposit. Deposits pay no fees,
+// their gas only bounds the call and is accounted in the block. The funds stay
+// minted if the call fails.
+func (st *StateTransition) transitionDeposit() (*ExecutionResult, error) {
+	var (
+		msg    = st.msg
+		config = st.evm.ChainConfig().Executor.DepositConfig(st.evm.Context.BlockNumber)
+		rules  = st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.Random != nil, st.evm.Context.Time)
+	)
+	if config == nil {
+		return nil, ErrDepositsDisabled
+	}
+	if st.state.GetState(config.Address, msg.SourceHash) != (common.Hash{}) {
+		return nil, fmt.Errorf("%w: source %x", ErrDepositReplayed, msg.SourceHash)
+	}
+	mint, overflow := uint256.FromBig(msg.Mint)
+	if overflow {
+		return nil, fmt.Errorf("%w: address %v mint exceeds 256 bits", ErrInsufficientFundsForTransfer, msg.From.Hex())
+	}
+	value, overflow := uint256.FromBig(msg.Value)
+	if overflow {
+		return nil, fmt.Errorf("%w: address %v", ErrInsufficientFundsForTransfer, msg.From.Hex())
+	}
+	gas, err := IntrinsicGas(msg.Data, nil, false, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return nil, err
+	}
+	if msg.GasLimit < gas {
+		return nil, fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, msg.GasLimit, gas)
+	}
+	if err := st.gp.SubGas(msg.GasLimit); err != nil {
+		return nil, err
+	}
+	st.initialGas, st.gasRemaining = msg.GasLimit, msg.GasLimit-gas
+
+	if tracer := st.evm.Config.Tracer; tracer != nil {
+		tracer.CaptureTxStart(st.initialGas)
+		defer func() {
+			tracer.CaptureTxEnd(st.gasRemaining)
+		}()
+	}
+	// Record the deposit as minted. The account is given a nonce, so it isn't
+	// removed as empty at the end of the block.
+	if st.state.GetNonce(config.Address) == 0 {
+		st.state.SetNonce(config.Address, 1)
+	}
+	st.state.SetState(config.Address, msg.SourceHash, depositMinted)
+	st.state.AddBalance(msg.From, mint)
+
+	st.state.Prepare(rules, msg.From, st.evm.Context.Coinbase, msg.To, vm.ActivePrecompiles(rules), nil)
+	ret, remaining, vmerr := st.evm.Call(vm.AccountRef(msg.From), st.to(), msg.Data, st.gasRemaining, value)
+	st.gasRemaining = remaining
+
+	// The gas price is zero, refunding only returns the gas left to the block
+	var gasRefund uint64
+	if !rules.IsLondon {
+		gasRefund = st.refundGas(params.RefundQuotient)
+	} else {
+		gasRefund = st.refundGas(params.RefundQuotientEIP3529)
+	}
+	return &ExecutionResult{
+		UsedGas:     st.gasUsed(),
+		RefundedGas: gasRefund,
+		Err:         vmerr,
+		ReturnData:  ret,
+	}, nil
+}
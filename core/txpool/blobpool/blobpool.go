@@ -362,7 +362,7 @@ func (p *BlobPool) Init(gasTip *big.Int, head *types.Header, reserve txpool.Addr
 	// Initialize the state with head block, or fallback to empty one in
 	// case the head state is not available(might occur when node is not
 	// fully synced).
-	state, err := p.chain.StateAt(head.Root)
+	state, err := p.chain.StateAt(p.chain.PostStateRoot(head))
 	if err != nil {
 		state, err = p.chain.StateAt(types.EmptyRootHash)
 	}
@@ -749,7 +749,13 @@ func (p *BlobPool) Reset(oldHead, newHead *types.Header) {
 		resettimeHist.Update(time.Since(start).Nanoseconds())
 	}(time.Now())
 
-	statedb, err := p.chain.StateAt(newHead.Root)
+	statedb, err := p.chain.StateAt(p.chain.PostStateRoot(newHead))
+	if errors.Is(err, core.ErrStateNotCommitted) {
+		// The post state of the head lands in the background, the pool catches
+		// up on it with the next head
+		log.Debug("Deferring blobpool reset until the head state is committed", "number", newHead.Number, "hash", newHead.Hash())
+		return
+	}
 	if err != nil {
 		log.Error("Failed to reset blobpool state", "err", err)
 		return
@@ -150,6 +150,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) PostStateRoot(header *types.Header) common.Hash {
+	return header.Root
+}
+
 // makeAddressReserver is a utility method to sanity check that accounts are
 // properly reserved by the blobpool (no duplicate reserves or unreserves).
 func makeAddressReserver() txpool.AddressReserver {
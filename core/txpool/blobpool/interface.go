@@ -41,4 +41,8 @@ type BlockChain interface {
 
 	// StateAt returns a state database for a given root hash (generally the head).
 	StateAt(root common.Hash) (*state.StateDB, error)
+
+	// PostStateRoot returns the root of the post state of a block, the zero
+	// hash if the header defers its root and the state isn't committed yet.
+	PostStateRoot(header *types.Header) common.Hash
 }
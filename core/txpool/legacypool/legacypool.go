@@ -116,6 +116,10 @@ type BlockChain interface {
 
 	// StateAt returns a state database for a given root hash (generally the head).
 	StateAt(root common.Hash) (*state.StateDB, error)
+
+	// PostStateRoot returns the root of the post state of a block, the zero
+	// hash if the header defers its root and the state isn't committed yet.
+	PostStateRoot(header *types.Header) common.Hash
 }
 
 // Config are the configuration parameters of the transaction pool.
@@ -234,6 +238,7 @@ type LegacyPool struct {
 
 type txpoolResetRequest struct {
 	oldHead, newHead *types.Header
+	state            *state.StateDB // post state of the new head, retrieved from the chain if nil
 }
 
 // New creates a new transaction pool to gather, sort and filter inbound
@@ -297,7 +302,7 @@ func (pool *LegacyPool) Init(gasTip *big.Int, head *types.Header, reserve txpool
 	// Initialize the state with head block, or fallback to empty one in
 	// case the head state is not available(might occur when node is not
 	// fully synced).
-	statedb, err := pool.chain.StateAt(head.Root)
+	statedb, err := pool.chain.StateAt(pool.chain.PostStateRoot(head))
 	if err != nil {
 		statedb, err = pool.chain.StateAt(types.EmptyRootHash)
 	}
@@ -417,6 +422,23 @@ func (pool *LegacyPool) Reset(oldHead, newHead *types.Header) {
 	<-wait
 }
 
+// Prune schedules a reset of the pool to a freshly written head along with its
+// post state, dropping the txs included or made stale by the head without
+// waiting for the chain head event. Chains deferring their state roots commit
+// the post state in the background, the pool can't retrieve it before. States
+// landing after the pool moved past their head are ignored.
+func (pool *LegacyPool) Prune(head *types.Header, statedb *state.StateDB) {
+	if cur := pool.currentHead.Load(); cur != nil && head.Number.Cmp(cur.Number) <= 0 {
+		return
+	}
+	select {
+	case pool.reqResetCh <- &txpoolResetRequest{oldHead: pool.currentHead.Load(), newHead: head, state: statedb}:
+		done := <-pool.reorgDoneCh
+		<-done
+	case <-pool.reorgShutdownCh:
+	}
+}
+
 // SubscribeTransactions registers a subscription for new transaction events,
 // supporting feeding only newly seen or also resurrected transactions.
 func (pool *LegacyPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
@@ -1141,7 +1163,7 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 // The returned channel is closed when the reset has occurred.
 func (pool *LegacyPool) requestReset(oldHead *types.Header, newHead *types.Header) chan struct{} {
 	select {
-	case pool.reqResetCh <- &txpoolResetRequest{oldHead, newHead}:
+	case pool.reqResetCh <- &txpoolResetRequest{oldHead: oldHead, newHead: newHead}:
 		return <-pool.reorgDoneCh
 	case <-pool.reorgShutdownCh:
 		return pool.reorgShutdownCh
@@ -1197,11 +1219,15 @@ func (pool *LegacyPool) scheduleReorgLoop() {
 
 		select {
 		case req := <-pool.reqResetCh:
-			// Reset request: update head if request is already pending.
+			// Reset request: update head if request is already pending. The
+			// state handed over for a head is kept for further requests of it.
 			if reset == nil {
 				reset = req
 			} else {
-				reset.newHead = req.newHead
+				if req.state == nil && req.newHead != nil && reset.newHead != nil && req.newHead.Hash() == reset.newHead.Hash() {
+					req.state = reset.state
+				}
+				reset.newHead, reset.state = req.newHead, req.state
 			}
 			launchNextRun = true
 			pool.reorgDoneCh <- nextDone
@@ -1256,7 +1282,7 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	pool.mu.Lock()
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
-		pool.reset(reset.oldHead, reset.newHead)
+		pool.reset(reset.oldHead, reset.newHead, reset.state)
 
 		// Nonces were reset, discard any events that became stale
 		for addr := range events {
@@ -1321,8 +1347,9 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
-// of the transaction pool is valid with regard to the chain state.
-func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
+// of the transaction pool is valid with regard to the chain state. The post
+// state of the new head is retrieved from the chain unless given.
+func (pool *LegacyPool) reset(oldHead, newHead *types.Header, statedb *state.StateDB) {
 	// If we're reorging an old state, reinject all dropped transactions
 	var reinject types.Transactions
 
@@ -1404,10 +1431,19 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock() // Special case during testing
 	}
-	statedb, err := pool.chain.StateAt(newHead.Root)
-	if err != nil {
-		log.Error("Failed to reset txpool state", "err", err)
-		return
+	if statedb == nil {
+		var err error
+		statedb, err = pool.chain.StateAt(pool.chain.PostStateRoot(newHead))
+		if errors.Is(err, core.ErrStateNotCommitted) {
+			// The post state of the head lands in the background, the pool catches
+			// up on it with the next head
+			log.Debug("Deferring txpool reset until the head state is committed", "number", newHead.Number, "hash", newHead.Hash())
+			return
+		}
+		if err != nil {
+			log.Error("Failed to reset txpool state", "err", err)
+			return
+		}
 	}
 	pool.currentHead.Store(newHead)
 	pool.currentState = statedb
@@ -93,6 +93,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) PostStateRoot(header *types.Header) common.Hash {
+	return header.Root
+}
+
 func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
 	return bc.chainHeadFeed.Subscribe(ch)
 }
@@ -308,6 +312,44 @@ func TestStateChangeDuringReset(t *testing.T) {
 	}
 }
 
+// Tests that the pool can be pruned with the post state of a head handed over
+// by the block producer, before the chain serves the state.
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, address, big.NewInt(params.Ether))
+	if errs := pool.addRemotesSync([]*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key)}); errs[0] != nil {
+		t.Fatalf("failed to add txs: %v", errs[0])
+	}
+	// The head executed the first two txs
+	statedb := pool.currentState.Copy()
+	statedb.SetNonce(address, 2)
+
+	head := types.CopyHeader(pool.chain.CurrentBlock())
+	head.Number, head.BaseFee = big.NewInt(1), big.NewInt(params.InitialBaseFee)
+	pool.Prune(head, statedb)
+
+	// A state landing behind the head of the pool is ignored
+	stale := pool.currentState.Copy()
+	stale.SetNonce(address, 0)
+	pool.Prune(pool.chain.CurrentBlock(), stale)
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer))
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if nonce := pool.Nonce(address); nonce != 3 {
+		t.Fatalf("nonce mismatch: have %d, want %d", nonce, 3)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func testAddBalance(pool *LegacyPool, addr common.Address, amount *big.Int) {
 	pool.mu.Lock()
 	pool.currentState.AddBalance(addr, uint256.MustFromBig(amount))
@@ -400,7 +442,7 @@ func TestQueue2(t *testing.T) {
 	tx3 := transaction(11, 100, key)
 	from, _ := deriveSender(tx1)
 	testAddBalance(pool, from, big.NewInt(1000))
-	pool.reset(nil, nil)
+	pool.reset(nil, nil, nil)
 
 	pool.enqueueTx(tx1.Hash(), tx1, false, true)
 	pool.enqueueTx(tx2.Hash(), tx2, false, true)
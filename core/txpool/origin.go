@@ -0,0 +1,82 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Channels a transaction may enter the node through.
+const (
+	OriginRPC  = "rpc"  // submitted via JSON-RPC
+	OriginPeer = "peer" // received from a devp2p peer
+)
+
+// maxOrigins is the number of transactions the origin is remembered for. It
+// comfortably exceeds the pool capacity, so the origin of every pooled
+// transaction is known until it gets included.
+const maxOrigins = 1 << 16
+
+// Origin is where a transaction was first seen by the node.
+type Origin struct {
+	Kind   string // channel the transaction came through, OriginRPC or OriginPeer
+	Source string // IP of the RPC client, its APIKeySource if it sent an X-Api-Key header, or id of the devp2p peer
+}
+
+// APIKeySource returns the source of the transactions submitted with the given
+// API key. Clients are told apart by the hash of their key, so the key itself
+// doesn't leak wherever the origin is reported.
+func APIKeySource(key string) string {
+	return "key:" + crypto.Keccak256Hash([]byte(key)).Hex()
+}
+
+// originSet remembers the origin of the recently seen transactions.
+type originSet struct {
+	lock    sync.Mutex
+	origins lru.BasicLRU[common.Hash, Origin]
+}
+
+func newOriginSet() *originSet {
+	return &originSet{origins: lru.NewBasicLRU[common.Hash, Origin](maxOrigins)}
+}
+
+// TagOrigin records where the given transactions were received from. Only the
+// first origin of a transaction is kept, later deliveries of the same one don't
+// overwrite it.
+func (p *TxPool) TagOrigin(txs []*types.Transaction, origin Origin) {
+	p.origins.lock.Lock()
+	defer p.origins.lock.Unlock()
+
+	for _, tx := range txs {
+		if _, ok := p.origins.origins.Peek(tx.Hash()); !ok {
+			p.origins.origins.Add(tx.Hash(), origin)
+		}
+	}
+}
+
+// Origin returns where a transaction was first received from, if known.
+func (p *TxPool) Origin(hash common.Hash) (Origin, bool) {
+	p.origins.lock.Lock()
+	defer p.origins.lock.Unlock()
+
+	return p.origins.origins.Peek(hash)
+}
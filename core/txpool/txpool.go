@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -75,6 +76,8 @@ type TxPool struct {
 	term chan struct{}           // Termination channel to detect a closed pool
 
 	sync chan chan error // Testing / simulator channel to block until internal reset is done
+
+	origins *originSet // Where the recently seen transactions were received from
 }
 
 // New creates a new transaction pool to gather, sort and filter inbound
@@ -91,6 +94,7 @@ func New(gasTip *big.Int, chain BlockChain, subpools []SubPool) (*TxPool, error)
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
+		origins:      newOriginSet(),
 	}
 	for i, subpool := range subpools {
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
@@ -284,6 +288,24 @@ func (p *TxPool) SetGasTip(tip *big.Int) {
 	}
 }
 
+// pruner is implemented by the subpools which can be reset to a head along with
+// its post state, ahead of the state being retrievable from the chain.
+type pruner interface {
+	Prune(head *types.Header, statedb *state.StateDB)
+}
+
+// Prune hands the post state of a freshly written head over to the subpools,
+// so they drop the txs included or made stale by the head right away instead
+// of once they retrieved the state from the chain. The state must not be
+// modified afterwards.
+func (p *TxPool) Prune(head *types.Header, statedb *state.StateDB) {
+	for _, subpool := range p.subpools {
+		if pruner, ok := subpool.(pruner); ok {
+			pruner.Prune(head, statedb)
+		}
+	}
+}
+
 // Has returns an indicator whether the pool has a transaction cached with the
 // given hash.
 func (p *TxPool) Has(hash common.Hash) bool {
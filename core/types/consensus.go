@@ -0,0 +1,54 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+// ConsensusMeta links an executed block to the consensus decision it was
+// delivered by, so the txs of the block can be traced back to it.
+type ConsensusMeta struct {
+	BatchID  []byte // identifier of the consensus batch
+	Round    uint64 // consensus round the batch was decided in
+	Sequence uint64 // consensus height of the block
+
+	// Batches are the consensus batches coalesced into the block in order, empty
+	// if the block was executed from a single batch.
+	Batches []ConsensusBatch `rlp:"optional"`
+
+	Proposer uint64 `rlp:"optional"` // consensus id of the node which proposed the block
+	Epoch    uint64 `rlp:"optional"` // consensus epoch the block was decided in
+}
+
+// ConsensusBatch is one of several consensus batches executed as one block.
+type ConsensusBatch struct {
+	BatchID  []byte
+	Round    uint64
+	Sequence uint64
+	Txs      uint64 // number of txs of the batch included in the block
+	Proposer uint64 `rlp:"optional"`
+	Epoch    uint64 `rlp:"optional"`
+}
+
+// Batch returns the consensus batch the tx at the given index of the block was
+// delivered by.
+func (m *ConsensusMeta) Batch(index int) ConsensusBatch {
+	for _, batch := range m.Batches {
+		if uint64(index) < batch.Txs {
+			return batch
+		}
+		index -= int(batch.Txs)
+	}
+	return ConsensusBatch{BatchID: m.BatchID, Round: m.Round, Sequence: m.Sequence, Proposer: m.Proposer, Epoch: m.Epoch}
+}
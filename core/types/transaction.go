@@ -48,6 +48,7 @@ const (
 	AccessListTxType = 0x01
 	DynamicFeeTxType = 0x02
 	BlobTxType       = 0x03
+	DepositTxType    = 0x7e
 )
 
 // Transaction is an Ethereum transaction.
@@ -205,6 +206,8 @@ func (tx *Transaction) decodeTyped(b []byte) (TxData, error) {
 		inner = new(DynamicFeeTx)
 	case BlobTxType:
 		inner = new(BlobTx)
+	case DepositTxType:
+		inner = new(DepositTx)
 	default:
 		return nil, ErrTxTypeNotSupported
 	}
@@ -406,6 +409,26 @@ func (tx *Transaction) BlobHashes() []common.Hash {
 	return nil
 }
 
+// Mint returns the funds minted by deposit transactions, nil otherwise.
+func (tx *Transaction) Mint() *big.Int {
+	if deposit, ok := tx.inner.(*DepositTx); ok {
+		if deposit.Mint == nil {
+			return new(big.Int)
+		}
+		return new(big.Int).Set(deposit.Mint)
+	}
+	return nil
+}
+
+// SourceHash returns the hash identifying deposit transactions on their source
+// chain, zero otherwise.
+func (tx *Transaction) SourceHash() common.Hash {
+	if deposit, ok := tx.inner.(*DepositTx); ok {
+		return deposit.SourceHash
+	}
+	return common.Hash{}
+}
+
 // BlobTxSidecar returns the sidecar of a blob transaction, nil otherwise.
 func (tx *Transaction) BlobTxSidecar() *BlobTxSidecar {
 	if blobtx, ok := tx.inner.(*BlobTx); ok {
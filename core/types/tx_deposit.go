@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DepositTx represents funds bridged from another chain, minted by the consensus
+// layer once it attested to the deposit. It isn't signed, the consensus decision
+// including it authorizes it, and pays no fees, which were paid on the source
+// chain.
+type DepositTx struct {
+	ChainID    *big.Int
+	SourceHash common.Hash    // identifies the deposit on the source chain
+	From       common.Address // account the funds are minted to and the call is made from
+	To         common.Address // account the call is made to
+	Mint       *big.Int       // funds minted to the sender before the call
+	Value      *big.Int       // funds transferred by the call
+	Gas        uint64
+	Data       []byte
+}
+
+// copy creates a deep copy of the transaction data and initializes all fields.
+func (tx *DepositTx) copy() TxData {
+	cpy := &DepositTx{
+		SourceHash: tx.SourceHash,
+		From:       tx.From,
+		To:         tx.To,
+		Gas:        tx.Gas,
+		Data:       common.CopyBytes(tx.Data),
+		// These are copied below.
+		ChainID: new(big.Int),
+		Mint:    new(big.Int),
+		Value:   new(big.Int),
+	}
+	if tx.ChainID != nil {
+		cpy.ChainID.Set(tx.ChainID)
+	}
+	if tx.Mint != nil {
+		cpy.Mint.Set(tx.Mint)
+	}
+	if tx.Value != nil {
+		cpy.Value.Set(tx.Value)
+	}
+	return cpy
+}
+
+// accessors for innerTx.
+func (tx *DepositTx) txType() byte           { return DepositTxType }
+func (tx *DepositTx) chainID() *big.Int      { return tx.ChainID }
+func (tx *DepositTx) accessList() AccessList { return nil }
+func (tx *DepositTx) data() []byte           { return tx.Data }
+func (tx *DepositTx) gas() uint64            { return tx.Gas }
+func (tx *DepositTx) gasFeeCap() *big.Int    { return common.Big0 }
+func (tx *DepositTx) gasTipCap() *big.Int    { return common.Big0 }
+func (tx *DepositTx) gasPrice() *big.Int     { return common.Big0 }
+func (tx *DepositTx) value() *big.Int        { return tx.Value }
+func (tx *DepositTx) nonce() uint64          { return 0 }
+func (tx *DepositTx) to() *common.Address    { tmp := tx.To; return &tmp }
+
+func (tx *DepositTx) effectiveGasPrice(dst *big.Int, baseFee *big.Int) *big.Int {
+	return dst.SetUint64(0)
+}
+
+func (tx *DepositTx) rawSignatureValues() (v, r, s *big.Int) {
+	return common.Big0, common.Big0, common.Big0
+}
+
+func (tx *DepositTx) setSignatureValues(chainID, v, r, s *big.Int) {
+	// Deposits aren't signed
+}
+
+func (tx *DepositTx) encode(b *bytes.Buffer) error {
+	return rlp.Encode(b, tx)
+}
+
+func (tx *DepositTx) decode(input []byte) error {
+	return rlp.DecodeBytes(input, tx)
+}
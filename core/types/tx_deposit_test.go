@@ -0,0 +1,56 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// This test verifies that deposits survive the binary and JSON encodings and
+// are sent by the account they mint to.
+func TestDepositTxEncoding(t *testing.T) {
+	tx := NewTx(&DepositTx{
+		ChainID:    big.NewInt(1),
+		SourceHash: common.Hash{0x01},
+		From:       common.Address{0x02},
+		To:         common.Address{0x03},
+		Mint:       big.NewInt(1000),
+		Value:      big.NewInt(10),
+		Gas:        50_000,
+		Data:       []byte{0x04},
+	})
+	blob, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode deposit: %v", err)
+	}
+	dec := new(Transaction)
+	if err := dec.UnmarshalBinary(blob); err != nil {
+		t.Fatalf("failed to decode deposit: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Fatalf("hash mismatch: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+	js, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("failed to marshal deposit: %v", err)
+	}
+	dec = new(Transaction)
+	if err := json.Unmarshal(js, dec); err != nil {
+		t.Fatalf("failed to unmarshal deposit: %v", err)
+	}
+	if dec.Hash() != tx.Hash() {
+		t.Fatalf("json hash mismatch: have %x, want %x", dec.Hash(), tx.Hash())
+	}
+	if dec.Mint().Cmp(big.NewInt(1000)) != 0 || dec.SourceHash() != (common.Hash{0x01}) {
+		t.Fatalf("deposit mismatch: have mint %v source %x", dec.Mint(), dec.SourceHash())
+	}
+	from, err := Sender(LatestSignerForChainID(big.NewInt(1)), dec)
+	if err != nil {
+		t.Fatalf("failed to derive sender: %v", err)
+	}
+	if from != (common.Address{0x02}) {
+		t.Fatalf("sender mismatch: have %v, want %v", from, common.Address{0x02})
+	}
+}
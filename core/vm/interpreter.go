@@ -152,6 +152,10 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	defer func() {
 		returnStack(stack)
 	}()
+	// Release the memory of the frame from the tx allowance when returning
+	if in.evm.memoryLimit > 0 {
+		defer func() { in.evm.memoryUsed -= uint64(mem.Len()) }()
+	}
 	contract.Input = input
 
 	if debug {
@@ -205,6 +209,12 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 				if memorySize, overflow = math.SafeMul(toWordSize(memSize), 32); overflow {
 					return nil, ErrGasUintOverflow
 				}
+				// Enforce the memory allowance of the tx if the chain has one
+				if limit := in.evm.memoryLimit; limit > 0 && memorySize > uint64(mem.Len()) {
+					if memorySize-uint64(mem.Len()) > limit-in.evm.memoryUsed {
+						return nil, ErrMemoryLimit
+					}
+				}
 			}
 			// Consume the gas and return an error if not enough gas is available.
 			// cost is explicitly set so that the capture state defer method can get the proper cost
@@ -220,6 +230,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 				logged = true
 			}
 			if memorySize > 0 {
+				if in.evm.memoryLimit > 0 && memorySize > uint64(mem.Len()) {
+					in.evm.memoryUsed += memorySize - uint64(mem.Len())
+				}
 				mem.Resize(memorySize)
 			}
 		} else if debug {
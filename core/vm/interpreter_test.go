@@ -75,3 +75,55 @@ func TestLoopInterrupt(t *testing.T) {
 		}
 	}
 }
+
+func TestExecutorLimits(t *testing.T) {
+	var (
+		self = common.BytesToAddress([]byte("recursive"))
+		mem  = common.BytesToAddress([]byte("memory"))
+	)
+	// Counts its frames in slot zero and calls itself with all the gas left
+	recursive := common.Hex2Bytes("60016000540160005560006000600060006000305af100")
+
+	tests := []struct {
+		depth, memory uint64
+		frames        uint64 // frames executed by the recursive contract
+		memErr        error
+	}{
+		{depth: 0, memory: 0, frames: 0, memErr: nil},
+		{depth: 3, memory: 8192, frames: 4, memErr: ErrMemoryLimit},
+		{depth: 10, memory: 16384, frames: 11, memErr: nil},
+	}
+	for i, tt := range tests {
+		config := *params.AllEthashProtocolChanges
+		config.Executor = &params.ExecutorConfig{MaxCallDepth: tt.depth, MaxTxMemory: tt.memory}
+
+		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(self)
+		statedb.SetCode(self, recursive)
+		statedb.CreateAccount(mem)
+		statedb.SetCode(mem, common.Hex2Bytes("600061200052")) // mstore(0x2000, 0)
+		statedb.Finalise(true)
+
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *uint256.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *uint256.Int) {},
+			BlockNumber: common.Big0,
+		}
+		evm := NewEVM(vmctx, TxContext{}, statedb, &config, Config{})
+		if _, _, err := evm.Call(AccountRef(common.Address{}), mem, nil, 1_000_000, new(uint256.Int)); err != tt.memErr {
+			t.Errorf("test %d: memory error mismatch: have %v, want %v", i, err, tt.memErr)
+		}
+		if evm.memoryUsed != 0 {
+			t.Errorf("test %d: memory not released: %d", i, evm.memoryUsed)
+		}
+		if tt.depth == 0 {
+			continue
+		}
+		if _, _, err := evm.Call(AccountRef(common.Address{}), self, nil, 10_000_000, new(uint256.Int)); err != nil {
+			t.Errorf("test %d: recursion failed: %v", i, err)
+		}
+		if frames := statedb.GetState(self, common.Hash{}).Big().Uint64(); frames != tt.frames {
+			t.Errorf("test %d: frames mismatch: have %d, want %d", i, frames, tt.frames)
+		}
+	}
+}
@@ -20,12 +20,14 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"net"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc/calldatafee"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -203,7 +205,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.eth.BlockChain().StateAt(b.eth.BlockChain().PostStateRoot(header))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -225,7 +227,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.eth.BlockChain().StateAt(b.eth.BlockChain().PostStateRoot(header))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -288,9 +290,31 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if err := b.eth.Miner().Admit(); err != nil {
+		return err
+	}
+	b.eth.txPool.TagOrigin([]*types.Transaction{signedTx}, rpcOrigin(ctx))
 	return b.eth.txPool.Add([]*types.Transaction{signedTx}, true, false)[0]
 }
 
+// rpcOrigin returns the origin of a transaction submitted by the RPC client of
+// the given request, identified by the API key it sent, its IP or the transport
+// if it has none.
+func rpcOrigin(ctx context.Context) txpool.Origin {
+	info := rpc.PeerInfoFromContext(ctx)
+	if info.HTTP.APIKey != "" {
+		return txpool.Origin{Kind: txpool.OriginRPC, Source: txpool.APIKeySource(info.HTTP.APIKey)}
+	}
+	source := info.RemoteAddr
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	if source == "" {
+		source = info.Transport
+	}
+	return txpool.Origin{Kind: txpool.OriginRPC, Source: source}
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(false)
 	var txs types.Transactions
@@ -359,17 +383,44 @@ func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
 		prog.TxIndexFinishedBlocks = txProg.Indexed
 		prog.TxIndexRemainingBlocks = txProg.Remaining
 	}
+	// The chain is only as current as the consensus blocks executed into it
+	if lag := b.eth.Miner().HeadLag(); lag.Syncing {
+		prog.ExecutedBatch = lag.ExecutedHead
+		prog.ConsensusBatch = lag.ConsensusHead
+	}
 	return prog
 }
 
 func (b *EthAPIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
-	return b.gpo.SuggestTipCap(ctx)
+	tip, err := b.gpo.SuggestTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Never suggest a tip the executor wouldn't forward to consensus
+	if minTip := b.eth.Miner().MinTip(); tip.Cmp(minTip) < 0 {
+		tip = minTip
+	}
+	return tip, nil
 }
 
-func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, err error) {
+func (b *EthAPIBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (firstBlock *big.Int, reward [][]*big.Int, baseFee []*big.Int, gasUsedRatio []float64, calldataFee []*big.Int, err error) {
 	return b.gpo.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
 }
 
+// NextCalldataFee returns the fee per calldata byte of the block following the
+// given one, derived from the excess calldata left in its state.
+func (b *EthAPIBackend) NextCalldataFee(ctx context.Context, header *types.Header) (*big.Int, error) {
+	market := b.ChainConfig().Executor.CalldataFeeMarket()
+	if market == nil {
+		return nil, errors.New("calldata fee market not enabled")
+	}
+	statedb, err := b.eth.BlockChain().StateAt(b.eth.BlockChain().PostStateRoot(header))
+	if err != nil {
+		return nil, err
+	}
+	return calldatafee.CalcFee(market, calldatafee.ReadExcess(market, statedb)), nil
+}
+
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
@@ -0,0 +1,285 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/miner/commitment"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ExecutorAPI provides an API to inspect the executor bridging the chain to
+// the consensus layer. It also changes the executor settings, retries
+// quarantined blocks and re-executes historical ones, so like the admin and
+// debug namespaces the executor namespace must not be exposed publicly.
+type ExecutorAPI struct {
+	e *Ethereum
+}
+
+// NewExecutorAPI creates a new ExecutorAPI instance.
+func NewExecutorAPI(e *Ethereum) *ExecutorAPI {
+	return &ExecutorAPI{e}
+}
+
+// Alerts returns the critical executor conditions which are currently active.
+func (api *ExecutorAPI) Alerts() []miner.Alert {
+	return api.e.Miner().Alerts()
+}
+
+// MinTip returns the minimum effective tip a transaction currently has to pay
+// for the executor to forward it to the consensus layer.
+func (api *ExecutorAPI) MinTip() *hexutil.Big {
+	return (*hexutil.Big)(api.e.Miner().MinTip())
+}
+
+// HeadLag returns how far the execution lags behind consensus.
+func (api *ExecutorAPI) HeadLag() miner.HeadLag {
+	return api.e.Miner().HeadLag()
+}
+
+// InclusionReport returns the latency percentiles from first seeing a tx to its
+// inclusion, overall and per sender class.
+func (api *ExecutorAPI) InclusionReport() []miner.InclusionReport {
+	return api.e.Miner().InclusionReports()
+}
+
+// OriginReport returns the forwarded and included txs along with their mean
+// inclusion latency per origin, the RPC client or devp2p peer they came from.
+func (api *ExecutorAPI) OriginReport() []miner.OriginReport {
+	return api.e.Miner().OriginReports()
+}
+
+// Stats returns a snapshot of the executor internals: the consensus block being
+// executed, the queue depths, the consensus link and the recent block latencies.
+func (api *ExecutorAPI) Stats() miner.Stats {
+	return api.e.Miner().Stats()
+}
+
+// NonceGaps returns the senders whose txs forwarded to consensus are stalled
+// behind a nonce lost on its way to consensus, along with whether the missing
+// tx is still in the pool to be forwarded again.
+func (api *ExecutorAPI) NonceGaps() []miner.NonceGap {
+	return api.e.Miner().NonceGaps()
+}
+
+// GetConfig returns the effective executor configuration as a single snapshot:
+// the listening and consensus addresses, the gas and tip settings, the accepted
+// tx types and the feature flags, along with the changes made via SetConfig.
+// The alert hooks and export sinks are redacted, they may carry credentials.
+func (api *ExecutorAPI) GetConfig() miner.ConfigSnapshot {
+	return api.e.Miner().ExecutorConfig()
+}
+
+// SetConfig changes the executor settings which can safely be changed while
+// running, see miner.LiveConfig. Either all given settings are applied or none,
+// settings which can't be changed while running are rejected.
+func (api *ExecutorAPI) SetConfig(update json.RawMessage) (miner.ConfigSnapshot, error) {
+	var live miner.LiveConfig
+	dec := json.NewDecoder(bytes.NewReader(update))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&live); err != nil {
+		return miner.ConfigSnapshot{}, fmt.Errorf("invalid executor settings: %w", err)
+	}
+	return api.e.Miner().UpdateExecutorConfig(live)
+}
+
+// EpochSummaries creates a subscription delivering the state summary of every
+// settlement epoch ended by a block written from now on, see
+// miner.Config.EpochLength.
+func (api *ExecutorAPI) EpochSummaries(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		summaries := make(chan *miner.EpochSummary, 16)
+		sub := api.e.Miner().SubscribeEpochSummaries(summaries)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case summary := <-summaries:
+				notifier.Notify(rpcSub.ID, summary)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Reexecute re-runs a historical block against its parent state with the given
+// overrides of the coinbase, gas limit and txs left out, returning the outcome
+// of its txs and the accounts ending up different than without the overrides.
+func (api *ExecutorAPI) Reexecute(hash common.Hash, overrides *miner.ReexecOverrides) (*miner.ReexecResult, error) {
+	if overrides == nil {
+		overrides = new(miner.ReexecOverrides)
+	}
+	return api.e.Miner().Reexecute(hash, *overrides)
+}
+
+// GetTransactionReceipt returns the receipt of a transaction like
+// eth_getTransactionReceipt, extended with the consensus batch, round and
+// height the transaction was delivered by along with its proposer and consensus
+// epoch, if known.
+func (api *ExecutorAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	fields, err := ethapi.NewTransactionAPI(api.e.APIBackend, nil).GetTransactionReceipt(ctx, hash)
+	if err != nil || fields == nil {
+		return fields, err
+	}
+	if meta := api.e.BlockChain().GetConsensusMeta(fields["blockHash"].(common.Hash)); meta != nil {
+		batch := meta.Batch(int(fields["transactionIndex"].(hexutil.Uint64)))
+		fields["consensusBatchId"] = hexutil.Bytes(batch.BatchID)
+		fields["consensusRound"] = hexutil.Uint64(batch.Round)
+		fields["consensusSequence"] = hexutil.Uint64(batch.Sequence)
+		fields["consensusProposer"] = hexutil.Uint64(batch.Proposer)
+		fields["consensusEpoch"] = hexutil.Uint64(batch.Epoch)
+	}
+	return fields, nil
+}
+
+// EpochBlock is a canonical block decided in a consensus epoch.
+type EpochBlock struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// EpochBlocks returns the canonical blocks decided in the given consensus epoch
+// in ascending order, tying the chain back to the consensus decisions. Blocks
+// record the epoch in their extra-data too if the executor embeds the
+// provenance, see the provenance package.
+func (api *ExecutorAPI) EpochBlocks(epoch hexutil.Uint64) []EpochBlock {
+	blocks := make([]EpochBlock, 0)
+	for _, block := range api.e.BlockChain().GetConsensusEpochBlocks(uint64(epoch)) {
+		blocks = append(blocks, EpochBlock{Number: hexutil.Uint64(block.Number), Hash: block.Hash})
+	}
+	return blocks
+}
+
+// ListQuarantine returns the consensus blocks the executor failed to execute
+// irrecoverably, kept for retrying once the cause is fixed.
+func (api *ExecutorAPI) ListQuarantine() []miner.QuarantinedBlock {
+	return api.e.Miner().ListQuarantine()
+}
+
+// RetryQuarantined executes a quarantined consensus block again on top of the
+// chain head, dropping it from the quarantine once it landed.
+func (api *ExecutorAPI) RetryQuarantined(id uint64) (*miner.RetryResult, error) {
+	return api.e.Miner().RetryQuarantined(id)
+}
+
+// ReceiptCommitment is the commitment to the receipts of a block, see the
+// commitment package.
+type ReceiptCommitment struct {
+	BlockHash  common.Hash    `json:"blockHash"`
+	Count      hexutil.Uint64 `json:"count"`
+	Commitment common.Hash    `json:"commitment"`
+}
+
+// ReceiptProof proves the outcome of a tx against the receipt commitment of
+// its block.
+type ReceiptProof struct {
+	ReceiptCommitment
+	Index hexutil.Uint64 `json:"index"`
+	Leaf  common.Hash    `json:"leaf"`
+	Proof []common.Hash  `json:"proof"`
+}
+
+// ReceiptCommitment returns the commitment to the receipts of a block.
+func (api *ExecutorAPI) ReceiptCommitment(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*ReceiptCommitment, error) {
+	header, err := api.e.APIBackend.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	hash := header.Hash()
+	receipts := api.e.BlockChain().GetReceiptsByHash(hash)
+	return &ReceiptCommitment{BlockHash: hash, Count: hexutil.Uint64(len(receipts)), Commitment: commitment.Root(receipts)}, nil
+}
+
+// ReceiptProof returns the proof of the outcome of a tx against the receipt
+// commitment of its block.
+func (api *ExecutorAPI) ReceiptProof(ctx context.Context, hash common.Hash) (*ReceiptProof, error) {
+	lookup, _, err := api.e.BlockChain().GetTransactionLookup(hash)
+	if lookup == nil || err != nil {
+		return nil, err
+	}
+	receipts := api.e.BlockChain().GetReceiptsByHash(lookup.BlockHash)
+	if receipts == nil {
+		return nil, errors.New("receipts not found")
+	}
+	proof, err := commitment.Prove(receipts, int(lookup.Index))
+	if err != nil {
+		return nil, err
+	}
+	return &ReceiptProof{
+		ReceiptCommitment: ReceiptCommitment{
+			BlockHash:  lookup.BlockHash,
+			Count:      hexutil.Uint64(len(receipts)),
+			Commitment: commitment.Root(receipts),
+		},
+		Index: hexutil.Uint64(lookup.Index),
+		Leaf:  commitment.Leaf(receipts[lookup.Index]),
+		Proof: proof,
+	}, nil
+}
+
+// BlockRewards is the fee revenue of a block. The chain has no block subsidy,
+// the priority fees are all the coinbase earns.
+type BlockRewards struct {
+	BlockHash common.Hash    `json:"blockHash"`
+	Number    hexutil.Uint64 `json:"number"`
+	Coinbase  common.Address `json:"coinbase"`
+	Fees      *hexutil.Big   `json:"fees"`      // priority fees credited to the coinbase
+	BurntFees *hexutil.Big   `json:"burntFees"` // base fees burnt by the block
+}
+
+// BlockRewards returns the fees credited to the coinbase of a block.
+func (api *ExecutorAPI) BlockRewards(ctx context.Context, hash common.Hash) (*BlockRewards, error) {
+	block, err := api.e.APIBackend.BlockByHash(ctx, hash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	receipts := api.e.BlockChain().GetReceiptsByHash(hash)
+	if len(receipts) != len(block.Transactions()) {
+		return nil, errors.New("receipts not found")
+	}
+	burnt := new(big.Int)
+	if block.BaseFee() != nil {
+		burnt.Mul(block.BaseFee(), new(big.Int).SetUint64(block.GasUsed()))
+	}
+	return &BlockRewards{
+		BlockHash: hash,
+		Number:    hexutil.Uint64(block.NumberU64()),
+		Coinbase:  block.Coinbase(),
+		Fees:      (*hexutil.Big)(miner.PriorityFees(block, receipts)),
+		BurntFees: (*hexutil.Big)(burnt),
+	}, nil
+}
@@ -101,6 +101,8 @@ type Ethereum struct {
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 
 	shutdownTracker *shutdowncheck.ShutdownTracker // Tracks if and when the node has shutdown ungracefully
+
+	ready chan struct{} // Closed once the chain and the pool are initialized, see Ready
 }
 
 // New creates a new Ethereum object (including the
@@ -171,6 +173,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
 		p2pServer:         stack.Server(),
 		shutdownTracker:   shutdowncheck.NewShutdownTracker(chainDb),
+		ready:             make(chan struct{}),
 	}
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
@@ -245,13 +248,32 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		NoTxGossip:     config.NoTxGossip,
 	}); err != nil {
 		return nil, err
 	}
 
+	// Replicas executing consensus blocks must all compute the same results
+	if config.Miner.DeterminismCheck {
+		if err := miner.CheckDeterminism(eth.blockchain.GetVMConfig()); err != nil {
+			return nil, err
+		}
+	}
+	// The executor follows the node policy on unprotected txs
+	config.Miner.AllowUnprotectedTxs = config.Miner.AllowUnprotectedTxs || stack.Config().AllowUnprotectedTxs
 	eth.miner = miner.New(eth, &config.Miner, eth.blockchain.Config(), eth.EventMux(), eth.engine, eth.isLocalBlock)
 	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
 
+	// The executor gRPC API may share the authenticated endpoint instead of its
+	// own port
+	handler, err := eth.miner.ExecutorHandler()
+	if err != nil {
+		return nil, err
+	}
+	if handler != nil {
+		stack.RegisterGRPC("Executor API", handler, true)
+	}
+
 	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil}
 	if eth.APIBackend.allowUnprotectedTxs {
 		log.Info("Unprotected transactions allowed")
@@ -284,6 +306,8 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	// Successful startup; push a marker and check previous unclean shutdowns.
 	eth.shutdownTracker.MarkStartup()
 
+	// The executor created along the way may touch the chain and the pool now
+	close(eth.ready)
 	return eth, nil
 }
 
@@ -320,6 +344,10 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
+		}, {
+			// Privileged, must not be exposed publicly
+			Namespace: "executor",
+			Service:   NewExecutorAPI(s),
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.blockchain, s.eventMux),
@@ -471,6 +499,10 @@ func (s *Ethereum) StopMining() {
 	s.miner.Stop()
 }
 
+// Ready returns a channel closed once the chain head is loaded and the pool is
+// initialized, see miner.ReadyBackend.
+func (s *Ethereum) Ready() <-chan struct{} { return s.ready }
+
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
@@ -529,7 +561,11 @@ func (s *Ethereum) Start() error {
 // Stop implements node.Lifecycle, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
-	// Stop all the peer-related stuff first.
+	// Stop the miner first, letting the executor finish writing the block in
+	// progress while everything it depends on is still up.
+	s.miner.Close()
+
+	// Stop all the peer-related stuff next.
 	s.ethDialCandidates.Close()
 	s.snapDialCandidates.Close()
 	s.handler.Stop()
@@ -538,7 +574,6 @@ func (s *Ethereum) Stop() error {
 	s.bloomIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Close()
-	s.miner.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
 
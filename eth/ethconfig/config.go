@@ -90,6 +90,11 @@ type Config struct {
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
+	// NoTxGossip disables the exchange of transactions with eth peers, for
+	// deployments where transactions travel exclusively via the consensus layer.
+	// Blocks are still propagated.
+	NoTxGossip bool `toml:",omitempty"`
+
 	// Deprecated, use 'TransactionHistory' instead.
 	TxLookupLimit      uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
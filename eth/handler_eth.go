@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -52,7 +53,7 @@ func (h *ethHandler) PeerInfo(id enode.ID) interface{} {
 // AcceptTxs retrieves whether transaction processing is enabled on the node
 // or if inbound transactions should simply be dropped.
 func (h *ethHandler) AcceptTxs() bool {
-	return h.synced.Load()
+	return h.synced.Load() && !h.noTxGossip
 }
 
 // Handle is invoked from a peer's message handler when it receives a new remote
@@ -79,9 +80,11 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 				return errors.New("disallowed broadcast blob transaction")
 			}
 		}
+		h.txpool.TagOrigin(*packet, txpool.Origin{Kind: txpool.OriginPeer, Source: peer.ID()})
 		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
 
 	case *eth.PooledTransactionsResponse:
+		h.txpool.TagOrigin(*packet, txpool.Origin{Kind: txpool.OriginPeer, Source: peer.ID()})
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
 	default:
@@ -91,6 +91,9 @@ func (p *testTxPool) Add(txs []*types.Transaction, local bool, sync bool) []erro
 	return make([]error, len(txs))
 }
 
+// TagOrigin is a no-op, the test pool doesn't track where transactions come from.
+func (p *testTxPool) TagOrigin(txs []*types.Transaction, origin txpool.Origin) {}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending(enforceTips bool) map[common.Address][]*txpool.LazyTransaction {
 	p.lock.RLock()
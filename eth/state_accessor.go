@@ -44,17 +44,27 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 		triedb   *trie.Database
 		report   = true
 		origin   = block.NumberU64()
+		root     = eth.blockchain.PostStateRoot(block.Header())
 	)
+	// The states of the blocks are found by their post state roots, which the
+	// headers of chains deferring the state roots don't commit to
+	openState := func(block *types.Block, database state.Database) (*state.StateDB, error) {
+		root := eth.blockchain.PostStateRoot(block.Header())
+		if root == (common.Hash{}) {
+			return nil, core.ErrStateNotCommitted
+		}
+		return state.New(root, database, nil)
+	}
 	// The state is only for reading purposes, check the state presence in
 	// live database.
 	if readOnly {
 		// The state is available in live database, create a reference
 		// on top to prevent garbage collection and return a release
 		// function to deref it.
-		if statedb, err = eth.blockchain.StateAt(block.Root()); err == nil {
-			eth.blockchain.TrieDB().Reference(block.Root(), common.Hash{})
+		if statedb, err = eth.blockchain.StateAt(root); err == nil {
+			eth.blockchain.TrieDB().Reference(root, common.Hash{})
 			return statedb, func() {
-				eth.blockchain.TrieDB().Dereference(block.Root())
+				eth.blockchain.TrieDB().Dereference(root)
 			}, nil
 		}
 	}
@@ -68,8 +78,8 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 			// TODO(rjl493456442), clean cache is disabled to prevent memory leak,
 			// please re-enable it for better performance.
 			database = state.NewDatabaseWithConfig(eth.chainDb, trie.HashDefaults)
-			if statedb, err = state.New(block.Root(), database, nil); err == nil {
-				log.Info("Found disk backend for state trie", "root", block.Root(), "number", block.Number())
+			if statedb, err = openState(block, database); err == nil {
+				log.Info("Found disk backend for state trie", "root", root, "number", block.Number())
 				return statedb, noopReleaser, nil
 			}
 		}
@@ -91,7 +101,7 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 		// otherwise we would rewind past a persisted block (specific corner case is
 		// chain tracing from the genesis).
 		if !readOnly {
-			statedb, err = state.New(current.Root(), database, nil)
+			statedb, err = openState(current, database)
 			if err == nil {
 				return statedb, noopReleaser, nil
 			}
@@ -110,7 +120,7 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 			}
 			current = parent
 
-			statedb, err = state.New(current.Root(), database, nil)
+			statedb, err = openState(current, database)
 			if err == nil {
 				break
 			}
@@ -150,7 +160,7 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 			return nil, nil, fmt.Errorf("processing block %d failed: %v", current.NumberU64(), err)
 		}
 		// Finalize the state so any modifications are written to the trie
-		root, err := statedb.Commit(current.NumberU64(), eth.blockchain.Config().IsEIP158(current.Number()))
+		root, err := statedb.Commit(current.NumberU64(), eth.blockchain.Config().DeleteEmptyAccounts(current.Number()))
 		if err != nil {
 			return nil, nil, fmt.Errorf("stateAtBlock commit failed, number %d root %v: %w",
 				current.NumberU64(), current.Root().Hex(), err)
@@ -171,12 +181,12 @@ func (eth *Ethereum) hashState(ctx context.Context, block *types.Block, reexec u
 		_, nodes, imgs := triedb.Size() // all memory is contained within the nodes return in hashdb
 		log.Info("Historical state regenerated", "block", current.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
 	}
-	return statedb, func() { triedb.Dereference(block.Root()) }, nil
+	return statedb, func() { triedb.Dereference(root) }, nil
 }
 
 func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), error) {
 	// Check if the requested state is available in the live chain.
-	statedb, err := eth.blockchain.StateAt(block.Root())
+	statedb, err := eth.blockchain.StateAt(eth.blockchain.PostStateRoot(block.Header()))
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
@@ -253,7 +263,7 @@ func (eth *Ethereum) stateAtTransaction(ctx context.Context, block *types.Block,
 		}
 		// Ensure any modifications are committed to the state
 		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
-		statedb.Finalise(vmenv.ChainConfig().IsEIP158(block.Number()))
+		statedb.Finalise(vmenv.ChainConfig().DeleteEmptyAccounts(block.Number()))
 	}
 	return nil, vm.BlockContext{}, nil, nil, fmt.Errorf("transaction index %d out of range for block %#x", txIndex, block.Hash())
 }
@@ -124,6 +124,10 @@ type SyncProgress struct {
 	// "transaction indexing" fields
 	TxIndexFinishedBlocks  uint64 // Number of blocks whose transactions are already indexed
 	TxIndexRemainingBlocks uint64 // Number of blocks whose transactions are not indexed yet
+
+	// "executor sync" fields
+	ExecutedBatch  uint64 // Consensus height of the last executed block
+	ConsensusBatch uint64 // Consensus height the executor is catching up with
 }
 
 // Done returns the indicator if the initial sync is finished or not.
@@ -131,6 +135,9 @@ func (prog SyncProgress) Done() bool {
 	if prog.CurrentBlock < prog.HighestBlock {
 		return false
 	}
+	if prog.ExecutedBatch < prog.ConsensusBatch {
+		return false
+	}
 	return prog.TxIndexRemainingBlocks == 0
 }
 
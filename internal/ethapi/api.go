@@ -93,11 +93,12 @@ type feeHistoryResult struct {
 	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
 	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
 	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	CalldataFee  []*hexutil.Big   `json:"calldataFeePerByte,omitempty"`
 }
 
 // FeeHistory returns the fee market history.
 func (s *EthereumAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
-	oldest, reward, baseFee, gasUsed, err := s.b.FeeHistory(ctx, uint64(blockCount), lastBlock, rewardPercentiles)
+	oldest, reward, baseFee, gasUsed, calldataFee, err := s.b.FeeHistory(ctx, uint64(blockCount), lastBlock, rewardPercentiles)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +121,12 @@ func (s *EthereumAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecim
 			results.BaseFee[i] = (*hexutil.Big)(v)
 		}
 	}
+	if calldataFee != nil {
+		results.CalldataFee = make([]*hexutil.Big, len(calldataFee))
+		for i, v := range calldataFee {
+			results.CalldataFee[i] = (*hexutil.Big)(v)
+		}
+	}
 	return results, nil
 }
 
@@ -156,6 +163,8 @@ func (s *EthereumAPI) Syncing() (interface{}, error) {
 		"healingBytecode":        hexutil.Uint64(progress.HealingBytecode),
 		"txIndexFinishedBlocks":  hexutil.Uint64(progress.TxIndexFinishedBlocks),
 		"txIndexRemainingBlocks": hexutil.Uint64(progress.TxIndexRemainingBlocks),
+		"executedBatch":          hexutil.Uint64(progress.ExecutedBatch),
+		"consensusBatch":         hexutil.Uint64(progress.ConsensusBatch),
 	}, nil
 }
 
@@ -1325,6 +1334,8 @@ type RPCTransaction struct {
 	R                   *hexutil.Big      `json:"r"`
 	S                   *hexutil.Big      `json:"s"`
 	YParity             *hexutil.Uint64   `json:"yParity,omitempty"`
+	SourceHash          *common.Hash      `json:"sourceHash,omitempty"`
+	Mint                *hexutil.Big      `json:"mint,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1399,6 +1410,12 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		}
 		result.MaxFeePerBlobGas = (*hexutil.Big)(tx.BlobGasFeeCap())
 		result.BlobVersionedHashes = tx.BlobHashes()
+
+	case types.DepositTxType:
+		source := tx.SourceHash()
+		result.ChainID = (*hexutil.Big)(tx.ChainId())
+		result.SourceHash = &source
+		result.Mint = (*hexutil.Big)(tx.Mint())
 	}
 	return result
 }
@@ -23,6 +23,7 @@ var Modules = map[string]string{
 	"ethash":   EthashJs,
 	"debug":    DebugJs,
 	"eth":      EthJs,
+	"executor": ExecutorJs,
 	"miner":    MinerJs,
 	"net":      NetJs,
 	"personal": PersonalJs,
@@ -694,6 +695,95 @@ web3._extend({
 });
 `
 
+const ExecutorJs = `
+web3._extend({
+	property: 'executor',
+	methods: [
+		new web3._extend.Method({
+			name: 'getTransactionReceipt',
+			call: 'executor_getTransactionReceipt',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'blockRewards',
+			call: 'executor_blockRewards',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'listQuarantine',
+			call: 'executor_listQuarantine'
+		}),
+		new web3._extend.Method({
+			name: 'retryQuarantined',
+			call: 'executor_retryQuarantined',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'epochBlocks',
+			call: 'executor_epochBlocks',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getConfig',
+			call: 'executor_getConfig'
+		}),
+		new web3._extend.Method({
+			name: 'setConfig',
+			call: 'executor_setConfig',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reexecute',
+			call: 'executor_reexecute',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'receiptCommitment',
+			call: 'executor_receiptCommitment',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'receiptProof',
+			call: 'executor_receiptProof',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'alerts',
+			getter: 'executor_alerts'
+		}),
+		new web3._extend.Property({
+			name: 'minTip',
+			getter: 'executor_minTip',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Property({
+			name: 'headLag',
+			getter: 'executor_headLag'
+		}),
+		new web3._extend.Property({
+			name: 'inclusionReport',
+			getter: 'executor_inclusionReport'
+		}),
+		new web3._extend.Property({
+			name: 'originReport',
+			getter: 'executor_originReport'
+		}),
+		new web3._extend.Property({
+			name: 'stats',
+			getter: 'executor_stats'
+		}),
+		new web3._extend.Property({
+			name: 'nonceGaps',
+			getter: 'executor_nonceGaps'
+		}),
+	]
+});
+`
+
 const NetJs = `
 web3._extend({
 	property: 'net',
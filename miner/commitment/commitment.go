@@ -0,0 +1,123 @@
+// Package commitment implements the receipt commitment of executor blocks, a
+// binary Merkle tree over the outcome of every tx compact enough to prove the
+// outcome of a single tx to a fraud-proof verifier.
+//
+// The leaves commit to the tx hash, the status and the gas used of a receipt,
+// the root to the leaves and their number. Leaves, inner nodes and the root are
+// hashed with distinct prefixes so no node can be passed off as another kind.
+// The last node of a level with an odd number of nodes is promoted to the next
+// level unchanged.
+package commitment
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+	rootPrefix = 0x02
+)
+
+var errIndexOutOfRange = errors.New("receipt index out of range")
+
+// Leaf returns the commitment leaf of a receipt.
+func Leaf(receipt *types.Receipt) common.Hash {
+	var blob [1 + common.HashLength + 1 + 8]byte
+	blob[0] = leafPrefix
+	copy(blob[1:], receipt.TxHash[:])
+	blob[1+common.HashLength] = byte(receipt.Status)
+	binary.BigEndian.PutUint64(blob[2+common.HashLength:], receipt.GasUsed)
+	return crypto.Keccak256Hash(blob[:])
+}
+
+// Root returns the commitment of the receipts of a block.
+func Root(receipts types.Receipts) common.Hash {
+	level := leaves(receipts)
+	for len(level) > 1 {
+		level = parents(level)
+	}
+	var top common.Hash
+	if len(level) == 1 {
+		top = level[0]
+	}
+	return root(uint64(len(receipts)), top)
+}
+
+// Prove returns the sibling hashes on the path from the leaf of the receipt at
+// the given index to the root, bottom up.
+func Prove(receipts types.Receipts, index int) ([]common.Hash, error) {
+	if index < 0 || index >= len(receipts) {
+		return nil, errIndexOutOfRange
+	}
+	var proof []common.Hash
+	for level := leaves(receipts); len(level) > 1; level = parents(level) {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Verify checks that the leaf is the one at the given index of a commitment over
+// count receipts.
+func Verify(commitment common.Hash, leaf common.Hash, index, count int, proof []common.Hash) bool {
+	if index < 0 || index >= count {
+		return false
+	}
+	hash := leaf
+	for size := count; size > 1; size = (size + 1) / 2 {
+		if sibling := index ^ 1; sibling < size {
+			if len(proof) == 0 {
+				return false
+			}
+			if index%2 == 0 {
+				hash = node(hash, proof[0])
+			} else {
+				hash = node(proof[0], hash)
+			}
+			proof = proof[1:]
+		}
+		index /= 2
+	}
+	return len(proof) == 0 && root(uint64(count), hash) == commitment
+}
+
+// leaves returns the bottom level of the tree.
+func leaves(receipts types.Receipts) []common.Hash {
+	level := make([]common.Hash, len(receipts))
+	for i, receipt := range receipts {
+		level[i] = Leaf(receipt)
+	}
+	return level
+}
+
+// parents returns the level above the given one.
+func parents(level []common.Hash) []common.Hash {
+	next := make([]common.Hash, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			break
+		}
+		next = append(next, node(level[i], level[i+1]))
+	}
+	return next
+}
+
+func node(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte{nodePrefix}, left[:], right[:])
+}
+
+func root(count uint64, top common.Hash) common.Hash {
+	var blob [1 + 8]byte
+	blob[0] = rootPrefix
+	binary.BigEndian.PutUint64(blob[1:], count)
+	return crypto.Keccak256Hash(blob[:], top[:])
+}
@@ -0,0 +1,66 @@
+package commitment
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func makeReceipts(n int) types.Receipts {
+	receipts := make(types.Receipts, n)
+	for i := range receipts {
+		receipts[i] = &types.Receipt{TxHash: common.Hash{byte(i + 1)}, Status: uint64(i % 2), GasUsed: uint64(21000 + i)}
+	}
+	return receipts
+}
+
+func TestProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		receipts := makeReceipts(n)
+		commitment := Root(receipts)
+
+		for i := 0; i < n; i++ {
+			proof, err := Prove(receipts, i)
+			if err != nil {
+				t.Fatalf("%d receipts: failed to prove %d: %v", n, i, err)
+			}
+			leaf := Leaf(receipts[i])
+			if !Verify(commitment, leaf, i, n, proof) {
+				t.Errorf("%d receipts: proof of %d rejected", n, i)
+			}
+			// Proofs are bound to the position and the number of receipts
+			if n > 1 && Verify(commitment, leaf, (i+1)%n, n, proof) {
+				t.Errorf("%d receipts: proof of %d accepted at %d", n, i, (i+1)%n)
+			}
+			if Verify(commitment, leaf, i, n+1, proof) {
+				t.Errorf("%d receipts: proof of %d accepted for %d receipts", n, i, n+1)
+			}
+		}
+	}
+	if _, err := Prove(makeReceipts(2), 2); err != errIndexOutOfRange {
+		t.Errorf("error mismatch: have %v, want %v", err, errIndexOutOfRange)
+	}
+}
+
+func TestLeafFields(t *testing.T) {
+	receipt := &types.Receipt{TxHash: common.Hash{0x01}, Status: types.ReceiptStatusSuccessful, GasUsed: 21000}
+	leaf := Leaf(receipt)
+
+	failed := *receipt
+	failed.Status = types.ReceiptStatusFailed
+	if Leaf(&failed) == leaf {
+		t.Errorf("status not committed to")
+	}
+	used := *receipt
+	used.GasUsed++
+	if Leaf(&used) == leaf {
+		t.Errorf("gas used not committed to")
+	}
+	// The receipt fields outside of the commitment don't alter it
+	logged := *receipt
+	logged.Logs = []*types.Log{{Address: common.Address{0x01}}}
+	if Leaf(&logged) != leaf {
+		t.Errorf("logs committed to")
+	}
+}
@@ -0,0 +1,166 @@
+// Package conformance generates golden test vectors for the executor protocol,
+// so consensus layers implemented in other languages can verify that they encode
+// pb.ExecBlocks the way the executor expects, and that they derive the same
+// execution outcome from them.
+//
+// Every vector starts from a fixed genesis and feeds a sequence of canonically
+// encoded ExecBlocks through the execution pipeline of the executor, recording
+// the header and receipts of every block written. Blocks are finalized with
+// ethash semantics: the block reward is credited to the zero coinbase.
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Vector is a conformance test case: a sequence of consensus blocks executed on
+// top of a genesis, along with the outcome of each of them.
+type Vector struct {
+	Name    string        `json:"name"`
+	Comment string        `json:"comment,omitempty"`
+	Genesis *core.Genesis `json:"genesis"`
+	GasCeil uint64        `json:"gasCeil"` // gas limit the executor targets
+	Blocks  []*Block      `json:"blocks"`
+}
+
+// Block is a consensus block of a vector and its expected execution outcome.
+type Block struct {
+	Timestamp uint64           `json:"timestamp"` // header time the block is executed with
+	ExecBlock hexutil.Bytes    `json:"execBlock"` // deterministic protobuf encoding of the pb.ExecBlock
+	Header    *types.Header    `json:"header"`    // header of the written block
+	Receipts  []*types.Receipt `json:"receipts"`  // receipts of the written block
+}
+
+// backend is the minimal miner.Backend needed to run the executor offline.
+type backend struct {
+	chain *core.BlockChain
+}
+
+func (b *backend) BlockChain() *core.BlockChain { return b.chain }
+func (b *backend) TxPool() *txpool.TxPool       { return nil }
+
+// encode returns the canonical encoding of an ExecBlock. Deterministic mode
+// is needed to pin down the order of the metadata map.
+func encode(block *pb.ExecBlock) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(block)
+}
+
+// newChain initializes a fresh in-memory chain from the genesis of the vector.
+func newChain(genesis *core.Genesis) (*core.BlockChain, error) {
+	return core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+}
+
+// execute runs the consensus blocks through the executor in order, calling
+// the callback with the outcome of each of them.
+func execute(v *Vector, blocks []*pb.ExecBlock, timestamps []uint64, fn func(i int, block *types.Block, receipts types.Receipts) error) error {
+	chain, err := newChain(v.Genesis)
+	if err != nil {
+		return err
+	}
+	defer chain.Stop()
+
+	config := miner.DefaultConfig
+	config.GasCeil = v.GasCeil
+
+	eth := &backend{chain: chain}
+	for i, block := range blocks {
+		written, receipts, err := miner.ExecuteBlock(eth, &config, chain.Config(), chain.Engine(), block, timestamps[i])
+		if err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+		if err := fn(i, written, receipts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// build executes the consensus blocks of a test case, filling in the vector
+// with their encoding and outcome.
+func build(v *Vector, blocks []*pb.ExecBlock, timestamps []uint64) error {
+	v.Blocks = make([]*Block, len(blocks))
+	for i, block := range blocks {
+		blob, err := encode(block)
+		if err != nil {
+			return err
+		}
+		v.Blocks[i] = &Block{Timestamp: timestamps[i], ExecBlock: blob}
+	}
+	return execute(v, blocks, timestamps, func(i int, block *types.Block, receipts types.Receipts) error {
+		v.Blocks[i].Header = block.Header()
+		v.Blocks[i].Receipts = receipts
+		return nil
+	})
+}
+
+// Verify decodes the consensus blocks of the vector and executes them, checking
+// that the outcome matches the expected one.
+func (v *Vector) Verify() error {
+	var (
+		blocks     = make([]*pb.ExecBlock, len(v.Blocks))
+		timestamps = make([]uint64, len(v.Blocks))
+	)
+	for i, block := range v.Blocks {
+		blocks[i] = new(pb.ExecBlock)
+		if err := proto.Unmarshal(block.ExecBlock, blocks[i]); err != nil {
+			return fmt.Errorf("block %d: invalid encoding: %v", i, err)
+		}
+		// Encoding the decoded block must yield the vector's encoding, otherwise
+		// the vector isn't canonical and can't be used for encoder tests.
+		blob, err := encode(blocks[i])
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(blob, block.ExecBlock) {
+			return fmt.Errorf("block %d: non-canonical encoding", i)
+		}
+		timestamps[i] = block.Timestamp
+	}
+	return execute(v, blocks, timestamps, func(i int, block *types.Block, receipts types.Receipts) error {
+		want := v.Blocks[i]
+		if want.Header == nil {
+			return fmt.Errorf("block %d: missing expected header", i)
+		}
+		if have := block.Root(); have != want.Header.Root {
+			return fmt.Errorf("block %d: state root mismatch: have %x, want %x", i, have, want.Header.Root)
+		}
+		if have := block.ReceiptHash(); have != want.Header.ReceiptHash {
+			return fmt.Errorf("block %d: receipts root mismatch: have %x, want %x", i, have, want.Header.ReceiptHash)
+		}
+		if have := block.GasUsed(); have != want.Header.GasUsed {
+			return fmt.Errorf("block %d: gas used mismatch: have %d, want %d", i, have, want.Header.GasUsed)
+		}
+		if have := len(receipts); have != len(want.Receipts) {
+			return fmt.Errorf("block %d: receipt count mismatch: have %d, want %d", i, have, len(want.Receipts))
+		}
+		if have := block.Hash(); have != want.Header.Hash() {
+			return fmt.Errorf("block %d: hash mismatch: have %x, want %x", i, have, want.Header.Hash())
+		}
+		return nil
+	})
+}
+
+// Generate produces all the conformance vectors.
+func Generate() ([]*Vector, error) {
+	var vectors []*Vector
+	for _, gen := range generators {
+		v, err := gen()
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,54 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	first, err := Generate()
+	if err != nil {
+		t.Fatalf("failed to generate vectors: %v", err)
+	}
+	second, err := Generate()
+	if err != nil {
+		t.Fatalf("failed to generate vectors: %v", err)
+	}
+	have, _ := json.Marshal(first)
+	want, _ := json.Marshal(second)
+	if !bytes.Equal(have, want) {
+		t.Fatalf("vectors differ between runs")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	vectors, err := Generate()
+	if err != nil {
+		t.Fatalf("failed to generate vectors: %v", err)
+	}
+	for _, v := range vectors {
+		// Verify the vectors as consumed by third parties, after a JSON round trip
+		blob, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("%s: failed to encode vector: %v", v.Name, err)
+		}
+		var dec Vector
+		if err := json.Unmarshal(blob, &dec); err != nil {
+			t.Fatalf("%s: failed to decode vector: %v", v.Name, err)
+		}
+		if err := dec.Verify(); err != nil {
+			t.Errorf("%s: verification failed: %v", v.Name, err)
+		}
+		if len(dec.Blocks[0].Receipts) == 0 {
+			t.Errorf("%s: no txs executed", v.Name)
+		}
+		// Tampering with the outcome must be caught
+		dec.Blocks[len(dec.Blocks)-1].Header.Root = common.Hash{0x01}
+		if err := dec.Verify(); err == nil {
+			t.Errorf("%s: tampered vector verified", v.Name)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package conformance
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// Fixed accounts funded in the genesis of every vector.
+	aliceKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	bobKey, _   = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	alice       = crypto.PubkeyToAddress(aliceKey.PublicKey)
+	bob         = crypto.PubkeyToAddress(bobKey.PublicKey)
+	carol       = common.HexToAddress("0x000000000000000000000000000000000000ca01")
+
+	// emitter logs its calldata with the caller as topic and counts its calls
+	// in slot 1.
+	emitter     = common.HexToAddress("0x00000000000000000000000000000000000e0001")
+	emitterCode = common.FromHex("0x36600060003733366000a160015460010160015500")
+
+	// reverter reverts every call.
+	reverter     = common.HexToAddress("0x00000000000000000000000000000000000e0002")
+	reverterCode = common.FromHex("0x60006000fd")
+
+	// Header time of the first block of every vector, incremented per block.
+	baseTimestamp = uint64(1700000000)
+
+	funds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+)
+
+// generators are the test cases the vectors are produced from.
+var generators = []func() (*Vector, error){
+	transfersVector,
+	contractsVector,
+	invalidTxsVector,
+	multiBlockVector,
+	scheduleVector,
+	compressedVector,
+}
+
+// genesis returns the genesis shared by all the vectors. The executor seals
+// blocks without difficulty, which only become the head of post-merge chains.
+func genesis() *core.Genesis {
+	config := *params.TestChainConfig
+	config.TerminalTotalDifficulty = common.Big0
+	config.TerminalTotalDifficultyPassed = true
+	return &core.Genesis{
+		Config:     &config,
+		GasLimit:   30_000_000,
+		Difficulty: params.GenesisDifficulty,
+		Alloc: core.GenesisAlloc{
+			alice:    {Balance: funds},
+			bob:      {Balance: funds},
+			emitter:  {Balance: new(big.Int), Code: emitterCode, Storage: map[common.Hash]common.Hash{common.BigToHash(common.Big1): common.BigToHash(big.NewInt(41))}},
+			reverter: {Balance: new(big.Int), Code: reverterCode},
+		},
+	}
+}
+
+// newVector creates an empty vector on top of the shared genesis.
+func newVector(name, comment string) *Vector {
+	return &Vector{
+		Name:    name,
+		Comment: comment,
+		Genesis: genesis(),
+		GasCeil: 30_000_000,
+	}
+}
+
+// timestamps returns the header times of n consecutive blocks.
+func timestamps(n int) []uint64 {
+	times := make([]uint64, n)
+	for i := range times {
+		times[i] = baseTimestamp + uint64(i)*12
+	}
+	return times
+}
+
+// sign signs a tx with the chain config of the shared genesis.
+func sign(key *ecdsa.PrivateKey, tx types.TxData) *types.Transaction {
+	return types.MustSignNewTx(key, types.LatestSigner(params.TestChainConfig), tx)
+}
+
+// transfer creates a signed legacy value transfer.
+func transfer(key *ecdsa.PrivateKey, nonce uint64, to common.Address, value int64) *types.Transaction {
+	return sign(key, &types.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Value:    big.NewInt(value),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.GWei),
+	})
+}
+
+// call creates a signed dynamic fee contract call.
+func call(key *ecdsa.PrivateKey, nonce uint64, to *common.Address, data []byte) *types.Transaction {
+	return sign(key, &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		To:        to,
+		Gas:       100_000,
+		GasFeeCap: big.NewInt(2 * params.GWei),
+		GasTipCap: big.NewInt(params.GWei),
+		Data:      data,
+	})
+}
+
+// wrap encodes the txs the way the consensus layer relays them.
+func wrap(txs ...*types.Transaction) [][]byte {
+	blobs := make([][]byte, len(txs))
+	for i, tx := range txs {
+		payload, err := tx.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+		blobs[i] = wrapPayload(payload)
+	}
+	return blobs
+}
+
+// wrapPayload encodes an arbitrary payload as a normal pb.Transaction.
+func wrapPayload(payload []byte) []byte {
+	blob, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.Transaction{
+		Type:    pb.TransactionType_NORMAL,
+		Payload: payload,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return blob
+}
+
+func transfersVector() (*Vector, error) {
+	v := newVector("transfers", "value transfers of every supported tx type")
+
+	to := carol
+	blocks := []*pb.ExecBlock{{
+		Txs: wrap(
+			transfer(aliceKey, 0, carol, 1),
+			sign(bobKey, &types.AccessListTx{
+				ChainID:    params.TestChainConfig.ChainID,
+				Nonce:      0,
+				To:         &to,
+				Value:      big.NewInt(2),
+				Gas:        30_000,
+				GasPrice:   big.NewInt(params.GWei),
+				AccessList: types.AccessList{{Address: carol}},
+			}),
+			sign(aliceKey, &types.DynamicFeeTx{
+				ChainID:   params.TestChainConfig.ChainID,
+				Nonce:     1,
+				To:        &to,
+				Value:     big.NewInt(3),
+				Gas:       params.TxGas,
+				GasFeeCap: big.NewInt(2 * params.GWei),
+				GasTipCap: big.NewInt(params.GWei),
+			}),
+		),
+	}}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
+
+func contractsVector() (*Vector, error) {
+	v := newVector("contracts", "log emitting, reverting and contract creating txs")
+
+	blocks := []*pb.ExecBlock{{
+		Txs: wrap(
+			call(aliceKey, 0, &emitter, []byte("hello")),
+			call(bobKey, 0, &reverter, nil),
+			call(aliceKey, 1, nil, []byte{0x00}),
+			call(bobKey, 1, &emitter, []byte("world")),
+		),
+	}}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
+
+func invalidTxsVector() (*Vector, error) {
+	v := newVector("invalid-txs", "undecodable, oversized and unexecutable txs are dropped")
+
+	blocks := []*pb.ExecBlock{{
+		Txs: [][]byte{
+			{0xff, 0xff, 0xff},                       // not a pb.Transaction
+			wrapPayload([]byte{0x02, 0xc0}),          // not an eth tx
+			wrapPayload(make([]byte, 128*1024+1)),    // oversized
+			wrap(transfer(aliceKey, 5, carol, 1))[0], // nonce too high
+			wrap(transfer(bobKey, 0, carol, 1))[0],
+		},
+	}}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
+
+func multiBlockVector() (*Vector, error) {
+	v := newVector("multi-block", "consecutive blocks building on each other")
+
+	blocks := []*pb.ExecBlock{
+		{Txs: wrap(transfer(aliceKey, 0, bob, 1000), call(bobKey, 0, &emitter, []byte{0x01}))},
+		{Txs: wrap(transfer(aliceKey, 1, bob, 1000), call(bobKey, 1, &emitter, []byte{0x02}))},
+		{Txs: wrap(call(aliceKey, 2, &emitter, []byte{0x03}))},
+	}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
+
+func scheduleVector() (*Vector, error) {
+	v := newVector("schedule", "schedule hints of consensus leaving the tx order untouched")
+
+	// The hints run nonce 1 of alice first, her txs are executed in block order
+	// regardless
+	blocks := []*pb.ExecBlock{{
+		Txs: wrap(
+			transfer(aliceKey, 0, carol, 1),
+			transfer(aliceKey, 1, carol, 1),
+			call(bobKey, 0, &emitter, []byte("scheduled")),
+		),
+		Schedule: []*pb.TxGroup{{Txs: []uint32{1, 2}}, {Txs: []uint32{0}}},
+	}}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
+
+func compressedVector() (*Vector, error) {
+	v := newVector("compressed", "txs compressed with zstd")
+
+	list, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb.TxList{
+		Txs: wrap(transfer(aliceKey, 0, carol, 1), call(bobKey, 0, &emitter, []byte("compressed"))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	blocks := []*pb.ExecBlock{{
+		Compression:   pb.Compression_ZSTD,
+		CompressedTxs: enc.EncodeAll(list, nil),
+	}}
+	return v, build(v, blocks, timestamps(len(blocks)))
+}
@@ -0,0 +1,279 @@
+// Package divergence locates the first block two executor databases disagree
+// on, for debugging replicas which diverged although consensus delivered them
+// the same blocks.
+//
+// Once two chains diverge, every later block differs as well, as it commits to
+// its parent. The first divergent block is thus found by bisecting the common
+// height range of the canonical chains. Its headers, receipts and the post
+// state of the accounts its txs touched are then compared field by field.
+package divergence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+)
+
+var errNoChain = errors.New("database holds no chain")
+
+// Diff is a field two replicas disagree on.
+type Diff struct {
+	Field string
+	Local string
+	Other string
+}
+
+// ReceiptDiff lists the fields the receipts of a tx differ in.
+type ReceiptDiff struct {
+	Index  int
+	TxHash common.Hash
+	Diffs  []Diff
+}
+
+// AccountDiff lists the fields the post state of an account differs in.
+type AccountDiff struct {
+	Address common.Address
+	Diffs   []Diff
+}
+
+// Report is the outcome of comparing two executor databases.
+type Report struct {
+	LocalHead uint64 // head block number of the local chain
+	OtherHead uint64 // head block number of the other chain
+
+	Diverged bool   // whether the chains disagree on a block they both hold
+	Common   uint64 // last block number both chains agree on, unless they diverge at genesis
+
+	Local *types.Header // first divergent block of the local chain, nil unless diverged
+	Other *types.Header // first divergent block of the other chain, nil unless diverged
+
+	Header   []Diff        // header fields the divergent blocks differ in
+	Receipts []ReceiptDiff // receipts the divergent blocks differ in
+	Accounts []AccountDiff // touched accounts whose post state differs
+	StateErr error         // why the post states couldn't be compared, if so
+}
+
+// Compare walks the canonical chains of two executor databases and reports the
+// first block they diverge at.
+func Compare(local, other ethdb.Database) (*Report, error) {
+	localHead, err := headNumber(local)
+	if err != nil {
+		return nil, fmt.Errorf("local: %w", err)
+	}
+	otherHead, err := headNumber(other)
+	if err != nil {
+		return nil, fmt.Errorf("other: %w", err)
+	}
+	report := &Report{LocalHead: localHead, OtherHead: otherHead}
+
+	// Bisect the common range for the first block the chains disagree on
+	limit := localHead
+	if otherHead < limit {
+		limit = otherHead
+	}
+	agree := func(number uint64) bool {
+		return rawdb.ReadCanonicalHash(local, number) == rawdb.ReadCanonicalHash(other, number)
+	}
+	if agree(limit) {
+		report.Common = limit
+		return report, nil
+	}
+	number := uint64(sort.Search(int(limit)+1, func(n int) bool { return !agree(uint64(n)) }))
+	report.Diverged = true
+	if number > 0 {
+		report.Common = number - 1
+	}
+	localHash, otherHash := rawdb.ReadCanonicalHash(local, number), rawdb.ReadCanonicalHash(other, number)
+	report.Local = rawdb.ReadHeader(local, localHash, number)
+	report.Other = rawdb.ReadHeader(other, otherHash, number)
+	if report.Local == nil || report.Other == nil {
+		return nil, fmt.Errorf("missing header of divergent block %d", number)
+	}
+	report.Header = compareHeaders(report.Local, report.Other)
+
+	config := rawdb.ReadChainConfig(local, rawdb.ReadCanonicalHash(local, 0))
+	if config == nil {
+		return nil, errors.New("local: missing chain config")
+	}
+	var (
+		localReceipts = rawdb.ReadReceipts(local, localHash, number, report.Local.Time, config)
+		otherReceipts = rawdb.ReadReceipts(other, otherHash, number, report.Other.Time, config)
+	)
+	report.Receipts = compareReceipts(localReceipts, otherReceipts)
+
+	localRoot, otherRoot := postStateRoot(local, report.Local), postStateRoot(other, report.Other)
+	if localRoot != otherRoot {
+		touched := touchedAccounts(config, report.Local, rawdb.ReadBody(local, localHash, number), localReceipts)
+		for addr := range touchedAccounts(config, report.Other, rawdb.ReadBody(other, otherHash, number), otherReceipts) {
+			touched[addr] = struct{}{}
+		}
+		report.Accounts, report.StateErr = compareStates(local, other, localRoot, otherRoot, touched)
+	}
+	return report, nil
+}
+
+// headNumber returns the number of the head block of a database.
+func headNumber(db ethdb.Database) (uint64, error) {
+	hash := rawdb.ReadHeadBlockHash(db)
+	if hash == (common.Hash{}) {
+		return 0, errNoChain
+	}
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		return 0, fmt.Errorf("missing number of head block %x", hash)
+	}
+	return *number, nil
+}
+
+// postStateRoot returns the root of the post state of a block, recorded aside
+// of the header if the chain defers its state roots.
+func postStateRoot(db ethdb.Database, header *types.Header) common.Hash {
+	if root := rawdb.ReadPostStateRoot(db, header.Hash()); root != (common.Hash{}) {
+		return root
+	}
+	return header.Root
+}
+
+// diffs collects the fields with differing values.
+type diffs []Diff
+
+func (d *diffs) check(field string, local, other interface{}) {
+	if l, o := fmt.Sprint(local), fmt.Sprint(other); l != o {
+		*d = append(*d, Diff{Field: field, Local: l, Other: o})
+	}
+}
+
+// compareHeaders lists the header fields two blocks differ in, skipping the
+// hashes every divergent block differs in.
+func compareHeaders(local, other *types.Header) []Diff {
+	var d diffs
+	d.check("parentHash", local.ParentHash.Hex(), other.ParentHash.Hex())
+	d.check("stateRoot", local.Root.Hex(), other.Root.Hex())
+	d.check("txRoot", local.TxHash.Hex(), other.TxHash.Hex())
+	d.check("receiptsRoot", local.ReceiptHash.Hex(), other.ReceiptHash.Hex())
+	d.check("gasUsed", local.GasUsed, other.GasUsed)
+	d.check("gasLimit", local.GasLimit, other.GasLimit)
+	d.check("baseFee", local.BaseFee, other.BaseFee)
+	d.check("coinbase", local.Coinbase.Hex(), other.Coinbase.Hex())
+	d.check("timestamp", local.Time, other.Time)
+	d.check("extraData", common.Bytes2Hex(local.Extra), common.Bytes2Hex(other.Extra))
+	d.check("logsBloomHash", bloomHash(local.Bloom), bloomHash(other.Bloom))
+	return d
+}
+
+// bloomHash abbreviates a bloom filter to its hash.
+func bloomHash(bloom types.Bloom) string {
+	return crypto.Keccak256Hash(bloom[:]).Hex()
+}
+
+// compareReceipts lists the receipts two blocks differ in, by position.
+func compareReceipts(local, other types.Receipts) []ReceiptDiff {
+	var result []ReceiptDiff
+	for i := 0; i < len(local) || i < len(other); i++ {
+		var d diffs
+		switch {
+		case i >= len(local):
+			d.check("receipt", "missing", other[i].TxHash.Hex())
+		case i >= len(other):
+			d.check("receipt", local[i].TxHash.Hex(), "missing")
+		default:
+			l, o := local[i], other[i]
+			d.check("txHash", l.TxHash.Hex(), o.TxHash.Hex())
+			d.check("status", l.Status, o.Status)
+			d.check("gasUsed", l.GasUsed, o.GasUsed)
+			d.check("cumulativeGasUsed", l.CumulativeGasUsed, o.CumulativeGasUsed)
+			d.check("contractAddress", l.ContractAddress.Hex(), o.ContractAddress.Hex())
+			d.check("logs", len(l.Logs), len(o.Logs))
+			d.check("logsBloomHash", bloomHash(l.Bloom), bloomHash(o.Bloom))
+		}
+		if len(d) > 0 {
+			var hash common.Hash
+			if i < len(local) {
+				hash = local[i].TxHash
+			} else {
+				hash = other[i].TxHash
+			}
+			result = append(result, ReceiptDiff{Index: i, TxHash: hash, Diffs: d})
+		}
+	}
+	return result
+}
+
+// touchedAccounts returns the accounts a block likely modified: the fee
+// recipient, the senders and recipients of its txs, the contracts it created
+// and the ones that emitted logs.
+func touchedAccounts(config *params.ChainConfig, header *types.Header, body *types.Body, receipts types.Receipts) map[common.Address]struct{} {
+	touched := map[common.Address]struct{}{header.Coinbase: {}}
+	if body != nil {
+		signer := types.MakeSigner(config, header.Number, header.Time)
+		for _, tx := range body.Transactions {
+			if from, err := types.Sender(signer, tx); err == nil {
+				touched[from] = struct{}{}
+			}
+			if to := tx.To(); to != nil {
+				touched[*to] = struct{}{}
+			}
+		}
+	}
+	for _, receipt := range receipts {
+		if receipt.ContractAddress != (common.Address{}) {
+			touched[receipt.ContractAddress] = struct{}{}
+		}
+		for _, log := range receipt.Logs {
+			touched[log.Address] = struct{}{}
+		}
+	}
+	return touched
+}
+
+// compareStates lists the accounts whose state differs between the post states
+// of the divergent blocks. The states may be unavailable if pruned.
+func compareStates(local, other ethdb.Database, localRoot, otherRoot common.Hash, touched map[common.Address]struct{}) ([]AccountDiff, error) {
+	localState, err := openState(local, localRoot)
+	if err != nil {
+		return nil, fmt.Errorf("local state %x: %w", localRoot, err)
+	}
+	otherState, err := openState(other, otherRoot)
+	if err != nil {
+		return nil, fmt.Errorf("other state %x: %w", otherRoot, err)
+	}
+	addrs := make([]common.Address, 0, len(touched))
+	for addr := range touched {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Cmp(addrs[j]) < 0 })
+
+	var result []AccountDiff
+	for _, addr := range addrs {
+		var d diffs
+		d.check("exists", localState.Exist(addr), otherState.Exist(addr))
+		d.check("nonce", localState.GetNonce(addr), otherState.GetNonce(addr))
+		d.check("balance", localState.GetBalance(addr), otherState.GetBalance(addr))
+		d.check("codeHash", localState.GetCodeHash(addr).Hex(), otherState.GetCodeHash(addr).Hex())
+		d.check("storageRoot", localState.GetStorageRoot(addr).Hex(), otherState.GetStorageRoot(addr).Hex())
+		if len(d) > 0 {
+			result = append(result, AccountDiff{Address: addr, Diffs: d})
+		}
+	}
+	return result, nil
+}
+
+// openState opens a state of a database read-only, whichever scheme it uses.
+func openState(db ethdb.Database, root common.Hash) (*state.StateDB, error) {
+	config := &trie.Config{HashDB: hashdb.Defaults}
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		config = &trie.Config{PathDB: pathdb.ReadOnly}
+	}
+	return state.New(root, state.NewDatabaseWithConfig(db, config), nil)
+}
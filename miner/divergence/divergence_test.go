@@ -0,0 +1,111 @@
+package divergence
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testUser    = common.Address{0x01}
+)
+
+// newChain writes a chain of n blocks to a fresh database, transferring the
+// value returned by value from the funded test account in every block.
+func newChain(t *testing.T, n int, value func(int) int64) ethdb.Database {
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{testAddress: {Balance: big.NewInt(params.Ether)}},
+	}
+	signer := types.LatestSigner(genesis.Config)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), n, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(testAddress), testUser, big.NewInt(value(i)), params.TxGas, gen.BaseFee(), nil), signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	// Archive the states, the divergent block isn't the head
+	cache := core.DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cache.TrieDirtyDisabled = true
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, cache, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+	return db
+}
+
+func TestCompare(t *testing.T) {
+	local := newChain(t, 6, func(int) int64 { return 1 })
+
+	// Chains agreeing on every block they both hold don't diverge
+	report, err := Compare(local, newChain(t, 4, func(int) int64 { return 1 }))
+	if err != nil {
+		t.Fatalf("failed to compare chains: %v", err)
+	}
+	if report.Diverged || report.Common != 4 || report.LocalHead != 6 || report.OtherHead != 4 {
+		t.Errorf("report mismatch: have diverged %v at %d, heads %d/%d, want agreement up to 4, heads 6/4", report.Diverged, report.Common, report.LocalHead, report.OtherHead)
+	}
+	// Chains diverging are reported at the first block they disagree on
+	other := newChain(t, 6, func(i int) int64 {
+		if i >= 2 {
+			return 2
+		}
+		return 1
+	})
+	report, err = Compare(local, other)
+	if err != nil {
+		t.Fatalf("failed to compare chains: %v", err)
+	}
+	if !report.Diverged || report.Common != 2 || report.Local.Number.Uint64() != 3 {
+		t.Fatalf("divergence mismatch: have diverged %v after %d, want diverged after 2", report.Diverged, report.Common)
+	}
+	fields := make(map[string]bool)
+	for _, diff := range report.Header {
+		fields[diff.Field] = true
+	}
+	for _, field := range []string{"stateRoot", "txRoot"} {
+		if !fields[field] {
+			t.Errorf("header diff %s missing: have %v", field, report.Header)
+		}
+	}
+	if fields["parentHash"] || fields["gasUsed"] {
+		t.Errorf("unexpected header diffs: %v", report.Header)
+	}
+	// Same gas, different txs: only the hashes of the receipts differ
+	if len(report.Receipts) != 1 || report.Receipts[0].Index != 0 || report.Receipts[0].Diffs[0].Field != "txHash" {
+		t.Errorf("receipt diffs mismatch: have %v, want the tx hash of receipt 0", report.Receipts)
+	}
+	if report.StateErr != nil {
+		t.Fatalf("failed to compare states: %v", report.StateErr)
+	}
+	accounts := make(map[common.Address][]Diff)
+	for _, account := range report.Accounts {
+		accounts[account.Address] = account.Diffs
+	}
+	for _, addr := range []common.Address{testAddress, testUser} {
+		if diffs := accounts[addr]; len(diffs) != 1 || diffs[0].Field != "balance" {
+			t.Errorf("account %x diffs mismatch: have %v, want balance", addr, diffs)
+		}
+	}
+	if len(report.Accounts) != 2 {
+		t.Errorf("account diffs mismatch: have %d, want 2", len(report.Accounts))
+	}
+}
@@ -4,24 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net"
+	"net/http"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/consensus"
-	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/proto/pb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -31,28 +37,48 @@ const txMaxSize = 4 * 32 * 1024 // 128KB
 // information of the sealing block generation.
 type executor_env struct {
 	// 打包区块前的一些参数
-	signer   types.Signer
-	state    *state.StateDB // apply state changes here
-	gasPool  *core.GasPool  // available gas used to pack transactions
-	coinbase common.Address
-	header   *types.Header
+	signer    types.Signer
+	state     *state.StateDB // apply state changes here
+	stateBase uint64         // number of the committed block the state was opened on, for deferred state roots
+	chain     executionChain // headers and engine the block is executed against
+	gasPool   *core.GasPool  // available gas used to pack transactions
+	coinbase  common.Address
+	header    *types.Header
 
 	// 最后执行的结束后的结果，有多少tx被包括，他们的收据是什么
 	// 打包区块使用
 	tcount   int
 	txs      types.Transactions
 	receipts []*types.Receipt
+
+	shadow *shadowRun           // input of the shadow execution, nil if disabled
+	meta   *types.ConsensusMeta // consensus decision the block was delivered by, nil if none
+	budget *memoryBudget        // memory accounted to the execution, nil if unlimited
+
+	upgrades      map[common.Hash]struct{} // txs delivered as UPGRADE txs by consensus
+	unreservedGas uint64                   // gas of the txs not entitled to the reserved gas
+
+	calldataFee  *big.Int // fee per calldata byte burned from the sender, nil if none
+	calldataUsed uint64   // calldata bytes of the included txs
 }
 
 // copy creates a deep copy of environment.
 func (env *executor_env) copy() *executor_env {
 	cpy := &executor_env{
-		signer:   env.signer,
-		state:    env.state.Copy(),
-		tcount:   env.tcount,
-		coinbase: env.coinbase,
-		header:   types.CopyHeader(env.header),
-		receipts: copyReceipts(env.receipts),
+		signer:    env.signer,
+		state:     env.state.Copy(),
+		stateBase: env.stateBase,
+		chain:     env.chain,
+		tcount:    env.tcount,
+		coinbase:  env.coinbase,
+		header:    types.CopyHeader(env.header),
+		receipts:  copyReceipts(env.receipts),
+
+		upgrades:      env.upgrades,
+		unreservedGas: env.unreservedGas,
+
+		calldataFee:  env.calldataFee,
+		calldataUsed: env.calldataUsed,
 	}
 	if env.gasPool != nil {
 		gasPool := *env.gasPool
@@ -63,9 +89,43 @@ func (env *executor_env) copy() *executor_env {
 	return cpy
 }
 
+// verifyEnv is the context the txs consensus asks about are verified in, the
+// header and signer of the last written block. It is never modified once
+// published, a new one is swapped in with every written block.
+type verifyEnv struct {
+	header *types.Header
+	signer types.Signer
+}
+
 type execReq struct {
 	timestamp int64
 	txs       types.Transactions
+	metadata  map[string][]byte        // consensus provided inputs of the system calls
+	schedule  [][]int                  // groups of independent txs hinted by consensus, nil if none
+	sequence  uint64                   // consensus height of the block, zero if unknown
+	meta      *types.ConsensusMeta     // consensus decision the block was delivered by, nil if none
+	upgrades  map[common.Hash]struct{} // txs delivered as UPGRADE txs, entitled to the reserved gas
+
+	expectedRoot common.Hash // state root consensus expects the block to reach, zero if unchecked
+	extra        []byte      // extra-data of the header supplied by consensus, nil if none
+	branch       string      // competing consensus branch the block extends, empty for the finalized lineage
+	parentHash   common.Hash // block consensus expects the block on top of, zero if unchecked
+	parentNumber uint64      // number of the expected parent
+
+	report batchReport // breakdown of the execution, logged once done
+
+	batches []types.ConsensusBatch // consensus blocks coalesced into the request, nil if not coalesced
+	origins []int                  // index of the coalesced consensus block of each tx
+
+	tentative chan *tentativeResult // outcome of the execution if the block awaits confirmation, nil otherwise
+	result    chan *blockResult     // outcome of the execution if consensus awaits it, nil otherwise
+	sub       *submission           // submission the retries of the block are handed the outcome by, nil if none
+	dropped   map[int]error         // undecodable txs by their position in the consensus block
+	warmer    *batchWarmer          // pre-loader of the state accessed by the block, nil if disabled
+	wal       []uint64              // write-ahead log entries of the consensus blocks in the request
+
+	raw         []*pb.ExecBlock // consensus blocks of the request, kept for the quarantine if enabled
+	quarantined uint64          // quarantine entry the request is retried from, zero if none
 }
 
 type executorServer struct {
@@ -74,68 +134,282 @@ type executorServer struct {
 }
 
 // Receive txs from consensus layer
-func (es *executorServer) CommitBlock(ctx context.Context, pbBlock *pb.ExecBlock) (*pb.Empty, error) {
-	pbtxs := pbBlock.GetTxs()
-	if len(pbtxs) == 0 {
-		return &pb.Empty{}, nil
+func (es *executorServer) CommitBlock(ctx context.Context, pbBlock *pb.ExecBlock) (*pb.BlockResult, error) {
+	return es.commitBlock(ctx, pbBlock)
+}
+
+// commitBlock queues a consensus block for execution, however it was delivered.
+// If consensus awaits the outcome of the block, it returns once executed.
+func (es *executorServer) commitBlock(ctx context.Context, pbBlock *pb.ExecBlock) (res *pb.BlockResult, err error) {
+	receivedBlocksMeter.Mark(1)
+	if es.executorPtr.halted.Load() {
+		return &pb.BlockResult{}, errExecutorHalted
+	}
+	if es.executorPtr.stopping.Load() || !es.executorPtr.deliveries.begin() {
+		return &pb.BlockResult{}, errExecutorStopping
+	}
+	defer es.executorPtr.deliveries.end()
+	// Consensus retries the blocks it didn't hear back about in time, they are
+	// handed the outcome of the first submission instead of being executed
+	// again on top of the new head
+	id, sub, dup := es.executorPtr.submissions.claimBlock(pbBlock)
+	if dup {
+		return es.awaitDuplicate(ctx, pbBlock, sub)
+	}
+	var (
+		start   = time.Now()
+		req     *execReq
+		dropped map[int]error
+		acked   bool
+	)
+	defer func() {
+		switch {
+		case sub == nil:
+		case !acked:
+			// Blocks failing before being queued are tried again
+			es.executorPtr.submissions.forget(id, sub)
+		case req == nil || req.sub == nil:
+			// Awaited blocks are settled once executed
+			sub.settle(res, err)
+		}
+	}()
+	// Branch blocks aren't written to the chain until consensus finalizes
+	// their branch, there is no outcome to hand back yet
+	if pbBlock.GetAwaitResult() && len(pbBlock.GetBranch()) != 0 {
+		return &pb.BlockResult{}, errAwaitedBranch
+	}
+	if es.executorPtr.config.Stateless {
+		err = es.executorPtr.commitStateless(pbBlock)
+		acked = err == nil
+		return &pb.BlockResult{}, err
+	}
+	profileStage(stageDecode, len(pbBlock.GetTxs()), func() {
+		req, dropped, err = decodeExecBlock(pbBlock, es.executorPtr.verified)
+	})
+	if err != nil {
+		es.executorPtr.quarantineBlock(pbBlock, err)
+		return &pb.BlockResult{}, err
+	}
+	// Blocks building on a head the executor moved away from never land on
+	// their parent, consensus reconciles the chains first
+	if err := es.executorPtr.checkDelivered(req); err != nil {
+		return &pb.BlockResult{}, err
+	}
+	await := pbBlock.GetAwaitResult()
+
+	// Receive txs from consensus layer
+	if req != nil {
+		req.report.decode = time.Since(start)
+		req.timestamp = es.executorPtr.now().UnixNano()
+		if es.executorPtr.config.Quarantine {
+			req.raw = []*pb.ExecBlock{pbBlock}
+		}
+		if await {
+			req.result = make(chan *blockResult, 1)
+			req.sub = sub
+		}
+		if err := es.executorPtr.logBlock(req, pbBlock); err != nil {
+			return &pb.BlockResult{}, err
+		}
+		es.executorPtr.warmUp(req)
+	}
+	// Blocks without txs count as executed once their predecessors are
+	if err := es.executorPtr.queueBlock(pbBlock.GetSequence(), req); err != nil {
+		return &pb.BlockResult{}, err
 	}
-	var errs []error = make([]error, 0)
+	acked = true
+	if await {
+		return es.awaitResult(ctx, req, dropped)
+	}
+	// Check if there are protobuf errors in the consensus block
+	if len(dropped) != 0 {
+		errStr := fmt.Sprintf("There are %d errors in the block", len(dropped))
+		return &pb.BlockResult{}, fmt.Errorf(errStr)
+	}
+	return &pb.BlockResult{}, nil
+}
+
+// decodeExecBlock decodes the txs of a consensus block into an execution
+// request, returning nil if the block carries neither txs, extra-data nor
+// consensus metadata. Undecodable txs are
+// dropped from the block and returned by their position in it, while an
+// undecodable block is an error. The txs verified for consensus before are
+// taken from the verified cache, which may be nil.
+func decodeExecBlock(pbBlock *pb.ExecBlock, verified *txCache) (*execReq, map[int]error, error) {
+	pbtxs, err := blockTxs(pbBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := verifyChecksums(pbBlock, pbtxs); err != nil {
+		checksumMismatchMeter.Mark(1)
+		log.Warn("Rejecting consensus block failing checksums", "sequence", pbBlock.GetSequence(), "err", err)
+		return nil, nil, err
+	}
+	var dropped = make(map[int]error)
 	var txs types.Transactions = make(types.Transactions, 0)
+	var positions = make(map[int]int) // position of the decoded txs in the consensus block
+	var upgrades map[common.Hash]struct{}
 
-	for _, byte := range pbtxs {
+	for i, byte := range pbtxs {
 		pbTx := new(pb.Transaction)
 		err := proto.Unmarshal(byte, pbTx)
 		if err != nil {
-			errs = append(errs, err)
+			dropped[i] = err
+			continue
+		}
+		// Oversized txs are dropped by every replica based on the raw payload
+		// alone, so a rogue leader can't make the replicas diverge.
+		if len(pbTx.Payload) > txMaxSize {
+			log.Warn("Dropping oversized transaction from consensus block", "size", len(pbTx.Payload), "limit", txMaxSize)
+			dropped[i] = fmt.Errorf("%w: transaction size %v, limit %v", txpool.ErrOversizedData, len(pbTx.Payload), txMaxSize)
 			continue
 		}
-		tx := new(types.Transaction)
-		err = tx.UnmarshalBinary(pbTx.Payload)
+		tx, err := verified.decode(pbTx.Payload)
 		if err != nil {
-			errs = append(errs, err)
+			dropped[i] = err
 			continue
 		}
+		if err := checkDeposit(pbTx, tx); err != nil {
+			dropped[i] = err
+			continue
+		}
+		positions[i] = len(txs)
 		txs = append(txs, tx)
+
+		if pbTx.Type == pb.TransactionType_UPGRADE {
+			if upgrades == nil {
+				upgrades = make(map[common.Hash]struct{})
+			}
+			upgrades[tx.Hash()] = struct{}{}
+		}
 	}
-	// Receive txs from consensus layer
-	if txs.Len() != 0 {
-		es.executorPtr.execCh <- &execReq{timestamp: time.Now().UnixNano(), txs: txs}
+	extra := pbBlock.GetHeader().GetExtraData()
+	if len(extra) > int(params.MaximumExtraDataSize) {
+		return nil, nil, fmt.Errorf("%w: have %d, want at most %d", errOversizedExtra, len(extra), params.MaximumExtraDataSize)
 	}
-
-	// Check if there are protobuf errors in the consensus block
-	if len(errs) != 0 {
-		errStr := fmt.Sprintf("There are %d errors in the block", len(errs))
-		return &pb.Empty{}, fmt.Errorf(errStr)
+	var meta *types.ConsensusMeta
+	if len(pbBlock.GetBatchId()) > 0 || pbBlock.GetRound() != 0 || pbBlock.GetSequence() != 0 || pbBlock.GetProposer() != 0 || pbBlock.GetEpoch() != 0 {
+		meta = &types.ConsensusMeta{
+			BatchID:  pbBlock.GetBatchId(),
+			Round:    pbBlock.GetRound(),
+			Sequence: pbBlock.GetSequence(),
+			Proposer: pbBlock.GetProposer(),
+			Epoch:    pbBlock.GetEpoch(),
+		}
 	}
-	return &pb.Empty{}, nil
+	// Blocks without txs may still record their extra-data or provenance,
+	// the executor tells once it knows where they land
+	if txs.Len() == 0 && len(extra) == 0 && meta == nil {
+		return nil, dropped, nil
+	}
+	if parent := pbBlock.GetParentHash(); len(parent) != 0 && len(parent) != common.HashLength {
+		return nil, nil, fmt.Errorf("%w: have %d bytes, want %d", errParentHash, len(parent), common.HashLength)
+	}
+	req := &execReq{
+		txs:      txs,
+		metadata: pbBlock.GetMetadata(),
+		schedule: decodeSchedule(pbBlock.GetSchedule(), positions, len(pbtxs)),
+		sequence: pbBlock.GetSequence(),
+		upgrades: upgrades,
+		dropped:  dropped,
+		meta:     meta,
+
+		expectedRoot: common.BytesToHash(pbBlock.GetExpectedRoot()),
+		extra:        extra,
+		branch:       string(pbBlock.GetBranch()),
+		parentHash:   common.BytesToHash(pbBlock.GetParentHash()),
+		parentNumber: pbBlock.GetParentNumber(),
+	}
+	return req, dropped, nil
 }
 
 func (es *executorServer) VerifyTx(ctx context.Context, pTx *pb.Transaction) (*pb.Result, error) {
 	if pTx.Type != pb.TransactionType_NORMAL && pTx.Type != pb.TransactionType_UPGRADE {
-		return &pb.Result{Success: false}, nil
+		return &pb.Result{Success: false, Code: pb.VerifyCode_INVALID, Reason: "unsupported tx type"}, nil
 	}
-	tx := new(types.Transaction)
-	err := tx.UnmarshalBinary(pTx.Payload)
-	if err != nil {
-		return &pb.Result{Success: false}, nil
+	// Consensus may ask about the same tx repeatedly, it's decoded once and
+	// verified once per block. The decoded tx is kept for the execution too.
+	var (
+		hash = crypto.Keccak256Hash(pTx.Payload)
+		v    = es.executorPtr.verified.get(hash)
+		tx   *types.Transaction
+	)
+	if v != nil {
+		tx = v.tx
+	} else {
+		tx = new(types.Transaction)
+		if err := tx.UnmarshalBinary(pTx.Payload); err != nil {
+			return &pb.Result{Success: false, Code: pb.VerifyCode_INVALID, Reason: err.Error()}, nil
+		}
+		// Reject txs of other chains up front, the signature check would
+		// only report them as having an invalid sender
+		if err := es.executorPtr.checkChainID(tx); err != nil {
+			chainIDRejectedMeter.Mark(1)
+			return verifyResult(err), nil
+		}
+	}
+	env := es.executorPtr.verifyEnv()
+	if v != nil && v.env == env {
+		return verifyResult(v.err), nil
 	}
 	// default all txs here are remote
-	env := es.executorPtr.env
-	err = txpool.ValidateTransaction(tx, env.header, env.signer, es.executorPtr.opts)
+	err := txpool.ValidateTransaction(tx, env.header, env.signer, es.executorPtr.verifyOpts)
+	es.executorPtr.verified.add(hash, &verifiedTx{tx: tx, env: env, err: err})
+	return verifyResult(err), nil
+}
+
+// verifyResult converts the outcome of verifying a tx for consensus.
+func verifyResult(err error) *pb.Result {
 	if err != nil {
-		return &pb.Result{Success: false}, nil
+		return &pb.Result{Success: false, Code: verifyCode(err), Reason: err.Error()}
 	}
-	return &pb.Result{Success: true}, nil
+	return &pb.Result{Success: true}
 }
 
 //----------------------------------------------------------------------------------------------
 
 type executorClient struct {
-	p2pClient pb.P2PClient // to send txs to consensus layer
+	p2pClient       pb.P2PClient       // to send txs to consensus layer
+	consensusClient pb.ConsensusClient // to report back about the delivered blocks
+
+	channel atomic.Pointer[txChannel] // stream opened by consensus the txs are pushed into, nil if none
+	resend  *resendQueue              // txs failed to reach consensus, sent again once it's back, nil if disabled
 }
 
-// need add a loop routine to sendTx to consensus layer, when execCh has new txs
+// sendTx forwards a tx to consensus. Txs failing while consensus is unreachable
+// are queued for sending again, errTxQueued is returned then.
 func (ec *executorClient) sendTx(tx *types.Transaction) (*pb.Empty, error) {
+	packet, err := txPacket(tx)
+	if err != nil {
+		return nil, err
+	}
+	if ch := ec.channel.Load(); ch != nil && ch.send(tx.Hash(), packet) {
+		sentTxsMeter.Mark(1)
+		return &pb.Empty{}, nil
+	}
+	if err := ec.sendPacket(tx.Hash(), packet); err != nil {
+		return nil, err
+	}
+	sentTxsMeter.Mark(1)
+	return &pb.Empty{}, nil
+}
+
+// sendPacket sends the packet of a tx with the unary P2P.Send call, queueing
+// it for sending again if consensus is unreachable.
+func (ec *executorClient) sendPacket(hash common.Hash, packet *pb.Packet) error {
+	_, err := ec.p2pClient.Send(context.Background(), packet)
+	if err != nil {
+		if ec.resend != nil && transientErr(err) {
+			ec.resend.add(hash, packet)
+			return fmt.Errorf("%w: %v", errTxQueued, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// txPacket wraps a tx into the client packet consensus expects.
+func txPacket(tx *types.Transaction) (*pb.Packet, error) {
 	data, err := tx.MarshalBinary()
 	if err != nil {
 		return nil, err
@@ -153,104 +427,254 @@ func (ec *executorClient) sendTx(tx *types.Transaction) (*pb.Empty, error) {
 	if err != nil {
 		return nil, err
 	}
-	packet := &pb.Packet{
+	return &pb.Packet{
 		Msg:         rawRequest,
 		ConsensusID: -1,
 		Epoch:       -1,
 		Type:        pb.PacketType_CLIENTPACKET,
-	}
-	_, err = ec.p2pClient.Send(context.Background(), packet)
-	if err != nil {
-		return nil, err
-	}
-	return &pb.Empty{}, nil
+	}, nil
 }
 
 //----------------------------------------------------------------------------------------------
 
 type executor struct {
 	config      *Config                   // other config
+	live        atomic.Pointer[Config]    // config including the changes made while running, nil if unchanged
+	settingsMu  sync.Mutex                // serializes the config changes and snapshots
 	chainConfig *params.ChainConfig       // chain config
 	engine      consensus.Engine          // assemble block
 	eth         Backend                   // blockchain and txpool
-	opts        *txpool.ValidationOptions // to do basic validation of Tx
+	clock       Clock                     // source of the block timestamps, the system clock if nil
+	verifyOpts  *txpool.ValidationOptions // validation of the txs consensus asks to verify
+	forwardOpts *txpool.ValidationOptions // validation of the txs forwarded to consensus, the tip floor is minTip
+	verified    *txCache                  // txs verified for consensus, nil if disabled
+	submissions *submissions              // consensus blocks submitted recently, nil if not tracked
 
-	env *executor_env  // may be useful in some local situations
-	wg  sync.WaitGroup // for go-routine
+	env atomic.Pointer[verifyEnv] // verification context of the last written block, nil until the first one
+	wg  sync.WaitGroup            // for go-routine
 
 	running atomic.Bool   // a functional judge
 	startCh chan struct{} // ...
 	exitCh  chan struct{} // ...
+	ready   chan struct{} // closed once the backend is initialized and the WAL replayed, nil if ready from the start
+
+	newWorkCh          chan *newWorkReq     // to launch a new batch to consensus
+	resubmitIntervalCh chan time.Duration   // recommit intervals set by the user
+	resubmitAdjustCh   chan *intervalAdjust // feedback of the forwarding rounds on the recommit interval
+	execCh             chan *execReq        // received from consensus, and go to execute
+	confirmCh          chan *confirmReq     // decisions of consensus about the tentative blocks
+	finalizeCh         chan *finalizeReq    // competing branches finalized by consensus
+	setHeadCh          chan *setHeadReq     // blocks consensus rewinds the chain to
 
-	newWorkCh chan *newWorkReq // to launch a new batch to consensus
-	execCh    chan *execReq    // received from consensus, and go to execute
+	pending  *pendingBlock      // executed block awaiting confirmation, only accessed by the execution loop
+	branches map[string]*branch // competing branches executed aside of the chain, only accessed by the execution loop
+	order    blockOrder         // consensus blocks held back until their predecessors are queued
+	deferred *execReq           // block received while coalescing which couldn't be merged, only accessed by the execution loop
+	inflight *inflightWrite     // block being written in the background, only accessed by the execution loop
+	commits  stateCommits       // post states committed in the background for deferred state roots, only accessed by the execution loop
+
+	quarantine quarantine // consensus blocks failed irrecoverably, kept for the operator to retry
 
 	mu       sync.RWMutex   // The lock used to protect the coinbase
 	coinbase common.Address // yeah, baby
 
+	minTip   atomic.Pointer[big.Int]      // minimum effective tip of the txs forwarded to consensus
+	template atomic.Pointer[workTemplate] // header template of the blocks on top of the last parent
+	gasGov   atomic.Pointer[governedCeil] // gas ceiling read from the governance contract at the last epoch boundary
+
+	sending    atomic.Pointer[atomic.Int32] // interrupt of the forwarding round in flight, nil if none
+	executing  atomic.Bool                  // whether a consensus block is executing, forwarding waits meanwhile
+	sendMissed atomic.Bool                  // whether a forwarding round gave way to a consensus block since the last one
+	resendCh   chan struct{}                // rounds to run again once the consensus block executed
+
+	forwardOnce   sync.Once      // opens the state caches of the forwarding
+	forwardCache  state.Database // state caches the forwarding reads through, apart from the execution's
+	forwardResume time.Time      // time the forwarding may run again, only accessed by the send loop
+
 	// recommit is the time interval to re-create sealing work or to re-build
 	// payload in proof-of-stake stage.
 	recommit time.Duration
 
+	resubmitHook func(time.Duration, time.Duration) // Method to call upon updating resubmitting interval.
+
 	// client to consensus layer
 	execClient *executorClient
 
 	// server to consensus layer
-	server *grpc.Server // server pointer to the running server
-
+	server      *grpc.Server     // server pointer to the running server
+	listener    net.Listener     // listener the server is serving, nil until started
+	gateway     *http.Server     // HTTP/JSON gateway in front of the server, nil if disabled
+	gatewayConn *grpc.ClientConn // connection of the gateway to the server
+
+	mux         *event.TypeMux  // event mux of the node the executor events are posted on
+	stakingSubs stakingFanout   // consensus clients following the staking contract events of the written blocks
+	epochFeed   event.Feed      // summaries of the settlement epochs ended by the written blocks
+	standbys    diffBroadcaster // standby executors following the state accessed by the written blocks
+	hotSet      *hotSet         // frequently accessed contracts kept warm across blocks
+
+	heldTxs *lru.Cache[common.Hash, struct{}] // txs held back by the exclusion list already metered, nil if not tracked
+
+	inclusion *inclusionTracker        // time from first seen to inclusion of the forwarded txs
+	nonceGaps nonceGaps                // senders stalled below their forwarded txs
+	headCh    chan *pb.Head            // written blocks to announce to consensus
+	resultCh  chan *pb.ExecutionResult // outcome of the written blocks to report to consensus
+	exportCh  chan *ExportedBlock      // written blocks to publish to the receipt sink, nil if disabled
+	eventCh   chan interface{}         // executor events to post on the event mux, nil if there is none
+	shadowSem chan struct{}            // slot of the running shadow execution
+	execStats execStats                // consensus blocks going through the execution
+
+	alerter        *alerter     // sinks of the critical conditions
+	writeFailures  int          // number of consecutive failed chain writes
+	linkDownSince  atomic.Int64 // time the consensus layer became unreachable in unix nanoseconds, zero if reachable
+	linkDownPosted atomic.Bool  // whether the current outage of the consensus layer was published
+
+	consensusSeq atomic.Uint64 // consensus height last heard of
+	executedSeq  atomic.Uint64 // consensus height of the last executed block
+	walNext      atomic.Uint64 // index of the next consensus block logged to the write-ahead log
+
+	halted     atomic.Bool // whether a failed block halted the execution
+	stopping   atomic.Bool // whether the executor is shutting down, rejecting further consensus blocks
+	catchingUp atomic.Bool // whether the execution is replaying a backlog of consensus blocks
+	shedding   atomic.Bool // whether tx forwarding is paused under load
+	slowDisk   atomic.Bool // whether tx forwarding is paused by block writes exceeding their deadline
+	writing    atomic.Bool // whether a block is being written to the chain
+
+	health     *health.Server // standard gRPC health service of the executor API, nil if not serving
+	healthLock sync.Mutex     // serializes the health updates
+	serving    bool           // health last reported, guarded by healthLock
+
+	deliveries deliveries // consensus blocks being handed over to the execution, waited for on shutdown
+	drainOnce  sync.Once  // drains the deliveries once, on shutdown or before the node endpoint goes
 }
 
-// newExecutor creates a new executor.
-func newExecutor(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool, cli pb.P2PClient) *executor {
+// newBaseExecutor creates an executor with the state shared by the node and the
+// test harness, without any of the channels and loops driving it.
+func newBaseExecutor(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend) *executor {
+	// Stateless executors have no state to keep warm
+	var hotSet *hotSet
+	if !config.Stateless {
+		hotSet = newHotSet(config.HotSetWindow, config.HotSetSize)
+	}
+	// Consensus may be allowed to order txs this node wouldn't forward itself
+	verifyTip := config.GasPrice
+	if config.VerifyTip != nil {
+		verifyTip = config.VerifyTip
+	}
 	executor := &executor{
 		config:      config,
 		chainConfig: chainConfig,
 		engine:      engine,
 		eth:         eth,
 
-		opts: &txpool.ValidationOptions{
-			Config: chainConfig,
-			Accept: 0 |
-				1<<types.LegacyTxType |
-				1<<types.AccessListTxType |
-				1<<types.DynamicFeeTxType,
-			MaxSize: txMaxSize,
-			MinTip:  config.GasPrice,
-		},
+		verifyOpts:  newValidationOptions(chainConfig, verifyTip),
+		forwardOpts: newValidationOptions(chainConfig, config.GasPrice),
+		verified:    newTxCache(config.VerifyCacheSize),
+		submissions: newSubmissions(dedupCacheSize),
+		heldTxs:     lru.NewCache[common.Hash, struct{}](maxHeldTxs),
 
 		coinbase: config.Etherbase,
 
-		startCh: make(chan struct{}, 1),
-		exitCh:  make(chan struct{}),
+		hotSet:    hotSet,
+		inclusion: newInclusionTracker(config.PrioritySenders),
+		alerter:   newAlerter(config),
+	}
+	minTip := new(big.Int)
+	if config.GasPrice != nil {
+		minTip.Set(config.GasPrice)
+	}
+	executor.minTip.Store(minTip)
+	return executor
+}
 
-		newWorkCh: make(chan *newWorkReq),
-		execCh:    make(chan *execReq),
+// newExecutor creates a new executor.
+func newExecutor(config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, eth Backend, mux *event.TypeMux, isLocalBlock func(header *types.Header) bool, init bool, cli pb.P2PClient, cons pb.ConsensusClient) *executor {
+	executor := newBaseExecutor(config, chainConfig, engine, eth)
+	executor.mux = mux
+
+	executor.startCh = make(chan struct{}, 1)
+	executor.exitCh = make(chan struct{})
+
+	executor.newWorkCh = make(chan *newWorkReq)
+	executor.resendCh = make(chan struct{}, 1)
+	executor.resubmitIntervalCh = make(chan time.Duration)
+	executor.resubmitAdjustCh = make(chan *intervalAdjust, resubmitAdjustChanSize)
+	executor.execCh = make(chan *execReq)
+	executor.confirmCh = make(chan *confirmReq)
+	executor.finalizeCh = make(chan *finalizeReq)
+	executor.setHeadCh = make(chan *setHeadReq)
+
+	executor.shadowSem = make(chan struct{}, 1)
+	executor.headCh = make(chan *pb.Head, 1)
+	executor.resultCh = make(chan *pb.ExecutionResult, resultQueue)
+	if mux != nil {
+		executor.eventCh = make(chan interface{}, eventQueue)
+	}
+
+	for _, eip := range config.ShadowEIPs {
+		if !vm.ValidEip(eip) {
+			log.Warn("Invalid shadow execution EIP", "eip", eip)
+		}
+	}
+	if share := config.ForwardShare; share < 0 || share >= 100 {
+		log.Warn("Ignoring invalid tx forwarding share", "share", share)
 	}
 
 	// Sanitize recommit interval if the user-specified one is too short.
-	// recommit := executor.config.Recommit
-	// if recommit < minRecommitInterval {
-	// 	log.Warn("Sanitizing miner recommit interval", "provided", recommit, "updated", minRecommitInterval)
-	// 	recommit = minRecommitInterval
-	// }
-	// TODO : 暂定recommit为minRecommitInterval
-	recommit := minRecommitInterval
+	recommit := executor.config.Recommit
+	if recommit < minRecommitInterval {
+		log.Warn("Sanitizing miner recommit interval", "provided", recommit, "updated", minRecommitInterval)
+		recommit = minRecommitInterval
+	}
 	executor.recommit = recommit
 
 	// Register the grpc client
-	executor.execClient = &executorClient{p2pClient: cli}
+	executor.execClient = &executorClient{p2pClient: cli, consensusClient: cons, resend: newResendQueue(config.ResendQueue)}
 
 	// Register the grpc server
 	executorServer := executorServer{executorPtr: executor}
-	s := grpc.NewServer()
+	opts, err := serverOptions(config)
+	if err != nil {
+		log.Crit("Failed to set up executor TLS", "err", err)
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(meteredUnaryInterceptor, executor.readyUnaryInterceptor), grpc.ChainStreamInterceptor(meteredStreamInterceptor, executor.readyStreamInterceptor))
+	s := grpc.NewServer(opts...)
 	pb.RegisterExecutorServer(s, &executorServer)
+	pb.RegisterQueryServer(s, &queryServer{executorPtr: executor})
+	executor.health = registerHealth(s)
 	executor.server = s // then we can handle the server
-
+	publishStats(executor)
+
+	var sink ReceiptSink
+	if config.ReceiptExport != "" {
+		var err error
+		if sink, err = newReceiptSink(config.ReceiptExport); err != nil {
+			log.Error("Failed to create receipt sink, export disabled", "url", config.ReceiptExport, "err", err)
+		} else {
+			executor.exportCh = make(chan *ExportedBlock, config.ReceiptExportQueue)
+		}
+	}
 	// start loop
-	executor.wg.Add(3)
+	executor.wg.Add(10)
 	go executor.sendLoop()
 	go executor.executionLoop()
 	go executor.newExecLoop(recommit)
+	go executor.headLoop()
+	go executor.resultLoop()
+	go executor.shedLoop()
+	go executor.inFlightLoop()
+	go executor.nonceGapLoop()
+	go executor.exportLoop(sink)
+	go executor.resendLoop()
+	// A stuck mux subscriber must not hold up the shutdown, not waited on
+	go executor.eventLoop()
+	if config.StandbyPrimary != "" {
+		executor.wg.Add(1)
+		go executor.standbyLoop()
+	}
+	// The backend may still be initializing, the loops touching it wait
+	executor.ready = make(chan struct{})
+	executor.wg.Add(1)
+	go executor.initLoop()
 	// Submit first work to initialize pending state.
 	if init {
 		executor.startCh <- struct{}{}
@@ -263,17 +687,32 @@ func (e *executor) isRunning() bool {
 	return e.running.Load()
 }
 
-// start sets the running status as 1 and triggers new work submitting.
-func (e *executor) start() {
-	e.running.Store(true)
-	// !!! 这一段应该进入配置文件
-	listen, err := net.Listen("tcp", "127.0.0.1:9876") // will be included in config
-	if err != nil {
-		panic("cannot listen!")
+// start sets the running status as 1 and triggers new work submitting. The
+// executor gRPC API starts listening on the first start, unless served on a
+// node endpoint, failing to do so leaves the executor stopped.
+func (e *executor) start() error {
+	if e.listener == nil && !e.onNode() {
+		addr := e.config.ExecutorListenAddr
+		if addr == "" {
+			addr = DefaultConfig.ExecutorListenAddr
+		}
+		listener, err := listen(addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		e.listener = listener
+		go e.serve(listener)
+		log.Info("Executor API started", "addr", listener.Addr())
 	}
+	e.running.Store(true)
 
-	go e.server.Serve(listen)
+	if e.config.GatewayAddr != "" && e.gateway == nil && e.listener != nil {
+		if err := e.startGateway(dialTarget(e.listener)); err != nil {
+			log.Error("Failed to start executor gateway", "addr", e.config.GatewayAddr, "err", err)
+		}
+	}
 	e.startCh <- struct{}{}
+	return nil
 }
 
 // stop sets the running status as 0.
@@ -283,10 +722,23 @@ func (e *executor) stop() {
 
 // close terminates all background threads maintained by the worker.
 // Note the worker does not support being closed multiple times.
+//
+// Consensus blocks are no longer acked, but the block being executed is
+// still written: close only returns once the chain write completed, so it has
+// to be called before the chain and its database are stopped.
 func (e *executor) close() {
 	e.running.Store(false)
-	e.server.Stop()
+	e.stopGateway()
+
+	// The consensus blocks delivered so far are executed before the loops
+	// are torn down, the execution loop takes the deferred ones first
+	e.drain()
+	e.drainHeld()
 	close(e.exitCh)
+	e.cutServer()
+	if e.writing.Load() {
+		log.Info("Waiting for block write to complete")
+	}
 	e.wg.Wait()
 }
 
@@ -297,14 +749,41 @@ func (e *executor) etherbase() common.Address {
 	return e.coinbase
 }
 
+// newValidationOptions creates the options txs are validated with before being
+// forwarded to or verified for consensus.
+func newValidationOptions(chainConfig *params.ChainConfig, minTip *big.Int) *txpool.ValidationOptions {
+	return &txpool.ValidationOptions{
+		Config: chainConfig,
+		Accept: 0 |
+			1<<types.LegacyTxType |
+			1<<types.AccessListTxType |
+			1<<types.DynamicFeeTxType,
+		MaxSize: txMaxSize,
+		MinTip:  minTip,
+	}
+}
+
+// getMinTip retrieves the minimum effective tip required for forwarding.
+func (e *executor) getMinTip() *big.Int {
+	return new(big.Int).Set(e.minTip.Load())
+}
+
+// setMinTip updates the minimum effective tip required for forwarding.
+func (e *executor) setMinTip(tip *big.Int) {
+	e.minTip.Store(new(big.Int).Set(tip))
+}
+
 // 缺少启动用的循环newWorkLoop
 // newExecLoop
 func (e *executor) newExecLoop(recommit time.Duration) {
 	defer e.wg.Done()
+	if !e.waitReady() {
+		return
+	}
 	var (
-		interrupt *atomic.Int32
-		// minRecommit = recommit // minimal resubmit interval specified by user.
-		timestamp int64 // timestamp for each round of sealing.
+		interrupt   *atomic.Int32
+		minRecommit = recommit // minimal resubmit interval specified by user.
+		timestamp   int64      // timestamp for each round of sealing.
 	)
 
 	timer := time.NewTimer(0)
@@ -313,10 +792,9 @@ func (e *executor) newExecLoop(recommit time.Duration) {
 
 	// commit aborts in-flight transaction execution with given signal and resubmits a new one.
 	commit := func(s int32) {
-		// 这里应该不用暂存中断的逻辑？
-		// if interrupt != nil {
-		// 	interrupt.Store(s)
-		// }
+		if interrupt != nil {
+			interrupt.Store(s)
+		}
 		interrupt = new(atomic.Int32)
 		select {
 		case e.newWorkCh <- &newWorkReq{interrupt: interrupt, timestamp: timestamp}:
@@ -331,89 +809,160 @@ func (e *executor) newExecLoop(recommit time.Duration) {
 	for {
 		select {
 		case <-e.startCh:
-			fmt.Println("send the first start signal")
-			timestamp = time.Now().Unix()
+			timestamp = e.now().Unix()
 			commit(commitInterruptNewHead)
 		case <-timer.C:
-			fmt.Println("send the time start signal")
 			if e.isRunning() {
 				commit(commitInterruptResubmit)
 			}
+		case <-e.resendCh:
+			if e.isRunning() {
+				commit(commitInterruptResubmit)
+			}
+
+		case interval := <-e.resubmitIntervalCh:
+			// Adjust resubmit interval explicitly by user.
+			if interval < minRecommitInterval {
+				log.Warn("Sanitizing miner recommit interval", "provided", interval, "updated", minRecommitInterval)
+				interval = minRecommitInterval
+			}
+			log.Info("Executor recommit interval update", "from", minRecommit, "to", interval)
+			minRecommit, recommit = interval, interval
+
+			if e.resubmitHook != nil {
+				e.resubmitHook(minRecommit, recommit)
+			}
+
+		case adjust := <-e.resubmitAdjustCh:
+			// Adjust resubmit interval by feedback.
+			before := recommit
+			if adjust.inc {
+				target := float64(recommit.Nanoseconds()) / adjust.ratio
+				recommit = recalcRecommit(minRecommit, recommit, target, true)
+			} else {
+				recommit = recalcRecommit(minRecommit, recommit, float64(minRecommit.Nanoseconds()), false)
+			}
+			log.Trace("Executor recommit interval adjusted", "from", before, "to", recommit)
+
+			if e.resubmitHook != nil {
+				e.resubmitHook(minRecommit, recommit)
+			}
+
 		case <-e.exitCh:
 			return
 		}
 	}
 }
 
+// setRecommitInterval updates the interval txs are forwarded to consensus at.
+func (e *executor) setRecommitInterval(interval time.Duration) {
+	select {
+	case e.resubmitIntervalCh <- interval:
+	case <-e.exitCh:
+	}
+}
+
+// adjustResubmitInterval adjusts the resubmit interval.
+func (e *executor) adjustResubmitInterval(message *intervalAdjust) {
+	select {
+	case e.resubmitAdjustCh <- message:
+	default:
+		log.Warn("the resubmitAdjustCh is full, discard the message")
+	}
+}
+
 // prepareWork constructs the sealing task according to the given parameters,
 // either based on the last chain head or specified parent. In this function
 // the pending transactions are not filled yet, only the empty task returned.
-func (e *executor) prepareWork(genParams *generateParams) (*executor_env, error) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
-
-	// Find the parent block for sealing task
-	parent := e.eth.BlockChain().CurrentBlock()
-	if genParams.parentHash != (common.Hash{}) {
-		block := e.eth.BlockChain().GetBlockByHash(genParams.parentHash)
-		if block == nil {
-			return nil, fmt.Errorf("missing parent")
-		}
-		parent = block.Header()
+// The batchSize is the number of txs going to be executed on top of the task,
+// zero if the task is not going to be executed at all.
+func (e *executor) prepareWork(genParams *generateParams, batchSize int) (*executor_env, error) {
+	// Find the parent block for sealing task along with the header fields
+	// derived from it
+	tmpl, err := e.workTemplate(genParams.parentHash)
+	if err != nil {
+		return nil, err
 	}
 	// Sanity check the timestamp correctness, recap the timestamp
 	// to parent+1 if the mutation is allowed.
 	timestamp := genParams.timestamp
-	if parent.Time >= timestamp {
+	if tmpl.parent.Time >= timestamp {
 		if genParams.forceTime {
-			return nil, fmt.Errorf("invalid timestamp, parent %d given %d", parent.Time, timestamp)
+			return nil, fmt.Errorf("invalid timestamp, parent %d given %d", tmpl.parent.Time, timestamp)
 		}
-		timestamp = parent.Time + 1
+		timestamp = tmpl.parent.Time + 1
 	}
 	// Construct the sealing block header.
-	header := &types.Header{
-		ParentHash: parent.Hash(),
-		Number:     new(big.Int).Add(parent.Number, common.Big1),
-		GasLimit:   core.CalcGasLimit(parent.GasLimit, e.config.GasCeil),
-		Time:       timestamp,
-		Coinbase:   genParams.coinbase,
-		// ! TODO:just for test
-		Difficulty: big.NewInt(0),
-	}
-	// Adding EIP 1559 logic
-	if e.chainConfig.IsLondon(header.Number) {
-		header.BaseFee = eip1559.CalcBaseFee(e.chainConfig, parent)
-		if !e.chainConfig.IsLondon(parent.Number) {
-			parentGasLimit := parent.GasLimit * e.chainConfig.ElasticityMultiplier()
-			header.GasLimit = core.CalcGasLimit(parentGasLimit, e.config.GasCeil)
-		}
-	}
+	header := tmpl.header(timestamp, genParams.coinbase)
+
 	// Could potentially happen if starting to mine in an odd state.
 	// Note genParams.coinbase can be different with header.Coinbase
 	// since clique algorithm can modify the coinbase field in header.
-	env, err := e.makeEnv(parent, header, genParams.coinbase)
+	var env *executor_env
+	if genParams.forward {
+		env, err = e.makeForwardEnv(tmpl.parent, header, e.signerAt(tmpl, timestamp), genParams.coinbase)
+	} else {
+		// Starting the prefetcher has a fixed overhead which dominates the
+		// execution of tiny batches, only bother with it for larger ones.
+		prefetch := batchSize > 0 && batchSize >= e.config.PrefetchThreshold
+		env, err = e.makeEnv(tmpl.parent, header, e.signerAt(tmpl, timestamp), genParams.coinbase, genParams.state, prefetch)
+	}
 	if err != nil {
 		log.Error("Failed to create sealing context", "err", err)
 		return nil, err
 	}
-
+	// The block capacity follows the governance contract if the chain has one.
+	// The state of the parent is stale if its header defers the root and no
+	// post state was passed.
+	post := env.state
+	if e.deferredRoots(header.Number) && genParams.state == nil {
+		post = nil
+	}
+	if err := e.applyGasGovernor(tmpl.parent, env, post, !genParams.forward); err != nil {
+		log.Error("Failed to apply governed gas ceiling", "err", err)
+		return nil, err
+	}
 	return env, nil
 }
 
-// makeEnv creates a new environment for the sealing block.
-func (e *executor) makeEnv(parent *types.Header, header *types.Header, coinbase common.Address) (*executor_env, error) {
+// makeEnv creates a new environment for the sealing block, executing on the
+// given state or the one of the parent if nil.
+func (e *executor) makeEnv(parent *types.Header, header *types.Header, signer types.Signer, coinbase common.Address, state *state.StateDB, prefetch bool) (*executor_env, error) {
 	// Retrieve the parent state to execute on top and start a prefetcher for
 	// the miner to speed block sealing up a bit.
-	state, err := e.eth.BlockChain().StateAt(parent.Root)
-	if err != nil {
-		return nil, err
+	chain := e.eth.BlockChain()
+	root := chain.PostStateRoot(parent)
+	if state == nil {
+		if root == (common.Hash{}) {
+			return nil, fmt.Errorf("%w: block %d %x", core.ErrStateNotCommitted, parent.Number, parent.Hash())
+		}
+		var err error
+		if state, err = chain.StateAt(root); err != nil {
+			return nil, err
+		}
+	}
+	if prefetch {
+		state.StartPrefetcher("miner")
 	}
-	state.StartPrefetcher("miner")
+	// Blocks touching many contracts spend most of the hashing on their
+	// storage tries, which are independent of each other
+	workers := e.config.HashWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	state.SetHashWorkers(workers)
 
+	// Pull the contracts hot in the recent blocks into the clean cache
+	if e.hotSet != nil && root != (common.Hash{}) {
+		if warm, err := chain.StateAt(root); err == nil {
+			go e.hotSet.warm(warm)
+		}
+	}
 	// Note the passed coinbase may be different with header.Coinbase.
 	env := &executor_env{
-		signer:   types.MakeSigner(e.chainConfig, header.Number, header.Time),
+		signer:   signer,
 		state:    state,
+		chain:    chain,
 		coinbase: coinbase,
 		header:   header,
 	}
@@ -424,11 +973,18 @@ func (e *executor) makeEnv(parent *types.Header, header *types.Header, coinbase
 
 func (e *executor) sendLoop() {
 	defer e.wg.Done()
+	if !e.waitReady() {
+		return
+	}
 	for {
 		select {
 		case req := <-e.newWorkCh:
-			fmt.Println("sendLoop get a newWorkCh and start send tx")
+			if e.paceForward() {
+				continue
+			}
+			start := time.Now()
 			e.sendNewTxBatch(req.interrupt, req.timestamp)
+			e.forwarded(time.Since(start))
 		case <-e.exitCh:
 			return
 		}
@@ -441,32 +997,88 @@ func (e *executor) sendNewTxBatch(interrupt *atomic.Int32, timestamp int64) {
 	// 	return
 	// }
 
-	// Set the coinbase if the worker is running or it's required
-	var coinbase common.Address
-	if e.isRunning() {
-		coinbase = e.etherbase()
-		if coinbase == (common.Address{}) {
-			log.Error("Refusing to mine without etherbase")
-			return
-		}
+	// Set the coinbase if the worker is running or it's required, txs are
+	// only proposed on behalf of an etherbase
+	coinbase, err := e.feeRecipient()
+	if err != nil {
+		log.Error("Refusing to forward txs without etherbase")
+		return
+	}
+
+	// Hold the txs back while the execution catches up with consensus
+	if e.throttled() {
+		log.Debug("Throttling tx forwarding", "lag", e.headLag())
+		return
+	}
+	// Stateless executors have no state to select txs on
+	if e.config.Stateless {
+		return
+	}
+	// Leave the resources to the execution while under load
+	if e.shedding.Load() {
+		log.Debug("Shedding tx forwarding")
+		return
+	}
+	// Don't propose more work while the disk can't keep up with the writes
+	if e.slowDisk.Load() {
+		log.Debug("Pausing tx forwarding on slow disk")
+		return
+	}
+	// Consensus blocks go first, the round is forwarded again once they executed
+	if !e.beginSend(interrupt) {
+		log.Debug("Deferring tx forwarding to consensus block execution")
+		return
 	}
+	defer e.endSend(interrupt)
 
 	work, err := e.prepareWork(&generateParams{
 		timestamp: uint64(timestamp),
 		coinbase:  coinbase,
-	})
+		forward:   true,
+	}, 0)
 	if err != nil {
 		return
 	}
-	e.fillTransactions(interrupt, work)
+	switch err := e.fillTransactions(interrupt, work); {
+	case err == nil:
+		// Every pending tx was forwarded, decrease resubmit interval in case of
+		// current interval is larger than the user-specified one.
+		e.adjustResubmitInterval(&intervalAdjust{inc: false})
+
+	case errors.Is(err, errBlockInterruptedByRecommit):
+		// Notify resubmit loop to increase resubmitting interval if the
+		// interruption is due to frequent commits.
+		gaslimit := work.header.GasLimit
+		ratio := float64(gaslimit-work.gasPool.Gas()) / float64(gaslimit)
+		if ratio < 0.1 {
+			ratio = 0.1
+		}
+		e.adjustResubmitInterval(&intervalAdjust{
+			ratio: ratio,
+			inc:   true,
+		})
+	}
 }
 
 func (e *executor) fillTransactions(interrupt *atomic.Int32, env *executor_env) error {
-	pending := e.eth.TxPool().Pending(true)
-
+	pool := e.eth.TxPool()
+	if pool == nil {
+		return errBackendNotReady
+	}
+	// Txs the pool dropped are forwarded again if they return, replacements
+	// have a hash of their own anyway
+	if e.inclusion != nil {
+		if pruned := e.inclusion.prune(pool.Has, env.state.GetNonce, e.settings().InFlightRetry, time.Now()); pruned > 0 {
+			log.Debug("Stopped awaiting forwarded txs", "pruned", pruned)
+		}
+	}
+	pending := pool.Pending(true)
+	if held := excludeTxs(pending, e.settings().ForwardExclude); len(held) > 0 {
+		log.Debug("Held back txs of excluded contracts", "senders", len(held), "new", e.meterHeld(held))
+	}
 	// Split the pending transactions into locals and remotes.
 	localTxs, remoteTxs := make(map[common.Address][]*txpool.LazyTransaction), pending
-	for _, account := range e.eth.TxPool().Locals() {
+	for _, account := range pool.Locals() {
 		if txs := remoteTxs[account]; len(txs) > 0 {
 			delete(remoteTxs, account)
 			localTxs[account] = txs
@@ -474,31 +1086,85 @@ func (e *executor) fillTransactions(interrupt *atomic.Int32, env *executor_env)
 	}
 	// TODO: for test
 	if len(localTxs) == 0 {
-		fmt.Println("no txs")
+		log.Trace("No local transactions to forward")
 		return nil
 	}
+	var sent types.Transactions
+	forward := func(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error {
+		if err := e.forwardTx(ltx, tx, local); err != nil {
+			return err
+		}
+		sent = append(sent, tx)
+		return nil
+	}
+	aborted, err := e.forwardTransactions(interrupt, env, localTxs, remoteTxs, forward)
+	if len(sent) > 0 {
+		e.postEvent(BatchSentEvent{Txs: sent})
+	}
+	if err != nil {
+		e.reportInterrupt(err, sent, aborted)
+	}
+	return err
+}
+
+// forwardFunc hands a selected tx over to consensus.
+type forwardFunc func(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error
+
+// forwardTransactions selects the pending txs to forward to consensus, locals
+// first, each by price and nonce or shared among their submitters. If
+// interrupted, the hashes of the pending txs left unsent are returned along with
+// the interruption.
+func (e *executor) forwardTransactions(interrupt *atomic.Int32, env *executor_env, localTxs, remoteTxs map[common.Address][]*txpool.LazyTransaction, forward forwardFunc) ([]common.Hash, error) {
 	// Fill the block with all available pending transactions.
 	if len(localTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
-		if err := e.sendTransactions(env, txs, interrupt); err != nil {
-			return err
+		txs := e.newTxSet(env, localTxs)
+		if err := e.sendTransactions(env, txs, interrupt, true, forward); err != nil {
+			return append(txs.Remaining(), lazyHashes(remoteTxs)...), err
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
-		if err := e.sendTransactions(env, txs, interrupt); err != nil {
-			return err
+		txs := e.newTxSet(env, remoteTxs)
+		if err := e.sendTransactions(env, txs, interrupt, false, forward); err != nil {
+			return txs.Remaining(), err
 		}
 	}
+	return nil, nil
+}
+
+// forwardTx sends a tx to consensus.
+func (e *executor) forwardTx(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error {
+	_, err := e.execClient.sendTx(tx)
+	e.trackLink(err)
+	switch {
+	case errors.Is(err, errTxQueued):
+		// Queued txs reach consensus once it's back, don't forward them again
+		log.Trace("Queued transaction for consensus", "hash", ltx.Hash, "err", err)
+	case err != nil:
+		log.Trace("Failed to send transaction", "hash", ltx.Hash, "err", err)
+		return err
+	}
+	if e.inclusion != nil {
+		from, _ := types.Sender(types.LatestSigner(e.chainConfig), tx)
+		e.inclusion.forwarded(tx, ltx.Time, from, local, e.txOrigin(tx))
+	}
 	return nil
 }
 
-func (e *executor) sendTransactions(env *executor_env, txs *transactionsByPriceAndNonce, interrupt *atomic.Int32) error {
+func (e *executor) sendTransactions(env *executor_env, txs txSet, interrupt *atomic.Int32, local bool, forward forwardFunc) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
 	}
-
+	minTip := e.getMinTip()
+
+	// Forwarding may be stricter than the verification, the tip floor follows
+	// the one set through executor_setMinTip.
+	var opts *txpool.ValidationOptions
+	if e.forwardOpts != nil {
+		opts = new(txpool.ValidationOptions)
+		*opts = *e.forwardOpts
+		opts.MinTip = minTip
+	}
 	for {
 		// Check interruption signal and abort building if it's fired.
 		if interrupt != nil {
@@ -529,6 +1195,15 @@ func (e *executor) sendTransactions(env *executor_env, txs *transactionsByPriceA
 			txs.Pop()
 			continue
 		}
+		// The pool drops the txs of the written blocks once it caught up with
+		// the head in the background, don't forward them again meanwhile. Dry
+		// runs have no state to check against.
+		if from, _ := types.Sender(env.signer, tx); env.state != nil && tx.Nonce() < env.state.GetNonce(from) {
+			log.Trace("Ignoring stale transaction", "hash", ltx.Hash, "nonce", tx.Nonce())
+			staleForwardMeter.Mark(1)
+			txs.Shift()
+			continue
+		}
 		// Check whether the tx is replay protected. If we're not in the EIP155 hf
 		// phase, start ignoring the sender until we do.
 		if tx.Protected() && !e.chainConfig.IsEIP155(env.header.Number) {
@@ -536,17 +1211,52 @@ func (e *executor) sendTransactions(env *executor_env, txs *transactionsByPriceA
 			txs.Pop()
 			continue
 		}
+		// Leave the reserved gas to governance
+		if !e.fitsUnreserved(env, tx) {
+			txs.Pop()
+			continue
+		}
+		// Don't forward txs of other chains, consensus would order them just for
+		// the execution to drop them.
+		if err := e.checkChainID(tx); err != nil {
+			log.Trace("Ignoring transaction of other chain", "hash", ltx.Hash, "err", err)
+			chainIDRejectedMeter.Mark(1)
+			txs.Pop()
+			continue
+		}
+		// Don't forward txs consensus would only order for the execution to drop
+		if opts != nil {
+			if err := txpool.ValidateTransaction(tx, env.header, env.signer, opts); err != nil {
+				log.Trace("Ignoring invalid transaction", "hash", ltx.Hash, "err", err)
+				txs.Pop()
+				continue
+			}
+		}
+		// Don't forward underpriced txs, wallets are told about the floor via
+		// eth_maxPriorityFeePerGas and executor_minTip.
+		if tip, err := tx.EffectiveGasTip(env.header.BaseFee); err != nil || tip.Cmp(minTip) < 0 {
+			log.Trace("Ignoring underpriced transaction", "hash", ltx.Hash, "mintip", minTip)
+			txs.Pop()
+			continue
+		}
 
-		// sendTx to consensus
-		_, err := e.execClient.sendTx(tx)
-		// fmt.Println("to", tx.To(), "value", tx.Value(), "nonce", tx.Nonce())
-		if err != nil {
-			log.Trace("Failed to send transaction", "hash", ltx.Hash, "err", err)
+		// sendTx to consensus, unless it already has the tx. Txs it has don't
+		// take up room in this round, leaving it to the txs not sent yet.
+		if e.inclusion != nil && e.inclusion.awaiting(ltx.Hash, e.settings().InFlightRetry) {
+			log.Trace("Skipping transaction awaiting inclusion", "hash", ltx.Hash)
+			txs.Shift()
+			continue
+		}
+		if err := forward(ltx, tx, local); err != nil {
 			txs.Pop()
 			continue
 		}
 		// !!! 不然这里的gasPool没被更新
 		env.gasPool.SubGas(tx.Gas())
+		if !e.isReserved(env, tx) {
+			env.unreservedGas += tx.Gas()
+		}
+		txs.Shift()
 	}
 	return nil
 }
@@ -555,38 +1265,300 @@ func (e *executor) executionLoop() {
 	defer e.wg.Done()
 
 	for {
+		// Blocks can't be executed on top of a tentative block, hold them
+		// back until consensus decided about it.
+		execCh := e.execCh
+		if e.pending != nil {
+			execCh = nil
+		}
+		// Blocks deferred by the coalescing go first
+		if e.deferred != nil && e.pending == nil {
+			req := e.deferred
+			e.deferred = nil
+			e.execute(req)
+			continue
+		}
 		select {
-		case req := <-e.execCh:
-			fmt.Println("executionLoop get a execCh and start execute txs")
-			e.executeNewTxBatch(req.timestamp, req.txs)
+		case req := <-execCh:
+			e.execute(req)
+		case <-e.inflightDone():
+			e.settleWrite()
+		case req := <-e.confirmCh:
+			e.settleWrite()
+			req.result <- e.confirmBlock(req.hash, req.commit)
+		case req := <-e.finalizeCh:
+			e.settleWrite()
+			req.result <- e.finalizeBranch(req.branch)
+		case req := <-e.setHeadCh:
+			e.settleWrite()
+			e.settleCommits(math.MaxUint64)
+			head, err := e.setHead(req)
+			req.result <- &setHeadResult{head: head, err: err}
 		case <-e.exitCh:
+			e.settleWrite()
+			e.settleCommits(math.MaxUint64)
+			if e.deferred != nil {
+				e.abandonResult(e.deferred, errExecutorStopping)
+				e.deferred = nil
+			}
 			return
 		}
 	}
 }
 
-func (e *executor) executeNewTxBatch(timestamp int64, txs types.Transactions) {
-	var coinbase common.Address
-	if e.isRunning() {
-		coinbase = e.etherbase()
-		if coinbase == (common.Address{}) {
-			log.Error("Refusing to mine without etherbase")
+// execute runs a consensus block received by the execution loop, aborting the
+// forwarding of local txs meanwhile.
+func (e *executor) execute(req *execReq) {
+	defer e.preemptSend()()
+
+	if req.tentative != nil {
+		e.settleWrite()
+		e.prepareBlock(req)
+		return
+	}
+	var err error
+	if req.branch != "" {
+		e.settleWrite()
+		err = e.executeBranch(req)
+	} else {
+		batch := e.coalesce(req)
+		err = e.executeNewTxBatch(batch)
+		if w := e.inflight; w != nil && w.req == batch {
+			// The outcome is known once the block landed
 			return
 		}
 	}
+	if req.result != nil {
+		e.deliverResult(req, &blockResult{result: e.blockResult(req), err: err})
+	}
+}
 
-	work, err := e.prepareWork(&generateParams{
-		timestamp: uint64(timestamp), // ...
-		coinbase:  coinbase,
+func (e *executor) executeNewTxBatch(req *execReq) error {
+	if e.halted.Load() {
+		log.Warn("Dropping consensus block, executor halted", "sequence", req.sequence, "txs", len(req.txs))
+		return errExecutorHalted
+	}
+	// Blocks expecting another parent than the one they'd land on are skipped
+	head := e.pipelineHead()
+	if err := checkParent(req, head); err != nil {
+		return e.finishBatch(req, head, time.Now(), err)
+	}
+	// Blocks with nothing to record count as executed like those without txs
+	if e.emptyBlock(req, head) {
+		e.markExecuted(req.sequence)
+		e.releaseWAL(req, nil)
+		return nil
+	}
+	if e.config.PipelineWrites && !e.chainConfig.Executor.DefersRoots() {
+		return e.pipelineBatch(req)
+	}
+	parent := e.eth.BlockChain().CurrentBlock()
+	start := time.Now()
+
+	e.execStats.begin(req)
+	err := e.finishBatch(req, parent, start, e.executeAndWrite(req))
+	e.execStats.end(e.eth.BlockChain().CurrentBlock().Number.Uint64(), err)
+	return err
+}
+
+// finishBatch applies the failure policy to a consensus block which failed on
+// top of the given parent, and reports the outcome of the block.
+func (e *executor) finishBatch(req *execReq, parent *types.Header, start time.Time, err error) error {
+	lost := err != nil
+	switch {
+	case errors.Is(err, errBlockMemoryLimit):
+		// Every executor trips over the same block, skip it instead of retrying
+		number := parent.Number.Uint64() + 1
+		log.Warn("Rejected consensus block over the memory limit", "number", number, "sequence", req.sequence, "txs", len(req.txs), "err", err)
+		sandboxTrippedMeter.Mark(1)
+		e.reportFault(pb.FaultType_REJECTED, req, number, err)
+	case errors.Is(err, errRootMismatch):
+		// Executing the block again reaches the same root, stop before the
+		// replica diverges any further
+		e.alerter.raise(AlertRootMismatch, fmt.Sprintf("block %d (sequence %d) diverged from the peer replicas: %v", parent.Number.Uint64()+1, req.sequence, err))
+		e.halt(req, parent.Number.Uint64()+1, err)
+	case errors.Is(err, errStateCommit):
+		// The chain holds a block whose state is lost, nothing can be
+		// executed on top of it
+		e.halt(req, parent.Number.Uint64()+1, err)
+	case errors.Is(err, errExecutorHalted):
+		// The failure of a block written in the background halted the
+		// execution meanwhile
+		lost = false
+	case errors.Is(err, errStaleParent):
+		// The block expects another parent than the head, consensus
+		// reconciles the chains and delivers it again
+		log.Warn("Skipped consensus block on stale parent", "sequence", req.sequence, "err", err)
+		staleParentMeter.Mark(1)
+		e.reportFault(pb.FaultType_STALE_PARENT, req, parent.Number.Uint64()+1, err)
+		lost = false
+	case err != nil && req.quarantined != 0:
+		// Quarantined blocks failing again stay in the quarantine, the
+		// operator retrying them decides what's next
+		lost = false
+	case err != nil:
+		err = e.handleFailure(req, parent, err)
+
+		// Rolled back blocks are delivered again by consensus
+		lost = err != nil && (e.config.FailurePolicy != FailureRollback || e.halted.Load())
+	}
+	if lost {
+		e.quarantineBlocks(req, parent.Number.Uint64()+1, err)
+	}
+	req.report.total = time.Since(start)
+	e.logReport(req, err)
+	e.releaseWAL(req, err)
+	if err != nil {
+		e.postEvent(BatchCommitFailedEvent{Number: parent.Number.Uint64() + 1, Sequence: req.sequence, Err: err})
+	}
+	return err
+}
+
+// executeAndWrite executes a consensus block and writes it to the chain.
+func (e *executor) executeAndWrite(req *execReq) error {
+	work, block, err := e.executeBatch(req)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	profileStage(stageCommit, len(req.txs), func() {
+		err = e.writeToChain(work, block)
 	})
+	req.report.write = time.Since(start)
+	e.trackWrite(err)
+	if err == nil {
+		e.recordWritten(req, work, block)
+	}
+	return err
+}
+
+// recordWritten accounts a consensus block written to the chain.
+func (e *executor) recordWritten(req *execReq, work *executor_env, block *types.Block) {
+	req.report.number, req.report.hash = block.NumberU64(), block.Hash()
+	req.report.included, req.report.gas = len(block.Transactions()), block.GasUsed()
+	e.countOrigins(&req.report, block.Transactions())
+	e.accountFees(req, block, work.receipts)
+	e.markExecuted(req.sequence)
+	e.shadowExecute(work)
+	e.postEvent(BatchExecutedEvent{Block: block, Sequence: req.sequence})
+}
+
+// executeBatch executes the txs of a consensus block on top of the chain head
+// and assembles the resulting block, without writing it to the chain.
+func (e *executor) executeBatch(req *execReq) (*executor_env, *types.Block, error) {
+	if req.warmer != nil {
+		req.report.warmed = req.warmer.stop()
+		req.warmer = nil
+	}
+	coinbase, err := e.execRecipient()
 	if err != nil {
-		return
+		log.Error("Refusing to execute without etherbase")
+		return nil, nil, err
+	}
+
+	var work *executor_env
+	if b := e.branches[req.branch]; b != nil {
+		work, err = e.prepareBranchWork(b, uint64(req.timestamp), coinbase)
+	} else if e.inflight != nil {
+		work, err = e.preparePipelinedWork(e.inflight, uint64(req.timestamp), coinbase, len(req.txs))
+	} else if e.deferredRoots(new(big.Int).Add(e.eth.BlockChain().CurrentBlock().Number, common.Big1)) {
+		work, err = e.prepareDeferredWork(uint64(req.timestamp), coinbase, len(req.txs))
+	} else {
+		work, err = e.prepareWork(&generateParams{
+			timestamp: uint64(req.timestamp), // ...
+			coinbase:  coinbase,
+		}, len(req.txs))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer work.state.StopPrefetcher()
+	work.meta = req.meta
+
+	// Signature recovery dominates the execution of plain transfers, spread it
+	// across the cores. The senders are cached in the txs as they're recovered,
+	// overlapping with the prefetcher loading the state of the first txs.
+	core.SenderCacher.Recover(work.signer, req.txs)
+	defer e.prefetchSenders(work, e.bypassPool(work, req.txs))()
+
+	block, err := e.processBlock(work, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkExpectedRoot(req, block); err != nil {
+		return nil, nil, err
+	}
+	return work, block, nil
+}
+
+// processBlock executes the txs of a consensus block on top of the prepared
+// environment and assembles the resulting block.
+func (e *executor) processBlock(work *executor_env, req *execReq) (*types.Block, error) {
+	if limit := e.chainConfig.Executor.BlockMemoryLimit(); limit != 0 {
+		work.budget = newMemoryBudget(limit, work.state)
+	}
+	work.upgrades = req.upgrades
+	e.prepareCalldataFee(work)
+
+	var (
+		logs  []*types.Log
+		err   error
+		start = time.Now()
+	)
+	profileStage(stageExecute, len(req.txs), func() {
+		err = sandboxed(func() error {
+			e.applySystemCalls(work, req.metadata)
+			if e.shadowEnabled() {
+				work.shadow = &shadowRun{state: work.state.Copy(), header: types.CopyHeader(work.header), calldataFee: work.calldataFee}
+			}
+			e.prefetchSchedule(work, req.txs, req.schedule)
+			txs := e.orderTxs(work, req.txs, req.origins)
+
+			logs = e.executeTransactions(work, txs) // logs may be needed by other modules
+			if work.budget != nil {
+				sandboxMemoryGauge.Update(int64(work.budget.used))
+				return work.budget.err
+			}
+			return nil
+		})
+	})
+	req.report.exec = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	var block *types.Block
+	start = time.Now()
+	profileStage(stageAssemble, len(req.txs), func() {
+		block, err = e.assembleBlock(work, req, logs)
+	})
+	req.report.assemble = time.Since(start)
+	req.report.recordReads(work.state)
+	return block, err
+}
+
+// assembleBlock finalizes the state of an executed consensus block and
+// assembles the resulting block.
+func (e *executor) assembleBlock(work *executor_env, req *execReq, logs []*types.Log) (*types.Block, error) {
+	if req.batches != nil {
+		work.meta = coalescedMeta(req, work.txs)
+	}
+	if work.shadow != nil {
+		work.shadow.root = work.state.IntermediateRoot(e.chainConfig.DeleteEmptyAccounts(work.header.Number))
+	}
+	if e.hotSet != nil {
+		e.hotSet.record(work.txs, logs)
 	}
-	e.executeTransactions(work, txs) // logs may be needed by other modules
-	e.writeToChain(work)             // 写入区块链，后续可以流水线化
+	e.updateCalldataExcess(work)
+	if err := e.applyExtra(work, req); err != nil {
+		return nil, err
+	}
+	// 组装一个区块
+	return e.assemble(work)
 }
 
 // 串行地执行交易，会返回一个Logs，或许以后会有用
+// The txs are executed as ordered by consensus, whether the local pool knows
+// them or not.
 func (e *executor) executeTransactions(env *executor_env, txs types.Transactions) []*types.Log {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
@@ -594,7 +1566,6 @@ func (e *executor) executeTransactions(env *executor_env, txs types.Transactions
 	}
 
 	var coalescedLogs []*types.Log
-	fmt.Println("start exec,txs len:", len((txs)))
 	for _, tx := range txs {
 
 		// If we don't have enough gas for any further transactions then we're done.
@@ -607,6 +1578,10 @@ func (e *executor) executeTransactions(env *executor_env, txs types.Transactions
 			log.Trace("Not enough gas left for transaction", "hash", tx.Hash(), "left", env.gasPool.Gas(), "needed", tx.Gas())
 			continue
 		}
+		// Regular txs can't crowd out governance ones
+		if !e.fitsUnreserved(env, tx) {
+			continue
+		}
 		// Transaction seems to fit, pull it up from the pooltinue
 		// Check whether the tx is replay protected. If we're not in the EIP155 hf
 		// phase, start ignoring the sender until we do.
@@ -614,11 +1589,25 @@ func (e *executor) executeTransactions(env *executor_env, txs types.Transactions
 			log.Trace("Ignoring replay protected transaction", "hash", tx.Hash(), "eip155", e.chainConfig.EIP155Block)
 			continue
 		}
+		// Drop txs signed for other chains. The unprotected tx policy is local to
+		// the node, ordered unprotected txs are executed regardless to stay
+		// deterministic.
+		if tx.Protected() {
+			if err := e.checkChainID(tx); err != nil {
+				log.Debug("Skipping transaction of other chain", "hash", tx.Hash(), "err", err)
+				chainIDRejectedMeter.Mark(1)
+				continue
+			}
+		}
 
 		from, _ := types.Sender(env.signer, tx)
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 		logs, err := e.executeTransaction(env, tx)
 		switch {
+		case errors.Is(err, errBlockMemoryLimit):
+			// The block is rejected as a whole, no need to execute the rest
+			return coalescedLogs
+
 		case errors.Is(err, core.ErrNonceTooLow):
 			// New head notification data race between the transaction pool and miner, shift
 			log.Trace("Skipping transaction with low nonce", "hash", tx.Hash, "sender", from, "nonce", tx.Nonce())
@@ -648,6 +1637,13 @@ func (e *executor) executeTransaction(env *executor_env, tx *types.Transaction)
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
 	env.tcount++
+	if !e.isReserved(env, tx) {
+		env.unreservedGas += receipt.GasUsed
+	}
+	env.calldataUsed += uint64(len(tx.Data()))
+	if err := env.budget.charge(env.state, receipt.Logs); err != nil {
+		return nil, err
+	}
 	return receipt.Logs, nil
 }
 
@@ -658,7 +1654,10 @@ func (e *executor) applyTransaction(env *executor_env, tx *types.Transaction) (*
 		snap = env.state.Snapshot()
 		gp   = env.gasPool.Gas()
 	)
-	receipt, err := core.ApplyTransaction(e.chainConfig, e.eth.BlockChain(), &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *e.eth.BlockChain().GetVMConfig())
+	if err := chargeCalldata(env.state, env.signer, env.calldataFee, tx); err != nil {
+		return nil, err
+	}
+	receipt, err := core.ApplyTransaction(e.chainConfig, env.chain, &env.coinbase, env.gasPool, env.state, env.header, tx, &env.header.GasUsed, *e.eth.BlockChain().GetVMConfig())
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
 		env.gasPool.SetGas(gp)
@@ -666,44 +1665,92 @@ func (e *executor) applyTransaction(env *executor_env, tx *types.Transaction) (*
 	return receipt, err
 }
 
-func (e *executor) writeToChain(env *executor_env) error {
-	// 组装一个区块
-	block, err := e.engine.FinalizeAndAssemble(e.eth.BlockChain(), env.header, env.state, env.txs, nil, env.receipts, nil)
-	if err != nil {
-		return err
-	}
+func (e *executor) writeToChain(env *executor_env, block *types.Block) error {
+	return e.writeBlock(env, block, nil)
+}
 
-	var (
-		receipts = make([]*types.Receipt, len(env.receipts))
-		logs     []*types.Log
-	)
+// writeBlock writes an executed block to the chain along with its state, unless
+// the state was already committed ahead by the write pipeline.
+func (e *executor) writeBlock(env *executor_env, block *types.Block, committed *core.CommittedState) error {
 	hash := block.Hash()
 	// 拷贝一下env.receipts
-	for i, env_receipt := range env.receipts {
-		receipt := new(types.Receipt)
-		receipts[i] = receipt
-		*receipt = *env_receipt
-
-		receipt.BlockHash = hash
-		receipt.BlockNumber = block.Number()
-		receipt.TransactionIndex = uint(i)
-
-		receipt.Logs = make([]*types.Log, len(env_receipt.Logs))
-		for i, env_log := range env_receipt.Logs {
-			log := new(types.Log)
-			receipt.Logs[i] = log
-			*log = *env_log
-			log.BlockHash = hash
-		}
-		logs = append(logs, receipt.Logs...)
+	receipts, logs := e.deriveReceipts(env, block)
+	// Link the block to its consensus decision before it becomes visible
+	if env.meta != nil {
+		e.eth.BlockChain().WriteConsensusMeta(hash, block.NumberU64(), env.meta)
 	}
 	// Commit block and state to database.
-	_, err = e.eth.BlockChain().WriteBlockAndSetHead(block, receipts, logs, env.state, true)
+	e.writing.Store(true)
+	done := e.watchWrite(block)
+	start := time.Now()
+	var err error
+	switch {
+	case committed != nil:
+		_, err = e.eth.BlockChain().WriteCommittedBlockAndSetHead(block, receipts, logs, committed, true)
+	case e.deferredRoots(block.Number()):
+		_, err = e.eth.BlockChain().WriteDeferredBlockAndSetHead(block, receipts, logs, true)
+	default:
+		_, err = e.eth.BlockChain().WriteBlockAndSetHead(block, receipts, logs, env.state, true)
+	}
+	done()
+	e.writing.Store(false)
 	if err != nil {
 		log.Error("Failed writing block to chain", "err", err)
 		return err
 	}
+	blockWriteTimer.UpdateSince(start)
+	blockGasMeter.Mark(int64(block.GasUsed()))
+	e.notifyHead(block, env.meta)
+	e.reportResult(block, env.meta)
+	e.export(block, receipts, env.meta)
+	e.publishStateDiff(block, env.state)
+	e.exportEpoch(block, env.state)
+	if e.inclusion != nil {
+		e.inclusion.included(block.Transactions(), time.Now())
+	}
+	// Deferred post states prune the pool once they are committed
+	if !e.deferredRoots(block.Number()) {
+		e.prunePool(block.Header(), block.Root())
+	}
+	// Forward the validator-set changes to the consensus layer
+	if events := stakingEvents(e.config.StakingContract, logs); len(events) > 0 {
+		e.stakingSubs.publish(events)
+	}
 	// 比较有信心说，这就是我的env
-	e.env = env.copy()
+	e.env.Store(&verifyEnv{header: block.Header(), signer: env.signer})
+	if e.deferredRoots(block.Number()) {
+		e.commitDeferred(env, block)
+	}
 	return nil
 }
+
+// prunePool drops the txs included or made stale by a written block from the
+// pool right away, on its own the pool only catches up with the next chain head
+// event whose post state is retrievable. Stateless executors only hold the
+// state of the witnesses.
+func (e *executor) prunePool(header *types.Header, root common.Hash) {
+	pool := e.eth.TxPool()
+	if pool == nil || e.config.Stateless {
+		return
+	}
+	statedb, err := e.eth.BlockChain().StateAt(root)
+	if err != nil {
+		log.Debug("Failed to open the post state for pruning the txpool", "number", header.Number, "err", err)
+		return
+	}
+	pool.Prune(header, statedb)
+}
+
+// verifyEnv returns the context the txs consensus asks about are verified in,
+// derived from the chain head until the executor wrote its first block.
+func (e *executor) verifyEnv() *verifyEnv {
+	if env := e.env.Load(); env != nil {
+		return env
+	}
+	head := e.eth.BlockChain().CurrentBlock()
+	env := &verifyEnv{header: head, signer: types.MakeSigner(e.chainConfig, head.Number, head.Time)}
+	if e.env.CompareAndSwap(nil, env) {
+		return env
+	}
+	return e.env.Load()
+}
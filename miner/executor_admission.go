@@ -0,0 +1,38 @@
+package miner
+
+import "fmt"
+
+// AdmissionError is returned when a transaction submission is rejected because
+// the executor can't currently get it to the consensus layer, so clients can
+// retry through another node instead of having their txs silently stuck.
+type AdmissionError struct {
+	Reason string
+}
+
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("transaction rejected by admission control: %s", e.Reason)
+}
+
+// ErrorCode returns the JSON-RPC error code of the limit exceeded condition.
+func (e *AdmissionError) ErrorCode() int { return -32005 }
+
+// admit checks whether new transactions may be accepted into the pool, given
+// the number of txs still waiting to be committed by consensus and the health
+// of the consensus link. The txs waiting are the pending ones of the pool and
+// the ones forwarded to consensus the pool dropped since.
+func (e *executor) admit() error {
+	if e.settings().AdmissionRejectLinkDown && e.consensusLinkDown() {
+		return &AdmissionError{Reason: "consensus layer unreachable"}
+	}
+	if limit := e.settings().AdmissionMaxPending; limit > 0 {
+		pool := e.eth.TxPool()
+		pending, _ := pool.Stats()
+		if e.inclusion != nil {
+			pending += e.inclusion.unpooled(pool.Has)
+		}
+		if pending >= limit {
+			return &AdmissionError{Reason: fmt.Sprintf("%d txs awaiting consensus, limit %d", pending, limit)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestAdmission(t *testing.T) {
+	backend := newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	config := &Config{AdmissionMaxPending: 1, AdmissionRejectLinkDown: true, AlertLinkTimeout: time.Minute}
+	e := &executor{config: config, eth: backend, alerter: newAlerter(config)}
+
+	if err := e.admit(); err != nil {
+		t.Fatalf("submission rejected on idle executor: %v", err)
+	}
+	// Fill up the pool
+	if errs := backend.txPool.Add([]*types.Transaction{pendingTxs[0]}, true, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction: %v", errs[0])
+	}
+	var admissionErr *AdmissionError
+	if err := e.admit(); !errors.As(err, &admissionErr) || admissionErr.ErrorCode() != -32005 {
+		t.Fatalf("submission admitted into full pool: %v", err)
+	}
+	// Make room, but take the consensus link down
+	config.AdmissionMaxPending = 0
+	e.trackLink(errors.New("connection refused"))
+	if err := e.admit(); err != nil {
+		t.Fatalf("submission rejected on fresh link failure: %v", err)
+	}
+	e.linkDownSince.Store(time.Now().Add(-time.Hour).UnixNano())
+	if err := e.admit(); !errors.As(err, &admissionErr) {
+		t.Fatalf("submission admitted with consensus link down: %v", err)
+	}
+	e.trackLink(nil)
+	if err := e.admit(); err != nil {
+		t.Fatalf("submission rejected after link recovery: %v", err)
+	}
+	// Txs in flight to consensus count, unless still pooled
+	config.AdmissionMaxPending = 2
+	e.inclusion = newInclusionTracker(nil)
+	e.inclusion.forwarded(pendingTxs[0], time.Now(), testBankAddress, false, txpool.Origin{})
+	if err := e.admit(); err != nil {
+		t.Fatalf("pooled in-flight tx counted twice: %v", err)
+	}
+	e.inclusion.forwarded(newTxs[0], time.Now(), testBankAddress, false, txpool.Origin{})
+	if err := e.admit(); !errors.As(err, &admissionErr) {
+		t.Fatalf("submission admitted with txs in flight to consensus: %v", err)
+	}
+}
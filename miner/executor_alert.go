@@ -0,0 +1,179 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Kinds of critical executor conditions the operator is alerted about.
+const (
+	AlertWriteFailures     = "WriteFailures"     // WriteBlockAndSetHead failed repeatedly
+	AlertConsensusLinkDown = "ConsensusLinkDown" // consensus layer unreachable for too long
+	AlertExecutionLag      = "ExecutionLag"      // execution too far behind consensus
+	AlertHalted            = "Halted"            // execution halted by a failed block
+	AlertRootMismatch      = "RootMismatch"      // state root diverged from the one consensus expected from the peer replicas
+	AlertSlowDisk          = "SlowDisk"          // block writes exceeding WriteDeadline, tx forwarding paused
+	AlertNonceGap          = "NonceGap"          // senders stalled by a forwarded tx lost on its way to consensus
+	AlertExportDropped     = "ExportDropped"     // written blocks dropped while the receipt sink was behind
+)
+
+// alertTimeout is the maximum time allowance for delivering an alert.
+const alertTimeout = 10 * time.Second
+
+// Alert is a critical executor condition which is currently active.
+type Alert struct {
+	Kind     string    `json:"kind"`
+	Message  string    `json:"message"`
+	Since    time.Time `json:"since"`    // time the condition was first raised
+	Last     time.Time `json:"last"`     // time the condition was last raised
+	Notified time.Time `json:"notified"` // time the sinks were last notified
+	Count    int       `json:"count"`    // number of times the condition was raised
+}
+
+// alerter delivers critical executor conditions to the configured sinks (a
+// webhook and/or a local command), notifying about each kind of condition at
+// most once per interval.
+type alerter struct {
+	webhook  string        // URL the alerts are POSTed to as JSON
+	command  string        // command executed with the alert in its environment
+	interval time.Duration // minimum time between two notifications of the same kind
+
+	mu     sync.Mutex
+	active map[string]*Alert
+}
+
+func newAlerter(config *Config) *alerter {
+	return &alerter{
+		webhook:  config.AlertWebhook,
+		command:  config.AlertCommand,
+		interval: config.AlertInterval,
+		active:   make(map[string]*Alert),
+	}
+}
+
+// raise marks the condition as active and notifies the sinks about it unless
+// they were already notified within the rate limiting interval.
+func (a *alerter) raise(kind string, message string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	alert := a.active[kind]
+	if alert == nil {
+		alert = &Alert{Kind: kind, Since: now}
+		a.active[kind] = alert
+	}
+	alert.Message, alert.Last = message, now
+	alert.Count++
+
+	if !alert.Notified.IsZero() && now.Sub(alert.Notified) < a.interval {
+		return
+	}
+	alert.Notified = now
+	log.Error("Executor alert raised", "kind", kind, "message", message, "count", alert.Count)
+
+	go a.notify(*alert)
+}
+
+// resolve marks the condition as no longer active.
+func (a *alerter) resolve(kind string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if alert, ok := a.active[kind]; ok {
+		log.Info("Executor alert resolved", "kind", kind, "duration", time.Since(alert.Since))
+		delete(a.active, kind)
+	}
+}
+
+// alerts returns the currently active conditions.
+func (a *alerter) alerts() []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(a.active))
+	for _, alert := range a.active {
+		alerts = append(alerts, *alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Kind < alerts[j].Kind })
+	return alerts
+}
+
+// notify delivers the alert to all the configured sinks.
+func (a *alerter) notify(alert Alert) {
+	if a.webhook != "" {
+		blob, err := json.Marshal(alert)
+		if err != nil {
+			log.Warn("Failed to encode executor alert", "err", err)
+			return
+		}
+		client := &http.Client{Timeout: alertTimeout}
+		res, err := client.Post(a.webhook, "application/json", bytes.NewReader(blob))
+		if err != nil {
+			log.Warn("Failed to deliver executor alert", "url", a.webhook, "err", err)
+		} else {
+			res.Body.Close()
+		}
+	}
+	if a.command != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, a.command)
+		cmd.Env = append(os.Environ(), "EXECUTOR_ALERT_KIND="+alert.Kind, "EXECUTOR_ALERT_MESSAGE="+alert.Message)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			log.Warn("Failed to run executor alert command", "cmd", a.command, "err", err, "output", string(out))
+		}
+	}
+}
+
+// trackWrite updates the streak of failed chain writes after every attempt,
+// alerting the operator if the executor seems unable to persist blocks.
+func (e *executor) trackWrite(err error) {
+	if err == nil {
+		e.writeFailures = 0
+		e.alerter.resolve(AlertWriteFailures)
+		return
+	}
+	e.writeFailures++
+	if e.writeFailures >= e.config.AlertWriteFailures {
+		e.alerter.raise(AlertWriteFailures, fmt.Sprintf("%d consecutive block writes failed, last: %v", e.writeFailures, err))
+	}
+}
+
+// trackLink updates the consensus link status after every interaction with the
+// consensus layer, alerting the operator if it stays unreachable for too long.
+func (e *executor) trackLink(err error) {
+	if err == nil {
+		e.linkDownSince.Store(0)
+		e.linkDownPosted.Store(false)
+		e.alerter.resolve(AlertConsensusLinkDown)
+		return
+	}
+	e.linkDownSince.CompareAndSwap(0, time.Now().UnixNano())
+	if e.consensusLinkDown() {
+		since := time.Unix(0, e.linkDownSince.Load())
+		e.alerter.raise(AlertConsensusLinkDown, fmt.Sprintf("consensus layer unreachable for %v, last: %v", common.PrettyDuration(time.Since(since)), err))
+		if e.linkDownPosted.CompareAndSwap(false, true) {
+			e.postEvent(ConsensusLinkDownEvent{Since: since, Err: err})
+		}
+	}
+}
+
+// consensusLinkDown reports whether the consensus layer has been unreachable
+// for longer than tolerated.
+func (e *executor) consensusLinkDown() bool {
+	since := e.linkDownSince.Load()
+	return since != 0 && time.Since(time.Unix(0, since)) >= e.config.AlertLinkTimeout
+}
@@ -0,0 +1,55 @@
+package miner
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlerter(t *testing.T) {
+	delivered := make(chan Alert, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("failed to decode alert: %v", err)
+		}
+		delivered <- alert
+	}))
+	defer srv.Close()
+
+	e := &executor{config: &Config{AlertWebhook: srv.URL, AlertInterval: time.Hour, AlertWriteFailures: 2}}
+	e.alerter = newAlerter(e.config)
+
+	// A single failure is tolerated, the second one raises the alert
+	e.trackWrite(errors.New("disk full"))
+	if alerts := e.alerter.alerts(); len(alerts) != 0 {
+		t.Fatalf("alert raised prematurely: %v", alerts)
+	}
+	e.trackWrite(errors.New("disk full"))
+	select {
+	case alert := <-delivered:
+		if alert.Kind != AlertWriteFailures {
+			t.Fatalf("alert kind mismatch: have %s, want %s", alert.Kind, AlertWriteFailures)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("alert not delivered")
+	}
+	// Further failures are rate limited
+	e.trackWrite(errors.New("disk full"))
+	select {
+	case alert := <-delivered:
+		t.Fatalf("rate limited alert delivered: %v", alert)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 1 || alerts[0].Count != 2 {
+		t.Fatalf("active alerts mismatch: %v", alerts)
+	}
+	// A successful write resolves the alert
+	e.trackWrite(nil)
+	if alerts := e.alerter.alerts(); len(alerts) != 0 {
+		t.Fatalf("alert not resolved: %v", alerts)
+	}
+}
@@ -0,0 +1,208 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// maxBranchBlocks is the maximum number of blocks held across all competing
+// branches. Branches are expected to be short-lived, consensus delivering more
+// blocks without finalizing any is misbehaving.
+const maxBranchBlocks = 64
+
+var (
+	branchExecMeter    = metrics.NewRegisteredMeter("executor/branch/executed", nil)
+	branchDiscardMeter = metrics.NewRegisteredMeter("executor/branch/discarded", nil)
+
+	errUnknownBranch   = errors.New("unknown branch")
+	errStaleBranch     = errors.New("branch forked off a previous head")
+	errBranchesFull    = errors.New("too many blocks on competing branches")
+	errTentativeBranch = errors.New("tentative blocks can't extend a branch")
+	errAwaitedBranch   = errors.New("branch blocks can't await their result")
+)
+
+// branch is a lineage of blocks consensus delivered on a competing branch. Its
+// blocks are executed on isolated copies of the state instead of being written
+// to the chain, until consensus finalizes one of the branches.
+type branch struct {
+	base   common.Hash     // chain head the branch forked off
+	blocks []*pendingBlock // executed blocks of the branch, oldest first
+}
+
+// finalizeReq is the branch consensus finalized.
+type finalizeReq struct {
+	branch string     // finalized branch, empty if the chain itself won
+	result chan error // outcome of the promotion
+}
+
+// FinalizeBranch writes the blocks of the branch consensus finalized to the
+// chain, discarding every competing branch.
+func (es *executorServer) FinalizeBranch(ctx context.Context, req *pb.BranchRequest) (*pb.Empty, error) {
+	finalize := &finalizeReq{
+		branch: string(req.GetBranch()),
+		result: make(chan error, 1),
+	}
+	select {
+	case es.executorPtr.finalizeCh <- finalize:
+	case <-es.executorPtr.exitCh:
+		return &pb.Empty{}, errExecutorStopping
+	case <-ctx.Done():
+		return &pb.Empty{}, ctx.Err()
+	}
+	return &pb.Empty{}, <-finalize.result
+}
+
+// branchTip returns the last executed block of a branch, nil if the branch
+// has none yet and forks off the chain head.
+func (e *executor) branchTip(id string) *pendingBlock {
+	if b := e.branches[id]; b != nil {
+		return b.blocks[len(b.blocks)-1]
+	}
+	return nil
+}
+
+// prepareBranchWork prepares the environment of a block on top of the tip of a
+// branch, executing on a copy of the state the tip was executed into.
+func (e *executor) prepareBranchWork(b *branch, timestamp uint64, coinbase common.Address) (*executor_env, error) {
+	tip := b.blocks[len(b.blocks)-1]
+	tmpl := e.newTemplate(tip.block.Header())
+	if tmpl.parent.Time >= timestamp {
+		timestamp = tmpl.parent.Time + 1
+	}
+	env := &executor_env{
+		signer:    e.signerAt(tmpl, timestamp),
+		state:     tip.env.state.Copy(),
+		stateBase: tip.env.stateBase,
+		chain:     e.eth.BlockChain(),
+		coinbase:  coinbase,
+		header:    tmpl.header(timestamp, coinbase),
+	}
+	if err := e.applyBranchGasGovernor(b, tmpl.parent, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// applyBranchGasGovernor applies the governed gas ceiling to a block on top of
+// a branch, reading it at the branch block the epoch boundary falls on if any.
+func (e *executor) applyBranchGasGovernor(b *branch, parent *types.Header, env *executor_env) error {
+	gov := e.chainConfig.Executor.GasGovernance()
+	if gov == nil {
+		return nil
+	}
+	number := gov.Boundary(parent.Number.Uint64())
+	for _, pending := range b.blocks {
+		if pending.block.NumberU64() == number {
+			return e.applyGovernedCeil(gov, parent, pending.block.Header(), env, pending.env.state, true)
+		}
+	}
+	return e.applyGasGovernor(parent, env, nil, true)
+}
+
+// executeBranch executes a block of a competing branch, keeping it aside of the
+// chain. A branch with a block failing to execute can't be finalized, it is
+// dropped entirely.
+func (e *executor) executeBranch(req *execReq) error {
+	if e.halted.Load() {
+		log.Warn("Dropping branch block, executor halted", "branch", req.branch, "sequence", req.sequence, "txs", len(req.txs))
+		return errExecutorHalted
+	}
+	parent := e.eth.BlockChain().CurrentBlock()
+	if tip := e.branchTip(req.branch); tip != nil {
+		parent = tip.block.Header()
+	}
+	var held int
+	for _, b := range e.branches {
+		held += len(b.blocks)
+	}
+	if held >= maxBranchBlocks {
+		err := fmt.Errorf("%w: %d held", errBranchesFull, held)
+		e.dropBranch(req, parent.Number.Uint64()+1, err)
+		return err
+	}
+	if e.emptyBlock(req, parent) {
+		e.markExecuted(req.sequence)
+		return nil
+	}
+	start := time.Now()
+	base := e.eth.BlockChain().CurrentBlock().Hash()
+
+	work, block, err := e.executeBatch(req)
+	if err != nil {
+		e.dropBranch(req, parent.Number.Uint64()+1, err)
+		return err
+	}
+	if e.branches == nil {
+		e.branches = make(map[string]*branch)
+	}
+	b := e.branches[req.branch]
+	if b == nil {
+		b = &branch{base: base}
+		e.branches[req.branch] = b
+	}
+	b.blocks = append(b.blocks, &pendingBlock{env: work, block: block, sequence: req.sequence})
+	branchExecMeter.Mark(1)
+
+	log.Info("Executed branch block", "branch", req.branch, "number", block.Number(), "hash", block.Hash(), "root", block.Root(), "txs", len(block.Transactions()), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// dropBranch discards the branch of a block which couldn't be executed on it,
+// as the later blocks of the branch can't be either. Consensus is told, it
+// delivers the branch again if still competing.
+func (e *executor) dropBranch(req *execReq, number uint64, err error) {
+	log.Warn("Failed to execute branch block, dropping branch", "branch", req.branch, "number", number, "sequence", req.sequence, "err", err)
+	e.discardBranch(req.branch)
+	e.reportFault(pb.FaultType_DISCARDED_BRANCH, req, number, err)
+}
+
+// discardBranch drops a branch along with its executed blocks.
+func (e *executor) discardBranch(id string) {
+	if b := e.branches[id]; b != nil {
+		branchDiscardMeter.Mark(int64(len(b.blocks)))
+		delete(e.branches, id)
+	}
+}
+
+// finalizeBranch promotes the branch consensus finalized, writing its blocks to
+// the chain in order, and discards every competing one. The empty branch
+// stands for the chain itself, merely discarding all branches.
+func (e *executor) finalizeBranch(id string) error {
+	finalized := e.branches[id]
+	delete(e.branches, id)
+	for other := range e.branches {
+		log.Info("Discarded competing branch", "branch", other, "blocks", len(e.branches[other].blocks))
+		e.discardBranch(other)
+	}
+	if id == "" {
+		return nil
+	}
+	if finalized == nil {
+		return fmt.Errorf("%w: %q", errUnknownBranch, id)
+	}
+	if head := e.eth.BlockChain().CurrentBlock().Hash(); head != finalized.base {
+		branchDiscardMeter.Mark(int64(len(finalized.blocks)))
+		return fmt.Errorf("%w: have %x, want %x", errStaleBranch, head, finalized.base)
+	}
+	for _, pending := range finalized.blocks {
+		err := e.writeToChain(pending.env, pending.block)
+		e.trackWrite(err)
+		if err != nil {
+			return err
+		}
+		e.markExecuted(pending.sequence)
+		e.shadowExecute(pending.env)
+		e.postEvent(BatchExecutedEvent{Block: pending.block, Sequence: pending.sequence})
+	}
+	head := finalized.blocks[len(finalized.blocks)-1].block
+	log.Info("Finalized branch", "branch", id, "blocks", len(finalized.blocks), "number", head.Number(), "hash", head.Hash())
+	return nil
+}
@@ -0,0 +1,141 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestCompetingBranches(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		finalizeCh:  make(chan *finalizeReq),
+		alerter:     newAlerter(testConfig),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	signer := types.LatestSigner(&config)
+	transfer := func(nonce uint64, value int64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(value),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+	}
+	es := &executorServer{executorPtr: e}
+	commit := func(branch string, txs ...*types.Transaction) {
+		block := newTestExecBlock(t, txs...)
+		block.Branch = []byte(branch)
+		if _, err := es.CommitBlock(context.Background(), block); err != nil {
+			t.Fatalf("failed to commit block: %v", err)
+		}
+	}
+	finalize := func(branch string) error {
+		_, err := es.FinalizeBranch(context.Background(), &pb.BranchRequest{Branch: []byte(branch)})
+		return err
+	}
+	chain := backend.chain
+	balance := func() *big.Int {
+		state, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		return state.GetBalance(testUserAddress).ToBig()
+	}
+	initial := balance()
+
+	// Competing branches execute aside of the chain, each on its own lineage
+	commit("a", transfer(0, 1000))
+	commit("b", transfer(0, 5000))
+	commit("a", transfer(1, 1000))
+	if err := finalize("missing"); !errors.Is(err, errUnknownBranch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownBranch)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 0 {
+		t.Fatalf("branch blocks written before finalization, head %d", head)
+	}
+	// Unknown branches discard every competing branch, start over
+	commit("a", transfer(0, 1000))
+	commit("b", transfer(0, 5000))
+	commit("a", transfer(1, 1000))
+	if err := finalize("a"); err != nil {
+		t.Fatalf("failed to finalize branch: %v", err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 2 {
+		t.Fatalf("head mismatch: have %d, want %d", head, 2)
+	}
+	if have, want := balance(), new(big.Int).Add(initial, big.NewInt(2000)); have.Cmp(want) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", have, want)
+	}
+	if err := finalize("b"); !errors.Is(err, errUnknownBranch) {
+		t.Errorf("error mismatch: have %v, want %v", err, errUnknownBranch)
+	}
+	// Branches forked off a head which moved on can't be finalized
+	commit("c", transfer(2, 1000))
+	commit("", transfer(2, 1000))
+	if err := finalize("c"); !errors.Is(err, errStaleBranch) {
+		t.Errorf("error mismatch: have %v, want %v", err, errStaleBranch)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 3 {
+		t.Errorf("head mismatch: have %d, want %d", head, 3)
+	}
+}
+
+func TestBranchFault(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	config := &Config{UnsetEtherbase: EtherbaseRefuse}
+	client := new(testConsensusClient)
+	e := &executor{
+		config:      config,
+		chainConfig: ethashChainConfig,
+		engine:      backend.chain.Engine(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execClient:  &executorClient{consensusClient: client},
+		alerter:     newAlerter(config),
+	}
+	// Executing without an etherbase fails, the branch is dropped and consensus
+	// told about it
+	e.running.Store(true)
+
+	head := backend.chain.CurrentBlock()
+	err := e.executeBranch(&execReq{timestamp: int64(head.Time + 1), txs: types.Transactions{pendingTxs[0]}, sequence: 7, branch: "a"})
+	if !errors.Is(err, errMissingEtherbase) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingEtherbase)
+	}
+	if len(client.faults) != 1 {
+		t.Fatalf("fault count mismatch: have %d, want 1", len(client.faults))
+	}
+	if fault := client.faults[0]; fault.Type != pb.FaultType_DISCARDED_BRANCH || fault.Sequence != 7 || fault.BlockNumber != head.Number.Uint64()+1 {
+		t.Errorf("fault mismatch: have %v, want type %v sequence 7", fault, pb.FaultType_DISCARDED_BRANCH)
+	}
+	if _, ok := e.branches["a"]; ok {
+		t.Errorf("failed branch not dropped")
+	}
+}
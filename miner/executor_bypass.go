@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	poolKnownMeter   = metrics.NewRegisteredMeter("executor/pool/known", nil)
+	poolUnknownMeter = metrics.NewRegisteredMeter("executor/pool/unknown", nil)
+)
+
+// bypassPool accounts the txs of a consensus block as known or unknown to the
+// local pool. Consensus may order txs this node never saw, so the execution
+// never consults the pool for their availability; the lookup only feeds the
+// metrics and the prefetching of the senders of the unknown txs, which weren't
+// touched when added to the pool. The senders of the unknown txs are returned.
+func (e *executor) bypassPool(work *executor_env, txs types.Transactions) []common.Address {
+	if !e.config.PoolLookup {
+		return nil
+	}
+	pool := e.eth.TxPool()
+	if pool == nil {
+		return nil
+	}
+	var unknown []common.Address
+	for _, tx := range txs {
+		if pool.Has(tx.Hash()) {
+			continue
+		}
+		// Senders are recovered ahead by the sender cacher
+		if from, err := types.Sender(work.signer, tx); err == nil {
+			unknown = append(unknown, from)
+		}
+	}
+	poolKnownMeter.Mark(int64(len(txs) - len(unknown)))
+	poolUnknownMeter.Mark(int64(len(unknown)))
+
+	if len(unknown) > 0 {
+		log.Debug("Executing transactions unknown to the pool", "number", work.header.Number, "txs", len(txs), "unknown", len(unknown))
+	}
+	return unknown
+}
+
+// prefetchSenders loads the state of the given senders on a copy of the state
+// of the block in the background, warming the caches shared with the execution
+// running meanwhile. The senders are loaded in block order to stay ahead of the
+// execution. The returned function stops the prefetching.
+//
+// Stateless executors only have the state of their witnesses, which covers the
+// senders the execution touches anyway.
+func (e *executor) prefetchSenders(work *executor_env, senders []common.Address) func() {
+	if len(senders) == 0 || e.config.Stateless {
+		return func() {}
+	}
+	var (
+		statedb   = work.state.Copy()
+		interrupt atomic.Bool
+		done      = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		for _, from := range senders {
+			if interrupt.Load() {
+				return
+			}
+			statedb.GetNonce(from)
+		}
+	}()
+	return func() {
+		interrupt.Store(true)
+		<-done
+	}
+}
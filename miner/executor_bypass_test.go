@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestPoolBypass(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	if errs := backend.txPool.Add(types.Transactions{pendingTxs[0]}, true, true); errs[0] != nil {
+		t.Fatalf("failed to add tx to pool: %v", errs[0])
+	}
+	cfg := *testConfig
+	cfg.PoolLookup = true
+	e := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+
+	// Only the txs missing from the pool are accounted as unknown
+	txs := types.Transactions{pendingTxs[0], newTxs[0]}
+	statedb, _ := backend.chain.State()
+	env := &executor_env{signer: types.LatestSigner(&config), state: statedb, header: backend.chain.CurrentHeader()}
+	unknown := e.bypassPool(env, txs)
+	if len(unknown) != 1 || unknown[0] != testBankAddress {
+		t.Errorf("unknown senders mismatch: have %v, want %v", unknown, []common.Address{testBankAddress})
+	}
+	// Their state is loaded without touching the state of the block
+	e.prefetchSenders(env, unknown)()
+	if len(statedb.WorkingSet()) != 0 {
+		t.Errorf("prefetching loaded the state of the block: %v", statedb.WorkingSet())
+	}
+	// Unknown txs are executed all the same
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: txs}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.GetBlockByHash(backend.chain.CurrentBlock().Hash())
+	if have := len(head.Transactions()); have != len(txs) {
+		t.Errorf("included txs mismatch: have %d, want %d", have, len(txs))
+	}
+}
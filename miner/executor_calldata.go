@@ -0,0 +1,63 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus/misc/calldatafee"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/holiman/uint256"
+)
+
+var (
+	calldataFeeGauge    = metrics.NewRegisteredGauge("executor/calldata/fee", nil)
+	calldataBytesGauge  = metrics.NewRegisteredGauge("executor/calldata/bytes", nil)
+	calldataExcessGauge = metrics.NewRegisteredGauge("executor/calldata/excess", nil)
+)
+
+// prepareCalldataFee derives the fee per calldata byte of the block from the
+// excess calldata left in the parent state.
+func (e *executor) prepareCalldataFee(env *executor_env) {
+	market := e.chainConfig.Executor.CalldataFeeMarket()
+	if market == nil {
+		return
+	}
+	env.calldataFee = calldatafee.CalcFee(market, calldatafee.ReadExcess(market, env.state))
+	calldataFeeGauge.Update(env.calldataFee.Int64())
+}
+
+// updateCalldataExcess stores the excess calldata left by the block, pricing
+// the calldata of the next one.
+func (e *executor) updateCalldataExcess(env *executor_env) {
+	market := e.chainConfig.Executor.CalldataFeeMarket()
+	if market == nil {
+		return
+	}
+	excess := calldatafee.CalcExcess(market, calldatafee.ReadExcess(market, env.state), env.calldataUsed)
+	calldatafee.WriteExcess(market, env.state, excess)
+
+	calldataBytesGauge.Update(int64(env.calldataUsed))
+	calldataExcessGauge.Update(int64(excess))
+}
+
+// chargeCalldata burns the calldata fee of a tx from the balance of its sender
+// before it is executed. Txs whose sender can't afford the fee are invalid.
+// Deposits pay no fees, they were paid on the source chain.
+func chargeCalldata(statedb *state.StateDB, signer types.Signer, fee *big.Int, tx *types.Transaction) error {
+	if fee == nil || len(tx.Data()) == 0 || tx.Type() == types.DepositTxType {
+		return nil
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	cost, overflow := uint256.FromBig(new(big.Int).Mul(fee, big.NewInt(int64(len(tx.Data())))))
+	if have := statedb.GetBalance(from); overflow || have.Cmp(cost) < 0 {
+		return fmt.Errorf("%w: calldata fee of address %v: have %v, want %v", core.ErrInsufficientFunds, from.Hex(), have, cost)
+	}
+	statedb.SubBalance(from, cost)
+	return nil
+}
@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/calldatafee"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCalldataFee(t *testing.T) {
+	config := postMergeConfig()
+	market := &params.CalldataFee{
+		Address:        common.Address{0xca, 0x11},
+		Target:         50,
+		MinFee:         big.NewInt(params.GWei),
+		UpdateFraction: 100,
+	}
+	config.Executor = &params.ExecutorConfig{CalldataFee: market}
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	signer := types.LatestSigner(&config)
+	transfer := func(nonce uint64, data []byte) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee), Data: data})
+	}
+	execute := func(txs ...*types.Transaction) *types.Block {
+		if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: txs}); err != nil {
+			t.Fatalf("failed to execute block: %v", err)
+		}
+		return backend.chain.GetBlockByHash(backend.chain.CurrentBlock().Hash())
+	}
+	parent, _ := backend.chain.State()
+	tx := transfer(0, make([]byte, 150))
+	block := execute(tx)
+	statedb, _ := backend.chain.StateAt(block.Root())
+
+	// The calldata fee is burned on top of the gas
+	receipts := backend.chain.GetReceiptsByHash(block.Hash())
+	spent := new(big.Int).Sub(parent.GetBalance(testBankAddress).ToBig(), statedb.GetBalance(testBankAddress).ToBig())
+	want := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipts[0].GasUsed))
+	want.Add(want, big.NewInt(150*params.GWei))
+	if spent.Cmp(want) != 0 {
+		t.Fatalf("sender spending mismatch: have %v, want %v", spent, want)
+	}
+	// Calldata over the target raises the fee of the next block
+	if excess := calldatafee.ReadExcess(market, statedb); excess != 100 {
+		t.Fatalf("excess calldata mismatch: have %d, want %d", excess, 100)
+	}
+	next := calldatafee.CalcFee(market, 100)
+	if next.Cmp(big.NewInt(params.GWei)) <= 0 {
+		t.Fatalf("calldata fee not raised: have %v", next)
+	}
+	// Senders unable to afford the calldata fee are skipped
+	poor := *market
+	poor.MinFee = new(big.Int).Mul(testBankFunds, big.NewInt(2))
+	config.Executor.CalldataFee = &poor
+	if block := execute(transfer(1, []byte{0x01})); len(block.Transactions()) != 0 {
+		t.Fatalf("unaffordable tx included")
+	}
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var (
+	errChainIDMismatch = errors.New("chain ID mismatch")
+	errUnprotectedTx   = errors.New("unprotected tx not allowed")
+)
+
+var chainIDRejectedMeter = metrics.NewRegisteredMeter("executor/chainid/rejected", nil)
+
+// checkChainID verifies that a tx is signed for the chain of the executor. Txs
+// without replay protection carry no chain ID, they are only accepted if the
+// policy allows them.
+func (e *executor) checkChainID(tx *types.Transaction) error {
+	if !tx.Protected() {
+		if e.settings().AllowUnprotectedTxs {
+			return nil
+		}
+		return errUnprotectedTx
+	}
+	if tx.ChainId().Cmp(e.chainConfig.ChainID) != 0 {
+		return fmt.Errorf("%w: have %v, want %v", errChainIDMismatch, tx.ChainId(), e.chainConfig.ChainID)
+	}
+	return nil
+}
+
+// verifyCode returns the VerifyTx code of a chain ID check error.
+func verifyCode(err error) pb.VerifyCode {
+	switch {
+	case err == nil:
+		return pb.VerifyCode_VALID
+	case errors.Is(err, errChainIDMismatch):
+		return pb.VerifyCode_CHAIN_ID_MISMATCH
+	case errors.Is(err, errUnprotectedTx):
+		return pb.VerifyCode_UNPROTECTED
+	default:
+		return pb.VerifyCode_INVALID
+	}
+}
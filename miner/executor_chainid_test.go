@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestVerifyTxChainID(t *testing.T) {
+	var (
+		other     = new(big.Int).Add(params.TestChainConfig.ChainID, common.Big1)
+		foreign   = types.MustSignNewTx(testBankKey, types.LatestSignerForChainID(other), &types.LegacyTx{To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		homestead = types.MustSignNewTx(testBankKey, types.HomesteadSigner{}, &types.LegacyTx{To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	)
+	e := &executor{config: testConfig, chainConfig: params.TestChainConfig}
+	es := &executorServer{executorPtr: e}
+
+	tests := []struct {
+		tx   *types.Transaction
+		code pb.VerifyCode
+	}{
+		{foreign, pb.VerifyCode_CHAIN_ID_MISMATCH},
+		{homestead, pb.VerifyCode_UNPROTECTED},
+	}
+	for i, tt := range tests {
+		payload, _ := tt.tx.MarshalBinary()
+		res, err := es.VerifyTx(context.Background(), &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+		if err != nil {
+			t.Fatalf("test %d: failed to verify tx: %v", i, err)
+		}
+		if res.Success || res.Code != tt.code {
+			t.Errorf("test %d: result mismatch: have %v (%v), want %v", i, res.Code, res.Success, tt.code)
+		}
+	}
+	// The unprotected tx policy is configurable, other chains are always rejected
+	e.config = &Config{AllowUnprotectedTxs: true}
+	if err := e.checkChainID(homestead); err != nil {
+		t.Errorf("unprotected tx rejected: %v", err)
+	}
+	if err := e.checkChainID(foreign); verifyCode(err) != pb.VerifyCode_CHAIN_ID_MISMATCH {
+		t.Errorf("foreign tx error mismatch: have %v, want %v", err, errChainIDMismatch)
+	}
+}
+
+func TestForwardingChainID(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		head   = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.GWei)}
+		other  = new(big.Int).Add(params.TestChainConfig.ChainID, common.Big1)
+	)
+	transfer := func(signer types.Signer, nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(2 * params.GWei)})
+	}
+	pending := map[common.Address]types.Transactions{
+		from: {
+			transfer(types.LatestSigner(params.TestChainConfig), 0),
+			transfer(types.HomesteadSigner{}, 1),
+			transfer(types.LatestSignerForChainID(other), 2),
+		},
+	}
+	policy := ForwardingPolicy{GasLimit: 10 * params.TxGas, Locals: []common.Address{from}}
+	if report := SimulateForwarding(params.TestChainConfig, head, pending, policy); report.Txs != 1 {
+		t.Errorf("forwarded txs mismatch: have %d, want %d", report.Txs, 1)
+	}
+	policy.AllowUnprotectedTxs = true
+	if report := SimulateForwarding(params.TestChainConfig, head, pending, policy); report.Txs != 2 {
+		t.Errorf("forwarded txs mismatch with unprotected txs allowed: have %d, want %d", report.Txs, 2)
+	}
+}
@@ -0,0 +1,155 @@
+package miner
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// channelQueue is the number of forwarded txs buffered for the channel. Once
+// full, forwarding waits for consensus to catch up.
+const channelQueue = 256
+
+var (
+	channelTxsMeter      = metrics.NewRegisteredMeter("executor/channel/txs", nil)
+	channelBlocksMeter   = metrics.NewRegisteredMeter("executor/channel/blocks", nil)
+	channelRefusedMeter  = metrics.NewRegisteredMeter("executor/channel/refused", nil)
+	channelFallbackMeter = metrics.NewRegisteredMeter("executor/channel/fallback", nil)
+
+	errChannelOpen = errors.New("channel already open")
+)
+
+// channelTx is a forwarded tx queued for the channel.
+type channelTx struct {
+	hash   common.Hash
+	packet *pb.Packet
+}
+
+// txChannel is the stream the forwarded txs are pushed into while consensus
+// holds a channel open.
+type txChannel struct {
+	packets chan channelTx
+	closed  chan struct{}
+	lock    sync.RWMutex // held by the senders, so the queue is complete once closed
+}
+
+// send queues a tx packet, waiting while the queue is full. It returns false
+// if the channel closed in the meantime.
+func (ch *txChannel) send(hash common.Hash, packet *pb.Packet) bool {
+	ch.lock.RLock()
+	defer ch.lock.RUnlock()
+
+	select {
+	case <-ch.closed:
+		return false
+	default:
+	}
+	select {
+	case ch.packets <- channelTx{hash, packet}:
+		channelTxsMeter.Mark(1)
+		return true
+	case <-ch.closed:
+		return false
+	}
+}
+
+// close stops taking txs, returning the ones still queued.
+func (ch *txChannel) close() []channelTx {
+	close(ch.closed)
+
+	// Wait for the senders racing the close to queue their txs or give up
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	var queued []channelTx
+	for {
+		select {
+		case tx := <-ch.packets:
+			queued = append(queued, tx)
+		default:
+			return queued
+		}
+	}
+}
+
+// Channel is a long-lived bidirectional stream with consensus, replacing the
+// unary P2P.Send and CommitBlock calls: the blocks consensus pushes are queued
+// for execution in order, and the txs forwarded while the channel is open are
+// pushed back on it. Both directions are subject to the flow control of the
+// stream, so a slow side holds the other back. Only one channel is open at a
+// time, the forwarded txs fall back to P2P.Send once it closes, the ones still
+// queued by then included. Blocks failing the way CommitBlock would have are
+// reported to consensus as REFUSED faults, there's no call for them to fail.
+func (es *executorServer) Channel(stream pb.Executor_ChannelServer) error {
+	e := es.executorPtr
+	client := e.execClient
+	ch := &txChannel{packets: make(chan channelTx, channelQueue), closed: make(chan struct{})}
+	if !client.channel.CompareAndSwap(nil, ch) {
+		return errChannelOpen
+	}
+	log.Info("Consensus channel opened")
+
+	// Push the forwarded txs until the stream breaks or closes
+	var (
+		sent   = make(chan struct{})
+		failed *channelTx // tx the stream broke on, if any
+	)
+	go func() {
+		defer close(sent)
+		for {
+			select {
+			case tx := <-ch.packets:
+				if err := stream.Send(tx.packet); err != nil {
+					log.Debug("Failed to push transaction on the channel", "err", err)
+					failed = &tx
+					return
+				}
+			case <-ch.closed:
+				return
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+	defer func() {
+		client.channel.CompareAndSwap(ch, nil)
+		queued := ch.close()
+		<-sent
+		if failed != nil {
+			queued = append([]channelTx{*failed}, queued...)
+		}
+		if len(queued) > 0 {
+			log.Debug("Sending queued transactions after the channel closed", "txs", len(queued))
+			channelFallbackMeter.Mark(int64(len(queued)))
+		}
+		for _, tx := range queued {
+			if err := client.sendPacket(tx.hash, tx.packet); err != nil {
+				log.Debug("Failed to send transaction queued for the channel", "hash", tx.hash, "err", err)
+			}
+		}
+	}()
+	for {
+		block, err := stream.Recv()
+		if err == io.EOF {
+			log.Info("Consensus channel closed")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		channelBlocksMeter.Mark(1)
+		if _, err := es.commitBlock(stream.Context(), block); err != nil {
+			if errors.Is(err, errExecutorHalted) || errors.Is(err, errExecutorStopping) {
+				return err
+			}
+			log.Warn("Refused block on the consensus channel", "sequence", block.GetSequence(), "err", err)
+			channelRefusedMeter.Mark(1)
+			e.reportFault(pb.FaultType_REFUSED, &execReq{sequence: block.GetSequence()}, 0, err)
+		}
+	}
+}
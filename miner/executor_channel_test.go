@@ -0,0 +1,123 @@
+package miner
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConsensusChannel(t *testing.T) {
+	var (
+		p2p       = new(testP2PClient)
+		consensus = new(testConsensusClient)
+	)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq, 1),
+		execClient:  &executorClient{p2pClient: p2p, consensusClient: consensus},
+		alerter:     newAlerter(testConfig),
+	}
+	server := grpc.NewServer()
+	pb.RegisterExecutorServer(server, &executorServer{executorPtr: e})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewExecutorClient(conn).Channel(ctx)
+	if err != nil {
+		t.Fatalf("failed to open channel: %v", err)
+	}
+	// Blocks pushed by consensus are queued for execution
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.Sequence = 3
+	if err := stream.Send(block); err != nil {
+		t.Fatalf("failed to push block: %v", err)
+	}
+	if req := <-e.execCh; req.sequence != 3 || len(req.txs) != 1 || req.txs[0].Hash() != pendingTxs[0].Hash() {
+		t.Fatalf("queued block mismatch: have #%d with %d txs", req.sequence, len(req.txs))
+	}
+	// Blocks failing like CommitBlock would have are reported, the fault is
+	// checked once the channel closed
+	refused := newTestExecBlock(t, pendingTxs[0])
+	refused.Sequence, refused.AwaitResult, refused.Branch = 4, true, []byte{0x01}
+	if err := stream.Send(refused); err != nil {
+		t.Fatalf("failed to push block: %v", err)
+	}
+	// Forwarded txs are pushed back on the channel instead of sent one by one
+	if _, err := e.execClient.sendTx(newTxs[0]); err != nil {
+		t.Fatalf("failed to forward tx: %v", err)
+	}
+	packet, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive packet: %v", err)
+	}
+	want, _ := txPacket(newTxs[0])
+	if !proto.Equal(packet, want) {
+		t.Errorf("packet mismatch: have %v, want %v", packet, want)
+	}
+	if p2p.sent != 0 {
+		t.Errorf("tx sent outside the channel")
+	}
+	// A single channel is open at a time
+	other, err := pb.NewExecutorClient(conn).Channel(ctx)
+	if err != nil {
+		t.Fatalf("failed to open channel: %v", err)
+	}
+	if _, err := other.Recv(); err == nil || !strings.Contains(err.Error(), errChannelOpen.Error()) {
+		t.Errorf("error mismatch: have %v, want %v", err, errChannelOpen)
+	}
+	// Once closed, forwarding falls back to the unary calls
+	stream.CloseSend()
+	for e.execClient.channel.Load() != nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(consensus.faults) != 1 || consensus.faults[0].Type != pb.FaultType_REFUSED || consensus.faults[0].Sequence != 4 {
+		t.Errorf("faults mismatch: have %v, want refused block 4", consensus.faults)
+	}
+	if _, err := e.execClient.sendTx(newTxs[0]); err != nil {
+		t.Fatalf("failed to forward tx: %v", err)
+	}
+	if p2p.sent != 1 {
+		t.Errorf("sent tx count mismatch: have %d, want %d", p2p.sent, 1)
+	}
+}
+
+func TestChannelClose(t *testing.T) {
+	ch := &txChannel{packets: make(chan channelTx, channelQueue), closed: make(chan struct{})}
+	for _, tx := range []*types.Transaction{pendingTxs[0], newTxs[0]} {
+		packet, _ := txPacket(tx)
+		if !ch.send(tx.Hash(), packet) {
+			t.Fatalf("open channel refused tx")
+		}
+	}
+	// The txs still queued are handed back once closed, no more are taken
+	queued := ch.close()
+	if len(queued) != 2 || queued[0].hash != pendingTxs[0].Hash() || queued[1].hash != newTxs[0].Hash() {
+		t.Fatalf("queued txs mismatch: have %v, want the 2 sent", queued)
+	}
+	packet, _ := txPacket(newTxs[0])
+	if ch.send(newTxs[0].Hash(), packet) {
+		t.Errorf("closed channel took tx")
+	}
+}
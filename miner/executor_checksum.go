@@ -0,0 +1,85 @@
+package miner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var (
+	errChecksumMismatch = errors.New("checksum mismatch")
+
+	checksumMismatchMeter = metrics.NewRegisteredMeter("executor/checksum/mismatch", nil)
+)
+
+// checksumHasher returns the constructor of the hash the checksums of a block
+// are computed with.
+func checksumHasher(algo pb.Checksum) (func() hash.Hash, error) {
+	switch algo {
+	case pb.Checksum_XXHASH64:
+		return func() hash.Hash { return xxhash.New() }, nil
+	case pb.Checksum_SHA256:
+		return sha256.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum %v", algo)
+	}
+}
+
+// txsChecksum computes the checksum of the txs of a block, each prefixed by its
+// length so the tx boundaries are covered too.
+func txsChecksum(newHash func() hash.Hash, txs [][]byte) []byte {
+	var (
+		h    = newHash()
+		size [4]byte
+	)
+	for _, tx := range txs {
+		binary.BigEndian.PutUint32(size[:], uint32(len(tx)))
+		h.Write(size[:])
+		h.Write(tx)
+	}
+	return h.Sum(nil)
+}
+
+// verifyChecksums checks the txs of a block against the checksums consensus
+// sent along, if any. The txs are checked before being decoded, so a payload
+// corrupted on the transport fails the whole block with errChecksumMismatch
+// instead of a decoding error, or worse, every replica dropping different txs.
+func verifyChecksums(block *pb.ExecBlock, txs [][]byte) error {
+	sums, total := block.GetTxChecksums(), block.GetTxsChecksum()
+	if len(sums) == 0 && len(total) == 0 {
+		return nil
+	}
+	newHash, err := checksumHasher(block.GetChecksum())
+	if err != nil {
+		return err
+	}
+	if len(sums) > 0 {
+		if len(sums) != len(txs) {
+			return fmt.Errorf("%w: have %d txs, want %d", errChecksumMismatch, len(txs), len(sums))
+		}
+		var corrupted []int
+		for i, tx := range txs {
+			h := newHash()
+			h.Write(tx)
+			if !bytes.Equal(h.Sum(nil), sums[i]) {
+				corrupted = append(corrupted, i)
+			}
+		}
+		if len(corrupted) > 0 {
+			return fmt.Errorf("%w: corrupted txs at positions %v", errChecksumMismatch, corrupted)
+		}
+	}
+	if len(total) > 0 {
+		if have := txsChecksum(newHash, txs); !bytes.Equal(have, total) {
+			return fmt.Errorf("%w: have %x, want %x", errChecksumMismatch, have, total)
+		}
+	}
+	return nil
+}
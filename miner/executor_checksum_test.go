@@ -0,0 +1,67 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// sealChecksums sets the checksums of a block like consensus does.
+func sealChecksums(t *testing.T, block *pb.ExecBlock, algo pb.Checksum) {
+	newHash, err := checksumHasher(algo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Checksum, block.TxChecksums = algo, nil
+	for _, tx := range block.Txs {
+		h := newHash()
+		h.Write(tx)
+		block.TxChecksums = append(block.TxChecksums, h.Sum(nil))
+	}
+	block.TxsChecksum = txsChecksum(newHash, block.Txs)
+}
+
+func TestChecksums(t *testing.T) {
+	for _, algo := range []pb.Checksum{pb.Checksum_XXHASH64, pb.Checksum_SHA256} {
+		tests := []struct {
+			name    string
+			corrupt func(block *pb.ExecBlock)
+			err     error
+		}{
+			{"intact", func(block *pb.ExecBlock) {}, nil},
+			{"unchecked", func(block *pb.ExecBlock) {
+				block.TxChecksums, block.TxsChecksum = nil, nil
+				block.Txs[0] = block.Txs[0][:10]
+			}, nil},
+			{"truncated tx", func(block *pb.ExecBlock) { block.Txs[1] = block.Txs[1][:len(block.Txs[1])-1] }, errChecksumMismatch},
+			{"missing tx", func(block *pb.ExecBlock) { block.Txs = block.Txs[:1] }, errChecksumMismatch},
+			{"batch only", func(block *pb.ExecBlock) { block.TxChecksums = nil; block.Txs[0][5] ^= 0xff }, errChecksumMismatch},
+			{"moved boundary", func(block *pb.ExecBlock) {
+				// Shifting bytes between txs keeps their concatenation
+				block.TxChecksums = nil
+				block.Txs[0], block.Txs[1] = append(block.Txs[0], block.Txs[1][0]), block.Txs[1][1:]
+			}, errChecksumMismatch},
+		}
+		for _, tt := range tests {
+			block := newTestExecBlock(t, pendingTxs[0], newTxs[0])
+			sealChecksums(t, block, algo)
+			tt.corrupt(block)
+
+			_, _, err := decodeExecBlock(block, nil)
+			if tt.err == nil && err != nil {
+				t.Errorf("%v %s: unexpected error: %v", algo, tt.name, err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("%v %s: error mismatch: have %v, want %v", algo, tt.name, err, tt.err)
+			}
+		}
+	}
+	// Checksums of an unknown algorithm can't be trusted either way
+	block := newTestExecBlock(t, pendingTxs[0])
+	sealChecksums(t, block, pb.Checksum_SHA256)
+	block.Checksum = pb.Checksum_NO_CHECKSUM
+	if _, _, err := decodeExecBlock(block, nil); err == nil {
+		t.Errorf("block with checksums of unknown algorithm accepted")
+	}
+}
@@ -0,0 +1,131 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var coalescedMeter = metrics.NewRegisteredMeter("executor/coalesced", nil)
+
+// coalesce waits up to the coalescing window for further consensus blocks to
+// execute together with the given one as a single chain block, amortizing the
+// per-block overhead for consensus protocols committing many tiny blocks.
+//
+// Only plain blocks are merged: the first one may carry system call inputs and
+// header extra-data, further ones may carry none of those nor schedule hints.
+// Blocks with an expected state root are never merged, their root is only
+// reached on their own, nor are blocks of competing branches or blocks
+// consensus awaits the outcome of. Further blocks expecting a parent aren't
+// merged either, their parent is the block they'd be merged into. Merging stops
+// once the txs might not fit in the gas limit. The first block which can't be
+// merged is deferred to the next iteration of the execution loop.
+func (e *executor) coalesce(req *execReq) *execReq {
+	window := e.settings().CoalesceWindow
+	if window == 0 || req.schedule != nil || req.expectedRoot != (common.Hash{}) || req.branch != "" || req.result != nil {
+		return req
+	}
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	gas := txsGas(req.txs)
+	for {
+		select {
+		case next := <-e.execCh:
+			if next.tentative != nil || len(next.metadata) > 0 || len(next.extra) > 0 || next.schedule != nil || next.expectedRoot != (common.Hash{}) || next.branch != "" || next.result != nil || next.parentHash != (common.Hash{}) {
+				e.deferred = next
+				return req
+			}
+			if ceil := e.gasCeil(); ceil != 0 && gas+txsGas(next.txs) > ceil {
+				e.deferred = next
+				return req
+			}
+			gas += txsGas(next.txs)
+			req = mergeReqs(req, next)
+
+		case <-timer.C:
+			return req
+		case <-e.exitCh:
+			return req
+		}
+	}
+}
+
+// mergeReqs appends the txs of a consensus block to a previous one, keeping
+// track of the block each tx came from.
+func mergeReqs(req, next *execReq) *execReq {
+	if req.batches == nil {
+		req.origins = make([]int, len(req.txs))
+		req.batches = []types.ConsensusBatch{consensusBatch(req)}
+	}
+	for range next.txs {
+		req.origins = append(req.origins, len(req.batches))
+	}
+	req.batches = append(req.batches, consensusBatch(next))
+	req.txs = append(req.txs, next.txs...)
+	req.wal = append(req.wal, next.wal...)
+	req.raw = append(req.raw, next.raw...)
+	for hash := range next.upgrades {
+		if req.upgrades == nil {
+			req.upgrades = make(map[common.Hash]struct{})
+		}
+		req.upgrades[hash] = struct{}{}
+	}
+
+	if next.sequence > req.sequence {
+		req.sequence = next.sequence
+	}
+	req.report.decode += next.report.decode
+	if next.warmer != nil {
+		next.warmer.stop()
+	}
+	coalescedMeter.Mark(1)
+	log.Debug("Coalesced consensus block", "sequence", next.sequence, "txs", len(next.txs), "batches", len(req.batches), "total", len(req.txs))
+	return req
+}
+
+// consensusBatch returns the consensus decision a block was delivered by.
+func consensusBatch(req *execReq) types.ConsensusBatch {
+	if req.meta == nil {
+		return types.ConsensusBatch{Sequence: req.sequence}
+	}
+	return types.ConsensusBatch{BatchID: req.meta.BatchID, Round: req.meta.Round, Sequence: req.meta.Sequence, Proposer: req.meta.Proposer, Epoch: req.meta.Epoch}
+}
+
+// coalescedMeta returns the consensus metadata of a block executed from
+// several consensus blocks, counting the included txs of each of them. The
+// included txs of every consensus block are contiguous, in the order of the
+// blocks.
+func coalescedMeta(req *execReq, included types.Transactions) *types.ConsensusMeta {
+	batches := make([]types.ConsensusBatch, len(req.batches))
+	copy(batches, req.batches)
+
+	origins := make(map[common.Hash]int, len(req.txs))
+	for i := len(req.txs) - 1; i >= 0; i-- {
+		origins[req.txs[i].Hash()] = req.origins[i]
+	}
+	for _, tx := range included {
+		batches[origins[tx.Hash()]].Txs++
+	}
+	last := batches[len(batches)-1]
+	return &types.ConsensusMeta{
+		BatchID:  last.BatchID,
+		Round:    last.Round,
+		Sequence: req.sequence,
+		Batches:  batches,
+		Proposer: last.Proposer,
+		Epoch:    last.Epoch,
+	}
+}
+
+// txsGas returns the total gas limit of the txs.
+func txsGas(txs types.Transactions) uint64 {
+	var gas uint64
+	for _, tx := range txs {
+		gas += tx.Gas()
+	}
+	return gas
+}
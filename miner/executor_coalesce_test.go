@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestCoalesceBlocks(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	exec := *testConfig
+	exec.CoalesceWindow = time.Second
+	e := &executor{
+		config:      &exec,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		confirmCh:   make(chan *confirmReq),
+		alerter:     newAlerter(&exec),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	signer := types.LatestSigner(&config)
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &testUserAddress,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(params.InitialBaseFee),
+		})
+	}
+	var (
+		es = &executorServer{executorPtr: e}
+		a  = newTestExecBlock(t, transfer(0))
+		b  = newTestExecBlock(t, transfer(1), transfer(5)) // nonce gap, not included
+		c  = newTestExecBlock(t, transfer(2))
+	)
+	a.BatchId, a.Sequence = []byte("a"), 1
+	b.BatchId, b.Sequence = []byte("b"), 2
+	c.BatchId, c.Sequence = []byte("c"), 3
+	c.Metadata = map[string][]byte{"unused": {0x01}} // system call inputs, starts a new block
+
+	for _, block := range []*pb.ExecBlock{a, b, c} {
+		if _, err := es.CommitBlock(context.Background(), block); err != nil {
+			t.Fatalf("failed to commit block: %v", err)
+		}
+	}
+	chain := backend.chain
+	for start := time.Now(); chain.CurrentBlock().Number.Uint64() < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("blocks not executed, head %d", chain.CurrentBlock().Number)
+		}
+	}
+	// The first two consensus blocks are executed as one
+	first := chain.GetBlockByNumber(1)
+	if have := len(first.Transactions()); have != 2 {
+		t.Fatalf("coalesced tx count mismatch: have %d, want %d", have, 2)
+	}
+	meta := chain.GetConsensusMeta(first.Hash())
+	if meta == nil || len(meta.Batches) != 2 {
+		t.Fatalf("coalesced batches mismatch: have %+v", meta)
+	}
+	if meta.Sequence != 2 || meta.Batches[0].Txs != 1 || meta.Batches[1].Txs != 1 {
+		t.Fatalf("coalesced batches mismatch: have %+v", meta)
+	}
+	if have := string(meta.Batch(0).BatchID); have != "a" {
+		t.Errorf("batch of tx 0 mismatch: have %q, want %q", have, "a")
+	}
+	if have := string(meta.Batch(1).BatchID); have != "b" {
+		t.Errorf("batch of tx 1 mismatch: have %q, want %q", have, "b")
+	}
+	// The block with system call inputs is executed on its own
+	second := chain.GetBlockByNumber(2)
+	if have := len(second.Transactions()); have != 1 {
+		t.Fatalf("deferred tx count mismatch: have %d, want %d", have, 1)
+	}
+	if meta := chain.GetConsensusMeta(second.Hash()); meta == nil || string(meta.BatchID) != "c" || len(meta.Batches) != 0 {
+		t.Fatalf("deferred metadata mismatch: have %+v", meta)
+	}
+	if have := e.executedSeq.Load(); have != 3 {
+		t.Fatalf("executed sequence mismatch: have %d, want %d", have, 3)
+	}
+}
@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxDecompressedSize is the maximum size a compressed consensus block may
+// expand to, protecting the executor from decompression bombs.
+const maxDecompressedSize = 256 * 1024 * 1024
+
+var errDecompressedTooLarge = errors.New("decompressed block too large")
+
+// zstdDecoder is shared across blocks, DecodeAll is safe for concurrent use.
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecompressedSize))
+
+// blockTxs returns the raw txs of a consensus block, decompressing them first
+// if the consensus layer sent them compressed.
+func blockTxs(block *pb.ExecBlock) ([][]byte, error) {
+	if len(block.GetCompressedTxs()) == 0 {
+		return block.GetTxs(), nil
+	}
+	var (
+		blob []byte
+		err  error
+	)
+	switch block.GetCompression() {
+	case pb.Compression_ZSTD:
+		blob, err = zstdDecoder.DecodeAll(block.GetCompressedTxs(), nil)
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			err = errDecompressedTooLarge
+		}
+	default:
+		err = fmt.Errorf("unsupported compression %v", block.GetCompression())
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := new(pb.TxList)
+	if err := proto.Unmarshal(blob, list); err != nil {
+		return nil, err
+	}
+	return list.GetTxs(), nil
+}
+
+// negotiateCompression picks the best block compression both the consensus
+// layer and the executor support.
+func negotiateCompression(supported []pb.Compression) pb.Compression {
+	compression := pb.Compression_UNCOMPRESSED
+	for _, c := range supported {
+		if c == pb.Compression_ZSTD {
+			compression = pb.Compression_ZSTD
+		}
+	}
+	return compression
+}
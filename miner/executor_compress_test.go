@@ -0,0 +1,50 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBlockTxsCompression(t *testing.T) {
+	txs := [][]byte{bytes.Repeat([]byte{0x01}, 1024), bytes.Repeat([]byte{0x02}, 2048)}
+	blob, _ := proto.Marshal(&pb.TxList{Txs: txs})
+
+	enc, _ := zstd.NewWriter(nil)
+	compressed := enc.EncodeAll(blob, nil)
+
+	have, err := blockTxs(&pb.ExecBlock{Compression: pb.Compression_ZSTD, CompressedTxs: compressed})
+	if err != nil {
+		t.Fatalf("failed to decompress block: %v", err)
+	}
+	if len(have) != len(txs) || !bytes.Equal(have[0], txs[0]) || !bytes.Equal(have[1], txs[1]) {
+		t.Fatalf("decompressed txs mismatch")
+	}
+	// Uncompressed blocks are passed through
+	if have, err := blockTxs(&pb.ExecBlock{Txs: txs}); err != nil || len(have) != len(txs) {
+		t.Fatalf("uncompressed block mismatch: %v", err)
+	}
+	// Corrupt payloads are rejected
+	if _, err := blockTxs(&pb.ExecBlock{Compression: pb.Compression_ZSTD, CompressedTxs: compressed[:len(compressed)/2]}); err == nil {
+		t.Fatalf("truncated block accepted")
+	}
+	if _, err := blockTxs(&pb.ExecBlock{Compression: pb.Compression(42), CompressedTxs: compressed}); err == nil {
+		t.Fatalf("unknown compression accepted")
+	}
+}
+
+func TestHandshakeCompression(t *testing.T) {
+	es := new(executorServer)
+	res, _ := es.Handshake(context.Background(), &pb.HandshakeRequest{Compressions: []pb.Compression{pb.Compression_UNCOMPRESSED, pb.Compression_ZSTD}})
+	if res.Compression != pb.Compression_ZSTD {
+		t.Fatalf("compression mismatch: have %v, want %v", res.Compression, pb.Compression_ZSTD)
+	}
+	res, _ = es.Handshake(context.Background(), &pb.HandshakeRequest{})
+	if res.Compression != pb.Compression_UNCOMPRESSED {
+		t.Fatalf("compression mismatch: have %v, want %v", res.Compression, pb.Compression_UNCOMPRESSED)
+	}
+}
@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errNoPendingBlock   = errors.New("no tentative block awaiting confirmation")
+	errPendingMismatch  = errors.New("tentative block mismatch")
+	errExecutorStopping = errors.New("executor stopping")
+)
+
+// pendingBlock is a block executed in the two-phase flow, which is only
+// written to the chain once consensus confirms it.
+type pendingBlock struct {
+	env      *executor_env
+	block    *types.Block
+	sequence uint64 // consensus height of the block
+}
+
+// tentativeResult is the outcome of executing a two-phase block.
+type tentativeResult struct {
+	block *types.Block
+	err   error
+}
+
+// confirmReq is the decision of consensus about the tentative block.
+type confirmReq struct {
+	hash   common.Hash // hash of the tentative block, zero to abort whichever is pending
+	commit bool        // whether to write the block or discard it
+	result chan error  // outcome of the decision
+}
+
+// PrepareBlock executes a consensus block like CommitBlock, but instead of
+// writing it to the chain it returns the tentative header, holding the block
+// back until consensus confirms or aborts it via ConfirmCommit. This enables
+// consensus protocols voting on the execution outcome. Blocks delivered in the
+// meantime are queued behind the tentative one.
+func (es *executorServer) PrepareBlock(ctx context.Context, pbBlock *pb.ExecBlock) (*pb.TentativeBlock, error) {
+	if es.executorPtr.halted.Load() {
+		return nil, errExecutorHalted
+	}
+	if es.executorPtr.config.Stateless {
+		return nil, errStateless
+	}
+	req, dropped, err := decodeExecBlock(pbBlock, es.executorPtr.verified)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || len(req.txs) == 0 {
+		return nil, errEmptyBlock
+	}
+	if req.branch != "" {
+		return nil, errTentativeBranch
+	}
+	if len(dropped) != 0 {
+		log.Warn("Dropped undecodable txs from tentative block", "count", len(dropped))
+	}
+	req.timestamp = es.executorPtr.now().UnixNano()
+	req.tentative = make(chan *tentativeResult, 1)
+	es.executorPtr.warmUp(req)
+
+	if err := es.executorPtr.queueBlock(req.sequence, req); err != nil {
+		return nil, err
+	}
+	var res *tentativeResult
+	select {
+	case res = <-req.tentative:
+	case <-es.executorPtr.exitCh:
+		return nil, errExecutorStopping
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	header := res.block.Header()
+	blob, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TentativeBlock{
+		Number:       header.Number.Uint64(),
+		Hash:         header.Hash().Bytes(),
+		ParentHash:   header.ParentHash.Bytes(),
+		StateRoot:    header.Root.Bytes(),
+		ReceiptsRoot: header.ReceiptHash.Bytes(),
+		GasUsed:      header.GasUsed,
+		Header:       blob,
+	}, nil
+}
+
+// ConfirmCommit writes the tentative block to the chain or discards it.
+func (es *executorServer) ConfirmCommit(ctx context.Context, req *pb.ConfirmRequest) (*pb.Empty, error) {
+	confirm := &confirmReq{
+		hash:   common.BytesToHash(req.GetHash()),
+		commit: req.GetCommit(),
+		result: make(chan error, 1),
+	}
+	select {
+	case es.executorPtr.confirmCh <- confirm:
+	case <-es.executorPtr.exitCh:
+		return &pb.Empty{}, errExecutorStopping
+	case <-ctx.Done():
+		return &pb.Empty{}, ctx.Err()
+	}
+	return &pb.Empty{}, <-confirm.result
+}
+
+// prepareBlock executes a two-phase block, keeping it pending on success.
+func (e *executor) prepareBlock(req *execReq) {
+	work, block, err := e.executeBatch(req)
+	if err == nil {
+		e.pending = &pendingBlock{env: work, block: block, sequence: req.sequence}
+		log.Info("Executed tentative block", "number", block.Number(), "hash", block.Hash(), "root", block.Root(), "txs", len(block.Transactions()))
+	}
+	req.tentative <- &tentativeResult{block: block, err: err}
+}
+
+// confirmBlock writes the pending block to the chain if consensus committed
+// it, or discards it if consensus aborted it.
+func (e *executor) confirmBlock(hash common.Hash, commit bool) error {
+	if e.pending == nil {
+		return errNoPendingBlock
+	}
+	pending := e.pending
+	if have := pending.block.Hash(); hash != have && (commit || hash != (common.Hash{})) {
+		return fmt.Errorf("%w: have %x, want %x", errPendingMismatch, hash, have)
+	}
+	e.pending = nil
+
+	if !commit {
+		log.Info("Discarded tentative block", "number", pending.block.Number(), "hash", pending.block.Hash())
+		return nil
+	}
+	err := e.writeToChain(pending.env, pending.block)
+	e.trackWrite(err)
+	if err == nil {
+		e.markExecuted(pending.sequence)
+		e.shadowExecute(pending.env)
+		e.postEvent(BatchExecutedEvent{Block: pending.block, Sequence: pending.sequence})
+	}
+	return err
+}
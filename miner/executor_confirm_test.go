@@ -0,0 +1,95 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestExecBlock wraps the txs into a consensus block.
+func newTestExecBlock(t testing.TB, txs ...*types.Transaction) *pb.ExecBlock {
+	block := new(pb.ExecBlock)
+	for _, tx := range txs {
+		payload, _ := tx.MarshalBinary()
+		blob, err := proto.Marshal(&pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		block.Txs = append(block.Txs, blob)
+	}
+	return block
+}
+
+func TestTwoPhaseCommit(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		confirmCh:   make(chan *confirmReq),
+		alerter:     newAlerter(testConfig),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	var (
+		es    = &executorServer{executorPtr: e}
+		chain = backend.chain
+	)
+	// Prepare a block, it must not be written before confirmation
+	tentative, err := es.PrepareBlock(context.Background(), newTestExecBlock(t, pendingTxs[0]))
+	if err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	if tentative.Number != 1 {
+		t.Fatalf("number mismatch: have %d, want %d", tentative.Number, 1)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 0 {
+		t.Fatalf("tentative block written before confirmation, head %d", head)
+	}
+	// Confirming another block must fail, confirming the tentative one must succeed
+	if _, err := es.ConfirmCommit(context.Background(), &pb.ConfirmRequest{Hash: common.Hash{0x01}.Bytes(), Commit: true}); !errors.Is(err, errPendingMismatch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errPendingMismatch)
+	}
+	if _, err := es.ConfirmCommit(context.Background(), &pb.ConfirmRequest{Hash: tentative.Hash, Commit: true}); err != nil {
+		t.Fatalf("failed to confirm block: %v", err)
+	}
+	head := chain.CurrentBlock()
+	if head.Hash() != common.BytesToHash(tentative.Hash) {
+		t.Fatalf("head mismatch: have %x, want %x", head.Hash(), tentative.Hash)
+	}
+	if head.Root != common.BytesToHash(tentative.StateRoot) || head.ReceiptHash != common.BytesToHash(tentative.ReceiptsRoot) {
+		t.Fatalf("written block differs from the tentative one")
+	}
+	// Prepare another block and abort it
+	if _, err := es.PrepareBlock(context.Background(), newTestExecBlock(t, newTxs[0])); err != nil {
+		t.Fatalf("failed to prepare block: %v", err)
+	}
+	if _, err := es.ConfirmCommit(context.Background(), &pb.ConfirmRequest{Commit: false}); err != nil {
+		t.Fatalf("failed to abort block: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != head.Hash() {
+		t.Fatalf("aborted block written")
+	}
+	if _, err := es.ConfirmCommit(context.Background(), &pb.ConfirmRequest{Commit: false}); !errors.Is(err, errNoPendingBlock) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoPendingBlock)
+	}
+}
@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// dedupCacheSize is the number of consensus blocks remembered to detect the
+// submissions consensus retries.
+const dedupCacheSize = 4096
+
+var duplicateBlockMeter = metrics.NewRegisteredMeter("executor/dedup/duplicates", nil)
+
+// submission is a consensus block submitted through CommitBlock, whose outcome
+// is handed to the submissions retrying it.
+type submission struct {
+	done   chan struct{} // closed once the outcome is known
+	result *pb.BlockResult
+	err    error
+}
+
+// settle records the outcome of the submission.
+func (s *submission) settle(result *pb.BlockResult, err error) {
+	s.result, s.err = result, err
+	close(s.done)
+}
+
+// submissions remembers the consensus blocks submitted recently by their
+// content, so a block consensus retries after a timeout isn't executed twice.
+// A nil tracker remembers nothing.
+type submissions struct {
+	lock sync.Mutex
+	lru  lru.BasicLRU[common.Hash, *submission]
+}
+
+// newSubmissions creates a tracker of the given number of consensus blocks.
+func newSubmissions(size int) *submissions {
+	return &submissions{lru: lru.NewBasicLRU[common.Hash, *submission](size)}
+}
+
+// claim returns the submission of a consensus block with the given identifier,
+// reporting whether it was submitted before.
+func (s *submissions) claim(id common.Hash) (*submission, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if sub, ok := s.lru.Get(id); ok {
+		return sub, true
+	}
+	sub := &submission{done: make(chan struct{})}
+	s.lru.Add(id, sub)
+	return sub, false
+}
+
+// claimBlock returns the identifier and submission of a consensus block,
+// reporting whether it was submitted before. The block isn't identified if
+// nothing is remembered.
+func (s *submissions) claimBlock(pbBlock *pb.ExecBlock) (common.Hash, *submission, bool) {
+	if s == nil {
+		return common.Hash{}, nil, false
+	}
+	id := blockID(pbBlock)
+	sub, dup := s.claim(id)
+	return id, sub, dup
+}
+
+// forget drops a submission whose block wasn't queued, so it's executed when
+// consensus submits it again.
+func (s *submissions) forget(id common.Hash, sub *submission) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if cur, ok := s.lru.Peek(id); ok && cur == sub {
+		s.lru.Remove(id)
+	}
+}
+
+// reset forgets every submission, so the blocks consensus delivers again after
+// a rewind are executed.
+func (s *submissions) reset() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lru.Purge()
+}
+
+// blockID identifies a consensus block by its content, however consensus asks
+// for its outcome.
+func blockID(pbBlock *pb.ExecBlock) common.Hash {
+	await := pbBlock.AwaitResult
+	pbBlock.AwaitResult = false
+	blob, _ := proto.MarshalOptions{Deterministic: true}.Marshal(pbBlock)
+	pbBlock.AwaitResult = await
+	return crypto.Keccak256Hash(blob)
+}
+
+// awaitDuplicate returns the outcome of a consensus block submitted before,
+// waiting for it if the block is still being executed.
+func (es *executorServer) awaitDuplicate(ctx context.Context, pbBlock *pb.ExecBlock, sub *submission) (*pb.BlockResult, error) {
+	duplicateBlockMeter.Mark(1)
+	log.Debug("Ignoring duplicate consensus block", "sequence", pbBlock.GetSequence(), "txs", len(pbBlock.GetTxs()))
+
+	select {
+	case <-sub.done:
+		return sub.result, sub.err
+	case <-es.executorPtr.exitCh:
+		return &pb.BlockResult{}, errExecutorStopping
+	case <-ctx.Done():
+		return &pb.BlockResult{}, ctx.Err()
+	}
+}
+
+// deliverResult hands the outcome of an executed consensus block to consensus
+// awaiting it, and to the submissions retrying the block.
+func (e *executor) deliverResult(req *execReq, res *blockResult) {
+	if req.result != nil {
+		req.result <- res
+	}
+	if req.sub != nil {
+		result := res.result
+		if res.err != nil {
+			result = &pb.BlockResult{}
+		}
+		req.sub.settle(result, res.err)
+	}
+}
+
+// abandonResult settles the outcome of a consensus block dropped without being
+// executed, so neither consensus awaiting it nor the submissions retrying it
+// wait for it.
+func (e *executor) abandonResult(req *execReq, err error) {
+	if req != nil {
+		e.deliverResult(req, &blockResult{result: &pb.BlockResult{}, err: err})
+	}
+}
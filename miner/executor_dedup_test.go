@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestDuplicateBlocks(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		confirmCh:   make(chan *confirmReq),
+		alerter:     newAlerter(testConfig),
+		submissions: newSubmissions(16),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	var (
+		es     = &executorServer{executorPtr: e}
+		signer = types.LatestSigner(&config)
+		chain  = backend.chain
+	)
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	// Consensus gives up on an awaited block before it's executed, and submits
+	// it again
+	block := newTestExecBlock(t, transfer(0), transfer(1))
+	block.Sequence, block.AwaitResult = 1, true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	es.CommitBlock(ctx, block)
+
+	first, err := es.CommitBlock(context.Background(), block)
+	if err != nil {
+		t.Fatalf("failed to commit retried block: %v", err)
+	}
+	head := chain.CurrentBlock()
+	if head.Number.Uint64() != 1 {
+		t.Fatalf("head mismatch: have %d, want 1", head.Number)
+	}
+	if common.BytesToHash(first.Hash) != head.Hash() || len(first.Txs) != 2 {
+		t.Fatalf("retried block result mismatch: have hash %x txs %d, want %x", first.Hash, len(first.Txs), head.Hash())
+	}
+	// Retries are handed the original outcome whether awaiting it or not
+	block.AwaitResult = false
+	if res, err := es.CommitBlock(context.Background(), block); err != nil || common.BytesToHash(res.Hash) != head.Hash() {
+		t.Fatalf("retried block result mismatch: have %v, %v", res, err)
+	}
+	// Blocks with a different content are executed
+	next := newTestExecBlock(t, transfer(2))
+	next.Sequence, next.AwaitResult = 2, true
+	second, err := es.CommitBlock(context.Background(), next)
+	if err != nil {
+		t.Fatalf("failed to commit next block: %v", err)
+	}
+	if second.Number != 2 || chain.CurrentBlock().Number.Uint64() != 2 {
+		t.Fatalf("next block not executed: result number %d, head %d", second.Number, chain.CurrentBlock().Number)
+	}
+	if txs := len(chain.GetBlockByNumber(2).Transactions()); txs != 1 {
+		t.Errorf("next block txs mismatch: have %d, want 1", txs)
+	}
+}
+
+func TestDroppedBlocksSettled(t *testing.T) {
+	e := &executor{config: testConfig, submissions: newSubmissions(16)}
+
+	// An awaited block held back for its predecessors is dropped on shutdown,
+	// its retries must not wait for an outcome that never comes
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.Sequence, block.AwaitResult = 3, true
+
+	id, sub, _ := e.submissions.claimBlock(block)
+	req := &execReq{sequence: 3, result: make(chan *blockResult, 1), sub: sub}
+	e.order.next = 1
+	e.order.held = map[uint64]*execReq{3: req, 4: nil}
+
+	e.drainHeld()
+	if len(e.order.held) != 0 {
+		t.Fatalf("held blocks mismatch: have %d, want 0", len(e.order.held))
+	}
+	select {
+	case <-sub.done:
+	default:
+		t.Fatalf("dropped block not settled")
+	}
+	if !errors.Is(sub.err, errExecutorStopping) {
+		t.Errorf("error mismatch: have %v, want %v", sub.err, errExecutorStopping)
+	}
+	if res := <-req.result; !errors.Is(res.err, errExecutorStopping) {
+		t.Errorf("result error mismatch: have %v, want %v", res.err, errExecutorStopping)
+	}
+	if cur, dup := e.submissions.claim(id); !dup || cur != sub {
+		t.Errorf("submission of the dropped block forgotten")
+	}
+}
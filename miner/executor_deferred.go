@@ -0,0 +1,244 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	deferredCommitTimer = metrics.NewRegisteredTimer("executor/deferred/commit", nil)
+	deferredWaitTimer   = metrics.NewRegisteredTimer("executor/deferred/wait", nil)
+	deferredCarryMeter  = metrics.NewRegisteredMeter("executor/deferred/carried", nil)
+
+	errStateCommit = errors.New("failed to commit deferred state")
+)
+
+// stateCommit is the post state of a written block being committed in the
+// background.
+type stateCommit struct {
+	block *types.Block
+	state *state.StateDB // copy of the post state, dropped once committed
+
+	wal []uint64 // write-ahead log entries of the consensus blocks of the block, dropped once committed
+
+	err  error         // outcome of the commit, set once done is closed
+	done chan struct{} // closed once the commit finished
+}
+
+// stateCommits tracks the post states of the written blocks on chains whose
+// headers commit to deferred state roots.
+//
+// A header only commits to the post state of the block RootDepth blocks before
+// it, so a block is written right after its execution and hashing its state
+// overlaps with the execution of the next blocks. The states are committed in
+// the background in the order the blocks were written. Until the post state of
+// a block landed, its child executes on the state carried over in memory. The
+// carried state accumulates the changes of every block since the committed
+// state it was opened on, it's opened afresh once that's over RootDepth blocks
+// old.
+type stateCommits struct {
+	pending []*stateCommit // commits not reaped yet, in write order
+
+	carry *state.StateDB // post state of the last written block, nil once taken
+	hash  common.Hash    // hash of the block the carried state belongs to
+	base  uint64         // number of the committed block the carried state was opened on
+}
+
+// deferredRoots reports whether the header of the block with the given number
+// commits to a deferred state root.
+func (e *executor) deferredRoots(num *big.Int) bool {
+	return e.chainConfig.Executor.RootDepth(num) > 0
+}
+
+// prepareDeferredWork prepares the environment of a block on top of the chain
+// head, executing on the carried post state of the head if not committed yet.
+func (e *executor) prepareDeferredWork(timestamp uint64, coinbase common.Address, batchSize int) (*executor_env, error) {
+	statedb, base, err := e.parentState(e.eth.BlockChain().CurrentBlock())
+	if err != nil {
+		return nil, err
+	}
+	work, err := e.prepareWork(&generateParams{
+		timestamp: timestamp,
+		coinbase:  coinbase,
+		state:     statedb,
+	}, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	work.stateBase = base
+	return work, nil
+}
+
+// parentState returns the post state of a block to execute its child on, along
+// with the number of the committed block the state was opened on. The carried
+// state is taken if it belongs to the block, otherwise the state is opened once
+// committed.
+func (e *executor) parentState(parent *types.Header) (*state.StateDB, uint64, error) {
+	// The child mutates the carried state, it can only be taken once
+	carry, base := e.commits.carry, e.commits.base
+	if e.commits.hash != parent.Hash() {
+		carry = nil
+	}
+	e.commits.carry = nil
+
+	if carry != nil {
+		if err := e.reapCommits(); err != nil {
+			return nil, 0, err
+		}
+		if len(e.commits.pending) > 0 && parent.Number.Uint64()-base <= e.chainConfig.Executor.RootDepth(new(big.Int).Add(parent.Number, common.Big1)) {
+			deferredCarryMeter.Mark(1)
+			return carry, base, nil
+		}
+	}
+	if err := e.settleCommits(parent.Number.Uint64()); err != nil {
+		return nil, 0, err
+	}
+	chain := e.eth.BlockChain()
+	statedb, err := chain.StateAt(chain.PostStateRoot(parent))
+	if err != nil {
+		return nil, 0, err
+	}
+	return statedb, parent.Number.Uint64(), nil
+}
+
+// finalizeDeferred finalizes the block of the environment like the engines do,
+// except that the header commits to the deferred state root instead of the
+// hash of the post state. The post state is committed once the block was
+// written.
+func (e *executor) finalizeDeferred(work *executor_env) (*types.Block, error) {
+	header := work.header
+	parent := work.chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	root, err := e.deferredRoot(parent)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals := e.finalize(work)
+	work.state.Finalise(e.chainConfig.DeleteEmptyAccounts(header.Number))
+
+	header.Root = root
+	return types.NewBlockWithWithdrawals(header, work.txs, nil, nil, withdrawals, trie.NewStackTrie(nil)), nil
+}
+
+// deferredRoot returns the state root the header of a child of the given block
+// commits to, waiting for the state to be committed in the background. The
+// post states of the blocks of competing branches aren't committed, they are
+// hashed instead.
+func (e *executor) deferredRoot(parent *types.Header) (common.Hash, error) {
+	chain := e.eth.BlockChain()
+	ancestor := parent
+	depth := e.chainConfig.Executor.RootDepth(new(big.Int).Add(parent.Number, common.Big1))
+	for i := uint64(1); i < depth && ancestor.Number.Sign() > 0; i++ {
+		if pending := e.branchBlock(ancestor.ParentHash); pending != nil {
+			ancestor = pending.block.Header()
+		} else if ancestor = chain.GetHeader(ancestor.ParentHash, ancestor.Number.Uint64()-1); ancestor == nil {
+			return common.Hash{}, consensus.ErrUnknownAncestor
+		}
+	}
+	if pending := e.branchBlock(ancestor.Hash()); pending != nil {
+		return pending.env.state.IntermediateRoot(e.chainConfig.DeleteEmptyAccounts(ancestor.Number)), nil
+	}
+	wait := time.Now()
+	if err := e.settleCommits(ancestor.Number.Uint64()); err != nil {
+		return common.Hash{}, err
+	}
+	deferredWaitTimer.UpdateSince(wait)
+
+	root := chain.PostStateRoot(ancestor)
+	if root == (common.Hash{}) {
+		return common.Hash{}, fmt.Errorf("%w: block %d %x", core.ErrStateNotCommitted, ancestor.Number, ancestor.Hash())
+	}
+	return root, nil
+}
+
+// branchBlock returns the executed block of a competing branch with the given
+// hash, nil if there's none.
+func (e *executor) branchBlock(hash common.Hash) *pendingBlock {
+	for _, b := range e.branches {
+		for _, pending := range b.blocks {
+			if pending.block.Hash() == hash {
+				return pending
+			}
+		}
+	}
+	return nil
+}
+
+// commitDeferred starts committing the post state of a written block in the
+// background after the ones written before, pruning the txpool once it landed,
+// and carries the state over to the child of the block.
+func (e *executor) commitDeferred(env *executor_env, block *types.Block) {
+	c := &stateCommit{block: block, state: env.state.Copy(), done: make(chan struct{})}
+
+	var prev *stateCommit
+	if n := len(e.commits.pending); n > 0 {
+		prev = e.commits.pending[n-1]
+	}
+	e.commits.pending = append(e.commits.pending, c)
+	e.commits.carry, e.commits.hash, e.commits.base = env.state, block.Hash(), env.stateBase
+
+	chain := e.eth.BlockChain()
+	go func() {
+		defer close(c.done)
+		if prev != nil {
+			<-prev.done
+		}
+		start := time.Now()
+		var root common.Hash
+		profileStage(stageCommit, len(block.Transactions()), func() {
+			root, c.err = chain.CommitDeferredState(block, c.state)
+		})
+		c.state = nil
+		deferredCommitTimer.UpdateSince(start)
+		if c.err == nil {
+			e.prunePool(block.Header(), root)
+		}
+	}()
+}
+
+// settleCommits waits for the post states of the written blocks up to the given
+// number to be committed, returning the first failed commit.
+func (e *executor) settleCommits(number uint64) error {
+	for _, c := range e.commits.pending {
+		if c.block.NumberU64() > number {
+			break
+		}
+		<-c.done
+	}
+	return e.reapCommits()
+}
+
+// reapCommits drops the finished commits, returning the first failed one.
+func (e *executor) reapCommits() error {
+	var err error
+	for len(e.commits.pending) > 0 {
+		c := e.commits.pending[0]
+		select {
+		case <-c.done:
+		default:
+			return err
+		}
+		e.commits.pending = e.commits.pending[1:]
+		if c.err == nil {
+			e.deleteWAL(c.wal)
+		}
+		if c.err != nil && err == nil {
+			log.Error("Failed to commit deferred state", "number", c.block.Number(), "hash", c.block.Hash(), "err", c.err)
+			err = fmt.Errorf("%w of block %d: %v", errStateCommit, c.block.NumberU64(), c.err)
+		}
+	}
+	return err
+}
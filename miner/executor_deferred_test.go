@@ -0,0 +1,156 @@
+package miner
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// slowCommitDB holds back recording the post state roots, so the next blocks
+// are executed while the states of their parents are being committed.
+type slowCommitDB struct {
+	ethdb.Database
+}
+
+func (db *slowCommitDB) Put(key []byte, value []byte) error {
+	if len(key) == 1+common.HashLength && key[0] == 'R' {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return db.Database.Put(key, value)
+}
+
+func TestDeferredStateRoots(t *testing.T) {
+	// Executor blocks only pass the header checks of the beacon engine
+	config := postMergeConfig()
+
+	var (
+		signer = types.LatestSigner(&config)
+		start  = time.Now().Unix() - 10
+		blocks = 6
+		value  = big.NewInt(1000)
+	)
+	run := func(depth uint64, fork *big.Int) ([]*types.Block, []common.Hash) {
+		config := config
+		config.Executor = &params.ExecutorConfig{DeferredRootDepth: depth, DeferredRootBlock: fork}
+
+		backend := newTestExecBackend(&config, ethash.NewFaker(), &slowCommitDB{Database: rawdb.NewMemoryDatabase()}, 0)
+		defer backend.close()
+
+		// Blocks are written as soon as executed, the write pipeline is bypassed
+		cfg := *testConfig
+		cfg.PipelineWrites = depth > 0
+		e := &executor{config: &cfg, chainConfig: &config, engine: beacon.New(ethash.NewFaker()), eth: backend, alerter: newAlerter(&cfg)}
+
+		for i := 0; i < blocks; i++ {
+			tx := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Value: value, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+			if errs := backend.txPool.Add(types.Transactions{tx}, true, true); errs[0] != nil {
+				t.Fatalf("failed to add tx %d: %v", i, errs[0])
+			}
+			req, _, err := decodeExecBlock(newTestExecBlock(t, tx), nil)
+			if err != nil {
+				t.Fatalf("failed to decode block: %v", err)
+			}
+			req.timestamp = start + int64(i)
+			if err := e.executeNewTxBatch(req); err != nil {
+				t.Fatalf("failed to execute block %d: %v", i+1, err)
+			}
+			if e.inflight != nil {
+				t.Fatalf("block %d handed off to the write pipeline", i+1)
+			}
+		}
+		if err := e.settleCommits(math.MaxUint64); err != nil {
+			t.Fatalf("failed to commit states: %v", err)
+		}
+		// The pool drops the executed txs as soon as the states landed
+		if pending, queued := backend.txPool.Stats(); pending+queued != 0 {
+			t.Errorf("depth %d: executed txs left in pool: %d pending, %d queued", depth, pending, queued)
+		}
+		chain := backend.chain
+		if head := chain.CurrentBlock().Number.Uint64(); head != uint64(blocks) {
+			t.Fatalf("head mismatch: have %d, want %d", head, blocks)
+		}
+		var (
+			written = make([]*types.Block, blocks+1)
+			roots   = make([]common.Hash, blocks+1)
+		)
+		for i := range written {
+			written[i] = chain.GetBlockByNumber(uint64(i))
+			if roots[i] = chain.PostStateRoot(written[i].Header()); !chain.HasState(roots[i]) {
+				t.Fatalf("block %d state missing", i)
+			}
+		}
+		state, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to open head state: %v", err)
+		}
+		if have, want := state.GetBalance(testUserAddress), new(big.Int).Mul(value, big.NewInt(int64(blocks))); have.ToBig().Cmp(want) != 0 {
+			t.Errorf("balance mismatch: have %v, want %v", have, want)
+		}
+		// Importing the blocks validates the deferred roots
+		if depth > 0 {
+			replica, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, backend.genesis, nil, beacon.New(ethash.NewFaker()), vm.Config{}, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create replica: %v", err)
+			}
+			defer replica.Stop()
+			if n, err := replica.InsertChain(written[1:]); err != nil {
+				t.Fatalf("failed to import block %d: %v", n+1, err)
+			}
+			for i, block := range written {
+				if root := replica.PostStateRoot(block.Header()); root != roots[i] {
+					t.Errorf("imported block %d post state root mismatch: have %x, want %x", i, root, roots[i])
+				}
+			}
+			// Re-executing a block starts from and reaches the post states
+			res, err := e.reexecute(written[blocks].Hash(), ReexecOverrides{})
+			if err != nil {
+				t.Fatalf("failed to re-execute block: %v", err)
+			}
+			if !res.Reproduced || res.Root != roots[blocks] {
+				t.Errorf("re-execution mismatch: have %+v, want root %x", res, roots[blocks])
+			}
+		}
+		return written, roots
+	}
+	immediate, want := run(0, nil)
+	for i, block := range immediate {
+		if block.Root() != want[i] {
+			t.Fatalf("block %d root mismatch: have %x, want %x", i, block.Root(), want[i])
+		}
+	}
+	// Chains may start deferring their roots at a fork block
+	for _, tt := range []struct {
+		depth uint64
+		fork  int
+	}{{1, 0}, {2, 0}, {2, 3}} {
+		depth := tt.depth
+		written, roots := run(depth, big.NewInt(int64(tt.fork)))
+		for i, block := range written {
+			// The post states are the ones of the chain without deferred roots
+			if roots[i] != want[i] {
+				t.Errorf("depth %d fork %d block %d post state root mismatch: have %x, want %x", depth, tt.fork, i, roots[i], want[i])
+			}
+			committed := 0
+			switch {
+			case i < tt.fork:
+				committed = i
+			case uint64(i) > depth:
+				committed = i - int(depth)
+			}
+			if block.Root() != want[committed] {
+				t.Errorf("depth %d fork %d block %d header root mismatch: have %x, want block %d root %x", depth, tt.fork, i, block.Root(), committed, want[committed])
+			}
+		}
+	}
+}
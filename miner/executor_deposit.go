@@ -0,0 +1,31 @@
+package miner
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var (
+	depositMeter         = metrics.NewRegisteredMeter("executor/deposit/delivered", nil)
+	depositRejectedMeter = metrics.NewRegisteredMeter("executor/deposit/rejected", nil)
+
+	errDepositType = errors.New("deposit tx type mismatch")
+)
+
+// checkDeposit ensures deposit txs, which mint funds without a signature, only
+// enter the chain when consensus delivered them as BRIDGE_DEPOSIT txs it
+// attested to, and that txs delivered as deposits are deposits indeed.
+func checkDeposit(pbTx *pb.Transaction, tx *types.Transaction) error {
+	deposit := tx.Type() == types.DepositTxType
+	if deposit != (pbTx.Type == pb.TransactionType_BRIDGE_DEPOSIT) {
+		depositRejectedMeter.Mark(1)
+		return errDepositType
+	}
+	if deposit {
+		depositMeter.Mark(1)
+	}
+	return nil
+}
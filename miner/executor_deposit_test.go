@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeposit(t *testing.T) {
+	var (
+		config  = postMergeConfig()
+		bridged = common.HexToAddress("0xb1d6e")
+		minted  = big.NewInt(params.Ether)
+	)
+	config.Executor = &params.ExecutorConfig{Deposits: &params.Deposits{Address: common.HexToAddress("0xde9051")}}
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	deposit := types.NewTx(&types.DepositTx{
+		ChainID:    config.ChainID,
+		SourceHash: common.Hash{0x01},
+		From:       bridged,
+		To:         testUserAddress,
+		Mint:       minted,
+		Value:      big.NewInt(1000),
+		Gas:        params.TxGas,
+	})
+	execute := func(kind pb.TransactionType) (*execReq, error) {
+		payload, _ := deposit.MarshalBinary()
+		blob, err := proto.Marshal(&pb.Transaction{Type: kind, Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to encode deposit: %v", err)
+		}
+		req, dropped, err := decodeExecBlock(&pb.ExecBlock{Txs: [][]byte{blob}}, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		if req == nil {
+			return nil, dropped[0]
+		}
+		req.timestamp = time.Now().UnixNano()
+		return req, e.executeNewTxBatch(req)
+	}
+	// Deposits are only minted when consensus delivered them as such
+	if _, err := execute(pb.TransactionType_NORMAL); !errors.Is(err, errDepositType) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errDepositType)
+	}
+	if _, err := execute(pb.TransactionType_BRIDGE_DEPOSIT); err != nil {
+		t.Fatalf("failed to execute deposit: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	receipts := backend.chain.GetReceiptsByHash(head.Hash())
+	if len(receipts) != 1 || receipts[0].Type != types.DepositTxType || receipts[0].Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("receipt mismatch: have %v, want a successful deposit", receipts)
+	}
+	if fees := totalFees(backend.chain.GetBlockByHash(head.Hash()), receipts); fees.Sign() != 0 {
+		t.Fatalf("fees mismatch: have %v, want 0", fees)
+	}
+	statedb, _ := backend.chain.State()
+	if have, want := statedb.GetBalance(bridged).ToBig(), new(big.Int).Sub(minted, big.NewInt(1000)); have.Cmp(want) != 0 {
+		t.Fatalf("sender balance mismatch: have %v, want %v", have, want)
+	}
+	if have := statedb.GetBalance(testUserAddress).ToBig(); have.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("recipient balance mismatch: have %v, want %v", have, 1000)
+	}
+	// Deposits delivered again aren't minted twice
+	if _, err := execute(pb.TransactionType_BRIDGE_DEPOSIT); err != nil {
+		t.Fatalf("failed to execute replayed deposit: %v", err)
+	}
+	statedb, _ = backend.chain.State()
+	if have := statedb.GetBalance(testUserAddress).ToBig(); have.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("replayed deposit minted: have %v, want %v", have, 1000)
+	}
+}
@@ -0,0 +1,44 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var divergedMeter = metrics.NewRegisteredMeter("executor/determinism/diverged", nil)
+
+var (
+	errNonDeterministic = errors.New("non-deterministic execution config")
+	errRootMismatch     = errors.New("state root mismatch")
+)
+
+// CheckDeterminism audits the VM config consensus blocks are executed with.
+// Every replica has to compute the same results from the same blocks, so
+// settings altering the execution locally are rejected: tracers may have side
+// effects or fail the execution, the other settings change its outcome.
+func CheckDeterminism(config *vm.Config) error {
+	switch {
+	case config.Tracer != nil:
+		return fmt.Errorf("%w: tracer %T attached to the block execution", errNonDeterministic, config.Tracer)
+	case config.NoBaseFee:
+		return fmt.Errorf("%w: base fee disabled", errNonDeterministic)
+	case len(config.ExtraEips) > 0:
+		return fmt.Errorf("%w: extra EIPs %v enabled locally", errNonDeterministic, config.ExtraEips)
+	}
+	return nil
+}
+
+// checkExpectedRoot compares the state root of an executed block with the
+// one consensus expects it to reach, if any.
+func checkExpectedRoot(req *execReq, block *types.Block) error {
+	if req.expectedRoot == (common.Hash{}) || block.Root() == req.expectedRoot {
+		return nil
+	}
+	divergedMeter.Mark(1)
+	return fmt.Errorf("%w: have %x, want %x", errRootMismatch, block.Root(), req.expectedRoot)
+}
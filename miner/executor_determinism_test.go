@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestCheckDeterminism(t *testing.T) {
+	tests := []struct {
+		config vm.Config
+		fail   bool
+	}{
+		{config: vm.Config{}},
+		{config: vm.Config{EnablePreimageRecording: true}},
+		{config: vm.Config{Tracer: logger.NewStructLogger(nil)}, fail: true},
+		{config: vm.Config{NoBaseFee: true}, fail: true},
+		{config: vm.Config{ExtraEips: []int{3855}}, fail: true},
+	}
+	for i, tt := range tests {
+		err := CheckDeterminism(&tt.config)
+		if tt.fail != errors.Is(err, errNonDeterministic) {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
+
+func TestExpectedRoot(t *testing.T) {
+	config := postMergeConfig()
+
+	var (
+		timestamp = time.Now().UnixNano()
+		alerts    *alerter
+	)
+	execute := func(root common.Hash) (*testWorkerBackend, *testConsensusClient, error) {
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		client := new(testConsensusClient)
+		e := &executor{
+			config:      testConfig,
+			chainConfig: &config,
+			engine:      ethash.NewFaker(),
+			eth:         backend,
+			execClient:  &executorClient{consensusClient: client},
+			alerter:     newAlerter(testConfig),
+		}
+		alerts = e.alerter
+		err := e.executeNewTxBatch(&execReq{timestamp: timestamp, txs: types.Transactions{pendingTxs[0]}, sequence: 1, expectedRoot: root})
+		return backend, client, err
+	}
+	reference, _, err := execute(common.Hash{})
+	if err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	defer reference.close()
+	root := reference.chain.CurrentBlock().Root
+
+	// Blocks reaching the expected root are written
+	backend, _, err := execute(root)
+	if err != nil {
+		t.Fatalf("failed to execute block with expected root: %v", err)
+	}
+	defer backend.close()
+	if head := backend.chain.CurrentBlock(); head.Root != root {
+		t.Fatalf("state root mismatch: have %x, want %x", head.Root, root)
+	}
+	// Diverging blocks halt the execution before being written
+	backend, client, err := execute(common.Hash{0x01})
+	if !errors.Is(err, errRootMismatch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRootMismatch)
+	}
+	defer backend.close()
+	if number := backend.chain.CurrentBlock().Number.Uint64(); number != 0 {
+		t.Errorf("diverging block written: head %d", number)
+	}
+	if len(client.faults) != 1 || client.faults[0].Type != pb.FaultType_FATAL {
+		t.Errorf("fault mismatch: have %v, want one %v", client.faults, pb.FaultType_FATAL)
+	}
+	// The operator is alerted about the divergence, not just the halt
+	active := make(map[string]bool)
+	for _, alert := range alerts.alerts() {
+		active[alert.Kind] = true
+	}
+	if !active[AlertRootMismatch] || !active[AlertHalted] {
+		t.Errorf("alerts mismatch: have %v, want %s and %s", active, AlertRootMismatch, AlertHalted)
+	}
+}
@@ -0,0 +1,121 @@
+package miner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// deliveries tracks the consensus blocks being handed over to the execution.
+type deliveries struct {
+	lock   sync.Mutex
+	active int
+	idle   chan struct{} // closed once no longer taking blocks and none remain, nil while taking them
+}
+
+// begin registers a block being delivered, false if no longer taking blocks.
+func (d *deliveries) begin() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.idle != nil {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// end unregisters a delivered block.
+func (d *deliveries) end() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.active--; d.active == 0 && d.idle != nil {
+		close(d.idle)
+	}
+}
+
+// close stops taking blocks, returning a channel closed once the blocks being
+// delivered are handed over.
+func (d *deliveries) close() <-chan struct{} {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle
+}
+
+// drain stops the executor from taking further consensus blocks and waits for
+// the ones being delivered, once. The executor API mounted on a node endpoint
+// is drained before the endpoint shuts down.
+func (e *executor) drain() {
+	e.drainOnce.Do(func() {
+		e.stopping.Store(true)
+		e.updateHealth()
+		e.drainServer()
+	})
+}
+
+// drainServer stops the server from taking further consensus blocks and waits
+// for the ones being delivered to be handed over to the execution, or executed
+// if consensus awaits them, for up to the drain timeout. Deliveries still going
+// on past the timeout are cut by cutServer once the loops are torn down. The
+// handler transports of servers mounted on a node endpoint can't be drained
+// gracefully, the deliveries are waited for instead.
+func (e *executor) drainServer() {
+	timeout := e.config.DrainTimeout
+	if e.server == nil || timeout <= 0 {
+		e.deliveries.close()
+		return
+	}
+	done := e.deliveries.close()
+	if !e.onNode() {
+		stopped := make(chan struct{})
+		go func() {
+			e.server.GracefulStop()
+			close(stopped)
+		}()
+		done = stopped
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		log.Warn("Timed out draining consensus blocks, dropping deliveries", "timeout", timeout)
+	}
+}
+
+// cutServer closes the connections left by the drain. Deliveries stuck handing
+// their block over are released by the exit of the loops first, the server
+// waits for them.
+func (e *executor) cutServer() {
+	if e.server != nil {
+		e.server.Stop()
+	}
+}
+
+// drainHeld drops the consensus blocks left awaiting their predecessors, which
+// can't be executed before shutting down. They are replayed from the WAL if
+// enabled, delivered again by consensus otherwise.
+func (e *executor) drainHeld() {
+	o := &e.order
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if len(o.held) > 0 {
+		log.Warn("Shutting down with consensus blocks awaiting predecessors", "held", len(o.held), "next", o.next, "wal", e.config.BlockWAL)
+	}
+	for seq, req := range o.held {
+		e.abandonResult(req, errExecutorStopping)
+		delete(o.held, seq)
+	}
+	heldBlocksGauge.Update(0)
+}
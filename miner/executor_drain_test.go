@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestDrainOnClose(t *testing.T) {
+	config := postMergeConfig()
+
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(&config), &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+
+	for _, drain := range []bool{true, false} {
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		cfg := *testConfig
+		cfg.DrainTimeout = 5 * time.Second
+		if !drain {
+			cfg.DrainTimeout = 50 * time.Millisecond
+		}
+		e := &executor{
+			config:      &cfg,
+			chainConfig: &config,
+			engine:      ethash.NewFaker(),
+			eth:         backend,
+			exitCh:      make(chan struct{}),
+			execCh:      make(chan *execReq),
+			server:      grpc.NewServer(),
+			alerter:     newAlerter(&cfg),
+		}
+		pb.RegisterExecutorServer(e.server, &executorServer{executorPtr: e})
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		go e.server.Serve(listener)
+
+		conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+
+		// Deliver a block while the execution is busy
+		delivered := make(chan error, 1)
+		go func() {
+			_, err := pb.NewExecutorClient(conn).CommitBlock(context.Background(), newTestExecBlock(t, tx))
+			delivered <- err
+		}()
+		for start := time.Now(); e.execStats.queued.Load() == 0; time.Sleep(time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("drain %v: block not delivered", drain)
+			}
+		}
+		closed := make(chan struct{})
+		go func() {
+			e.close()
+			close(closed)
+		}()
+		for !e.stopping.Load() {
+			time.Sleep(time.Millisecond)
+		}
+		// Further blocks are rejected once shutting down
+		server := &executorServer{executorPtr: e}
+		if _, err := server.CommitBlock(context.Background(), newTestExecBlock(t, tx)); !errors.Is(err, errExecutorStopping) {
+			t.Errorf("drain %v: commit error mismatch: have %v, want %v", drain, err, errExecutorStopping)
+		}
+		// Blocks delivered before are executed if the execution frees up in time
+		if drain {
+			e.wg.Add(1)
+			go e.executionLoop()
+		}
+		select {
+		case <-closed:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("drain %v: executor not closed", drain)
+		}
+		if err := <-delivered; (err == nil) != drain {
+			t.Errorf("drain %v: delivery error mismatch: have %v", drain, err)
+		}
+		want := uint64(0)
+		if drain {
+			want = 1
+		}
+		if head := backend.chain.CurrentBlock().Number.Uint64(); head != want {
+			t.Errorf("drain %v: head mismatch: have %d, want %d", drain, head, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var (
+	healthyEndpointsGauge = metrics.NewRegisteredGauge("executor/consensus/healthy", nil)
+	failoverMeter         = metrics.NewRegisteredMeter("executor/consensus/failover", nil)
+
+	errNoEndpoint = errors.New("no consensus endpoint")
+)
+
+// consensusEndpoint is a consensus replica the executor calls into.
+type consensusEndpoint struct {
+	target  string
+	conn    grpc.ClientConnInterface
+	healthy atomic.Bool // whether the connection to the endpoint is up, as last watched
+}
+
+// endpointSet calls into the first healthy consensus endpoint in the order
+// configured, failing over to the next ones while it's down. The forwarded
+// txs are spread round-robin over the healthy endpoints if balancing. The
+// endpoints are tried in turn if none is known to be healthy.
+//
+// The health of an endpoint follows the state of its connection alone. A call
+// failing on an endpoint is retried on the next ones, but the endpoint is
+// tried first again by the next call: a single slow or overloaded response
+// doesn't tell it's down, and nothing would tell once it's back.
+type endpointSet struct {
+	endpoints []*consensusEndpoint
+	balance   bool
+	next      atomic.Uint64 // endpoint the next balanced tx is sent to
+	active    atomic.Int32  // index of the endpoint last called into successfully
+}
+
+// newEndpointSet creates a set of the given endpoints, assumed healthy until
+// checked.
+func newEndpointSet(endpoints []*consensusEndpoint, balance bool) *endpointSet {
+	for _, ep := range endpoints {
+		ep.healthy.Store(true)
+	}
+	healthyEndpointsGauge.Update(int64(len(endpoints)))
+	return &endpointSet{endpoints: endpoints, balance: balance}
+}
+
+// dialEndpoints connects to the consensus endpoints at the given targets,
+// skipping the ones failing to dial.
+func dialEndpoints(targets []string, creds credentials.TransportCredentials, config *Config) []*consensusEndpoint {
+	var endpoints []*consensusEndpoint
+	for _, target := range targets {
+		conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds), reconnectParams(config.ReconnectBackoff))
+		if err != nil {
+			log.Error("Failed to dial consensus endpoint", "target", target, "err", err)
+			continue
+		}
+		endpoints = append(endpoints, &consensusEndpoint{target: target, conn: conn})
+	}
+	return endpoints
+}
+
+// order returns the endpoints in the order a call to the given method tries
+// them: the healthy ones first, starting with the next one in turn for the
+// balanced txs and with the first one configured otherwise.
+func (s *endpointSet) order(method string) []*consensusEndpoint {
+	var healthy, down []*consensusEndpoint
+	for _, ep := range s.endpoints {
+		if ep.healthy.Load() {
+			healthy = append(healthy, ep)
+		} else {
+			down = append(down, ep)
+		}
+	}
+	if s.balance && method == pb.P2P_Send_FullMethodName && len(healthy) > 1 {
+		start := int(s.next.Add(1)-1) % len(healthy)
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+	return append(healthy, down...)
+}
+
+// setHealth records the state of the connection to an endpoint.
+func (s *endpointSet) setHealth(ep *consensusEndpoint, healthy bool) {
+	if ep.healthy.Swap(healthy) == healthy {
+		return
+	}
+	var count int64
+	for _, ep := range s.endpoints {
+		if ep.healthy.Load() {
+			count++
+		}
+	}
+	healthyEndpointsGauge.Update(count)
+	if count == 0 {
+		log.Error("All consensus endpoints down", "endpoints", len(s.endpoints))
+	}
+}
+
+// called records the outcome of a call into an endpoint, logging a failover if
+// the calls moved over to another one.
+func (s *endpointSet) called(ep *consensusEndpoint, method string, err error) {
+	if err != nil {
+		return
+	}
+	if s.balance && method == pb.P2P_Send_FullMethodName {
+		return
+	}
+	for i, e := range s.endpoints {
+		if e != ep {
+			continue
+		}
+		if prev := s.active.Swap(int32(i)); prev != int32(i) {
+			log.Warn("Failed over to consensus endpoint", "from", s.endpoints[prev].target, "to", ep.target)
+			failoverMeter.Mark(1)
+		}
+		return
+	}
+}
+
+// Invoke calls a unary method on the endpoints in turn until one is reachable.
+func (s *endpointSet) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	err := errNoEndpoint
+	for _, ep := range s.order(method) {
+		err = ep.conn.Invoke(ctx, method, args, reply, opts...)
+		s.called(ep, method, err)
+		if err == nil || !transientErr(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	meterCall("client", clientCallsMeter, clientErrorsMeter, err)
+	return err
+}
+
+// NewStream opens a stream on the endpoints in turn until one is reachable.
+func (s *endpointSet) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	err := errNoEndpoint
+	for _, ep := range s.order(method) {
+		var stream grpc.ClientStream
+		stream, err = ep.conn.NewStream(ctx, desc, method, opts...)
+		s.called(ep, method, err)
+		if err == nil || !transientErr(err) || ctx.Err() != nil {
+			return stream, err
+		}
+	}
+	return nil, err
+}
+
+// watchEndpoints checks the health of the consensus endpoints through the
+// state of their connections.
+func (e *executor) watchEndpoints(s *endpointSet) {
+	for _, ep := range s.endpoints {
+		if conn, ok := ep.conn.(*grpc.ClientConn); ok {
+			ep := ep
+			e.watchConn(conn, func(healthy bool) { s.setHealth(ep, healthy) })
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testEndpointConn is a consensus endpoint counting the calls into it, failing
+// them with the set error.
+type testEndpointConn struct {
+	err   error
+	calls map[string]int
+}
+
+func (c *testEndpointConn) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[method]++
+	return c.err
+}
+
+func (c *testEndpointConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, c.err
+}
+
+func TestEndpointFailover(t *testing.T) {
+	var (
+		conns     = []*testEndpointConn{{}, {}, {}}
+		endpoints = make([]*consensusEndpoint, len(conns))
+	)
+	for i, conn := range conns {
+		endpoints[i] = &consensusEndpoint{target: string(rune('a' + i)), conn: conn}
+	}
+	set := newEndpointSet(endpoints, false)
+	p2p, cons := pb.NewP2PClient(set), pb.NewConsensusClient(set)
+
+	// Calls go to the primary while it's up
+	if _, err := p2p.Send(context.Background(), &pb.Packet{}); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if conns[0].calls[pb.P2P_Send_FullMethodName] != 1 {
+		t.Errorf("send not served by primary: %v", conns[0].calls)
+	}
+	// Calls failing on the primary fail over, without demoting it
+	conns[0].err = status.Error(codes.ResourceExhausted, "overloaded")
+	if _, err := cons.NotifyHead(context.Background(), &pb.Head{}); err != nil {
+		t.Fatalf("failed to notify head: %v", err)
+	}
+	if !endpoints[0].healthy.Load() || set.active.Load() != 1 {
+		t.Errorf("failover mismatch: primary healthy %v, active %d", endpoints[0].healthy.Load(), set.active.Load())
+	}
+	// Calls stay away from it once its connection is down
+	set.setHealth(endpoints[0], false)
+	if _, err := cons.NotifyHead(context.Background(), &pb.Head{}); err != nil {
+		t.Fatalf("failed to notify head: %v", err)
+	}
+	if have := conns[0].calls[pb.Consensus_NotifyHead_FullMethodName]; have != 1 {
+		t.Errorf("down primary calls mismatch: have %d, want 1", have)
+	}
+	if have := conns[1].calls[pb.Consensus_NotifyHead_FullMethodName]; have != 2 {
+		t.Errorf("failover calls mismatch: have %d, want 2", have)
+	}
+	// Rejections are no outage
+	conns[1].err = status.Error(codes.InvalidArgument, "invalid head")
+	if _, err := cons.NotifyHead(context.Background(), &pb.Head{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error mismatch: have %v, want %v", err, codes.InvalidArgument)
+	}
+	if conns[2].calls != nil {
+		t.Errorf("rejected call failed over: %v", conns[2].calls)
+	}
+	// Calls move back once the primary is healthy again
+	conns[0].err, conns[1].err = nil, nil
+	set.setHealth(endpoints[0], true)
+	if _, err := cons.NotifyHead(context.Background(), &pb.Head{}); err != nil {
+		t.Fatalf("failed to notify head: %v", err)
+	}
+	if have := conns[0].calls[pb.Consensus_NotifyHead_FullMethodName]; have != 2 || set.active.Load() != 0 {
+		t.Errorf("recovered primary calls mismatch: have %d, want 2", have)
+	}
+}
+
+func TestEndpointBalance(t *testing.T) {
+	var (
+		conns     = []*testEndpointConn{{}, {}, {}}
+		endpoints = make([]*consensusEndpoint, len(conns))
+	)
+	for i, conn := range conns {
+		endpoints[i] = &consensusEndpoint{target: string(rune('a' + i)), conn: conn}
+	}
+	set := newEndpointSet(endpoints, true)
+	set.setHealth(endpoints[2], false)
+	p2p, cons := pb.NewP2PClient(set), pb.NewConsensusClient(set)
+
+	// Txs are spread over the healthy endpoints, the rest goes to the primary
+	for i := 0; i < 6; i++ {
+		if _, err := p2p.Send(context.Background(), &pb.Packet{}); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+		if _, err := cons.NotifyHead(context.Background(), &pb.Head{}); err != nil {
+			t.Fatalf("failed to notify head: %v", err)
+		}
+	}
+	for i, want := range []int{3, 3, 0} {
+		if have := conns[i].calls[pb.P2P_Send_FullMethodName]; have != want {
+			t.Errorf("endpoint %d sends mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if have := conns[0].calls[pb.Consensus_NotifyHead_FullMethodName]; have != 6 {
+		t.Errorf("primary head notifications mismatch: have %d, want 6", have)
+	}
+}
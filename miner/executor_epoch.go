@@ -0,0 +1,134 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	epochExportedMeter = metrics.NewRegisteredMeter("executor/epoch/exported", nil)
+	epochFailedMeter   = metrics.NewRegisteredMeter("executor/epoch/failed", nil)
+)
+
+// StorageSlot is a storage slot of a contract exported at the end of every
+// settlement epoch.
+type StorageSlot struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+}
+
+// AccountBalance is the balance of a settlement account.
+type AccountBalance struct {
+	Address common.Address `json:"address"`
+	Balance *hexutil.Big   `json:"balance"`
+}
+
+// StorageValue is the value of an exported storage slot.
+type StorageValue struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+	Value   common.Hash    `json:"value"`
+}
+
+// EpochSummary is the state of the settlement accounts and contracts at the
+// final block of an epoch, for consortium members reconciling their off-chain
+// settlement against the chain. The accounts and slots are sorted, so every
+// replica configured alike exports the same summary.
+type EpochSummary struct {
+	Epoch    uint64           `json:"epoch"`  // number of the epoch, the first one ends with block EpochLength
+	Number   uint64           `json:"number"` // final block of the epoch
+	Hash     common.Hash      `json:"hash"`
+	Balances []AccountBalance `json:"balances"`
+	Storage  []StorageValue   `json:"storage"`
+	Digest   common.Hash      `json:"digest"` // keccak256 of the RLP encoding of the fields above
+}
+
+// epochSummary collects the summary of an epoch from the post state of its
+// final block, nil if the block doesn't end an epoch.
+func epochSummary(config *Config, block *types.Block, statedb *state.StateDB) *EpochSummary {
+	length := config.EpochLength
+	if length == 0 || block.NumberU64() == 0 || block.NumberU64()%length != 0 {
+		return nil
+	}
+	summary := &EpochSummary{
+		Epoch:    block.NumberU64() / length,
+		Number:   block.NumberU64(),
+		Hash:     block.Hash(),
+		Balances: make([]AccountBalance, 0, len(config.EpochAccounts)),
+		Storage:  make([]StorageValue, 0, len(config.EpochStorage)),
+	}
+	for _, addr := range config.EpochAccounts {
+		summary.Balances = append(summary.Balances, AccountBalance{Address: addr, Balance: (*hexutil.Big)(statedb.GetBalance(addr).ToBig())})
+	}
+	sort.Slice(summary.Balances, func(i, j int) bool {
+		return bytes.Compare(summary.Balances[i].Address[:], summary.Balances[j].Address[:]) < 0
+	})
+	for _, slot := range config.EpochStorage {
+		summary.Storage = append(summary.Storage, StorageValue{Address: slot.Address, Slot: slot.Slot, Value: statedb.GetState(slot.Address, slot.Slot)})
+	}
+	sort.Slice(summary.Storage, func(i, j int) bool {
+		a, b := summary.Storage[i], summary.Storage[j]
+		if c := bytes.Compare(a.Address[:], b.Address[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(a.Slot[:], b.Slot[:]) < 0
+	})
+	summary.Digest = summary.digest()
+	return summary
+}
+
+// digest hashes the RLP encoding of the summary, without the digest itself.
+func (s *EpochSummary) digest() common.Hash {
+	balances := make([]interface{}, len(s.Balances))
+	for i, b := range s.Balances {
+		balances[i] = []interface{}{b.Address, b.Balance.ToInt()}
+	}
+	storage := make([]interface{}, len(s.Storage))
+	for i, v := range s.Storage {
+		storage[i] = []interface{}{v.Address, v.Slot, v.Value}
+	}
+	blob, _ := rlp.EncodeToBytes([]interface{}{s.Epoch, s.Number, s.Hash, balances, storage})
+	return crypto.Keccak256Hash(blob)
+}
+
+// exportEpoch publishes the summary of the epoch ended by a written block to
+// the RPC subscribers, and appends it to the export file if configured.
+func (e *executor) exportEpoch(block *types.Block, statedb *state.StateDB) {
+	summary := epochSummary(e.config, block, statedb)
+	if summary == nil {
+		return
+	}
+	if path := e.config.EpochExport; path != "" {
+		if err := appendJSON(path, summary); err != nil {
+			epochFailedMeter.Mark(1)
+			log.Error("Failed to export epoch summary", "epoch", summary.Epoch, "number", summary.Number, "path", path, "err", err)
+		}
+	}
+	epochExportedMeter.Mark(1)
+	log.Info("Exported epoch summary", "epoch", summary.Epoch, "number", summary.Number, "digest", summary.Digest)
+	e.epochFeed.Send(summary)
+}
+
+// appendJSON appends a value to a file as a JSON line.
+func appendJSON(path string, v interface{}) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
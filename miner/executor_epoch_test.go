@@ -0,0 +1,114 @@
+package miner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestEpochExport(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	var (
+		path  = filepath.Join(t.TempDir(), "epochs.jsonl")
+		slot  = common.Hash{0x01}
+		other = common.HexToAddress("0xbeef")
+	)
+	cfg := *testConfig
+	cfg.EpochLength = 2
+	cfg.EpochAccounts = []common.Address{testBankAddress, testUserAddress}
+	cfg.EpochStorage = []StorageSlot{{Address: other, Slot: slot}}
+	cfg.EpochExport = path
+	e := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+
+	summaries := make(chan *EpochSummary, 4)
+	sub := e.epochFeed.Subscribe(summaries)
+	defer sub.Unsubscribe()
+
+	var (
+		signer = types.LatestSigner(&config)
+		value  = big.NewInt(1000)
+		start  = time.Now().UnixNano()
+	)
+	for i := 0; i < 5; i++ {
+		tx := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Value: value, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		req, _, err := decodeExecBlock(newTestExecBlock(t, tx), nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = start + int64(i)*int64(time.Second)
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block %d: %v", i+1, err)
+		}
+	}
+	// Blocks 2 and 4 end an epoch
+	chain := backend.chain
+	var published []*EpochSummary
+	for len(summaries) > 0 {
+		published = append(published, <-summaries)
+	}
+	if len(published) != 2 {
+		t.Fatalf("summaries mismatch: have %d, want 2", len(published))
+	}
+	for i, summary := range published {
+		number := uint64(2 * (i + 1))
+		if summary.Epoch != uint64(i+1) || summary.Number != number || summary.Hash != chain.GetBlockByNumber(number).Hash() {
+			t.Errorf("summary %d block mismatch: have epoch %d number %d hash %x", i, summary.Epoch, summary.Number, summary.Hash)
+		}
+		// The accounts are sorted whatever order they were configured in
+		if len(summary.Balances) != 2 || bytes.Compare(summary.Balances[0].Address[:], summary.Balances[1].Address[:]) >= 0 {
+			t.Fatalf("summary %d balances mismatch: have %+v", i, summary.Balances)
+		}
+		for _, b := range summary.Balances {
+			if b.Address != testUserAddress {
+				continue
+			}
+			if have, want := b.Balance.ToInt(), new(big.Int).Mul(value, new(big.Int).SetUint64(number)); have.Cmp(want) != 0 {
+				t.Errorf("summary %d balance mismatch: have %v, want %v", i, have, want)
+			}
+		}
+		if len(summary.Storage) != 1 || summary.Storage[0].Address != other || summary.Storage[0].Slot != slot || summary.Storage[0].Value != (common.Hash{}) {
+			t.Errorf("summary %d storage mismatch: have %+v", i, summary.Storage)
+		}
+		if summary.Digest != summary.digest() {
+			t.Errorf("summary %d digest mismatch: have %x, want %x", i, summary.Digest, summary.digest())
+		}
+	}
+	if published[0].Digest == published[1].Digest {
+		t.Errorf("distinct epochs share digest %x", published[0].Digest)
+	}
+	// The same summaries were appended to the export file
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open export: %v", err)
+	}
+	defer file.Close()
+
+	var exported int
+	for scanner := bufio.NewScanner(file); scanner.Scan(); exported++ {
+		var summary EpochSummary
+		if err := json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+			t.Fatalf("failed to decode exported summary: %v", err)
+		}
+		if exported < len(published) && summary.Digest != published[exported].Digest {
+			t.Errorf("exported summary %d digest mismatch: have %x, want %x", exported, summary.Digest, published[exported].Digest)
+		}
+	}
+	if exported != len(published) {
+		t.Errorf("exported summaries mismatch: have %d, want %d", exported, len(published))
+	}
+}
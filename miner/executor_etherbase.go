@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// EtherbasePolicy is the reaction of the executor to consensus blocks delivered
+// while no etherbase is set. Forwarding txs to consensus always requires one,
+// as the node proposes on behalf of the etherbase, but a replica executing the
+// decisions of consensus doesn't need one to follow the chain.
+type EtherbasePolicy string
+
+const (
+	// EtherbaseBurn executes the block with the zero address as coinbase, the
+	// fees and rewards of the block are burnt.
+	EtherbaseBurn EtherbasePolicy = "burn"
+
+	// EtherbaseRefuse refuses to execute the block, which then goes through
+	// the failure policy.
+	EtherbaseRefuse EtherbasePolicy = "refuse"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting unknown policies.
+func (p *EtherbasePolicy) UnmarshalText(input []byte) error {
+	switch policy := EtherbasePolicy(input); policy {
+	case "", EtherbaseBurn, EtherbaseRefuse:
+		*p = policy
+		return nil
+	default:
+		return fmt.Errorf("unknown etherbase policy %q, want %q or %q", input, EtherbaseBurn, EtherbaseRefuse)
+	}
+}
+
+// execRecipient returns the coinbase of the consensus blocks executed by the
+// executor, applying the etherbase policy if the etherbase is unset.
+func (e *executor) execRecipient() (common.Address, error) {
+	coinbase, err := e.feeRecipient()
+	if errors.Is(err, errMissingEtherbase) && e.config.UnsetEtherbase != EtherbaseRefuse {
+		log.Debug("Executing without etherbase, burning the fees")
+		return common.Address{}, nil
+	}
+	return coinbase, err
+}
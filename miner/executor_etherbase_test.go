@@ -0,0 +1,58 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestUnsetEtherbase(t *testing.T) {
+	for _, policy := range []EtherbasePolicy{"", EtherbaseBurn, EtherbaseRefuse} {
+		config := postMergeConfig()
+
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		minerConfig := *testConfig
+		minerConfig.UnsetEtherbase = policy
+		e := &executor{config: &minerConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&minerConfig)}
+		e.running.Store(true)
+
+		// Txs are never forwarded without an etherbase
+		if _, err := e.feeRecipient(); !errors.Is(err, errMissingEtherbase) {
+			t.Errorf("policy %q: forwarding error mismatch: have %v, want %v", policy, err, errMissingEtherbase)
+		}
+		head := backend.chain.CurrentBlock()
+		err := e.executeNewTxBatch(&execReq{timestamp: int64(head.Time + 1), txs: types.Transactions{pendingTxs[0]}})
+		if policy == EtherbaseRefuse {
+			if !errors.Is(err, errMissingEtherbase) {
+				t.Errorf("policy %q: error mismatch: have %v, want %v", policy, err, errMissingEtherbase)
+			}
+			if backend.chain.CurrentBlock().Number.Cmp(head.Number) != 0 {
+				t.Errorf("policy %q: block written without etherbase", policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("policy %q: failed to execute block: %v", policy, err)
+		}
+		block := backend.chain.CurrentBlock()
+		if block.Number.Uint64() != head.Number.Uint64()+1 || block.Coinbase != (common.Address{}) {
+			t.Errorf("policy %q: block mismatch: have number %d coinbase %x, want number %d zero coinbase", policy, block.Number, block.Coinbase, head.Number.Uint64()+1)
+		}
+	}
+}
+
+func TestEtherbasePolicyUnmarshal(t *testing.T) {
+	var policy EtherbasePolicy
+	if err := policy.UnmarshalText([]byte("refuse")); err != nil || policy != EtherbaseRefuse {
+		t.Errorf("policy mismatch: have %q, %v, want %q", policy, err, EtherbaseRefuse)
+	}
+	if err := policy.UnmarshalText([]byte("mint")); err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+}
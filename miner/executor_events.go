@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// eventQueue is the number of executor events buffered for the event mux,
+// further ones are dropped until its subscribers catch up.
+const eventQueue = 256
+
+var eventDroppedMeter = metrics.NewRegisteredMeter("executor/events/dropped", nil)
+
+// BatchSentEvent is posted when a batch of txs was forwarded to consensus.
+type BatchSentEvent struct {
+	Txs types.Transactions
+}
+
+// BatchExecutedEvent is posted when a consensus block was executed and its
+// block written to the chain.
+type BatchExecutedEvent struct {
+	Block    *types.Block
+	Sequence uint64 // consensus height of the block, zero if unknown
+}
+
+// BatchCommitFailedEvent is posted when a consensus block couldn't be written
+// to the chain, after the failure policy was applied.
+type BatchCommitFailedEvent struct {
+	Number   uint64 // number the block would have had
+	Sequence uint64 // consensus height of the block, zero if unknown
+	Err      error
+}
+
+// ConsensusLinkDownEvent is posted once the consensus layer has been
+// unreachable for longer than tolerated.
+type ConsensusLinkDownEvent struct {
+	Since time.Time
+	Err   error
+}
+
+// postEvent queues an executor event for the event mux of the node, if any.
+// The mux delivers to every subscriber in turn, so the events are posted from
+// their own loop and dropped while a slow subscriber holds it up, instead of
+// stalling the execution.
+func (e *executor) postEvent(ev interface{}) {
+	if e.eventCh == nil {
+		return
+	}
+	select {
+	case e.eventCh <- ev:
+	default:
+		eventDroppedMeter.Mark(1)
+		log.Warn("Event subscribers behind, dropping executor event", "type", fmt.Sprintf("%T", ev))
+	}
+}
+
+// eventLoop posts the queued executor events on the event mux in order.
+func (e *executor) eventLoop() {
+	if e.eventCh == nil {
+		return
+	}
+	for {
+		select {
+		case ev := <-e.eventCh:
+			e.mux.Post(ev)
+		case <-e.exitCh:
+			return
+		}
+	}
+}
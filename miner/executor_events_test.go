@@ -0,0 +1,91 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestExecutorEvents(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	mux := new(event.TypeMux)
+	defer mux.Stop()
+	sub := mux.Subscribe(BatchExecutedEvent{}, BatchCommitFailedEvent{}, ConsensusLinkDownEvent{})
+	defer sub.Unsubscribe()
+
+	cfg := *testConfig
+	cfg.AlertLinkTimeout = 0
+	e := &executor{
+		config:      &cfg,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		mux:         mux,
+		eventCh:     make(chan interface{}, eventQueue),
+		exitCh:      make(chan struct{}),
+		execClient:  &executorClient{consensusClient: new(testConsensusClient)},
+		alerter:     newAlerter(&cfg),
+	}
+	defer close(e.exitCh)
+	go e.eventLoop()
+
+	next := func() interface{} {
+		select {
+		case ev := <-sub.Chan():
+			return ev.Data
+		case <-time.After(time.Second):
+			t.Fatalf("no event posted")
+			return nil
+		}
+	}
+	// Executed blocks are announced with their consensus height
+	go e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}, sequence: 3})
+	if ev, ok := next().(BatchExecutedEvent); !ok || ev.Sequence != 3 || ev.Block.NumberU64() != 1 {
+		t.Fatalf("executed event mismatch: have %+v", ev)
+	}
+	// Rejected blocks are announced as failed
+	config.Executor = &params.ExecutorConfig{MaxBlockMemory: 1}
+	go e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{newTxs[0]}, sequence: 4})
+	if ev, ok := next().(BatchCommitFailedEvent); !ok || ev.Sequence != 4 || ev.Number != 2 || !errors.Is(ev.Err, errBlockMemoryLimit) {
+		t.Fatalf("failed event mismatch: have %+v", ev)
+	}
+	// Outages of the consensus layer are announced once
+	go func() {
+		e.trackLink(errors.New("unreachable"))
+		e.trackLink(errors.New("unreachable"))
+	}()
+	if _, ok := next().(ConsensusLinkDownEvent); !ok {
+		t.Fatalf("link down event not posted")
+	}
+	select {
+	case ev := <-sub.Chan():
+		t.Fatalf("unexpected event: %+v", ev.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+	// Subscribers not keeping up must not hold back the execution
+	stalled := mux.Subscribe(BatchSentEvent{})
+	defer stalled.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*eventQueue; i++ {
+			e.postEvent(BatchSentEvent{})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("event posting blocked by stalled subscriber")
+	}
+}
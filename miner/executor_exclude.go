@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// excludedForwardMeter counts the pending txs held back from consensus by the
+// forwarding exclusion list, including the later txs of their senders. Txs held
+// back over several rounds are counted once.
+var excludedForwardMeter = metrics.NewRegisteredMeter("executor/forward/excluded", nil)
+
+// excludedContractCounter returns the counter of the txs calling an excluded
+// contract held back from consensus, each counted once.
+func excludedContractCounter(contract common.Address) metrics.Counter {
+	return metrics.GetOrRegisterCounter("executor/forward/excluded/"+contract.Hex(), nil)
+}
+
+// maxHeldTxs is the number of txs held back by the exclusion list remembered,
+// so the txs held back round after round are only metered once.
+const maxHeldTxs = 4096
+
+// heldTxs are the pending txs of a sender held back from consensus, the first
+// one calling an excluded contract and the later ones depending on it.
+type heldTxs struct {
+	contract common.Address
+	txs      []*txpool.LazyTransaction
+}
+
+// excludeTxs drops the pending txs calling the contracts excluded from the
+// forwarding, along with the later txs of their senders which couldn't execute
+// without them anyway. Returns the txs dropped per sender.
+func excludeTxs(pending map[common.Address][]*txpool.LazyTransaction, contracts []common.Address) []heldTxs {
+	if len(contracts) == 0 {
+		return nil
+	}
+	excluded := make(map[common.Address]struct{}, len(contracts))
+	for _, contract := range contracts {
+		excluded[contract] = struct{}{}
+	}
+	var held []heldTxs
+	for from, txs := range pending {
+		for i, ltx := range txs {
+			// Evicted txs are skipped by the forwarding anyway
+			tx := ltx.Resolve()
+			if tx == nil || tx.To() == nil {
+				continue
+			}
+			if _, ok := excluded[*tx.To()]; !ok {
+				continue
+			}
+			log.Trace("Ignoring transaction to excluded contract", "hash", ltx.Hash, "to", tx.To(), "dropped", len(txs)-i)
+			held = append(held, heldTxs{contract: *tx.To(), txs: txs[i:]})
+
+			if i == 0 {
+				delete(pending, from)
+			} else {
+				pending[from] = txs[:i]
+			}
+			break
+		}
+	}
+	return held
+}
+
+// meterHeld meters the txs held back by the exclusion list which weren't held
+// back in an earlier round, returning their number.
+func (e *executor) meterHeld(held []heldTxs) int {
+	var fresh int
+	for _, h := range held {
+		for i, ltx := range h.txs {
+			if e.heldTxs != nil {
+				if e.heldTxs.Contains(ltx.Hash) {
+					continue
+				}
+				e.heldTxs.Add(ltx.Hash, struct{}{})
+			}
+			if i == 0 {
+				excludedContractCounter(h.contract).Inc(1)
+			}
+			fresh++
+		}
+	}
+	excludedForwardMeter.Mark(int64(fresh))
+	return fresh
+}
@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestForwardExclude(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	// The second tx calls an excluded contract, the third one depends on it
+	var (
+		signer   = types.LatestSigner(ethashChainConfig)
+		contract = common.HexToAddress("0xdead")
+		txs      = make(types.Transactions, 3)
+	)
+	for i := range txs {
+		to := testUserAddress
+		if i == 1 {
+			to = contract
+		}
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &to, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	for _, err := range backend.txPool.Add(txs, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	cfg := *testConfig
+	cfg.ForwardExclude = []common.Address{contract}
+
+	p2p := new(testP2PClient)
+	e := &executor{
+		config:      &cfg,
+		chainConfig: ethashChainConfig,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		execClient:  &executorClient{p2pClient: p2p},
+		alerter:     newAlerter(&cfg),
+		heldTxs:     lru.NewCache[common.Hash, struct{}](maxHeldTxs),
+	}
+	e.setMinTip(common.Big0)
+
+	forward := func() {
+		work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 0)
+		if err != nil {
+			t.Fatalf("failed to prepare work: %v", err)
+		}
+		if err := e.fillTransactions(nil, work); err != nil {
+			t.Fatalf("failed to forward txs: %v", err)
+		}
+	}
+
+	forward()
+	if p2p.sent != 1 {
+		t.Errorf("forwarded txs mismatch: have %d, want 1", p2p.sent)
+	}
+	// Txs held back again are only metered once
+	forward()
+	if held := e.heldTxs.Len(); held != 2 {
+		t.Errorf("held txs mismatch: have %d, want 2", held)
+	}
+	if fresh := e.meterHeld(excludeTxs(backend.txPool.Pending(true), cfg.ForwardExclude)); fresh != 0 {
+		t.Errorf("metered held txs mismatch: have %d, want 0", fresh)
+	}
+	// Lifting the exclusion while running forwards the held back txs
+	if _, err := e.updateConfig(LiveConfig{ForwardExclude: &[]common.Address{}}); err != nil {
+		t.Fatalf("failed to update config: %v", err)
+	}
+	p2p.sent = 0
+	forward()
+	if p2p.sent != len(txs) {
+		t.Errorf("forwarded txs mismatch after lifting exclusion: have %d, want %d", p2p.sent, len(txs))
+	}
+	if len(cfg.ForwardExclude) != 1 {
+		t.Errorf("startup config modified: %v", cfg.ForwardExclude)
+	}
+}
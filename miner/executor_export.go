@@ -0,0 +1,198 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	exportTimeout    = 10 * time.Second // maximum time allowance for publishing a block
+	exportMaxBackoff = time.Minute      // maximum delay between two attempts to publish a block
+)
+
+var (
+	exportPublishedMeter = metrics.NewRegisteredMeter("executor/export/published", nil)
+	exportFailedMeter    = metrics.NewRegisteredMeter("executor/export/failed", nil)
+	exportDroppedMeter   = metrics.NewRegisteredMeter("executor/export/dropped", nil)
+)
+
+var errUnknownSink = errors.New("unknown receipt sink")
+
+// ExportedBlock is a block written to the chain along with its receipts, as
+// published to the receipt sink.
+type ExportedBlock struct {
+	Header       *types.Header        `json:"header"`
+	Hash         common.Hash          `json:"hash"`
+	Transactions types.Transactions   `json:"transactions"`
+	Receipts     types.Receipts       `json:"receipts"`
+	Consensus    *types.ConsensusMeta `json:"consensus,omitempty"` // consensus decision the block was delivered by
+}
+
+// ReceiptSink publishes the blocks written by the executor to an external
+// system, like a message bus. Publish is called from a single goroutine in
+// block order; a failed block is retried until it is published. The execution
+// doesn't wait for the sink though: blocks written while ReceiptExportQueue
+// blocks are waiting to be published are dropped, leaving a gap in the numbers
+// the sink sees.
+type ReceiptSink interface {
+	Publish(block *ExportedBlock) error
+	Close() error
+}
+
+// ReceiptSinkFactory creates the receipt sink of an export URL.
+type ReceiptSinkFactory func(u *url.URL) (ReceiptSink, error)
+
+var (
+	sinksLock sync.RWMutex
+	sinks     = map[string]ReceiptSinkFactory{
+		"file":  newFileSink,
+		"http":  newHTTPSink,
+		"https": newHTTPSink,
+	}
+)
+
+// RegisterReceiptSink makes a receipt sink available for the export URLs of
+// the given scheme, allowing builds to plug in message buses like Kafka or
+// NATS without the executor depending on their clients.
+func RegisterReceiptSink(scheme string, factory ReceiptSinkFactory) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+
+	sinks[scheme] = factory
+}
+
+// newReceiptSink creates the receipt sink of an export URL.
+func newReceiptSink(rawurl string) (ReceiptSink, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	sinksLock.RLock()
+	factory := sinks[u.Scheme]
+	sinksLock.RUnlock()
+
+	if factory == nil {
+		return nil, fmt.Errorf("%w: %q", errUnknownSink, u.Scheme)
+	}
+	return factory(u)
+}
+
+// fileSink appends the blocks to a file as JSON lines.
+type fileSink struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newFileSink(u *url.URL) (ReceiptSink, error) {
+	file, err := os.OpenFile(u.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (s *fileSink) Publish(block *ExportedBlock) error { return s.enc.Encode(block) }
+func (s *fileSink) Close() error                       { return s.file.Close() }
+
+// httpSink POSTs the blocks as JSON to an endpoint.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSink(u *url.URL) (ReceiptSink, error) {
+	return &httpSink{url: u.String(), client: &http.Client{Timeout: exportTimeout}}, nil
+}
+
+func (s *httpSink) Publish(block *ExportedBlock) error {
+	blob, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	res, err := s.client.Post(s.url, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error { return nil }
+
+// export hands a block written to the chain over to the receipt sink. Blocks
+// are dropped instead of holding back the execution while the sink can't keep
+// up, the operator is alerted until the sink caught up.
+func (e *executor) export(block *types.Block, receipts types.Receipts, meta *types.ConsensusMeta) {
+	if e.exportCh == nil {
+		return
+	}
+	exported := &ExportedBlock{
+		Header:       block.Header(),
+		Hash:         block.Hash(),
+		Transactions: block.Transactions(),
+		Receipts:     receipts,
+		Consensus:    meta,
+	}
+	select {
+	case e.exportCh <- exported:
+	default:
+		exportDroppedMeter.Mark(1)
+		log.Warn("Receipt sink behind, dropping block", "number", block.Number(), "hash", block.Hash())
+		e.alerter.raise(AlertExportDropped, fmt.Sprintf("receipt sink behind, block %d dropped", block.Number()))
+	}
+}
+
+// exportLoop publishes the blocks written to the chain to the receipt sink,
+// retrying every block until it is published to keep them in order.
+func (e *executor) exportLoop(sink ReceiptSink) {
+	defer e.wg.Done()
+	if sink == nil {
+		return
+	}
+	defer sink.Close()
+
+	for {
+		select {
+		case block := <-e.exportCh:
+			backoff := time.Second
+			for {
+				err := sink.Publish(block)
+				if err == nil {
+					exportPublishedMeter.Mark(1)
+					if len(e.exportCh) == 0 {
+						e.alerter.resolve(AlertExportDropped)
+					}
+					break
+				}
+				exportFailedMeter.Mark(1)
+				log.Warn("Failed to publish block to receipt sink", "number", block.Header.Number, "hash", block.Hash, "retry", backoff, "err", err)
+				select {
+				case <-time.After(backoff):
+				case <-e.exitCh:
+					return
+				}
+				if backoff *= 2; backoff > exportMaxBackoff {
+					backoff = exportMaxBackoff
+				}
+			}
+		case <-e.exitCh:
+			return
+		}
+	}
+}
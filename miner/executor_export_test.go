@@ -0,0 +1,132 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// flakySink fails to publish the first block once.
+type flakySink struct {
+	failed    bool
+	published chan *ExportedBlock
+}
+
+func (s *flakySink) Publish(block *ExportedBlock) error {
+	if !s.failed {
+		s.failed = true
+		return errors.New("unavailable")
+	}
+	s.published <- block
+	return nil
+}
+
+func (s *flakySink) Close() error { return nil }
+
+func TestReceiptExport(t *testing.T) {
+	sink := &flakySink{published: make(chan *ExportedBlock, 1)}
+	RegisterReceiptSink("flaky", func(u *url.URL) (ReceiptSink, error) { return sink, nil })
+
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		alerter:     newAlerter(testConfig),
+		exitCh:      make(chan struct{}),
+		exportCh:    make(chan *ExportedBlock, 1),
+	}
+	s, err := newReceiptSink("flaky://bus/blocks")
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	e.wg.Add(1)
+	go e.exportLoop(s)
+	defer func() { close(e.exitCh); e.wg.Wait() }()
+
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	// Failed blocks are retried until published
+	select {
+	case block := <-sink.published:
+		head := backend.chain.CurrentBlock()
+		if block.Hash != head.Hash() || len(block.Receipts) != 1 || block.Receipts[0].TxHash != pendingTxs[0].Hash() {
+			t.Fatalf("exported block mismatch: have %x with %d receipts, want %x", block.Hash, len(block.Receipts), head.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block not published")
+	}
+	if _, err := newReceiptSink("kafka://broker/blocks"); !errors.Is(err, errUnknownSink) {
+		t.Errorf("unknown sink error mismatch: have %v, want %v", err, errUnknownSink)
+	}
+}
+
+func TestReceiptExportDrop(t *testing.T) {
+	e := &executor{
+		config:   testConfig,
+		alerter:  newAlerter(testConfig),
+		exportCh: make(chan *ExportedBlock, 1),
+	}
+	// Blocks beyond the queue are dropped and the operator alerted
+	for i := int64(1); i <= 2; i++ {
+		e.export(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i)}), nil, nil)
+	}
+	if queued := len(e.exportCh); queued != 1 {
+		t.Fatalf("queued blocks mismatch: have %d, want 1", queued)
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 1 || alerts[0].Kind != AlertExportDropped {
+		t.Errorf("alerts mismatch: have %v, want %s", alerts, AlertExportDropped)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.jsonl")
+	sink, err := newReceiptSink("file://" + path)
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	for i := int64(1); i <= 2; i++ {
+		block := &ExportedBlock{Header: &types.Header{Number: big.NewInt(i), Difficulty: new(big.Int)}, Hash: common.Hash{byte(i)}}
+		if err := sink.Publish(block); err != nil {
+			t.Fatalf("failed to publish block %d: %v", i, err)
+		}
+	}
+	sink.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open export: %v", err)
+	}
+	defer file.Close()
+
+	var lines int
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var block ExportedBlock
+		if err := json.Unmarshal(scanner.Bytes(), &block); err != nil {
+			t.Fatalf("failed to decode block %d: %v", lines, err)
+		}
+		if block.Hash != (common.Hash{byte(lines + 1)}) {
+			t.Errorf("block %d hash mismatch: have %x", lines, block.Hash)
+		}
+	}
+	if lines != 2 {
+		t.Errorf("exported block count mismatch: have %d, want %d", lines, 2)
+	}
+}
@@ -0,0 +1,65 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/miner/commitment"
+	"github.com/ethereum/go-ethereum/miner/provenance"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errOversizedExtra = errors.New("consensus extra-data too long")
+
+	droppedExtraMeter = metrics.NewRegisteredMeter("executor/extra/dropped", nil)
+)
+
+// applyExtra fills the extra-data of the block header, either with the bytes
+// supplied by consensus, the receipt commitment or the provenance record. From
+// the receipt commitment fork on, the commitment takes up all of the extra-data
+// and the bytes supplied by consensus are left out of the block, by every
+// executor alike. From the provenance fork on, they follow the record if they
+// fit in the remaining bytes and are left out otherwise.
+func (e *executor) applyExtra(work *executor_env, req *execReq) error {
+	if e.chainConfig.Executor.IsReceiptCommitment(work.header.Number) {
+		if len(req.extra) > 0 {
+			log.Debug("Dropping consensus extra-data for the receipt commitment", "number", work.header.Number, "sequence", req.sequence, "size", len(req.extra))
+			droppedExtraMeter.Mark(1)
+		}
+		work.header.Extra = commitment.Root(work.receipts).Bytes()
+		return nil
+	}
+	if e.chainConfig.Executor.IsProvenance(work.header.Number) {
+		var record provenance.Provenance
+		if meta := work.meta; meta != nil {
+			record = provenance.Provenance{Proposer: meta.Proposer, Epoch: meta.Epoch, Round: meta.Round}
+		}
+		work.header.Extra = record.Encode()
+		if len(req.extra) > int(params.MaximumExtraDataSize)-provenance.Size {
+			log.Debug("Dropping consensus extra-data not fitting the provenance record", "number", work.header.Number, "sequence", req.sequence, "size", len(req.extra))
+			droppedExtraMeter.Mark(1)
+			return nil
+		}
+		work.header.Extra = append(work.header.Extra, req.extra...)
+		return nil
+	}
+	if len(req.extra) > 0 {
+		work.header.Extra = req.extra
+	}
+	return nil
+}
+
+// emptyBlock reports whether a consensus block landing on top of the given
+// parent has neither txs to execute nor anything to record in its header, in
+// which case no chain block is made of it.
+func (e *executor) emptyBlock(req *execReq, parent *types.Header) bool {
+	if len(req.txs) > 0 || len(req.extra) > 0 {
+		return false
+	}
+	return req.meta == nil || !e.chainConfig.Executor.IsProvenance(new(big.Int).Add(parent.Number, common.Big1))
+}
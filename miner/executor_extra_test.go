@@ -0,0 +1,158 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/miner/commitment"
+	"github.com/ethereum/go-ethereum/miner/provenance"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestConsensusExtra(t *testing.T) {
+	// Oversized extra-data is rejected with the block
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.Header = &pb.HeaderExtension{ExtraData: make([]byte, params.MaximumExtraDataSize+1)}
+	if _, _, err := decodeExecBlock(block, nil); !errors.Is(err, errOversizedExtra) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errOversizedExtra)
+	}
+	extra := []byte("app hash")
+	block.Header.ExtraData = extra
+
+	for _, commit := range []bool{false, true} {
+		config := postMergeConfig()
+		if commit {
+			config.Executor = &params.ExecutorConfig{ReceiptCommitmentBlock: common.Big0}
+		}
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+		req, _, err := decodeExecBlock(block, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("commit %v: failed to execute block: %v", commit, err)
+		}
+		// The receipt commitment takes the place of the consensus extra-data
+		head := backend.chain.CurrentBlock()
+		want := extra
+		if commit {
+			want = commitment.Root(backend.chain.GetReceiptsByHash(head.Hash())).Bytes()
+		}
+		if !bytes.Equal(head.Extra, want) {
+			t.Errorf("commit %v: extra-data mismatch: have %x, want %x", commit, head.Extra, want)
+		}
+	}
+}
+
+func TestConsensusProvenance(t *testing.T) {
+	config := postMergeConfig()
+	config.Executor = &params.ExecutorConfig{ProvenanceBlock: common.Big0}
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.Proposer, block.Epoch, block.Round, block.Sequence = 3, 2, 7, 1
+	req, _, err := decodeExecBlock(block, nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	have, rest, err := provenance.Decode(head.Extra)
+	if err != nil {
+		t.Fatalf("failed to decode provenance: %v", err)
+	}
+	if want := (provenance.Provenance{Proposer: 3, Epoch: 2, Round: 7}); have != want || len(rest) != 0 {
+		t.Errorf("provenance mismatch: have %+v, %x, want %+v", have, rest, want)
+	}
+	if blocks := backend.chain.GetConsensusEpochBlocks(2); len(blocks) != 1 || blocks[0].Hash != head.Hash() {
+		t.Errorf("epoch index mismatch: have %v, want %x", blocks, head.Hash())
+	}
+	// Consensus supplied extra-data follows the record if it fits, blocks
+	// without txs land to record their provenance
+	for i, extra := range [][]byte{[]byte("app"), []byte("app hash")} {
+		block := newTestExecBlock(t)
+		block.Proposer, block.Epoch, block.Round, block.Sequence = 4, 2, uint64(8+i), uint64(2+i)
+		block.Header = &pb.HeaderExtension{ExtraData: extra}
+		req, _, err := decodeExecBlock(block, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block: %v", err)
+		}
+		head := backend.chain.CurrentBlock()
+		if head.Number.Uint64() != uint64(2+i) {
+			t.Fatalf("head number mismatch: have %d, want %d", head.Number, 2+i)
+		}
+		have, rest, err := provenance.Decode(head.Extra)
+		if err != nil {
+			t.Fatalf("failed to decode provenance: %v", err)
+		}
+		want := extra
+		if len(extra) > int(params.MaximumExtraDataSize)-provenance.Size {
+			want = nil
+		}
+		if have.Round != uint64(8+i) || !bytes.Equal(rest, want) {
+			t.Errorf("record mismatch: have %+v, %q, want round %d, %q", have, rest, 8+i, want)
+		}
+	}
+}
+
+func TestEmptyBlockExtra(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	// Blocks without txs land only to record consensus extra-data
+	block := newTestExecBlock(t)
+	block.Sequence = 1
+	req, _, err := decodeExecBlock(block, nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	if head := backend.chain.CurrentBlock(); head.Number.Uint64() != 0 {
+		t.Fatalf("head number mismatch: have %d, want %d", head.Number, 0)
+	}
+	if have := e.executedSeq.Load(); have != 1 {
+		t.Errorf("executed sequence mismatch: have %d, want %d", have, 1)
+	}
+	extra := []byte("app hash")
+	block.Header = &pb.HeaderExtension{ExtraData: extra}
+	block.Sequence = 2
+	if req, _, err = decodeExecBlock(block, nil); err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	if head := backend.chain.CurrentBlock(); head.Number.Uint64() != 1 || !bytes.Equal(head.Extra, extra) {
+		t.Errorf("head mismatch: have #%d %x, want #%d %x", head.Number, head.Extra, 1, extra)
+	}
+}
@@ -0,0 +1,188 @@
+package miner
+
+import (
+	"container/heap"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// otherSubmitter is the name the submitters missing from the quota config are
+// metered under.
+const otherSubmitter = "other"
+
+// SubmitterQuota is the share of the forwarded batches of a submitter of txs
+// relative to the others.
+type SubmitterQuota struct {
+	Name   string // label of the submitter in the metrics
+	Source string // RPC client IP, "key:" followed by the X-Api-Key header it sends, or devp2p peer id
+	Weight uint64 // share of the batches relative to the other submitters, unlisted ones share a single queue weighing 1
+}
+
+// txSet is the pending txs the forwarding selects from, see
+// transactionsByPriceAndNonce.
+type txSet interface {
+	Peek() *txpool.LazyTransaction
+	Shift()
+	Pop()
+	Remaining() []common.Hash
+}
+
+// newTxSet orders the pending txs to forward, shared among their submitters if
+// fair forwarding is enabled and by price alone otherwise.
+func (e *executor) newTxSet(env *executor_env, txs map[common.Address][]*txpool.LazyTransaction) txSet {
+	if !e.config.FairForwarding || e.eth == nil || e.eth.TxPool() == nil {
+		return newTransactionsByPriceAndNonce(env.signer, txs, env.header.BaseFee)
+	}
+	return newFairTxs(env.signer, txs, env.header.BaseFee, e.config.Submitters, e.eth.TxPool().Origin)
+}
+
+// submitterQueue is the pending txs of a single submitter, ordered by price.
+type submitterQueue struct {
+	name   string // label of the submitter in the metrics
+	weight uint64
+	txs    *transactionsByPriceAndNonce
+	finish float64 // virtual time the next tx of the submitter finishes at
+	last   float64 // virtual time the last forwarded tx of the submitter finished at
+
+	txsMeter metrics.Meter // forwarded txs of the submitter
+	gasMeter metrics.Meter // gas of the forwarded txs of the submitter
+}
+
+// schedule computes the virtual finish time of the next tx of the submitter if
+// forwarded after the given virtual time, false if there's none left.
+func (q *submitterQueue) schedule(now float64) bool {
+	next := q.txs.Peek()
+	if next == nil {
+		return false
+	}
+	start := now
+	if q.last > start {
+		start = q.last
+	}
+	q.finish = start + float64(next.Gas)/float64(q.weight)
+	return true
+}
+
+// submitterHeap orders the submitters by the virtual finish time of their next
+// tx.
+type submitterHeap []*submitterQueue
+
+func (h submitterHeap) Len() int           { return len(h) }
+func (h submitterHeap) Less(i, j int) bool { return h[i].finish < h[j].finish }
+func (h submitterHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *submitterHeap) Push(x interface{}) { *h = append(*h, x.(*submitterQueue)) }
+
+func (h *submitterHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// fairTxs shares the forwarded batches among the submitters of the pending txs
+// by weighted fair queuing on their gas, so one integrator flooding the pool
+// can't crowd out the others. The txs of every submitter are taken by price and
+// nonce, the submitter of a sender being the one of its next tx.
+type fairTxs struct {
+	queues submitterHeap
+	now    float64 // virtual finish time of the last forwarded tx
+}
+
+// newFairTxs splits the pending txs among the submitters listed in the quotas,
+// the unlisted ones sharing a single queue so spreading txs over many keys or
+// addresses doesn't gain a bigger share.
+func newFairTxs(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int, quotas []SubmitterQuota, origin func(common.Hash) (txpool.Origin, bool)) *fairTxs {
+	bySource := make(map[string]SubmitterQuota, len(quotas))
+	for _, quota := range quotas {
+		source := quota.Source
+		if key, ok := strings.CutPrefix(source, "key:"); ok {
+			source = txpool.APIKeySource(key)
+		}
+		bySource[source] = quota
+	}
+	split := make(map[string]map[common.Address][]*txpool.LazyTransaction)
+	for from, accTxs := range txs {
+		if len(accTxs) == 0 {
+			continue
+		}
+		o, _ := origin(accTxs[0].Hash)
+		source := o.Source
+		if _, ok := bySource[source]; !ok {
+			source = ""
+		}
+		if split[source] == nil {
+			split[source] = make(map[common.Address][]*txpool.LazyTransaction)
+		}
+		split[source][from] = accTxs
+	}
+	set := new(fairTxs)
+	for source, senders := range split {
+		q := &submitterQueue{name: otherSubmitter, weight: 1, txs: newTransactionsByPriceAndNonce(signer, senders, baseFee)}
+		if quota, ok := bySource[source]; ok {
+			q.name = quota.Name
+			if quota.Weight > 0 {
+				q.weight = quota.Weight
+			}
+		}
+		q.txsMeter = metrics.GetOrRegisterMeter("executor/fair/"+q.name+"/txs", nil)
+		q.gasMeter = metrics.GetOrRegisterMeter("executor/fair/"+q.name+"/gas", nil)
+		if q.schedule(0) {
+			set.queues = append(set.queues, q)
+		}
+	}
+	heap.Init(&set.queues)
+	return set
+}
+
+// Peek returns the next tx of the submitter whose turn it is.
+func (t *fairTxs) Peek() *txpool.LazyTransaction {
+	if len(t.queues) == 0 {
+		return nil
+	}
+	return t.queues[0].txs.Peek()
+}
+
+// Shift accounts the next tx to its submitter and moves on to its next one.
+func (t *fairTxs) Shift() {
+	q := t.queues[0]
+	tx := q.txs.Peek()
+	q.txsMeter.Mark(1)
+	q.gasMeter.Mark(int64(tx.Gas))
+
+	t.now, q.last = q.finish, q.finish
+	q.txs.Shift()
+	t.reschedule()
+}
+
+// Pop drops the next tx along with the later ones of its sender, without
+// accounting it to the submitter.
+func (t *fairTxs) Pop() {
+	t.queues[0].txs.Pop()
+	t.reschedule()
+}
+
+// reschedule puts the submitter of the last tx back in line for its next one.
+func (t *fairTxs) reschedule() {
+	if t.queues[0].schedule(t.now) {
+		heap.Fix(&t.queues, 0)
+	} else {
+		heap.Pop(&t.queues)
+	}
+}
+
+// Remaining returns the hashes of the txs not shifted or popped yet.
+func (t *fairTxs) Remaining() []common.Hash {
+	var hashes []common.Hash
+	for _, q := range t.queues {
+		hashes = append(hashes, q.txs.Remaining()...)
+	}
+	return hashes
+}
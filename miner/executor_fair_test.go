@@ -0,0 +1,96 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestFairForwarding(t *testing.T) {
+	var (
+		signer  = types.LatestSigner(params.TestChainConfig)
+		pending = make(map[common.Address][]*txpool.LazyTransaction)
+		origins = make(map[common.Hash]txpool.Origin)
+		senders = make(map[common.Address]string)
+	)
+	// An integrator floods the pool with better paying txs of many senders,
+	// another one submits a few cheaper txs of a single sender
+	add := func(source string, price int64, count int) {
+		key, _ := crypto.GenerateKey()
+		from := crypto.PubkeyToAddress(key.PublicKey)
+		senders[from] = source
+		for i := 0; i < count; i++ {
+			tx := types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(price)})
+			pending[from] = append(pending[from], &txpool.LazyTransaction{Hash: tx.Hash(), Tx: tx, Time: tx.Time(), GasFeeCap: tx.GasFeeCap(), GasTipCap: tx.GasTipCap(), Gas: tx.Gas()})
+			origins[tx.Hash()] = txpool.Origin{Kind: txpool.OriginRPC, Source: source}
+		}
+	}
+	for i := 0; i < 10; i++ {
+		add(txpool.APIKeySource("flood"), 100, 10)
+	}
+	add("10.0.0.1", 1, 5)
+
+	origin := func(hash common.Hash) (txpool.Origin, bool) {
+		o, ok := origins[hash]
+		return o, ok
+	}
+	// Tx sets consume the pending txs they are created from
+	pendingTxs := func() map[common.Address][]*txpool.LazyTransaction {
+		txs := make(map[common.Address][]*txpool.LazyTransaction, len(pending))
+		for from, accTxs := range pending {
+			txs[from] = accTxs
+		}
+		return txs
+	}
+	// take shifts the given number of txs, counting those of the small submitter
+	take := func(txs txSet, n int) int {
+		var small int
+		for i := 0; i < n; i++ {
+			ltx := txs.Peek()
+			if ltx == nil {
+				t.Fatalf("txs exhausted after %d", i)
+			}
+			from, _ := types.Sender(signer, ltx.Tx)
+			if senders[from] == "10.0.0.1" {
+				small++
+			}
+			txs.Shift()
+		}
+		return small
+	}
+	// By price alone the small submitter is crowded out
+	if small := take(newTransactionsByPriceAndNonce(signer, pendingTxs(), nil), 10); small != 0 {
+		t.Fatalf("small submitter txs by price mismatch: have %d, want 0", small)
+	}
+	// Unlisted submitters share a single queue ordered by price
+	if small := take(newFairTxs(signer, pendingTxs(), nil, nil, origin), 10); small != 0 {
+		t.Errorf("small unlisted submitter txs mismatch: have %d, want 0", small)
+	}
+	// Unweighted submitters share the batch evenly
+	quotas := []SubmitterQuota{{Name: "flood", Source: "key:flood"}, {Name: "small", Source: "10.0.0.1"}}
+	if small := take(newFairTxs(signer, pendingTxs(), nil, quotas, origin), 10); small != 5 {
+		t.Errorf("small submitter txs mismatch: have %d, want 5", small)
+	}
+	// Weighted submitters get their share, whatever the other ones left
+	quotas = []SubmitterQuota{{Name: "flood", Source: "key:flood", Weight: 3}}
+	txs := newFairTxs(signer, pendingTxs(), nil, quotas, origin)
+	if small := take(txs, 8); small != 2 {
+		t.Errorf("small submitter weighted txs mismatch: have %d, want 2", small)
+	}
+	if remaining := len(txs.Remaining()); remaining != 105-8 {
+		t.Errorf("remaining txs mismatch: have %d, want %d", remaining, 105-8)
+	}
+	// Dropped txs aren't accounted to their submitter
+	txs = newFairTxs(signer, pendingTxs(), nil, quotas, origin)
+	for i := 0; i < 5; i++ {
+		txs.Pop()
+	}
+	if small := take(txs, 8); small != 2 {
+		t.Errorf("small submitter txs after drops mismatch: have %d, want 2", small)
+	}
+}
@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// faultTimeout is the maximum time allowance for reporting a fault to consensus.
+const faultTimeout = 5 * time.Second
+
+var errExecutorHalted = errors.New("executor halted")
+
+// FailurePolicy is the reaction of the executor to a consensus block it failed
+// to execute or write to the chain. Consensus considers the block committed as
+// soon as CommitBlock returns, so without a policy the block is silently lost.
+type FailurePolicy string
+
+const (
+	// FailureRetry executes the block again with exponential backoff, halting
+	// once the retries are exhausted.
+	FailureRetry FailurePolicy = "retry"
+
+	// FailureHalt stops executing consensus blocks and reports a FATAL fault
+	// to consensus. The executor stays halted until restarted.
+	FailureHalt FailurePolicy = "halt"
+
+	// FailureRollback rewinds the chain to the parent of the failed block and
+	// asks consensus to deliver the block again.
+	FailureRollback FailurePolicy = "rollback"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting unknown policies.
+func (p *FailurePolicy) UnmarshalText(input []byte) error {
+	switch policy := FailurePolicy(input); policy {
+	case "", FailureRetry, FailureHalt, FailureRollback:
+		*p = policy
+		return nil
+	default:
+		return fmt.Errorf("unknown failure policy %q, want %q, %q or %q", input, FailureRetry, FailureHalt, FailureRollback)
+	}
+}
+
+// handleFailure applies the configured failure policy to a consensus block
+// which couldn't be executed on top of the given parent, returning the error
+// the block finally failed with, if any.
+func (e *executor) handleFailure(req *execReq, parent *types.Header, err error) error {
+	number := parent.Number.Uint64() + 1
+	log.Error("Failed to execute consensus block", "number", number, "sequence", req.sequence, "policy", e.config.FailurePolicy, "err", err)
+
+	switch e.config.FailurePolicy {
+	case FailureRetry:
+		backoff := e.config.FailureBackoff
+		for i := 0; i < e.config.FailureRetries; i++ {
+			select {
+			case <-time.After(backoff):
+			case <-e.exitCh:
+				return err
+			}
+			if err = e.executeAndWrite(req); err == nil {
+				log.Info("Executed consensus block on retry", "number", number, "sequence", req.sequence, "attempts", i+2)
+				return nil
+			}
+			log.Warn("Failed to retry consensus block", "number", number, "sequence", req.sequence, "attempt", i+2, "err", err)
+			backoff *= 2
+		}
+		e.halt(req, number, err)
+
+	case FailureHalt:
+		e.halt(req, number, err)
+
+	case FailureRollback:
+		chain := e.eth.BlockChain()
+		if head := chain.CurrentBlock(); head.Hash() != parent.Hash() {
+			if rerr := chain.SetHead(parent.Number.Uint64()); rerr != nil {
+				log.Error("Failed to roll back failed consensus block", "number", number, "err", rerr)
+				e.halt(req, number, err)
+				return err
+			}
+			log.Warn("Rolled back failed consensus block", "number", number, "head", head.Number)
+		}
+		e.reportFault(pb.FaultType_REDELIVER, req, number, err)
+	}
+	return err
+}
+
+// halt stops the execution of consensus blocks, reporting it to the operator
+// and to consensus.
+func (e *executor) halt(req *execReq, number uint64, err error) {
+	e.halted.Store(true)
+	e.updateHealth()
+	e.alerter.raise(AlertHalted, fmt.Sprintf("execution halted at block %d (sequence %d): %v", number, req.sequence, err))
+	e.reportFault(pb.FaultType_FATAL, req, number, err)
+}
+
+// reportFault notifies consensus about a failed block.
+func (e *executor) reportFault(kind pb.FaultType, req *execReq, number uint64, err error) {
+	if e.execClient == nil || e.execClient.consensusClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), faultTimeout)
+	defer cancel()
+
+	_, rerr := e.execClient.consensusClient.ReportFault(ctx, &pb.Fault{
+		Type:        kind,
+		Sequence:    req.sequence,
+		BlockNumber: number,
+		Reason:      err.Error(),
+	})
+	e.trackLink(rerr)
+	if rerr != nil {
+		log.Warn("Failed to report fault to consensus", "type", kind, "number", number, "err", rerr)
+	}
+}
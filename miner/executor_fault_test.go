@@ -0,0 +1,107 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+)
+
+// testConsensusClient records the faults and heads reported by the executor.
+type testConsensusClient struct {
+	faults     []*pb.Fault
+	heads      []*pb.Head
+	interrupts []*pb.Interrupt
+	results    []*pb.ExecutionResult
+}
+
+func (c *testConsensusClient) ReportFault(ctx context.Context, in *pb.Fault, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.faults = append(c.faults, in)
+	return &pb.Empty{}, nil
+}
+
+func (c *testConsensusClient) NotifyHead(ctx context.Context, in *pb.Head, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.heads = append(c.heads, in)
+	return &pb.Empty{}, nil
+}
+
+func (c *testConsensusClient) ReportInterrupt(ctx context.Context, in *pb.Interrupt, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.interrupts = append(c.interrupts, in)
+	return &pb.Empty{}, nil
+}
+
+func (c *testConsensusClient) ReportBlockResult(ctx context.Context, in *pb.ExecutionResult, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.results = append(c.results, in)
+	return &pb.Empty{}, nil
+}
+
+func TestFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy  FailurePolicy
+		fault   pb.FaultType
+		faults  int
+		halted  bool
+		elapsed time.Duration
+	}{
+		{policy: "", faults: 0},
+		{policy: FailureHalt, fault: pb.FaultType_FATAL, faults: 1, halted: true},
+		{policy: FailureRetry, fault: pb.FaultType_FATAL, faults: 1, halted: true, elapsed: 30 * time.Millisecond},
+		{policy: FailureRollback, fault: pb.FaultType_REDELIVER, faults: 1},
+	}
+	for _, tt := range tests {
+		backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		config := &Config{FailurePolicy: tt.policy, FailureRetries: 2, FailureBackoff: 10 * time.Millisecond, UnsetEtherbase: EtherbaseRefuse}
+		client := new(testConsensusClient)
+		e := &executor{
+			config:      config,
+			chainConfig: ethashChainConfig,
+			engine:      backend.chain.Engine(),
+			eth:         backend,
+			exitCh:      make(chan struct{}),
+			execClient:  &executorClient{consensusClient: client},
+			alerter:     newAlerter(config),
+		}
+		// Executing without an etherbase fails every time
+		e.running.Store(true)
+
+		head := backend.chain.CurrentBlock()
+		start := time.Now()
+		err := e.executeNewTxBatch(&execReq{timestamp: int64(head.Time + 1), txs: types.Transactions{pendingTxs[0]}, sequence: 7})
+		if !errors.Is(err, errMissingEtherbase) {
+			t.Fatalf("policy %q: error mismatch: have %v, want %v", tt.policy, err, errMissingEtherbase)
+		}
+		if elapsed := time.Since(start); elapsed < tt.elapsed {
+			t.Errorf("policy %q: backoff too short: have %v, want %v", tt.policy, elapsed, tt.elapsed)
+		}
+		if len(client.faults) != tt.faults {
+			t.Fatalf("policy %q: fault count mismatch: have %d, want %d", tt.policy, len(client.faults), tt.faults)
+		}
+		if tt.faults > 0 {
+			fault := client.faults[0]
+			if fault.Type != tt.fault || fault.Sequence != 7 || fault.BlockNumber != head.Number.Uint64()+1 {
+				t.Errorf("policy %q: fault mismatch: have %v, want type %v sequence 7", tt.policy, fault, tt.fault)
+			}
+		}
+		if e.halted.Load() != tt.halted {
+			t.Fatalf("policy %q: halted mismatch: have %v, want %v", tt.policy, e.halted.Load(), tt.halted)
+		}
+		if backend.chain.CurrentBlock().Hash() != head.Hash() {
+			t.Errorf("policy %q: head moved", tt.policy)
+		}
+		// Halted executors reject further blocks
+		if tt.halted {
+			server := &executorServer{executorPtr: e}
+			if _, err := server.CommitBlock(context.Background(), &pb.ExecBlock{}); !errors.Is(err, errExecutorHalted) {
+				t.Errorf("policy %q: commit error mismatch: have %v, want %v", tt.policy, err, errExecutorHalted)
+			}
+		}
+	}
+}
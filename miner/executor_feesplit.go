@@ -0,0 +1,67 @@
+package miner
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var errMissingEtherbase = errors.New("etherbase not set")
+
+// feeSplitter returns the fee splitter contract of the chain, nil if the fees
+// go to the etherbase.
+func (e *executor) feeSplitter() *params.FeeSplitter {
+	if e.chainConfig.Executor == nil {
+		return nil
+	}
+	return e.chainConfig.Executor.FeeSplitter
+}
+
+// feeRecipient returns the coinbase of the blocks built by the executor: the
+// fee splitter of the chain if it has one, the etherbase otherwise.
+func (e *executor) feeRecipient() (common.Address, error) {
+	if splitter := e.feeSplitter(); splitter != nil {
+		return splitter.Address, nil
+	}
+	if !e.isRunning() {
+		return common.Address{}, nil
+	}
+	coinbase := e.etherbase()
+	if coinbase == (common.Address{}) {
+		return common.Address{}, errMissingEtherbase
+	}
+	return coinbase, nil
+}
+
+// applyFeeSplit calls the fee splitter of the chain from the system address
+// once the block was finalized by the engine, so it can distribute the fees and
+// the block reward it collected. Gas spent on the call is not accounted in the
+// block.
+func (e *executor) applyFeeSplit(env *executor_env) {
+	splitter := e.feeSplitter()
+	if splitter == nil {
+		return
+	}
+	var (
+		msg = &core.Message{
+			From:      params.SystemAddress,
+			GasLimit:  splitter.Gas,
+			GasPrice:  common.Big0,
+			GasFeeCap: common.Big0,
+			GasTipCap: common.Big0,
+			To:        &splitter.Address,
+			Data:      splitter.Input,
+		}
+		blockCtx = core.NewEVMBlockContext(env.header, env.chain, nil)
+		vmenv    = vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), env.state, e.chainConfig, *e.eth.BlockChain().GetVMConfig())
+	)
+	env.state.AddAddressToAccessList(splitter.Address)
+	if _, _, err := vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, splitter.Gas, common.U2560); err != nil {
+		log.Warn("Fee split failed", "address", splitter.Address, "err", err)
+	}
+	env.state.Finalise(e.chainConfig.DeleteEmptyAccounts(env.header.Number))
+}
@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestFeeSplit(t *testing.T) {
+	var (
+		splitter = common.HexToAddress("0xfee5")
+		operator = common.HexToAddress("0x0bee")
+		config   = *params.TestChainConfig
+	)
+	config.Executor = &params.ExecutorConfig{
+		FeeSplitter: &params.FeeSplitter{Address: splitter, Gas: 100_000},
+	}
+	// Forward the whole balance to the operator:
+	// PUSH1 0 PUSH1 0 PUSH1 0 PUSH1 0 SELFBALANCE PUSH20 <operator> GAS CALL
+	code := append(common.FromHex("0x60006000600060004773"), operator.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL))
+
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			splitter:        {Code: code, Balance: common.Big0},
+		},
+	}
+	engine := ethash.NewFaker()
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Run the executor with an etherbase, which must be ignored
+	e := &executor{config: testConfig, chainConfig: &config, engine: engine, eth: &testWorkerBackend{chain: chain}, coinbase: testUserAddress}
+	e.running.Store(true)
+
+	work, block, err := e.executeBatch(&execReq{timestamp: int64(chain.CurrentBlock().Time + 1), txs: types.Transactions{pendingTxs[0]}})
+	if err != nil {
+		t.Fatalf("failed to execute batch: %v", err)
+	}
+	if block.Coinbase() != splitter {
+		t.Fatalf("coinbase mismatch: have %x, want %x", block.Coinbase(), splitter)
+	}
+	if len(work.receipts) != 1 {
+		t.Fatalf("tx not executed")
+	}
+	// The split runs after the finalization, distributing the block reward
+	// along with the fees of the same block
+	tip, _ := pendingTxs[0].EffectiveGasTip(work.header.BaseFee)
+	fees := new(big.Int).Mul(tip, new(big.Int).SetUint64(work.receipts[0].GasUsed))
+	fees.Add(fees, ethash.ConstantinopleBlockReward.ToBig())
+	if have := work.state.GetBalance(operator).ToBig(); have.Cmp(fees) != 0 {
+		t.Fatalf("operator balance mismatch: have %v, want %v", have, fees)
+	}
+	if have := work.state.GetBalance(splitter); !have.IsZero() {
+		t.Fatalf("splitter balance mismatch: have %v, want %v", have, 0)
+	}
+	if root := work.state.IntermediateRoot(true); block.Root() != root {
+		t.Fatalf("state root mismatch: have %x, want %x", block.Root(), root)
+	}
+	// The etherbase only received the transferred value
+	if have := work.state.GetBalance(testUserAddress).ToBig(); have.Cmp(pendingTxs[0].Value()) != 0 {
+		t.Fatalf("etherbase balance mismatch: have %v, want %v", have, pendingTxs[0].Value())
+	}
+}
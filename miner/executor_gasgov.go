@@ -0,0 +1,146 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	governedCeilGauge = metrics.NewRegisteredGauge("executor/gasgov/ceiling", nil)
+	governedFailMeter = metrics.NewRegisteredMeter("executor/gasgov/failed", nil)
+
+	errGovernedCeil  = errors.New("invalid governed gas ceiling")
+	errGovernedState = errors.New("governed gas ceiling boundary state unavailable")
+)
+
+// governedCeil is the gas ceiling read from the governance contract at an epoch
+// boundary.
+type governedCeil struct {
+	boundary common.Hash // block the ceiling was read at
+	ceil     uint64      // zero if the contract provided none
+}
+
+// applyGasGovernor moves the gas limit of the block of the environment towards
+// the ceiling held by the gas governance contract, if the chain has one. The
+// ceiling is read on the post state of the epoch boundary block, the given one
+// if that's the parent, so every replica computes the same gas limit. Executed
+// blocks wait for the post state of the boundary to be committed and fail if it
+// can't be opened, forwarding rounds only estimating what fits in a block use
+// the miner gas ceiling meanwhile.
+func (e *executor) applyGasGovernor(parent *types.Header, env *executor_env, post *state.StateDB, wait bool) error {
+	gov := e.chainConfig.Executor.GasGovernance()
+	if gov == nil {
+		return nil
+	}
+	boundary := parent
+	if number := gov.Boundary(parent.Number.Uint64()); number != parent.Number.Uint64() {
+		if boundary = e.eth.BlockChain().GetHeaderByNumber(number); boundary == nil {
+			return fmt.Errorf("%w: boundary #%d missing", errGovernedState, number)
+		}
+		post = nil
+	}
+	return e.applyGovernedCeil(gov, parent, boundary, env, post, wait)
+}
+
+// applyGovernedCeil moves the gas limit of the block of the environment towards
+// the ceiling read at the given boundary block, on its post state if known.
+func (e *executor) applyGovernedCeil(gov *params.GasGovernor, parent, boundary *types.Header, env *executor_env, post *state.StateDB, wait bool) error {
+	ceil, err := e.governedCeil(gov, boundary, env.header, post, wait)
+	if err != nil {
+		if wait {
+			governedFailMeter.Mark(1)
+			return err
+		}
+		ceil = 0
+	}
+	if ceil == 0 {
+		ceil = e.config.GasCeil
+	}
+	env.header.GasLimit = e.gasLimit(parent, ceil)
+	return nil
+}
+
+// governedCeil returns the gas ceiling read at the given boundary block, zero if
+// the governance contract provided none. The post state of the boundary is
+// opened unless given, waiting for it to be committed if asked to.
+func (e *executor) governedCeil(gov *params.GasGovernor, boundary, header *types.Header, post *state.StateDB, wait bool) (uint64, error) {
+	chain := e.eth.BlockChain()
+	hash := boundary.Hash()
+	cached := e.gasGov.Load()
+	if cached != nil && cached.boundary == hash {
+		return cached.ceil, nil
+	}
+	if post == nil {
+		// Deferred post states land in the background, don't mistake one not
+		// committed yet for an empty one
+		root := chain.PostStateRoot(boundary)
+		if root == (common.Hash{}) && wait {
+			if err := e.settleCommits(boundary.Number.Uint64()); err != nil {
+				return 0, err
+			}
+			root = chain.PostStateRoot(boundary)
+		}
+		if root == (common.Hash{}) {
+			return 0, fmt.Errorf("%w: post state of #%d not committed", errGovernedState, boundary.Number)
+		}
+		var err error
+		if post, err = chain.StateAt(root); err != nil {
+			return 0, fmt.Errorf("%w: %v", errGovernedState, err)
+		}
+	} else {
+		post = post.Copy()
+	}
+	ceil, err := e.callGasGovernor(gov, header, post)
+	if err != nil {
+		log.Warn("Failed to read governed gas ceiling", "boundary", boundary.Number, "hash", hash, "err", err)
+		governedFailMeter.Mark(1)
+	}
+	if cached == nil || cached.ceil != ceil {
+		log.Info("Governed gas ceiling updated", "boundary", boundary.Number, "hash", hash, "ceiling", ceil)
+	}
+	e.gasGov.Store(&governedCeil{boundary: hash, ceil: ceil})
+	governedCeilGauge.Update(int64(ceil))
+	return ceil, nil
+}
+
+// gasCeil returns the gas ceiling consensus blocks are coalesced up to, the one
+// last read from the governance contract if the chain has one.
+func (e *executor) gasCeil() uint64 {
+	if e.chainConfig.Executor.GasGovernance() != nil {
+		if cached := e.gasGov.Load(); cached != nil && cached.ceil != 0 {
+			return cached.ceil
+		}
+	}
+	return e.config.GasCeil
+}
+
+// callGasGovernor calls the gas governance contract for the ceiling on top of
+// the given state.
+func (e *executor) callGasGovernor(gov *params.GasGovernor, header *types.Header, statedb *state.StateDB) (uint64, error) {
+	var (
+		blockCtx = core.NewEVMBlockContext(header, e.eth.BlockChain(), nil)
+		vmenv    = vm.NewEVM(blockCtx, vm.TxContext{}, statedb, e.chainConfig, *e.eth.BlockChain().GetVMConfig())
+	)
+	ret, _, err := vmenv.StaticCall(vm.AccountRef(params.SystemAddress), gov.Address, gov.Input, gov.Gas)
+	if err != nil {
+		return 0, err
+	}
+	if len(ret) != 32 {
+		return 0, fmt.Errorf("%w: have %d bytes, want 32", errGovernedCeil, len(ret))
+	}
+	ceil := new(uint256.Int).SetBytes(ret)
+	if !ceil.IsUint64() {
+		return 0, fmt.Errorf("%w: %v exceeds uint64", errGovernedCeil, ceil)
+	}
+	return ceil.Uint64(), nil
+}
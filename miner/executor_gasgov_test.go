@@ -0,0 +1,98 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestGasGovernor(t *testing.T) {
+	var (
+		governor = common.HexToAddress("0x9a5")
+		config   = postMergeConfig()
+		raised   = 2 * params.GenesisGasLimit
+		lowered  = params.GenesisGasLimit / 2
+	)
+	config.Executor = &params.ExecutorConfig{
+		GasGovernor: &params.GasGovernor{Address: governor, Gas: 50_000, Epoch: 2},
+	}
+	// Store the calldata as the ceiling, return the ceiling without calldata:
+	// CALLDATASIZE ISZERO PUSH1 12 JUMPI PUSH1 0 CALLDATALOAD PUSH1 0 SSTORE STOP
+	// JUMPDEST PUSH1 0 SLOAD PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	code := common.FromHex("0x3615600c5760003560005500" + "5b60005460005260206000f3")
+
+	gspec := &core.Genesis{
+		Config:   &config,
+		GasLimit: params.GenesisGasLimit,
+		Alloc: core.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			governor:        {Code: code, Storage: map[common.Hash]common.Hash{{}: common.BigToHash(new(big.Int).SetUint64(raised))}},
+		},
+	}
+	signer := types.LatestSigner(&config)
+	txs := []*types.Transaction{
+		// Governance lowers the ceiling in the first block of the epoch
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &governor, Gas: 50_000, GasPrice: big.NewInt(params.InitialBaseFee), Data: common.BigToHash(new(big.Int).SetUint64(lowered)).Bytes()}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)}),
+		types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 2, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)}),
+	}
+	// The ceiling read at the boundary holds for the whole epoch, the lowered one
+	// only applies from the next epoch on. Blocks executed on top of a block
+	// being written or of a branch follow the same ceiling.
+	for _, mode := range []string{"sync", "pipelined", "branch"} {
+		engine := ethash.NewFaker()
+		chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		defer chain.Stop()
+
+		cfg := *testConfig
+		cfg.PipelineWrites = mode == "pipelined"
+		e := &executor{config: &cfg, chainConfig: &config, engine: engine, eth: &testWorkerBackend{chain: chain}, alerter: newAlerter(&cfg)}
+
+		var headers []*types.Header
+		for i := range txs {
+			req := &execReq{txs: types.Transactions{txs[i]}, timestamp: time.Now().UnixNano()}
+			if mode == "branch" {
+				req.branch = "fork"
+				if err := e.executeBranch(req); err != nil {
+					t.Fatalf("%s: failed to execute block %d: %v", mode, i+1, err)
+				}
+				headers = append(headers, e.branches["fork"].blocks[i].block.Header())
+				continue
+			}
+			if err := e.executeNewTxBatch(req); err != nil {
+				t.Fatalf("%s: failed to execute block %d: %v", mode, i+1, err)
+			}
+			headers = append(headers, e.pipelineHead())
+		}
+		if err := e.settleWrite(); err != nil {
+			t.Fatalf("%s: failed to write blocks: %v", mode, err)
+		}
+		parent := chain.Genesis().Header()
+		for i, ceil := range []uint64{raised, raised, lowered} {
+			if headers[i].Number.Uint64() != uint64(i+1) {
+				t.Fatalf("%s: block %d not executed", mode, i+1)
+			}
+			if want := e.gasLimit(parent, ceil); headers[i].GasLimit != want {
+				t.Errorf("%s: block %d gas limit mismatch: have %d, want %d", mode, i+1, headers[i].GasLimit, want)
+			}
+			parent = headers[i]
+		}
+		// Nodes fall back to their own ceiling without a ceiling on-chain
+		statedb, _ := chain.State()
+		if _, err := e.callGasGovernor(&params.GasGovernor{Address: testUserAddress, Gas: 50_000}, chain.CurrentBlock(), statedb); !errors.Is(err, errGovernedCeil) {
+			t.Errorf("%s: error mismatch: have %v, want %v", mode, err, errGovernedCeil)
+		}
+	}
+}
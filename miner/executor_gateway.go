@@ -0,0 +1,204 @@
+package miner
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	gatewayMaxBody = 16 * 1024 * 1024 // maximum size of a request body, consensus blocks included
+	gatewayTimeout = 30 * time.Second // maximum time allowance for a gateway call
+)
+
+// gatewayRoute is a unary method of the executor gRPC API served by the
+// gateway.
+type gatewayRoute struct {
+	method  string               // full gRPC method name
+	in, out func() proto.Message // constructors of the request and response messages
+}
+
+// gatewayRoutes maps the gateway paths to the gRPC methods they call.
+var gatewayRoutes = map[string]gatewayRoute{
+	"/v1/executor/commitBlock": {pb.Executor_CommitBlock_FullMethodName,
+		func() proto.Message { return new(pb.ExecBlock) }, func() proto.Message { return new(pb.Empty) }},
+	"/v1/executor/verifyTx": {pb.Executor_VerifyTx_FullMethodName,
+		func() proto.Message { return new(pb.Transaction) }, func() proto.Message { return new(pb.Result) }},
+	"/v1/executor/handshake": {pb.Executor_Handshake_FullMethodName,
+		func() proto.Message { return new(pb.HandshakeRequest) }, func() proto.Message { return new(pb.HandshakeResponse) }},
+	"/v1/executor/prepareBlock": {pb.Executor_PrepareBlock_FullMethodName,
+		func() proto.Message { return new(pb.ExecBlock) }, func() proto.Message { return new(pb.TentativeBlock) }},
+	"/v1/executor/confirmCommit": {pb.Executor_ConfirmCommit_FullMethodName,
+		func() proto.Message { return new(pb.ConfirmRequest) }, func() proto.Message { return new(pb.Empty) }},
+	"/v1/executor/heartbeat": {pb.Executor_Heartbeat_FullMethodName,
+		func() proto.Message { return new(pb.HeartbeatRequest) }, func() proto.Message { return new(pb.HeartbeatResponse) }},
+	"/v1/executor/verifyBlock": {pb.Executor_VerifyBlock_FullMethodName,
+		func() proto.Message { return new(pb.ExecBlock) }, func() proto.Message { return new(pb.Verification) }},
+	"/v1/query/balance": {pb.Query_GetBalance_FullMethodName,
+		func() proto.Message { return new(pb.AccountRequest) }, func() proto.Message { return new(pb.BalanceResponse) }},
+	"/v1/query/nonce": {pb.Query_GetNonce_FullMethodName,
+		func() proto.Message { return new(pb.AccountRequest) }, func() proto.Message { return new(pb.NonceResponse) }},
+	"/v1/query/call": {pb.Query_Call_FullMethodName,
+		func() proto.Message { return new(pb.CallRequest) }, func() proto.Message { return new(pb.CallResponse) }},
+	"/v1/query/receipt": {pb.Query_GetReceipt_FullMethodName,
+		func() proto.Message { return new(pb.ReceiptRequest) }, func() proto.Message { return new(pb.ReceiptResponse) }},
+}
+
+// gateway serves the executor gRPC API as JSON over HTTP for debugging tools,
+// scripts and dashboards. Calls are made through a connection to the gRPC
+// server rather than to the executor directly, so they go through the same
+// interceptors and credential checks as the ones of consensus. The request
+// headers are forwarded as gRPC metadata. The gateway is served over TLS along
+// with the server, authenticating its clients the same way.
+//
+// The messages are encoded with the protobuf JSON mapping, bytes fields as
+// base64. Streaming methods aren't served.
+type gateway struct {
+	conn  grpc.ClientConnInterface
+	stats func() Stats // executor internals served on /v1/executor/status
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/executor/status" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g.stats())
+		return
+	}
+	route, ok := gatewayRoutes[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, gatewayMaxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in, out := route.in(), route.out()
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), gatewayTimeout)
+	defer cancel()
+
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		md.Append(strings.ToLower(key), values...)
+	}
+	if err := g.conn.Invoke(metadata.NewOutgoingContext(ctx, md), route.method, in, out); err != nil {
+		s := status.Convert(err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(httpStatus(s.Code()))
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": s.Code().String(), "message": s.Message()})
+		return
+	}
+	blob, err := protojson.Marshal(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(blob)
+}
+
+// httpStatus maps the status code of a failed gRPC call to the HTTP one.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled, codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// listenGateway listens on the gateway address. The gateway calls the gRPC
+// server with the certificate of the node, so it's secured like the server:
+// over TLS if configured, requiring client certificates of the CA if set.
+func listenGateway(config *Config) (net.Listener, error) {
+	cfg, err := tlsConfig(config, true)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", config.GatewayAddr)
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		listener = tls.NewListener(listener, cfg)
+	}
+	return listener, nil
+}
+
+// startGateway serves the gateway on the configured address, in front of the
+// gRPC server listening on target.
+func (e *executor) startGateway(target string) error {
+	creds, err := clientCredentials(e.config)
+	if err != nil {
+		return err
+	}
+	listener, err := listenGateway(e.config)
+	if err != nil {
+		return err
+	}
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	e.gatewayConn = conn
+	e.gateway = &http.Server{Handler: &gateway{conn: conn, stats: e.stats}, ReadHeaderTimeout: 10 * time.Second}
+
+	go e.gateway.Serve(listener)
+	log.Info("Executor gateway started", "addr", listener.Addr())
+	return nil
+}
+
+// stopGateway stops the gateway if it's running.
+func (e *executor) stopGateway() {
+	if e.gateway == nil {
+		return
+	}
+	e.gateway.Close()
+	e.gatewayConn.Close()
+}
@@ -0,0 +1,151 @@
+package miner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestGateway(t *testing.T) {
+	e := &executor{config: testConfig, chainConfig: params.TestChainConfig}
+
+	// Serve the executor API behind a token check standing in for the auth
+	auth := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("authorization")) == 0 || md.Get("authorization")[0] != "Bearer secret" {
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		}
+		return handler(ctx, req)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(auth))
+	pb.RegisterExecutorServer(server, &executorServer{executorPtr: e})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	gw := httptest.NewServer(&gateway{conn: conn, stats: func() Stats { return Stats{Running: true} }})
+	defer gw.Close()
+
+	post := func(path, token, body string) (int, []byte) {
+		req, _ := http.NewRequest(http.MethodPost, gw.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to call %s: %v", path, err)
+		}
+		defer res.Body.Close()
+
+		blob, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("failed to read %s response: %v", path, err)
+		}
+		return res.StatusCode, blob
+	}
+	// Calls go through the auth of the gRPC server
+	if code, _ := post("/v1/executor/verifyTx", "", `{"type":"NORMAL","payload":"AQID"}`); code != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status mismatch: have %d, want %d", code, http.StatusUnauthorized)
+	}
+	code, blob := post("/v1/executor/verifyTx", "secret", `{"type":"NORMAL","payload":"AQID"}`)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d", code, http.StatusOK)
+	}
+	var result pb.Result
+	if err := protojson.Unmarshal(blob, &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	if result.Success || result.Code != pb.VerifyCode_INVALID {
+		t.Errorf("result mismatch: have %v, want %v", &result, pb.VerifyCode_INVALID)
+	}
+	// Malformed requests and unknown routes are rejected by the gateway
+	if code, _ := post("/v1/executor/verifyTx", "secret", `{"payload":`); code != http.StatusBadRequest {
+		t.Errorf("malformed request status mismatch: have %d, want %d", code, http.StatusBadRequest)
+	}
+	if code, _ := post("/v1/executor/stakingEvents", "secret", `{}`); code != http.StatusNotFound {
+		t.Errorf("unknown route status mismatch: have %d, want %d", code, http.StatusNotFound)
+	}
+	// The status is served from the executor internals
+	res, err := http.Get(gw.URL + "/v1/executor/status")
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	defer res.Body.Close()
+	var stats Stats
+	if err := json.NewDecoder(res.Body).Decode(&stats); err != nil || !stats.Running {
+		t.Errorf("status mismatch: have %+v, %v", stats, err)
+	}
+}
+
+func TestGatewayMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "executor", ca, caKey)
+	writeCert(t, dir, "consensus", ca, caKey)
+
+	config := &Config{
+		ExecutorTLSCert: filepath.Join(dir, "executor.crt"),
+		ExecutorTLSKey:  filepath.Join(dir, "executor.key"),
+		ExecutorTLSCA:   filepath.Join(dir, "ca.crt"),
+		GatewayAddr:     "127.0.0.1:0",
+	}
+	listener, err := listenGateway(config)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	gw := &http.Server{Handler: &gateway{stats: func() Stats { return Stats{Running: true} }}}
+	go gw.Serve(listener)
+	defer gw.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs}}}
+		res, err := client.Get("https://" + listener.Addr().String() + "/v1/executor/status")
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return nil
+	}
+	// Only clients holding a certificate of the CA get to the executor through
+	// the gateway
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "consensus.crt"), filepath.Join(dir, "consensus.key"))
+	if err != nil {
+		t.Fatalf("failed to load client certificate: %v", err)
+	}
+	if err := get(cert); err != nil {
+		t.Errorf("authenticated client rejected: %v", err)
+	}
+	if err := get(); err == nil {
+		t.Errorf("client without certificate accepted")
+	}
+	res, err := http.Get("http://" + listener.Addr().String() + "/v1/executor/status")
+	if err == nil && res.StatusCode == http.StatusOK {
+		t.Errorf("plaintext client accepted")
+	}
+}
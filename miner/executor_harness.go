@@ -0,0 +1,82 @@
+package miner
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+)
+
+// Clock is the source of the timestamps of the blocks the executor executes and
+// proposes. The system clock is used unless a test harness supplies its own.
+type Clock interface {
+	Now() time.Time
+}
+
+// now returns the current time of the executor clock.
+func (e *executor) now() time.Time {
+	if e.clock != nil {
+		return e.clock.Now()
+	}
+	return time.Now()
+}
+
+// Hooks are the seams a Harness drives the executor through in place of the
+// consensus layer and the system clock.
+type Hooks struct {
+	Clock     Clock              // timestamps of the executed and proposed blocks, the system clock if nil
+	P2P       pb.P2PClient       // receives the forwarded txs, dropped if nil
+	Consensus pb.ConsensusClient // receives the faults, heads and interrupts reported, dropped if nil
+}
+
+// discardP2P drops the forwarded txs of a harness without a P2P hook.
+type discardP2P struct{}
+
+func (discardP2P) Send(ctx context.Context, in *pb.Packet, opts ...grpc.CallOption) (*pb.Empty, error) {
+	return &pb.Empty{}, nil
+}
+
+// Harness runs an executor in-process, without the gRPC server, the background
+// loops nor a connection to consensus, so code built on top of the executor can
+// be unit tested against custom chain configs and fork schedules with just a
+// chain and optionally a tx pool as backend.
+type Harness struct {
+	e *executor
+}
+
+// NewHarness creates an executor on top of the given backend, driven through the
+// given hooks. The backend only needs a tx pool for forwarding txs.
+func NewHarness(eth Backend, config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, hooks Hooks) *Harness {
+	e := newBaseExecutor(config, chainConfig, engine, eth)
+	e.clock = hooks.Clock
+
+	p2p := hooks.P2P
+	if p2p == nil {
+		p2p = discardP2P{}
+	}
+	e.execClient = &executorClient{p2pClient: p2p, consensusClient: hooks.Consensus}
+	return &Harness{e: e}
+}
+
+// Execute executes a consensus block on top of the current head, exactly like a
+// block delivered via CommitBlock, timestamped by the clock hook. It returns
+// once the block was written, along with its receipts.
+func (h *Harness) Execute(block *pb.ExecBlock) (*types.Block, types.Receipts, error) {
+	return h.e.executeSync(block, h.e.now().UnixNano())
+}
+
+// VerifyTx verifies a tx like consensus asking the executor via gRPC.
+func (h *Harness) VerifyTx(tx *pb.Transaction) (*pb.Result, error) {
+	return (&executorServer{executorPtr: h.e}).VerifyTx(context.Background(), tx)
+}
+
+// Forward runs a single round of forwarding the pending txs of the pool to the
+// P2P hook, on top of the current head.
+func (h *Harness) Forward() {
+	h.e.sendNewTxBatch(new(atomic.Int32), h.e.now().Unix())
+}
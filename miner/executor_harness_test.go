@@ -0,0 +1,125 @@
+package miner_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	harnessKey, _ = crypto.GenerateKey()
+	harnessAddr   = crypto.PubkeyToAddress(harnessKey.PublicKey)
+)
+
+// harnessBackend is a chain and tx pool, all the executor needs.
+type harnessBackend struct {
+	chain *core.BlockChain
+	pool  *txpool.TxPool
+}
+
+func (b *harnessBackend) BlockChain() *core.BlockChain { return b.chain }
+func (b *harnessBackend) TxPool() *txpool.TxPool       { return b.pool }
+
+// fixedClock always returns the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// recordingP2P records the packets forwarded to consensus.
+type recordingP2P struct {
+	packets []*pb.Packet
+}
+
+func (c *recordingP2P) Send(ctx context.Context, in *pb.Packet, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.packets = append(c.packets, in)
+	return &pb.Empty{}, nil
+}
+
+func TestHarnessForkSchedules(t *testing.T) {
+	london := *params.AllEthashProtocolChanges
+	london.TerminalTotalDifficulty = common.Big0
+
+	preLondon := london
+	preLondon.LondonBlock = big.NewInt(2)
+	preLondon.ArrowGlacierBlock, preLondon.GrayGlacierBlock, preLondon.MergeNetsplitBlock = nil, nil, nil
+
+	for name, config := range map[string]*params.ChainConfig{"london": &london, "prelondon": &preLondon} {
+		t.Run(name, func(t *testing.T) {
+			gspec := &core.Genesis{Config: config, Alloc: core.GenesisAlloc{harnessAddr: {Balance: big.NewInt(params.Ether)}}}
+			chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to create chain: %v", err)
+			}
+			defer chain.Stop()
+			poolConfig := legacypool.DefaultConfig
+			poolConfig.Journal = ""
+			pool, _ := txpool.New(big.NewInt(1), chain, []txpool.SubPool{legacypool.New(poolConfig, chain)})
+			defer pool.Close()
+			pool.Sync() // wait for the pool to subscribe to the chain head
+
+			var (
+				now   = time.Unix(1700000000, 0)
+				p2p   = new(recordingP2P)
+				mconf = miner.DefaultConfig
+			)
+			mconf.Etherbase = common.Address{0x01}
+			mconf.GasPrice = big.NewInt(1)
+			h := miner.NewHarness(&harnessBackend{chain: chain, pool: pool}, &mconf, chain.Config(), ethash.NewFaker(), miner.Hooks{Clock: fixedClock(now), P2P: p2p})
+
+			signer := types.LatestSigner(chain.Config())
+			transfer := func(nonce uint64) *types.Transaction {
+				return types.MustSignNewTx(harnessKey, signer, &types.LegacyTx{Nonce: nonce, To: &common.Address{0x02}, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: big.NewInt(2 * params.InitialBaseFee)})
+			}
+			encode := func(tx *types.Transaction) []byte {
+				payload, _ := tx.MarshalBinary()
+				blob, _ := proto.Marshal(&pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+				return blob
+			}
+			// Blocks are executed with the clock of the harness and the forks of the chain
+			block, receipts, err := h.Execute(&pb.ExecBlock{Txs: [][]byte{encode(transfer(0))}})
+			if err != nil {
+				t.Fatalf("failed to execute block: %v", err)
+			}
+			if block.Time() != uint64(now.UnixNano()) {
+				t.Errorf("timestamp mismatch: have %d, want %d", block.Time(), now.UnixNano())
+			}
+			if have, want := block.BaseFee() != nil, chain.Config().IsLondon(block.Number()); have != want {
+				t.Errorf("base fee presence mismatch: have %v, want %v", have, want)
+			}
+			if len(receipts) != 1 || receipts[0].Status != types.ReceiptStatusSuccessful {
+				t.Fatalf("receipts mismatch: have %v", receipts)
+			}
+			// Txs are verified against the executed head
+			payload, _ := transfer(1).MarshalBinary()
+			res, err := h.VerifyTx(&pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+			if err != nil || !res.Success {
+				t.Fatalf("failed to verify tx: %v %v", err, res.GetReason())
+			}
+			// Pending txs are forwarded to the P2P hook
+			pool.Sync()
+			if errs := pool.Add([]*types.Transaction{transfer(1)}, true, true); errs[0] != nil {
+				t.Fatalf("failed to add tx: %v", errs[0])
+			}
+			h.Forward()
+			if len(p2p.packets) != 1 {
+				t.Errorf("forwarded packet count mismatch: have %d, want %d", len(p2p.packets), 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// headTimeout is the maximum time allowance for notifying consensus of a head.
+const headTimeout = 5 * time.Second
+
+// notifyHead queues a written block for announcement to consensus, so it can
+// advance its execution watermark without polling. Heads not announced yet are
+// superseded, consensus only needs the latest one.
+func (e *executor) notifyHead(block *types.Block, meta *types.ConsensusMeta) {
+	if e.headCh == nil {
+		return
+	}
+	head := &pb.Head{
+		Hash:      block.Hash().Bytes(),
+		Number:    block.NumberU64(),
+		StateRoot: block.Root().Bytes(),
+	}
+	if meta != nil {
+		head.Sequence = meta.Sequence
+	}
+	for {
+		select {
+		case e.headCh <- head:
+			return
+		default:
+			// Drop the stale head, unless the loop just picked it up
+			select {
+			case <-e.headCh:
+			default:
+			}
+		}
+	}
+}
+
+// headLoop announces the written blocks to consensus.
+func (e *executor) headLoop() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case head := <-e.headCh:
+			e.announceHead(head)
+		case <-e.exitCh:
+			return
+		}
+	}
+}
+
+// announceHead sends a chain head to consensus.
+func (e *executor) announceHead(head *pb.Head) {
+	if e.execClient == nil || e.execClient.consensusClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), headTimeout)
+	defer cancel()
+
+	_, err := e.execClient.consensusClient.NotifyHead(ctx, head)
+	e.trackLink(err)
+	if err != nil {
+		log.Debug("Failed to notify consensus of head", "number", head.Number, "err", err)
+	}
+}
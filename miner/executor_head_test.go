@@ -0,0 +1,59 @@
+package miner
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestNotifyHead(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	client := new(testConsensusClient)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		headCh:      make(chan *pb.Head, 1),
+		execClient:  &executorClient{consensusClient: client},
+		alerter:     newAlerter(testConfig),
+	}
+	// Write two blocks without announcing, only the latest must be queued
+	for i, tx := range []*types.Transaction{pendingTxs[0], newTxs[0]} {
+		req := &execReq{
+			timestamp: time.Now().UnixNano(),
+			txs:       types.Transactions{tx},
+			sequence:  uint64(10 + i),
+			meta:      &types.ConsensusMeta{Sequence: uint64(10 + i)},
+		}
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block %d: %v", i, err)
+		}
+	}
+	head := backend.chain.CurrentBlock()
+	if head.Number.Uint64() != 2 {
+		t.Fatalf("blocks not written, head %d", head.Number)
+	}
+	e.announceHead(<-e.headCh)
+	select {
+	case stale := <-e.headCh:
+		t.Fatalf("stale head queued: %v", stale)
+	default:
+	}
+	if len(client.heads) != 1 {
+		t.Fatalf("head count mismatch: have %d, want %d", len(client.heads), 1)
+	}
+	have := client.heads[0]
+	if !bytes.Equal(have.Hash, head.Hash().Bytes()) || have.Number != 2 || !bytes.Equal(have.StateRoot, head.Root.Bytes()) || have.Sequence != 11 {
+		t.Fatalf("head mismatch: have %v, want number 2 hash %x sequence 11", have, head.Hash())
+	}
+}
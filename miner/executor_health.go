@@ -0,0 +1,116 @@
+package miner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var servingGauge = metrics.NewRegisteredGauge("executor/serving", nil)
+
+// healthServices are the services the executor reports the health of, the
+// empty one standing for the server as a whole.
+var healthServices = []string{"", pb.Executor_ServiceDesc.ServiceName, pb.Query_ServiceDesc.ServiceName}
+
+// registerHealth serves the standard gRPC health service, reporting NOT_SERVING
+// until the executor accepts consensus blocks.
+func registerHealth(s *grpc.Server) *health.Server {
+	hs := health.NewServer()
+	for _, service := range healthServices {
+		hs.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	healthpb.RegisterHealthServer(s, hs)
+	return hs
+}
+
+// isReady reports whether the executor is initialized, without waiting.
+func (e *executor) isReady() bool {
+	if e.ready == nil {
+		return true
+	}
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// updateHealth reports the executor as serving once it's initialized, until it
+// halts or closes.
+func (e *executor) updateHealth() {
+	if e.health == nil {
+		return
+	}
+	e.healthLock.Lock()
+	defer e.healthLock.Unlock()
+
+	serving := e.isReady() && !e.halted.Load() && !e.stopping.Load()
+	if serving == e.serving {
+		return
+	}
+	e.serving = serving
+
+	state := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		state = healthpb.HealthCheckResponse_SERVING
+		servingGauge.Update(1)
+	} else {
+		servingGauge.Update(0)
+	}
+	for _, service := range healthServices {
+		e.health.SetServingStatus(service, state)
+	}
+	log.Info("Executor health changed", "status", state)
+}
+
+// isHealthCheck reports whether a gRPC method belongs to the health service.
+func isHealthCheck(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// awaitReady holds a call into the executor API until the executor is
+// initialized, failing it if the executor closes or the caller gives up first.
+func (e *executor) awaitReady(ctx context.Context) error {
+	if e.ready == nil {
+		return nil
+	}
+	select {
+	case <-e.ready:
+		return nil
+	case <-e.exitCh:
+		return status.Error(codes.Unavailable, errExecutorStopping.Error())
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// readyUnaryInterceptor holds unary calls until the executor is initialized,
+// answering health checks right away.
+func (e *executor) readyUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !isHealthCheck(info.FullMethod) {
+		if err := e.awaitReady(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+// readyStreamInterceptor holds streams until the executor is initialized,
+// answering health watches right away.
+func (e *executor) readyStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !isHealthCheck(info.FullMethod) {
+		if err := e.awaitReady(ss.Context()); err != nil {
+			return err
+		}
+	}
+	return handler(srv, ss)
+}
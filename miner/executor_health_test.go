@@ -0,0 +1,80 @@
+package miner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealth(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		ready:       make(chan struct{}),
+		alerter:     newAlerter(testConfig),
+	}
+	e.server = grpc.NewServer(grpc.ChainUnaryInterceptor(e.readyUnaryInterceptor), grpc.ChainStreamInterceptor(e.readyStreamInterceptor))
+	pb.RegisterExecutorServer(e.server, &executorServer{executorPtr: e})
+	e.health = registerHealth(e.server)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go e.serve(listener)
+	defer e.server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	check := func(want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		for _, service := range []string{"", pb.Executor_ServiceDesc.ServiceName} {
+			res, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				t.Fatalf("failed to check health of %q: %v", service, err)
+			}
+			if res.Status != want {
+				t.Fatalf("health of %q mismatch: have %v, want %v", service, res.Status, want)
+			}
+		}
+	}
+	// Health checks are answered while the executor initializes, calls into
+	// the executor wait for it
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := pb.NewExecutorClient(conn).Heartbeat(ctx, &pb.HeartbeatRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("error mismatch: have %v, want %v", err, codes.DeadlineExceeded)
+	}
+	close(e.ready)
+	e.updateHealth()
+	check(healthpb.HealthCheckResponse_SERVING)
+
+	if _, err := pb.NewExecutorClient(conn).Heartbeat(context.Background(), &pb.HeartbeatRequest{}); err != nil {
+		t.Fatalf("failed to call ready executor: %v", err)
+	}
+	// Halted executors accept no further blocks
+	e.halt(&execReq{}, 1, errRootMismatch)
+	check(healthpb.HealthCheckResponse_NOT_SERVING)
+}
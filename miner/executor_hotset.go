@@ -0,0 +1,129 @@
+package miner
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// hotAccount is a frequently accessed contract along with the storage slots
+// known to be accessed alongside it.
+type hotAccount struct {
+	addr  common.Address
+	slots []common.Hash
+}
+
+// hotSet tracks the most frequently accessed contracts and storage slots over
+// the last few executed blocks, so their trie nodes can be kept warm in the
+// clean cache before the next block is executed.
+type hotSet struct {
+	window int // number of recent blocks the access statistics are kept for
+	size   int // maximum number of contracts returned by top
+
+	mu     sync.Mutex
+	blocks []map[common.Address]map[common.Hash]struct{} // accesses of the recent blocks, oldest first
+	counts map[common.Address]int                        // accumulated access count of the window
+}
+
+// newHotSet creates a hot set, returning nil if tracking is disabled.
+func newHotSet(window, size int) *hotSet {
+	if window <= 0 || size <= 0 {
+		return nil
+	}
+	return &hotSet{
+		window: window,
+		size:   size,
+		counts: make(map[common.Address]int),
+	}
+}
+
+// record accumulates the contracts and slots accessed by an executed block,
+// evicting the statistics of the oldest block once the window is full.
+func (h *hotSet) record(txs types.Transactions, logs []*types.Log) {
+	accessed := make(map[common.Address]map[common.Hash]struct{})
+	touch := func(addr common.Address) map[common.Hash]struct{} {
+		if accessed[addr] == nil {
+			accessed[addr] = make(map[common.Hash]struct{})
+		}
+		return accessed[addr]
+	}
+	for _, tx := range txs {
+		if to := tx.To(); to != nil && len(tx.Data()) > 0 {
+			touch(*to)
+		}
+		for _, tuple := range tx.AccessList() {
+			slots := touch(tuple.Address)
+			for _, key := range tuple.StorageKeys {
+				slots[key] = struct{}{}
+			}
+		}
+	}
+	for _, l := range logs {
+		touch(l.Address)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.blocks = append(h.blocks, accessed)
+	for addr := range accessed {
+		h.counts[addr]++
+	}
+	if len(h.blocks) > h.window {
+		for addr := range h.blocks[0] {
+			if h.counts[addr]--; h.counts[addr] == 0 {
+				delete(h.counts, addr)
+			}
+		}
+		h.blocks = h.blocks[1:]
+	}
+}
+
+// top returns the most frequently accessed contracts of the window along with
+// all the slots they were seen accessing.
+func (h *hotSet) top() []hotAccount {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	addrs := make([]common.Address, 0, len(h.counts))
+	for addr := range h.counts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		if h.counts[addrs[i]] != h.counts[addrs[j]] {
+			return h.counts[addrs[i]] > h.counts[addrs[j]]
+		}
+		return addrs[i].Cmp(addrs[j]) < 0
+	})
+	if len(addrs) > h.size {
+		addrs = addrs[:h.size]
+	}
+	hot := make([]hotAccount, len(addrs))
+	for i, addr := range addrs {
+		hot[i].addr = addr
+		seen := make(map[common.Hash]struct{})
+		for _, block := range h.blocks {
+			for slot := range block[addr] {
+				if _, ok := seen[slot]; !ok {
+					seen[slot] = struct{}{}
+					hot[i].slots = append(hot[i].slots, slot)
+				}
+			}
+		}
+	}
+	return hot
+}
+
+// warm loads the accounts, code and slots of the hot set from the given state,
+// pulling the backing trie nodes into the clean cache. The statedb must not
+// be used by anyone else.
+func (h *hotSet) warm(statedb *state.StateDB) {
+	for _, account := range h.top() {
+		statedb.GetCodeSize(account.addr)
+		for _, slot := range account.slots {
+			statedb.GetState(account.addr, slot)
+		}
+	}
+}
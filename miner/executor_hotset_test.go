@@ -0,0 +1,36 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestHotSet(t *testing.T) {
+	var (
+		a = common.HexToAddress("0xa")
+		b = common.HexToAddress("0xb")
+		c = common.HexToAddress("0xc")
+	)
+	if newHotSet(0, 1) != nil || newHotSet(1, 0) != nil {
+		t.Fatalf("disabled hot set created")
+	}
+	h := newHotSet(2, 2)
+	h.record(nil, []*types.Log{{Address: a}, {Address: b}})
+	h.record(nil, []*types.Log{{Address: a}, {Address: c}})
+
+	top := h.top()
+	if len(top) != 2 || top[0].addr != a || top[1].addr != b {
+		t.Fatalf("hot set mismatch: %v", top)
+	}
+	// Push out the first block, b should be forgotten
+	h.record(nil, []*types.Log{{Address: c}})
+	top = h.top()
+	if len(top) != 2 || top[0].addr != c || top[1].addr != a {
+		t.Fatalf("hot set mismatch after eviction: %v", top)
+	}
+	if _, ok := h.counts[b]; ok {
+		t.Fatalf("evicted contract still tracked")
+	}
+}
@@ -0,0 +1,298 @@
+package miner
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Classes of senders the inclusion latency is reported for. The txs are also
+// reported per origin, under the txpool.OriginRPC and txpool.OriginPeer classes.
+const (
+	SenderClassAll      = "all"
+	SenderClassLocal    = "local"
+	SenderClassRemote   = "remote"
+	SenderClassPriority = "priority"
+)
+
+const (
+	inclusionSamples = 4096      // number of recent latencies kept per class
+	inclusionMaxSeen = 100_000   // maximum number of forwarded txs awaiting inclusion
+	inclusionMaxAge  = time.Hour // time after which a forwarded tx is no longer awaited
+)
+
+var (
+	inclusionPrunedMeter = metrics.NewRegisteredMeter("executor/inclusion/pruned", nil)
+	staleForwardMeter    = metrics.NewRegisteredMeter("executor/forward/stale", nil)
+)
+
+// InclusionReport summarizes the time it took the txs of a sender class to be
+// included in a block, from first being seen by the local pool.
+type InclusionReport struct {
+	Class    string  `json:"class"`
+	Included uint64  `json:"included"` // number of txs included since startup
+	P50      float64 `json:"p50ms"`    // median latency of the recent txs in milliseconds
+	P90      float64 `json:"p90ms"`
+	P99      float64 `json:"p99ms"`
+	Max      float64 `json:"maxms"`
+	Pending  int     `json:"pending"`         // forwarded txs not yet included
+	Oldest   float64 `json:"oldestPendingms"` // age of the oldest pending tx in milliseconds
+}
+
+// forwardedTx is a tx forwarded to consensus, awaiting inclusion.
+type forwardedTx struct {
+	tx        *types.Transaction
+	from      common.Address
+	firstSeen time.Time
+	sent      time.Time // time the tx was last forwarded
+	class     string
+	origin    txpool.Origin // where the tx was first received from, zero if unknown
+}
+
+// latencies is the recent inclusion latencies of a sender class.
+type latencies struct {
+	samples []time.Duration // ring buffer of the recent latencies
+	next    int             // position of the next sample in the ring
+	count   uint64          // number of samples ever added
+	hist    metrics.Histogram
+}
+
+func (l *latencies) add(d time.Duration) {
+	if len(l.samples) < inclusionSamples {
+		l.samples = append(l.samples, d)
+	} else {
+		l.samples[l.next] = d
+	}
+	l.next = (l.next + 1) % inclusionSamples
+	l.count++
+	l.hist.Update(int64(d))
+}
+
+// inclusionTracker records the time from a tx being first seen to it being
+// included in a block, so operators can check that the forwarding policy
+// treats all senders fairly.
+type inclusionTracker struct {
+	priority map[common.Address]struct{} // senders reported as a class of their own
+
+	mu      sync.Mutex
+	seen    map[common.Hash]forwardedTx
+	classes map[string]*latencies
+	sources map[txpool.Origin]*sourceStats // forwarding and inclusion stats per origin
+}
+
+func newInclusionTracker(priority []common.Address) *inclusionTracker {
+	t := &inclusionTracker{
+		priority: make(map[common.Address]struct{}),
+		seen:     make(map[common.Hash]forwardedTx),
+		classes:  make(map[string]*latencies),
+		sources:  make(map[txpool.Origin]*sourceStats),
+	}
+	for _, addr := range priority {
+		t.priority[addr] = struct{}{}
+	}
+	for _, class := range []string{SenderClassAll, SenderClassLocal, SenderClassRemote, SenderClassPriority, txpool.OriginRPC, txpool.OriginPeer} {
+		t.classes[class] = &latencies{
+			hist: metrics.NewRegisteredHistogram("executor/inclusion/"+class, nil, metrics.NewExpDecaySample(1028, 0.015)),
+		}
+	}
+	return t
+}
+
+// classify returns the class of a sender.
+func (t *inclusionTracker) classify(from common.Address, local bool) string {
+	if _, ok := t.priority[from]; ok {
+		return SenderClassPriority
+	}
+	if local {
+		return SenderClassLocal
+	}
+	return SenderClassRemote
+}
+
+// forwarded starts awaiting the inclusion of a tx forwarded to consensus.
+// Resending a tx doesn't reset the time it was first seen.
+func (t *inclusionTracker) forwarded(tx *types.Transaction, firstSeen time.Time, from common.Address, local bool, origin txpool.Origin) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hash := tx.Hash()
+	if fwd, ok := t.seen[hash]; ok {
+		fwd.sent = time.Now()
+		t.seen[hash] = fwd
+		return
+	}
+	if len(t.seen) >= inclusionMaxSeen {
+		for hash, fwd := range t.seen {
+			if time.Since(fwd.firstSeen) > inclusionMaxAge {
+				delete(t.seen, hash)
+			}
+		}
+		if len(t.seen) >= inclusionMaxSeen {
+			return
+		}
+	}
+	t.seen[hash] = forwardedTx{tx: tx, from: from, firstSeen: firstSeen, sent: time.Now(), class: t.classify(from, local), origin: origin}
+	if origin.Kind != "" {
+		t.source(origin).forwarded++
+	}
+}
+
+// awaiting reports whether a tx was forwarded to consensus less than retry ago
+// and wasn't included yet, so there is no need to forward it again.
+func (t *inclusionTracker) awaiting(hash common.Hash, retry time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fwd, ok := t.seen[hash]
+	return ok && time.Since(fwd.sent) < retry
+}
+
+// prune stops awaiting the forwarded txs which can't be included anymore: the
+// ones dropped from the pool, the ones superseded by the inclusion of another tx
+// of the same nonce and the ones awaited for longer than inclusionMaxAge. Txs
+// whose nonce was executed are given the retry interval to be reported as
+// included first, returning the number of txs no longer awaited.
+func (t *inclusionTracker) prune(pooled func(common.Hash) bool, nonce func(common.Address) uint64, retry time.Duration, now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var pruned int
+	for hash, fwd := range t.seen {
+		switch {
+		case now.Sub(fwd.firstSeen) > inclusionMaxAge:
+		case pooled(hash):
+			continue
+		case fwd.tx != nil && fwd.tx.Nonce() >= nonce(fwd.from):
+		case now.Sub(fwd.sent) >= retry:
+		default:
+			continue
+		}
+		delete(t.seen, hash)
+		pruned++
+	}
+	inclusionPrunedMeter.Mark(int64(pruned))
+	return pruned
+}
+
+// included records the inclusion latency of the forwarded txs of a block.
+func (t *inclusionTracker) included(txs types.Transactions, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tx := range txs {
+		fwd, ok := t.seen[tx.Hash()]
+		if !ok {
+			continue
+		}
+		delete(t.seen, tx.Hash())
+
+		latency := now.Sub(fwd.firstSeen)
+		t.classes[fwd.class].add(latency)
+		t.classes[SenderClassAll].add(latency)
+		if l, ok := t.classes[fwd.origin.Kind]; ok {
+			l.add(latency)
+			t.source(fwd.origin).include(latency)
+		}
+	}
+}
+
+// awaitedNonces returns the nonces of the forwarded txs awaiting inclusion per
+// sender, in no particular order.
+func (t *inclusionTracker) awaitedNonces() map[common.Address][]uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nonces := make(map[common.Address][]uint64)
+	for _, fwd := range t.seen {
+		if fwd.tx != nil {
+			nonces[fwd.from] = append(nonces[fwd.from], fwd.tx.Nonce())
+		}
+	}
+	return nonces
+}
+
+// inFlight returns the number of forwarded txs awaiting inclusion.
+func (t *inclusionTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.seen)
+}
+
+// unpooled returns the number of forwarded txs awaiting inclusion which were
+// dropped from the pool since, still bound for a block through consensus.
+func (t *inclusionTracker) unpooled(pooled func(common.Hash) bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var n int
+	for hash := range t.seen {
+		if !pooled(hash) {
+			n++
+		}
+	}
+	return n
+}
+
+// reports returns the inclusion latency percentiles of every sender class.
+func (t *inclusionTracker) reports() []InclusionReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		now     = time.Now()
+		pending = make(map[string]int)
+		oldest  = make(map[string]time.Duration)
+	)
+	for _, fwd := range t.seen {
+		for _, class := range []string{fwd.class, SenderClassAll, fwd.origin.Kind} {
+			pending[class]++
+			if age := now.Sub(fwd.firstSeen); age > oldest[class] {
+				oldest[class] = age
+			}
+		}
+	}
+	reports := make([]InclusionReport, 0, len(t.classes))
+	for class, l := range t.classes {
+		report := InclusionReport{
+			Class:    class,
+			Included: l.count,
+			Pending:  pending[class],
+			Oldest:   milliseconds(oldest[class]),
+		}
+		if len(l.samples) > 0 {
+			sorted := make([]time.Duration, len(l.samples))
+			copy(sorted, l.samples)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+			report.P50 = milliseconds(percentile(sorted, 0.50))
+			report.P90 = milliseconds(percentile(sorted, 0.90))
+			report.P99 = milliseconds(percentile(sorted, 0.99))
+			report.Max = milliseconds(sorted[len(sorted)-1])
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Class < reports[j].Class })
+	return reports
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
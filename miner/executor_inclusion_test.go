@@ -0,0 +1,142 @@
+package miner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestInclusionReports(t *testing.T) {
+	var (
+		priority = common.Address{0x01}
+		tracker  = newInclusionTracker([]common.Address{priority})
+		now      = time.Now()
+		other    = types.NewTransaction(0, common.Address{}, nil, 0, nil, nil)
+	)
+	// Forward a tx of every class, resending the local one later
+	rpc := txpool.Origin{Kind: txpool.OriginRPC, Source: "10.0.0.1"}
+	peer := txpool.Origin{Kind: txpool.OriginPeer, Source: "a1b2"}
+	tracker.forwarded(pendingTxs[0], now.Add(-time.Second), testBankAddress, true, rpc)
+	tracker.forwarded(pendingTxs[0], now, testBankAddress, true, rpc)
+	tracker.forwarded(newTxs[0], now.Add(-3*time.Second), testUserAddress, false, peer)
+	tracker.forwarded(other, now.Add(-5*time.Second), priority, false, peer)
+
+	// Include the local and the remote one
+	tracker.included(types.Transactions{pendingTxs[0], newTxs[0]}, now)
+
+	want := map[string]struct {
+		included uint64
+		p50, max time.Duration
+		pending  int
+	}{
+		SenderClassAll:      {included: 2, p50: time.Second, max: 3 * time.Second, pending: 1},
+		SenderClassLocal:    {included: 1, p50: time.Second, max: time.Second},
+		SenderClassRemote:   {included: 1, p50: 3 * time.Second, max: 3 * time.Second},
+		SenderClassPriority: {pending: 1},
+		txpool.OriginRPC:    {included: 1, p50: time.Second, max: time.Second},
+		txpool.OriginPeer:   {included: 1, p50: 3 * time.Second, max: 3 * time.Second, pending: 1},
+	}
+	reports := tracker.reports()
+	if len(reports) != len(want) {
+		t.Fatalf("report count mismatch: have %d, want %d", len(reports), len(want))
+	}
+	for _, report := range reports {
+		w := want[report.Class]
+		if report.Included != w.included {
+			t.Errorf("%s: included mismatch: have %d, want %d", report.Class, report.Included, w.included)
+		}
+		if report.P50 != milliseconds(w.p50) || report.Max != milliseconds(w.max) {
+			t.Errorf("%s: latency mismatch: have %v/%v, want %v/%v", report.Class, report.P50, report.Max, milliseconds(w.p50), milliseconds(w.max))
+		}
+		if report.Pending != w.pending {
+			t.Errorf("%s: pending mismatch: have %d, want %d", report.Class, report.Pending, w.pending)
+		}
+	}
+}
+
+func TestOriginReports(t *testing.T) {
+	var (
+		tracker = newInclusionTracker(nil)
+		now     = time.Now()
+		rpc     = txpool.Origin{Kind: txpool.OriginRPC, Source: "10.0.0.1"}
+		peer    = txpool.Origin{Kind: txpool.OriginPeer, Source: "a1b2"}
+	)
+	tracker.forwarded(pendingTxs[0], now.Add(-time.Second), testBankAddress, false, peer)
+	tracker.forwarded(newTxs[0], now.Add(-3*time.Second), testBankAddress, false, peer)
+	tracker.forwarded(types.NewTransaction(0, common.Address{}, nil, 0, nil, nil), now, testBankAddress, false, rpc)
+	tracker.forwarded(types.NewTransaction(1, common.Address{}, nil, 0, nil, nil), now, testBankAddress, false, txpool.Origin{})
+	tracker.included(types.Transactions{pendingTxs[0], newTxs[0]}, now)
+
+	// Txs of unknown origin aren't reported, the most active origin goes first
+	want := []OriginReport{
+		{Kind: txpool.OriginPeer, Source: "a1b2", Forwarded: 2, Included: 2, Mean: milliseconds(2 * time.Second)},
+		{Kind: txpool.OriginRPC, Source: "10.0.0.1", Forwarded: 1},
+	}
+	reports := tracker.originReports()
+	if len(reports) != len(want) {
+		t.Fatalf("report count mismatch: have %d, want %d", len(reports), len(want))
+	}
+	for i, report := range reports {
+		if report != want[i] {
+			t.Errorf("report %d mismatch: have %+v, want %+v", i, report, want[i])
+		}
+	}
+	// Origins beyond the limit are accounted together
+	for i := 0; i < maxOriginSources+10; i++ {
+		tx := types.NewTransaction(uint64(i+2), common.Address{}, nil, 0, nil, nil)
+		tracker.forwarded(tx, now, testBankAddress, false, txpool.Origin{Kind: txpool.OriginRPC, Source: fmt.Sprint(i)})
+	}
+	reports = tracker.originReports()
+	if len(reports) != maxOriginSources+1 {
+		t.Fatalf("report count mismatch: have %d, want %d", len(reports), maxOriginSources+1)
+	}
+	if reports[0].Source != otherSource || reports[0].Forwarded != 12 {
+		t.Errorf("overflow report mismatch: have %+v, want %d txs of %q", reports[0], 12, otherSource)
+	}
+}
+
+func TestInclusionPrune(t *testing.T) {
+	var (
+		tracker = newInclusionTracker(nil)
+		now     = time.Now()
+		retry   = 30 * time.Second
+	)
+	// pendingTxs[0] stays pooled, newTxs[0] got dropped unexecuted, the others
+	// have their nonces executed by a replacement
+	tracker.forwarded(pendingTxs[0], now, testBankAddress, false, txpool.Origin{})
+	tracker.forwarded(newTxs[0], now, testUserAddress, false, txpool.Origin{})
+	superseded := types.NewTransaction(0, common.Address{}, nil, 0, nil, nil)
+	tracker.forwarded(superseded, now, common.Address{0x01}, false, txpool.Origin{})
+
+	pooled := func(hash common.Hash) bool { return hash == pendingTxs[0].Hash() }
+	nonce := func(addr common.Address) uint64 {
+		if addr == testUserAddress {
+			return 0
+		}
+		return 1
+	}
+	if pruned := tracker.prune(pooled, nonce, retry, now); pruned != 1 {
+		t.Fatalf("pruned mismatch: have %d, want 1", pruned)
+	}
+	if tracker.awaiting(newTxs[0].Hash(), retry) {
+		t.Errorf("dropped tx still awaited")
+	}
+	// Superseded txs are given time to be reported as included first
+	if !tracker.awaiting(superseded.Hash(), retry) {
+		t.Fatalf("superseded tx pruned before the retry interval")
+	}
+	if pruned := tracker.prune(pooled, nonce, retry, now.Add(retry+time.Second)); pruned != 1 {
+		t.Fatalf("pruned mismatch: have %d, want 1", pruned)
+	}
+	// Pooled txs are only pruned once expired
+	if !tracker.awaiting(pendingTxs[0].Hash(), retry) {
+		t.Fatalf("pooled tx pruned")
+	}
+	if pruned := tracker.prune(pooled, nonce, retry, now.Add(inclusionMaxAge+time.Second)); pruned != 1 || tracker.inFlight() != 0 {
+		t.Errorf("expired tx not pruned: pruned %d, in flight %d", pruned, tracker.inFlight())
+	}
+}
@@ -0,0 +1,152 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// inFlightEntry is the persisted form of a tx forwarded to consensus which
+// wasn't included yet.
+type inFlightEntry struct {
+	Tx        []byte // binary encoding of the tx
+	FirstSeen uint64 // unix time in nanoseconds the tx was first seen
+	Sent      uint64 // unix time in nanoseconds the tx was last forwarded
+	Class     string
+}
+
+// snapshot returns the txs awaiting inclusion, leaving out the ones awaited
+// for so long they are no longer expected to be included.
+func (t *inclusionTracker) snapshot() []inFlightEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]inFlightEntry, 0, len(t.seen))
+	for _, fwd := range t.seen {
+		if fwd.tx == nil || time.Since(fwd.firstSeen) > inclusionMaxAge {
+			continue
+		}
+		blob, err := fwd.tx.MarshalBinary()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, inFlightEntry{
+			Tx:        blob,
+			FirstSeen: uint64(fwd.firstSeen.UnixNano()),
+			Sent:      uint64(fwd.sent.UnixNano()),
+			Class:     fwd.class,
+		})
+	}
+	return entries
+}
+
+// restore awaits the inclusion of a tx forwarded before a restart.
+func (t *inclusionTracker) restore(tx *types.Transaction, from common.Address, entry *inFlightEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.classes[entry.Class]; !ok {
+		entry.Class = SenderClassRemote
+	}
+	t.seen[tx.Hash()] = forwardedTx{
+		tx:        tx,
+		from:      from,
+		firstSeen: time.Unix(0, int64(entry.FirstSeen)),
+		sent:      time.Unix(0, int64(entry.Sent)),
+		class:     entry.Class,
+	}
+}
+
+// inFlightLoop periodically persists the txs awaiting inclusion, and once more
+// on shutdown, so a restarted executor knows which txs consensus already has.
+func (e *executor) inFlightLoop() {
+	defer e.wg.Done()
+	if !e.waitReady() {
+		return
+	}
+	if e.config.InFlightSnapshot == 0 || e.config.Stateless {
+		return
+	}
+	ticker := time.NewTicker(e.config.InFlightSnapshot)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.snapshotInFlight()
+		case <-e.exitCh:
+			e.snapshotInFlight()
+			return
+		}
+	}
+}
+
+// snapshotInFlight persists the txs awaiting inclusion.
+func (e *executor) snapshotInFlight() {
+	entries := e.inclusion.snapshot()
+	blob, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Error("Failed to encode in-flight txs", "err", err)
+		return
+	}
+	e.eth.BlockChain().WriteExecutorInFlight(blob)
+	log.Trace("Persisted in-flight txs", "txs", len(entries))
+}
+
+// restoreInFlight reconciles the persisted txs awaiting inclusion with the
+// chain on startup: txs included meanwhile or outdated by the nonce of their
+// sender are dropped, the rest are awaited again and put back in the pool, so
+// they are neither forwarded twice nor lost if consensus drops them.
+func (e *executor) restoreInFlight() {
+	if e.config.InFlightSnapshot == 0 || e.config.Stateless {
+		return
+	}
+	blob := e.eth.BlockChain().GetExecutorInFlight()
+	if len(blob) == 0 {
+		return
+	}
+	var entries []inFlightEntry
+	if err := rlp.DecodeBytes(blob, &entries); err != nil {
+		log.Warn("Discarding invalid in-flight txs", "err", err)
+		return
+	}
+	statedb, err := e.eth.BlockChain().State()
+	if err != nil {
+		log.Warn("Failed to reconcile in-flight txs", "err", err)
+		return
+	}
+	var (
+		signer   = types.LatestSigner(e.chainConfig)
+		included int
+		stale    int
+		restored types.Transactions
+	)
+	for i := range entries {
+		entry := &entries[i]
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(entry.Tx); err != nil {
+			stale++
+			continue
+		}
+		if lookup, _, _ := e.eth.BlockChain().GetTransactionLookup(tx.Hash()); lookup != nil {
+			included++
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil || statedb.GetNonce(from) > tx.Nonce() || time.Since(time.Unix(0, int64(entry.FirstSeen))) > inclusionMaxAge {
+			stale++
+			continue
+		}
+		e.inclusion.restore(tx, from, entry)
+		restored = append(restored, tx)
+	}
+	// Remote txs aren't journaled by the pool, add them back so they are
+	// forwarded again once they weren't included for too long
+	if len(restored) > 0 {
+		e.eth.TxPool().Add(restored, false, false)
+	}
+	log.Info("Restored in-flight txs", "restored", len(restored), "included", included, "stale", stale)
+}
@@ -0,0 +1,95 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestInFlightRestore(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.InFlightSnapshot = time.Second
+	cfg.InFlightRetry = time.Minute
+
+	e := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg), inclusion: newInclusionTracker(nil)}
+
+	// Forward a tx about to be included, one still awaited and one replaced by
+	// the included tx
+	replaced := types.MustSignNewTx(testBankKey, types.LatestSigner(&config), &types.LegacyTx{Nonce: 0, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(2 * params.InitialBaseFee)})
+	for _, tx := range []*types.Transaction{pendingTxs[0], newTxs[0], replaced} {
+		e.inclusion.forwarded(tx, time.Now(), testBankAddress, false, txpool.Origin{})
+	}
+	if !e.inclusion.awaiting(newTxs[0].Hash(), cfg.InFlightRetry) {
+		t.Fatalf("forwarded tx not awaited")
+	}
+	if e.inclusion.awaiting(newTxs[0].Hash(), 0) {
+		t.Fatalf("forwarded tx awaited past its retry")
+	}
+	e.snapshotInFlight()
+
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	// Restart with an empty tracker and reconcile the snapshot with the chain
+	restarted := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg), inclusion: newInclusionTracker(nil)}
+	restarted.restoreInFlight()
+
+	if n := restarted.inclusion.inFlight(); n != 1 {
+		t.Fatalf("in-flight txs mismatch: have %d, want %d", n, 1)
+	}
+	if !restarted.inclusion.awaiting(newTxs[0].Hash(), cfg.InFlightRetry) {
+		t.Errorf("restored tx not awaited")
+	}
+	if !backend.txPool.Has(newTxs[0].Hash()) {
+		t.Errorf("restored tx not in pool")
+	}
+}
+
+func TestInFlightSkipsGas(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.InFlightRetry = time.Minute
+
+	e := &executor{config: &cfg, chainConfig: ethashChainConfig, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg), inclusion: newInclusionTracker(nil)}
+	e.setMinTip(common.Big0)
+	e.inclusion.forwarded(pendingTxs[0], time.Now(), testBankAddress, true, txpool.Origin{})
+
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 0)
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	// Room is left for a single tx, the one consensus already has mustn't take it
+	work.gasPool = new(core.GasPool).AddGas(params.TxGas)
+
+	var sent types.Transactions
+	forward := func(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error {
+		sent = append(sent, tx)
+		return nil
+	}
+	var lazies []*txpool.LazyTransaction
+	for _, tx := range []*types.Transaction{pendingTxs[0], newTxs[0]} {
+		lazies = append(lazies, &txpool.LazyTransaction{Hash: tx.Hash(), Tx: tx, Time: tx.Time(), GasFeeCap: tx.GasFeeCap(), GasTipCap: tx.GasTipCap(), Gas: tx.Gas()})
+	}
+	local := map[common.Address][]*txpool.LazyTransaction{testBankAddress: lazies}
+	if _, err := e.forwardTransactions(nil, work, local, nil, forward); err != nil {
+		t.Fatalf("failed to forward txs: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Hash() != newTxs[0].Hash() {
+		t.Fatalf("forwarded txs mismatch: have %v, want %x", sent, newTxs[0].Hash())
+	}
+}
@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// interruptTimeout is the maximum time allowance for reporting an interrupted
+// forwarding round to consensus.
+const interruptTimeout = 5 * time.Second
+
+// interruptReasons maps the interruption errors of the forwarding to the
+// reasons reported to consensus.
+var interruptReasons = map[error]pb.InterruptReason{
+	errBlockInterruptedByNewHead:    pb.InterruptReason_NEW_HEAD,
+	errBlockInterruptedByRecommit:   pb.InterruptReason_RESUBMIT,
+	errBlockInterruptedByTimeout:    pb.InterruptReason_TIMEOUT,
+	errBlockInterruptedByPreemption: pb.InterruptReason_PREEMPTED,
+}
+
+// lazyHashes returns the hashes of the txs of a pending set.
+func lazyHashes(pending map[common.Address][]*txpool.LazyTransaction) []common.Hash {
+	var hashes []common.Hash
+	for _, txs := range pending {
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	return hashes
+}
+
+// reportInterrupt tells consensus which txs of an interrupted forwarding round
+// it received and which were left unsent, so its mempool doesn't wait for the
+// latter or count them as known. Errors other than interruptions are ignored.
+func (e *executor) reportInterrupt(err error, sent types.Transactions, aborted []common.Hash) {
+	reason, ok := interruptReasons[err]
+	if !ok {
+		return
+	}
+	log.Debug("Forwarding interrupted", "reason", reason, "sent", len(sent), "aborted", len(aborted))
+	if e.execClient == nil || e.execClient.consensusClient == nil {
+		return
+	}
+	report := &pb.Interrupt{
+		Reason:  reason,
+		Sent:    make([][]byte, len(sent)),
+		Aborted: make([][]byte, len(aborted)),
+	}
+	for i, tx := range sent {
+		report.Sent[i] = tx.Hash().Bytes()
+	}
+	for i, hash := range aborted {
+		report.Aborted[i] = hash.Bytes()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), interruptTimeout)
+	defer cancel()
+
+	_, rerr := e.execClient.consensusClient.ReportInterrupt(ctx, report)
+	e.trackLink(rerr)
+	if rerr != nil {
+		log.Debug("Failed to report interrupt to consensus", "reason", reason, "err", rerr)
+	}
+}
@@ -0,0 +1,135 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+)
+
+// testP2PClient accepts the txs sent to consensus, calling onSend after each.
+type testP2PClient struct {
+	sent   int
+	onSend func()
+}
+
+func (c *testP2PClient) Send(ctx context.Context, in *pb.Packet, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.sent++
+	if c.onSend != nil {
+		c.onSend()
+	}
+	return &pb.Empty{}, nil
+}
+
+func TestReportInterrupt(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	var (
+		signer = types.LatestSigner(ethashChainConfig)
+		txs    = make(types.Transactions, 3)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	for _, err := range backend.txPool.Add(txs, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	// The head changes right after the first tx went out
+	var (
+		interrupt = new(atomic.Int32)
+		consensus = new(testConsensusClient)
+		p2p       = &testP2PClient{onSend: func() { interrupt.Store(commitInterruptNewHead) }}
+	)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		execClient:  &executorClient{p2pClient: p2p, consensusClient: consensus},
+		alerter:     newAlerter(testConfig),
+	}
+	e.setMinTip(common.Big0)
+
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 0)
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	if err := e.fillTransactions(interrupt, work); err != errBlockInterruptedByNewHead {
+		t.Fatalf("error mismatch: have %v, want %v", err, errBlockInterruptedByNewHead)
+	}
+	if p2p.sent != 1 || len(consensus.interrupts) != 1 {
+		t.Fatalf("report mismatch: have %d sent %d reports, want 1 sent 1 report", p2p.sent, len(consensus.interrupts))
+	}
+	report := consensus.interrupts[0]
+	if report.Reason != pb.InterruptReason_NEW_HEAD {
+		t.Errorf("reason mismatch: have %v, want %v", report.Reason, pb.InterruptReason_NEW_HEAD)
+	}
+	if len(report.Sent) != 1 || common.BytesToHash(report.Sent[0]) != txs[0].Hash() {
+		t.Errorf("sent mismatch: have %x, want %x", report.Sent, txs[0].Hash())
+	}
+	if len(report.Aborted) != 2 || common.BytesToHash(report.Aborted[0]) != txs[1].Hash() || common.BytesToHash(report.Aborted[1]) != txs[2].Hash() {
+		t.Errorf("aborted mismatch: have %x, want %x %x", report.Aborted, txs[1].Hash(), txs[2].Hash())
+	}
+	// Rounds which ran to completion aren't reported
+	interrupt.Store(commitInterruptNone)
+	p2p.onSend = nil
+	if err := e.fillTransactions(interrupt, work); err != nil {
+		t.Fatalf("failed to forward txs: %v", err)
+	}
+	if len(consensus.interrupts) != 1 {
+		t.Errorf("report count mismatch: have %d, want 1", len(consensus.interrupts))
+	}
+}
+
+func TestForwardSkipsStaleNonces(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	var (
+		signer = types.LatestSigner(ethashChainConfig)
+		txs    = make(types.Transactions, 3)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	for _, err := range backend.txPool.Add(txs, true, true) {
+		if err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	p2p := new(testP2PClient)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		execClient:  &executorClient{p2pClient: p2p},
+		alerter:     newAlerter(testConfig),
+	}
+	e.setMinTip(common.Big0)
+
+	// The head executed the first two txs, the pool didn't catch up yet
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 0)
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	work.state.SetNonce(testBankAddress, 2)
+	if err := e.fillTransactions(nil, work); err != nil {
+		t.Fatalf("failed to forward txs: %v", err)
+	}
+	if p2p.sent != 1 {
+		t.Errorf("forwarded txs mismatch: have %d, want 1", p2p.sent)
+	}
+}
@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var forwardPacedMeter = metrics.NewRegisteredMeter("executor/send/paced", nil)
+
+// forwardShare returns the percentage of the time the forwarding rounds may
+// run, zero if not paced.
+func (e *executor) forwardShare() int {
+	if share := e.config.ForwardShare; share > 0 && share < 100 {
+		return share
+	}
+	return 0
+}
+
+// makeForwardEnv creates the environment selecting the txs to forward on top
+// of the given parent. Its state is read through caches of its own, and none
+// of the prefetcher, hashers and hot set warmer of the execution are started
+// for it, so forwarding rounds don't evict or hold up what the execution of
+// the consensus blocks relies on.
+func (e *executor) makeForwardEnv(parent *types.Header, header *types.Header, signer types.Signer, coinbase common.Address) (*executor_env, error) {
+	chain := e.eth.BlockChain()
+	e.forwardOnce.Do(func() {
+		e.forwardCache = chain.NewStateCache()
+	})
+	root := chain.PostStateRoot(parent)
+	if root == (common.Hash{}) {
+		return nil, fmt.Errorf("%w: block %d %x", core.ErrStateNotCommitted, parent.Number, parent.Hash())
+	}
+	statedb, err := state.New(root, e.forwardCache, chain.Snapshots())
+	if err != nil {
+		return nil, err
+	}
+	return &executor_env{
+		signer:   signer,
+		state:    statedb,
+		chain:    chain,
+		coinbase: coinbase,
+		header:   header,
+	}, nil
+}
+
+// paceForward reports whether a forwarding round has to wait, so forwarding
+// stays within its share of the time and leaves the rest to the execution.
+// Only called by the send loop.
+func (e *executor) paceForward() bool {
+	if e.forwardShare() == 0 || !time.Now().Before(e.forwardResume) {
+		return false
+	}
+	log.Debug("Pacing tx forwarding", "resume", common.PrettyDuration(time.Until(e.forwardResume)))
+	forwardPacedMeter.Mark(1)
+	return true
+}
+
+// forwarded accounts the time spent by a forwarding round, holding the next
+// one back in proportion to the share of the time left to the execution. Only
+// called by the send loop.
+func (e *executor) forwarded(busy time.Duration) {
+	share := e.forwardShare()
+	if share == 0 {
+		return
+	}
+	e.forwardResume = time.Now().Add(busy * time.Duration(100-share) / time.Duration(share))
+}
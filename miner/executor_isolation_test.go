@@ -0,0 +1,51 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestForwardIsolation(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.ForwardShare = 25
+	e := &executor{config: &cfg, chainConfig: ethashChainConfig, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+
+	// Forwarding reads the state through caches of its own
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), forward: true}, 0)
+	if err != nil {
+		t.Fatalf("failed to prepare forwarding: %v", err)
+	}
+	if work.state.Database() == backend.chain.StateCache() {
+		t.Errorf("forwarding shares the state caches of the execution")
+	}
+	if balance := work.state.GetBalance(testBankAddress); balance.ToBig().Cmp(testBankFunds) != 0 {
+		t.Errorf("forwarded state balance mismatch: have %v, want %v", balance, testBankFunds)
+	}
+	exec, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 1)
+	if err != nil {
+		t.Fatalf("failed to prepare execution: %v", err)
+	}
+	if exec.state.Database() != backend.chain.StateCache() {
+		t.Errorf("execution doesn't use the state caches of the chain")
+	}
+	// Forwarding rounds are paced to their share
+	e.forwarded(20 * time.Millisecond)
+	if !e.paceForward() {
+		t.Errorf("forwarding not paced after a round")
+	}
+	time.Sleep(80 * time.Millisecond)
+	if e.paceForward() {
+		t.Errorf("forwarding paced past its share")
+	}
+	cfg.ForwardShare = 0
+	e.forwarded(time.Second)
+	if e.paceForward() {
+		t.Errorf("forwarding paced without share")
+	}
+}
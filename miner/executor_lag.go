@@ -0,0 +1,134 @@
+package miner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var (
+	consensusHeadGauge = metrics.NewRegisteredGauge("executor/head/consensus", nil)
+	executedHeadGauge  = metrics.NewRegisteredGauge("executor/head/executed", nil)
+	headLagGauge       = metrics.NewRegisteredGauge("executor/head/lag", nil)
+)
+
+// HeadLag is the progress of the execution relative to consensus, measured in
+// consensus blocks.
+type HeadLag struct {
+	ConsensusHead uint64 `json:"consensusHead"` // consensus height last heard of
+	ExecutedHead  uint64 `json:"executedHead"`  // consensus height of the last executed block
+	Lag           uint64 `json:"lag"`           // number of consensus blocks not yet executed
+	Throttled     bool   `json:"throttled"`     // whether tx forwarding is paused to catch up
+	Syncing       bool   `json:"syncing"`       // whether the execution is replaying a backlog, reported by eth_syncing
+}
+
+// Heartbeat records the consensus head periodically announced by consensus
+// and reports back how far the execution lags behind it.
+func (es *executorServer) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	e := es.executorPtr
+	e.observeConsensusHead(req.GetSequence())
+
+	return &pb.HeartbeatResponse{
+		ExecutedSequence: e.executedSeq.Load(),
+		Lag:              e.headLag(),
+		Shedding:         e.shedding.Load(),
+	}, nil
+}
+
+// observeConsensusHead advances the consensus head if the sequence is newer.
+func (e *executor) observeConsensusHead(seq uint64) {
+	for {
+		head := e.consensusSeq.Load()
+		if seq <= head || e.consensusSeq.CompareAndSwap(head, seq) {
+			break
+		}
+	}
+	consensusHeadGauge.Update(int64(e.consensusSeq.Load()))
+	e.checkLag()
+}
+
+// markExecuted advances the executed head after the block with the given
+// consensus sequence was executed. Blocks without sequence are ignored.
+func (e *executor) markExecuted(seq uint64) {
+	if seq == 0 {
+		return
+	}
+	for {
+		head := e.executedSeq.Load()
+		if seq <= head || e.executedSeq.CompareAndSwap(head, seq) {
+			break
+		}
+	}
+	executedHeadGauge.Update(int64(e.executedSeq.Load()))
+
+	// Consensus obviously reached any block it delivered
+	e.observeConsensusHead(seq)
+}
+
+// headLag returns the number of consensus blocks not yet executed.
+func (e *executor) headLag() uint64 {
+	consensus, executed := e.consensusSeq.Load(), e.executedSeq.Load()
+	if consensus <= executed {
+		return 0
+	}
+	return consensus - executed
+}
+
+// lagging reports whether the execution lags further behind consensus than
+// tolerated.
+func (e *executor) lagging() bool {
+	limit := e.settings().MaxHeadLag
+	return limit > 0 && e.headLag() > limit
+}
+
+// throttled reports whether tx forwarding is paused until the execution
+// catches up with consensus.
+func (e *executor) throttled() bool {
+	return e.settings().ThrottleOnLag && e.lagging()
+}
+
+// checkLag updates the lag metric and alerts the operator if the execution
+// falls too far behind consensus.
+func (e *executor) checkLag() {
+	lag := e.headLag()
+	headLagGauge.Update(int64(lag))
+	e.checkSync(lag)
+
+	if !e.lagging() {
+		e.alerter.resolve(AlertExecutionLag)
+		return
+	}
+	e.alerter.raise(AlertExecutionLag, fmt.Sprintf("execution %d blocks behind consensus, tolerated %d", lag, e.settings().MaxHeadLag))
+	log.Debug("Execution lagging behind consensus", "lag", lag, "throttled", e.throttled())
+}
+
+// checkSync tracks whether the execution is replaying a backlog of consensus
+// blocks. The node reports itself as syncing from the moment the execution
+// falls more than SyncLag blocks behind until the backlog fully drained, so
+// users don't mistake the chain for current while it catches up.
+func (e *executor) checkSync(lag uint64) {
+	switch {
+	case lag > e.settings().SyncLag:
+		if e.catchingUp.CompareAndSwap(false, true) {
+			log.Info("Execution catching up with consensus", "lag", lag)
+		}
+	case lag == 0:
+		if e.catchingUp.CompareAndSwap(true, false) {
+			log.Info("Execution caught up with consensus", "head", e.executedSeq.Load())
+		}
+	}
+}
+
+// lagStatus returns the progress of the execution relative to consensus.
+func (e *executor) lagStatus() HeadLag {
+	return HeadLag{
+		ConsensusHead: e.consensusSeq.Load(),
+		ExecutedHead:  e.executedSeq.Load(),
+		Lag:           e.headLag(),
+		Throttled:     e.throttled(),
+		Syncing:       e.catchingUp.Load(),
+	}
+}
@@ -0,0 +1,80 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestHeadLag(t *testing.T) {
+	config := &Config{MaxHeadLag: 2, ThrottleOnLag: true}
+	e := &executor{config: config, alerter: newAlerter(config)}
+	es := &executorServer{executorPtr: e}
+
+	tests := []struct {
+		heartbeat uint64 // consensus height announced, zero if none
+		executed  uint64 // consensus height executed, zero if none
+		lag       uint64
+		throttled bool
+	}{
+		{heartbeat: 1, lag: 1},
+		{executed: 1, lag: 0},
+		{heartbeat: 4, lag: 3, throttled: true},
+		{heartbeat: 2, lag: 3, throttled: true}, // stale heartbeat
+		{executed: 3, lag: 1},
+		{executed: 6, lag: 0}, // delivered blocks advance the consensus head
+	}
+	for i, tt := range tests {
+		if tt.heartbeat != 0 {
+			res, err := es.Heartbeat(context.Background(), &pb.HeartbeatRequest{Sequence: tt.heartbeat})
+			if err != nil {
+				t.Fatalf("test %d: heartbeat failed: %v", i, err)
+			}
+			if res.Lag != tt.lag {
+				t.Errorf("test %d: reported lag mismatch: have %d, want %d", i, res.Lag, tt.lag)
+			}
+		}
+		if tt.executed != 0 {
+			e.markExecuted(tt.executed)
+		}
+		status := e.lagStatus()
+		if status.Lag != tt.lag {
+			t.Errorf("test %d: lag mismatch: have %d, want %d", i, status.Lag, tt.lag)
+		}
+		if status.Throttled != tt.throttled {
+			t.Errorf("test %d: throttled mismatch: have %v, want %v", i, status.Throttled, tt.throttled)
+		}
+		if alerted := len(e.alerter.alerts()) > 0; alerted != tt.throttled {
+			t.Errorf("test %d: alert mismatch: have %v, want %v", i, alerted, tt.throttled)
+		}
+	}
+}
+
+func TestSyncStatus(t *testing.T) {
+	config := &Config{SyncLag: 2}
+	e := &executor{config: config, alerter: newAlerter(config)}
+
+	tests := []struct {
+		heartbeat uint64 // consensus height announced, zero if none
+		executed  uint64 // consensus height executed, zero if none
+		syncing   bool
+	}{
+		{heartbeat: 2},
+		{heartbeat: 5, syncing: true},
+		{executed: 4, syncing: true}, // syncing until the backlog drained
+		{executed: 5},
+		{heartbeat: 7},
+	}
+	for i, tt := range tests {
+		if tt.heartbeat != 0 {
+			e.observeConsensusHead(tt.heartbeat)
+		}
+		if tt.executed != 0 {
+			e.markExecuted(tt.executed)
+		}
+		if status := e.lagStatus(); status.Syncing != tt.syncing {
+			t.Errorf("test %d: syncing mismatch: have %v, want %v", i, status.Syncing, tt.syncing)
+		}
+	}
+}
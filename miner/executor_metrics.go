@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	sentTxsMeter        = metrics.NewRegisteredMeter("executor/txs/sent", nil)
+	receivedBlocksMeter = metrics.NewRegisteredMeter("executor/blocks/received", nil)
+	blockExecTimer      = metrics.NewRegisteredTimer("executor/blocks/exec", nil)
+	blockGasMeter       = metrics.NewRegisteredMeter("executor/blocks/gas", nil) // rate is the gas executed per second
+	execQueueGauge      = metrics.NewRegisteredGauge("executor/queue/exec", nil)
+	blockWriteTimer     = metrics.NewRegisteredTimer("executor/write/time", nil)
+
+	serverCallsMeter  = metrics.NewRegisteredMeter("executor/grpc/server/calls", nil)
+	serverErrorsMeter = metrics.NewRegisteredMeter("executor/grpc/server/errors", nil)
+	clientCallsMeter  = metrics.NewRegisteredMeter("executor/grpc/client/calls", nil)
+	clientErrorsMeter = metrics.NewRegisteredMeter("executor/grpc/client/errors", nil)
+)
+
+// rpcCode returns the gRPC status code a call failed with, the context errors
+// counting as the codes the transport reports them as.
+func rpcCode(err error) codes.Code {
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+	return status.Code(err)
+}
+
+// meterCall records the outcome of a gRPC call, the failures broken down by
+// status code under the errors of the given side.
+func meterCall(side string, calls, errs metrics.Meter, err error) {
+	calls.Mark(1)
+	if err == nil {
+		return
+	}
+	errs.Mark(1)
+	metrics.GetOrRegisterMeter("executor/grpc/"+side+"/errors/"+rpcCode(err).String(), nil).Mark(1)
+}
+
+// meteredUnaryInterceptor meters the unary calls consensus makes into the
+// executor API, leaving the health checks out.
+func meteredUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	res, err := handler(ctx, req)
+	if !isHealthCheck(info.FullMethod) {
+		meterCall("server", serverCallsMeter, serverErrorsMeter, err)
+	}
+	return res, err
+}
+
+// meteredStreamInterceptor meters the streams consensus opens into the executor
+// API once they end, leaving the health watches out.
+func meteredStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, ss)
+	if !isHealthCheck(info.FullMethod) {
+		meterCall("server", serverCallsMeter, serverErrorsMeter, err)
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"success", nil, codes.OK},
+		{"status", status.Error(codes.FailedPrecondition, "stale parent"), codes.FailedPrecondition},
+		{"custom status", &staleParentError{head: &types.Header{Number: common.Big1}}, codes.FailedPrecondition},
+		{"deadline", fmt.Errorf("send: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{"canceled", context.Canceled, codes.Canceled},
+		{"plain", errors.New("boom"), codes.Unknown},
+	}
+	for _, tt := range tests {
+		if have := rpcCode(tt.err); have != tt.code {
+			t.Errorf("%s: code mismatch: have %v, want %v", tt.name, have, tt.code)
+		}
+	}
+}
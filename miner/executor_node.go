@@ -0,0 +1,63 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ExecutorOnAuth is the node endpoint the executor gRPC API can be served on
+// instead of a listener of its own, see Config.ExecutorEndpoint. Consensus
+// authenticates with the JWT secret of the node.
+const ExecutorOnAuth = "auth"
+
+var (
+	errExecutorEndpoint = errors.New("executor API only served on the authenticated endpoint")
+	errEndpointTLS      = errors.New("executor TLS client authentication not enforced on a node endpoint")
+)
+
+// NodeHandler serves the executor gRPC API on a node endpoint.
+type NodeHandler struct {
+	e *executor
+}
+
+func (h *NodeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.e.server.ServeHTTP(w, r)
+}
+
+// Drain stops taking consensus blocks and waits for the ones being delivered,
+// before the node endpoint shuts down.
+func (h *NodeHandler) Drain() {
+	h.e.drain()
+}
+
+// onNode reports whether the executor gRPC API is served on a node endpoint.
+func (e *executor) onNode() bool {
+	return e.config.ExecutorEndpoint != ""
+}
+
+// nodeHandler returns the executor gRPC API to be mounted on the authenticated
+// node endpoint, nil if the API listens on its own. The endpoint is plaintext,
+// the certificates of consensus can't be checked.
+func (e *executor) nodeHandler() (*NodeHandler, error) {
+	switch endpoint := e.config.ExecutorEndpoint; endpoint {
+	case "":
+		return nil, nil
+	case ExecutorOnAuth:
+		if e.config.ExecutorTLSCA != "" {
+			return nil, errEndpointTLS
+		}
+		if e.config.ExecutorTLSCert != "" {
+			log.Warn("Executor TLS only securing the connections to consensus", "endpoint", endpoint)
+		}
+		// The gateway has nothing to dial
+		if e.config.GatewayAddr != "" {
+			log.Warn("Executor gateway not served on a node endpoint", "endpoint", endpoint)
+		}
+		return &NodeHandler{e: e}, nil
+	default:
+		return nil, fmt.Errorf("%w, not %q", errExecutorEndpoint, endpoint)
+	}
+}
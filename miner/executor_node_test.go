@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestExecutorOnNode(t *testing.T) {
+	config := *testConfig
+	config.DrainTimeout = time.Second
+	e := &executor{config: &config, alerter: newAlerter(&config), exitCh: make(chan struct{})}
+	e.server = grpc.NewServer()
+	pb.RegisterExecutorServer(e.server, &executorServer{executorPtr: e})
+	e.health = registerHealth(e.server)
+
+	// The API listens on its own unless told otherwise, and is only mounted on
+	// the authenticated endpoint without client certificates to check
+	if handler, err := e.nodeHandler(); handler != nil || err != nil {
+		t.Fatalf("handler mismatch: have %v, %v, want none", handler, err)
+	}
+	config.ExecutorEndpoint = "http"
+	if _, err := e.nodeHandler(); !errors.Is(err, errExecutorEndpoint) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errExecutorEndpoint)
+	}
+	config.ExecutorEndpoint, config.ExecutorTLSCA = ExecutorOnAuth, "ca.crt"
+	if _, err := e.nodeHandler(); !errors.Is(err, errEndpointTLS) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errEndpointTLS)
+	}
+	config.ExecutorTLSCA = ""
+	handler, err := e.nodeHandler()
+	if err != nil {
+		t.Fatalf("failed to mount on the authenticated endpoint: %v", err)
+	}
+	// Consensus reaches the executor over the cleartext HTTP/2 of the node
+	srv := httptest.NewServer(h2c.NewHandler(handler, new(http2.Server)))
+	defer srv.Close()
+
+	conn, err := grpc.Dial(srv.Listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := pb.NewExecutorClient(conn).Heartbeat(context.Background(), &pb.HeartbeatRequest{}); err != nil {
+		t.Fatalf("failed to call executor: %v", err)
+	}
+	// Streams left open don't keep the executor from draining and shutting down
+	watch, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("failed to watch health: %v", err)
+	}
+	if _, err := watch.Recv(); err != nil {
+		t.Fatalf("failed to receive health: %v", err)
+	}
+	handler.Drain()
+	if _, err := (&executorServer{executorPtr: e}).commitBlock(context.Background(), newTestExecBlock(t, pendingTxs[0])); !errors.Is(err, errExecutorStopping) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errExecutorStopping)
+	}
+	close(e.exitCh)
+	e.cutServer()
+}
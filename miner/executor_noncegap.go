@@ -0,0 +1,198 @@
+package miner
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	nonceGapGauge       = metrics.NewRegisteredGauge("executor/noncegap/senders", nil)
+	nonceGapRepairMeter = metrics.NewRegisteredMeter("executor/noncegap/repaired", nil)
+)
+
+// NonceGap is a sender whose txs forwarded to consensus can't be included, as
+// the tx with the nonce in between was lost on its way to consensus. Consensus
+// keeps ordering the later txs, which the execution keeps skipping, until the
+// missing one is forwarded again.
+type NonceGap struct {
+	Sender    common.Address `json:"sender"`
+	Nonce     uint64         `json:"nonce"`     // missing nonce, the next one of the sender on chain
+	Forwarded uint64         `json:"forwarded"` // lowest nonce forwarded and awaiting inclusion
+	Txs       int            `json:"txs"`       // forwarded txs stuck behind the gap
+	InPool    bool           `json:"inPool"`    // whether the pool holds the missing tx
+	Since     time.Time      `json:"since"`     // time the nonce of the sender stalled
+	Repaired  int            `json:"repaired"`  // times the missing tx was forwarded again
+
+	stalled  bool      // whether the gap lasted for NonceGapTimeout
+	repairAt time.Time // time the missing tx was last forwarded again
+}
+
+// nonceGaps tracks the senders whose on-chain nonce stalled below their
+// forwarded txs. Gaps are only reported once stalled for NonceGapTimeout, the
+// missing tx may still be on its way.
+type nonceGaps struct {
+	mu   sync.Mutex
+	gaps map[common.Address]*NonceGap
+}
+
+// nonceGapLoop periodically checks the senders of the forwarded txs for nonce
+// gaps.
+func (e *executor) nonceGapLoop() {
+	defer e.wg.Done()
+	if !e.waitReady() {
+		return
+	}
+	if e.config.NonceGapTimeout == 0 || e.config.Stateless {
+		return
+	}
+	ticker := time.NewTicker(e.config.NonceGapTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.checkNonceGaps(time.Now())
+		case <-e.exitCh:
+			return
+		}
+	}
+}
+
+// checkNonceGaps compares the nonces of the forwarded txs awaiting inclusion
+// with the nonces of their senders on chain, reporting the senders stalled for
+// too long and forwarding their missing tx again if enabled.
+func (e *executor) checkNonceGaps(now time.Time) {
+	statedb, err := e.eth.BlockChain().State()
+	if err != nil {
+		log.Warn("Failed to check nonce gaps", "err", err)
+		return
+	}
+	e.nonceGaps.mu.Lock()
+	gaps := make(map[common.Address]*NonceGap)
+	for from, nonces := range e.inclusion.awaitedNonces() {
+		// Txs replaced or included meanwhile linger until they are purged
+		next := statedb.GetNonce(from)
+		lowest, txs := uint64(0), 0
+		for _, nonce := range nonces {
+			if nonce < next {
+				continue
+			}
+			if txs == 0 || nonce < lowest {
+				lowest = nonce
+			}
+			txs++
+		}
+		if txs == 0 || lowest == next {
+			continue
+		}
+		gap := e.nonceGaps.gaps[from]
+		if gap == nil || gap.Nonce != next {
+			gap = &NonceGap{Sender: from, Nonce: next, Since: now}
+		}
+		gap.Forwarded, gap.Txs = lowest, txs
+		gaps[from] = gap
+	}
+	e.nonceGaps.gaps = gaps
+
+	var stalled []*NonceGap
+	for _, gap := range gaps {
+		if gap.stalled = now.Sub(gap.Since) >= e.config.NonceGapTimeout; gap.stalled {
+			stalled = append(stalled, gap)
+		}
+	}
+	sort.Slice(stalled, func(i, j int) bool { return stalled[i].Since.Before(stalled[j].Since) })
+	nonceGapGauge.Update(int64(len(stalled)))
+
+	var repairs []*nonceGapRepair
+	for _, gap := range stalled {
+		if repair := e.missingTx(gap, now); repair != nil {
+			repairs = append(repairs, repair)
+		}
+	}
+	if len(stalled) == 0 {
+		e.alerter.resolve(AlertNonceGap)
+	} else {
+		e.alerter.raise(AlertNonceGap, fmt.Sprintf("%d senders stalled by nonce gaps, oldest %x missing nonce %d", len(stalled), stalled[0].Sender, stalled[0].Nonce))
+	}
+	e.nonceGaps.mu.Unlock()
+
+	// Forward the missing txs without holding up the gap reports meanwhile
+	for _, repair := range repairs {
+		e.repairNonceGap(repair, now)
+	}
+}
+
+// nonceGapRepair is the missing tx of a stalled sender to forward again.
+type nonceGapRepair struct {
+	gap     *NonceGap
+	missing *txpool.LazyTransaction
+	local   bool
+}
+
+// missingTx looks the missing tx of a gap up in the pool, returning it if it's
+// to be forwarded again: if enabled, at most once per NonceGapTimeout. The
+// nonce gaps must be locked.
+func (e *executor) missingTx(gap *NonceGap, now time.Time) *nonceGapRepair {
+	var missing *txpool.LazyTransaction
+	pending, _ := e.eth.TxPool().ContentFrom(gap.Sender)
+	for _, tx := range pending {
+		if tx.Nonce() == gap.Nonce {
+			missing = &txpool.LazyTransaction{Hash: tx.Hash(), Tx: tx, Time: tx.Time()}
+			break
+		}
+	}
+	gap.InPool = missing != nil
+	if missing == nil {
+		log.Warn("Sender stalled by nonce gap, missing tx not in pool", "sender", gap.Sender, "nonce", gap.Nonce, "forwarded", gap.Forwarded, "since", gap.Since)
+		return nil
+	}
+	if !e.config.NonceGapRepair || now.Sub(gap.repairAt) < e.config.NonceGapTimeout {
+		log.Warn("Sender stalled by nonce gap", "sender", gap.Sender, "nonce", gap.Nonce, "forwarded", gap.Forwarded, "since", gap.Since)
+		return nil
+	}
+	repair := &nonceGapRepair{gap: gap, missing: missing}
+	for _, account := range e.eth.TxPool().Locals() {
+		repair.local = repair.local || account == gap.Sender
+	}
+	return repair
+}
+
+// repairNonceGap forwards the missing tx of a gap again. The nonce gaps must
+// not be locked, consensus may take its time to take the tx.
+func (e *executor) repairNonceGap(repair *nonceGapRepair, now time.Time) {
+	gap, missing := repair.gap, repair.missing
+	if err := e.forwardTx(missing, missing.Tx, repair.local); err != nil {
+		log.Warn("Failed to forward missing tx of nonce gap", "sender", gap.Sender, "nonce", gap.Nonce, "err", err)
+		return
+	}
+	e.nonceGaps.mu.Lock()
+	defer e.nonceGaps.mu.Unlock()
+
+	gap.Repaired++
+	gap.repairAt = now
+	nonceGapRepairMeter.Mark(1)
+	log.Info("Forwarded missing tx of nonce gap", "sender", gap.Sender, "nonce", gap.Nonce, "hash", missing.Hash, "attempt", gap.Repaired)
+}
+
+// stalledNonceGaps returns the senders stalled by nonce gaps for longer than
+// NonceGapTimeout, ordered by the time they stalled.
+func (e *executor) stalledNonceGaps() []NonceGap {
+	e.nonceGaps.mu.Lock()
+	defer e.nonceGaps.mu.Unlock()
+
+	stalled := make([]NonceGap, 0)
+	for _, gap := range e.nonceGaps.gaps {
+		if gap.stalled {
+			stalled = append(stalled, *gap)
+		}
+	}
+	sort.Slice(stalled, func(i, j int) bool { return stalled[i].Since.Before(stalled[j].Since) })
+	return stalled
+}
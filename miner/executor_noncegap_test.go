@@ -0,0 +1,110 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestNonceGapRepair(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.NonceGapTimeout = time.Minute
+	cfg.NonceGapRepair = true
+
+	p2p := new(testP2PClient)
+	e := &executor{config: &cfg, chainConfig: ethashChainConfig, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg), inclusion: newInclusionTracker(nil)}
+	e.execClient = &executorClient{p2pClient: p2p}
+
+	// The first tx of the sender got lost, the later ones are awaited
+	var (
+		signer = types.LatestSigner(ethashChainConfig)
+		txs    = make(types.Transactions, 3)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	for _, tx := range txs[1:] {
+		e.inclusion.forwarded(tx, time.Now(), testBankAddress, false, txpool.Origin{})
+	}
+	// Gaps are given time to fill before being reported
+	now := time.Now()
+	e.checkNonceGaps(now)
+	if gaps := e.stalledNonceGaps(); len(gaps) != 0 {
+		t.Fatalf("gap reported before timeout: %v", gaps)
+	}
+	// Stalled gaps are reported, the missing tx can't be forwarded unless in
+	// the pool
+	now = now.Add(time.Minute)
+	e.checkNonceGaps(now)
+	gaps := e.stalledNonceGaps()
+	if len(gaps) != 1 {
+		t.Fatalf("gaps mismatch: have %d, want 1", len(gaps))
+	}
+	if gap := gaps[0]; gap.Sender != testBankAddress || gap.Nonce != 0 || gap.Forwarded != 1 || gap.Txs != 2 || gap.InPool || gap.Repaired != 0 {
+		t.Errorf("gap mismatch: have %+v", gap)
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 1 || alerts[0].Kind != AlertNonceGap {
+		t.Errorf("alerts mismatch: have %v, want %s", alerts, AlertNonceGap)
+	}
+	if p2p.sent != 0 {
+		t.Fatalf("forwarded %d txs without the missing one in the pool", p2p.sent)
+	}
+	// Once in the pool, the missing tx is forwarded again, closing the gap
+	p2p.onSend = func() {
+		if !e.nonceGaps.mu.TryLock() {
+			t.Errorf("nonce gaps locked while forwarding")
+			return
+		}
+		e.nonceGaps.mu.Unlock()
+	}
+	for _, err := range backend.txPool.Add(txs[:1], true, true) {
+		if err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	now = now.Add(time.Second)
+	e.checkNonceGaps(now)
+	if gaps := e.stalledNonceGaps(); len(gaps) != 1 || !gaps[0].InPool || gaps[0].Repaired != 1 {
+		t.Fatalf("gap not repaired: %+v", gaps)
+	}
+	if p2p.sent != 1 {
+		t.Fatalf("forwarded txs mismatch: have %d, want 1", p2p.sent)
+	}
+	e.checkNonceGaps(now.Add(time.Second))
+	if gaps := e.stalledNonceGaps(); len(gaps) != 0 {
+		t.Errorf("repaired gap still reported: %v", gaps)
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 0 {
+		t.Errorf("alert not resolved: %v", alerts)
+	}
+}
+
+func TestNonceGapContiguous(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.NonceGapTimeout = time.Minute
+
+	e := &executor{config: &cfg, chainConfig: ethashChainConfig, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg), inclusion: newInclusionTracker(nil)}
+
+	// Senders awaiting their next nonce aren't stalled, however old the txs
+	e.inclusion.forwarded(pendingTxs[0], time.Now(), testBankAddress, false, txpool.Origin{})
+	e.inclusion.forwarded(newTxs[0], time.Now(), testBankAddress, false, txpool.Origin{})
+
+	now := time.Now()
+	e.checkNonceGaps(now)
+	e.checkNonceGaps(now.Add(time.Hour))
+	if gaps := e.stalledNonceGaps(); len(gaps) != 0 {
+		t.Errorf("gap reported for contiguous nonces: %v", gaps)
+	}
+}
@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// errEmptyBlock is returned if a consensus block executed synchronously has
+// neither decodable txs nor anything to record in its header, in which case the
+// executor doesn't produce one.
+var errEmptyBlock = errors.New("no executable txs in block")
+
+// errNotHead is returned by ExecuteBlock if the executed block was written but
+// the fork choice rule of the chain refused to make it the head.
+var errNotHead = errors.New("executed block not set as head")
+
+// ExecuteBlock runs a consensus block through the execution pipeline of the
+// executor on top of the current head of the backend chain, exactly like a
+// block delivered via CommitBlock, and returns the written block along with
+// its receipts. Unlike CommitBlock, the block is executed synchronously with
+// the given header timestamp, and neither the gRPC server nor the connection
+// to the consensus layer is started, making it suitable for offline tooling.
+func ExecuteBlock(eth Backend, config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, block *pb.ExecBlock, timestamp uint64) (*types.Block, types.Receipts, error) {
+	e := &executor{
+		config:      config,
+		chainConfig: chainConfig,
+		engine:      engine,
+		eth:         eth,
+		alerter:     newAlerter(config),
+	}
+	e.minTip.Store(new(big.Int))
+	return e.executeSync(block, int64(timestamp))
+}
+
+// executeSync executes a consensus block with the given timestamp on top of the
+// current head, returning the written block along with its receipts.
+func (e *executor) executeSync(block *pb.ExecBlock, timestamp int64) (*types.Block, types.Receipts, error) {
+	req, _, err := decodeExecBlock(block, e.verified)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain := e.eth.BlockChain()
+	parent := chain.CurrentBlock()
+	if req == nil || e.emptyBlock(req, parent) {
+		return nil, nil, errEmptyBlock
+	}
+	req.timestamp = timestamp
+	if err := e.executeNewTxBatch(req); err != nil {
+		return nil, nil, err
+	}
+	if err := e.settleWrite(); err != nil {
+		return nil, nil, err
+	}
+	if err := e.settleCommits(math.MaxUint64); err != nil {
+		return nil, nil, err
+	}
+	head := chain.CurrentBlock()
+	if head.ParentHash != parent.Hash() {
+		return nil, nil, errNotHead
+	}
+	written := chain.GetBlockByHash(head.Hash())
+	return written, chain.GetReceiptsByHash(written.Hash()), nil
+}
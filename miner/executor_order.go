@@ -0,0 +1,162 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxHeldBlocks is the number of consensus blocks held back awaiting their
+// predecessors, further ones are rejected for consensus to retry them later.
+const maxHeldBlocks = 1024
+
+var (
+	heldBlocksGauge = metrics.NewRegisteredGauge("executor/order/held", nil)
+	reorderedMeter  = metrics.NewRegisteredMeter("executor/order/reordered", nil)
+	skippedSeqMeter = metrics.NewRegisteredMeter("executor/order/skipped", nil)
+
+	errTooManyHeld = errors.New("too many consensus blocks awaiting their predecessors")
+)
+
+// blockOrder hands the consensus blocks over to the execution loop in the order
+// of their consensus sequence, holding back the blocks delivered ahead of their
+// predecessors. The first block delivered starts the sequence unless resumed
+// from the head.
+type blockOrder struct {
+	lock sync.Mutex          // held while handing blocks over, so they're queued in order
+	next uint64              // sequence of the next block to execute, zero until known
+	held map[uint64]*execReq // blocks delivered ahead of their predecessors, nil if nothing to execute
+	gap  *time.Timer         // timer skipping the missing blocks, nil if not armed
+}
+
+// resumeOrder continues the consensus sequence after the one of the head.
+func (e *executor) resumeOrder() {
+	chain := e.eth.BlockChain()
+	if meta := chain.GetConsensusMeta(chain.CurrentBlock().Hash()); meta != nil && meta.Sequence != 0 {
+		e.order.next = meta.Sequence + 1
+	}
+}
+
+// queueBlock hands a consensus block over to the execution loop once all the
+// blocks preceding it in the consensus sequence were, holding it back until
+// then. A nil request has nothing to execute but still takes its turn. Blocks
+// without sequence and those of competing branches aren't ordered.
+func (e *executor) queueBlock(seq uint64, req *execReq) error {
+	if seq == 0 || (req != nil && req.branch != "") {
+		return e.dispatchBlock(seq, req)
+	}
+	o := &e.order
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	switch {
+	case o.next == 0:
+		o.next = seq
+
+	case seq < o.next:
+		// Consensus went back on its sequence, nothing to wait for
+		log.Debug("Consensus block behind the sequence", "sequence", seq, "next", o.next)
+		return e.dispatchBlock(seq, req)
+
+	case seq > o.next:
+		if _, ok := o.held[seq]; !ok && len(o.held) >= maxHeldBlocks {
+			return fmt.Errorf("%w: sequence %d, awaiting %d", errTooManyHeld, seq, o.next)
+		}
+		if o.held == nil {
+			o.held = make(map[uint64]*execReq)
+		}
+		o.held[seq] = req
+		reorderedMeter.Mark(1)
+		heldBlocksGauge.Update(int64(len(o.held)))
+		log.Debug("Holding back consensus block ahead of its predecessors", "sequence", seq, "awaiting", o.next)
+
+		e.armGap()
+		return nil
+	}
+	return e.releaseBlocks(req)
+}
+
+// releaseBlocks hands the next block of the sequence over, along with the held
+// blocks following it. The order lock is held.
+func (e *executor) releaseBlocks(req *execReq) error {
+	o := &e.order
+	err := e.dispatchBlock(o.next, req)
+	for err == nil {
+		o.next++
+		next, ok := o.held[o.next]
+		if !ok {
+			break
+		}
+		delete(o.held, o.next)
+		err = e.dispatchBlock(o.next, next)
+	}
+	heldBlocksGauge.Update(int64(len(o.held)))
+
+	if len(o.held) == 0 && o.gap != nil {
+		o.gap.Stop()
+		o.gap = nil
+	}
+	return err
+}
+
+// armGap starts waiting for the blocks missing from the sequence, if not
+// waiting already. The order lock is held.
+func (e *executor) armGap() {
+	if timeout := e.config.SequenceTimeout; timeout > 0 && e.order.gap == nil {
+		e.order.gap = time.AfterFunc(timeout, e.skipGap)
+	}
+}
+
+// skipGap gives up on the blocks missing from the sequence, executing the held
+// blocks from the first one on.
+func (e *executor) skipGap() {
+	o := &e.order
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.gap = nil
+	if len(o.held) == 0 {
+		return
+	}
+	first := uint64(0)
+	for seq := range o.held {
+		if first == 0 || seq < first {
+			first = seq
+		}
+	}
+	log.Warn("Skipping consensus blocks missing from the sequence", "from", o.next, "to", first-1, "timeout", e.config.SequenceTimeout)
+	skippedSeqMeter.Mark(int64(first - o.next))
+
+	req := o.held[first]
+	delete(o.held, first)
+	o.next = first
+	if err := e.releaseBlocks(req); err != nil {
+		log.Warn("Failed to queue held consensus blocks", "err", err)
+	}
+	if len(o.held) > 0 {
+		e.armGap()
+	}
+}
+
+// dispatchBlock hands a block over to the execution loop, or marks it executed
+// right away if there's nothing to execute.
+func (e *executor) dispatchBlock(seq uint64, req *execReq) error {
+	if req == nil {
+		e.markExecuted(seq)
+		return nil
+	}
+	queued := &e.execStats.queued
+	execQueueGauge.Update(int64(queued.Add(1)))
+	defer func() { execQueueGauge.Update(int64(queued.Add(-1))) }()
+
+	select {
+	case e.execCh <- req:
+		return nil
+	case <-e.exitCh:
+		return errExecutorStopping
+	}
+}
@@ -0,0 +1,107 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestOrderedExecution(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.SequenceTimeout = 100 * time.Millisecond
+	e := &executor{
+		config:      &cfg,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		confirmCh:   make(chan *confirmReq),
+		alerter:     newAlerter(&cfg),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	var (
+		es     = &executorServer{executorPtr: e}
+		signer = types.LatestSigner(&config)
+		chain  = backend.chain
+	)
+	block := func(seq uint64, nonce uint64) *pb.ExecBlock {
+		tx := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		pbBlock := newTestExecBlock(t, tx)
+		pbBlock.Sequence, pbBlock.AwaitResult = seq, true
+		return pbBlock
+	}
+	commit := func(pbBlock *pb.ExecBlock) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			_, err := es.CommitBlock(context.Background(), pbBlock)
+			errc <- err
+		}()
+		return errc
+	}
+	held := func() int {
+		e.order.lock.Lock()
+		defer e.order.lock.Unlock()
+		return len(e.order.held)
+	}
+	// The first block starts the sequence
+	if err := <-commit(block(1, 0)); err != nil {
+		t.Fatalf("failed to commit block 1: %v", err)
+	}
+	// A block delivered ahead of its predecessor waits for it
+	third := commit(block(3, 2))
+	for held() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 1 {
+		t.Fatalf("head mismatch while held: have %d, want 1", head)
+	}
+	if err := <-commit(block(2, 1)); err != nil {
+		t.Fatalf("failed to commit block 2: %v", err)
+	}
+	if err := <-third; err != nil {
+		t.Fatalf("failed to commit block 3: %v", err)
+	}
+	for number := uint64(1); number <= 3; number++ {
+		txs := chain.GetBlockByNumber(number).Transactions()
+		if len(txs) != 1 || txs[0].Nonce() != number-1 {
+			t.Fatalf("block %d txs mismatch: have %v", number, txs)
+		}
+	}
+	// Blocks missing from the sequence are skipped once timed out
+	start := time.Now()
+	if err := <-commit(block(5, 3)); err != nil {
+		t.Fatalf("failed to commit block 5: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.SequenceTimeout {
+		t.Errorf("gap skipped early: after %v, timeout %v", elapsed, cfg.SequenceTimeout)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 4 || held() != 0 {
+		t.Errorf("head after gap mismatch: have %d held %d, want 4 held 0", head, held())
+	}
+	// Blocks without sequence aren't ordered
+	if err := <-commit(block(0, 4)); err != nil {
+		t.Fatalf("failed to commit unsequenced block: %v", err)
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 5 {
+		t.Errorf("head mismatch: have %d, want 5", head)
+	}
+}
@@ -0,0 +1,148 @@
+package miner
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var errOrderingMismatch = errors.New("tx ordering mismatch")
+
+// pbOrderings maps the tx ordering rules to their handshake representation.
+var pbOrderings = map[params.TxOrdering]pb.TxOrdering{
+	params.TxOrderingConsensus: pb.TxOrdering_CONSENSUS_ORDER,
+	params.TxOrderingNonce:     pb.TxOrdering_SENDER_NONCE_ORDER,
+	params.TxOrderingTip:       pb.TxOrdering_TIP_ORDER,
+}
+
+// Handshake negotiates the block compression with the consensus layer and
+// advertises the tx ordering of the chain: the executor picks the best
+// compression both sides support, and refuses consensus expecting another
+// ordering than the one all replicas execute with.
+func (es *executorServer) Handshake(ctx context.Context, req *pb.HandshakeRequest) (*pb.HandshakeResponse, error) {
+	res := &pb.HandshakeResponse{
+		Compression: negotiateCompression(req.GetCompressions()),
+		Ordering:    pb.TxOrdering_CONSENSUS_ORDER,
+	}
+	if e := es.executorPtr; e != nil {
+		res.Ordering = pbOrderings[e.chainConfig.Executor.Ordering()]
+	}
+	if want := req.GetOrdering(); want != pb.TxOrdering_TX_ORDERING_UNSPECIFIED && want != res.Ordering {
+		return nil, fmt.Errorf("%w: have %v, want %v", errOrderingMismatch, res.Ordering, want)
+	}
+	return res, nil
+}
+
+// orderTxs sorts the txs of a consensus block per the tx ordering rule of the
+// chain. The txs of coalesced consensus blocks, given by origins, are sorted
+// block by block so each block's txs stay contiguous.
+func (e *executor) orderTxs(env *executor_env, txs types.Transactions, origins []int) types.Transactions {
+	ordering := e.chainConfig.Executor.Ordering()
+	if ordering == params.TxOrderingConsensus || len(txs) < 2 {
+		return txs
+	}
+	if len(origins) != len(txs) {
+		return sortTxs(ordering, env.signer, env.header.BaseFee, txs)
+	}
+	ordered := make(types.Transactions, 0, len(txs))
+	for start, end := 0, 0; start < len(txs); start = end {
+		for end = start + 1; end < len(txs) && origins[end] == origins[start]; end++ {
+		}
+		ordered = append(ordered, sortTxs(ordering, env.signer, env.header.BaseFee, txs[start:end])...)
+	}
+	return ordered
+}
+
+// sortTxs sorts txs per the given ordering, keeping the txs of every sender in
+// nonce order. Txs with an invalid signature go last in their original order,
+// they will be rejected anyway.
+func sortTxs(ordering params.TxOrdering, signer types.Signer, baseFee *big.Int, txs types.Transactions) types.Transactions {
+	var (
+		senders []common.Address
+		groups  = make(map[common.Address][]indexedTx)
+		invalid types.Transactions
+	)
+	for i, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			invalid = append(invalid, tx)
+			continue
+		}
+		if _, ok := groups[from]; !ok {
+			senders = append(senders, from)
+		}
+		groups[from] = append(groups[from], indexedTx{tx: tx, index: i})
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].tx.Nonce() < group[j].tx.Nonce() })
+	}
+	ordered := make(types.Transactions, 0, len(txs))
+	switch ordering {
+	case params.TxOrderingNonce:
+		for _, from := range senders {
+			for _, itx := range groups[from] {
+				ordered = append(ordered, itx.tx)
+			}
+		}
+	case params.TxOrderingTip:
+		heads := &txsByTip{baseFee: baseFee}
+		for _, from := range senders {
+			heads.groups = append(heads.groups, groups[from])
+		}
+		heap.Init(heads)
+		for heads.Len() > 0 {
+			group := heads.groups[0]
+			ordered = append(ordered, group[0].tx)
+			if len(group) > 1 {
+				heads.groups[0] = group[1:]
+				heap.Fix(heads, 0)
+			} else {
+				heap.Pop(heads)
+			}
+		}
+	}
+	return append(ordered, invalid...)
+}
+
+// indexedTx is a tx along with its position in the consensus block.
+type indexedTx struct {
+	tx    *types.Transaction
+	index int
+}
+
+// txsByTip is a heap of the nonce ordered txs of every sender, sorted by the
+// effective tip of their next tx and the position of that tx in the block.
+type txsByTip struct {
+	groups  [][]indexedTx
+	baseFee *big.Int
+}
+
+func (h *txsByTip) Len() int { return len(h.groups) }
+
+func (h *txsByTip) Less(i, j int) bool {
+	a, b := h.groups[i][0], h.groups[j][0]
+	if cmp := a.tx.EffectiveGasTipCmp(b.tx, h.baseFee); cmp != 0 {
+		return cmp > 0
+	}
+	return a.index < b.index
+}
+
+func (h *txsByTip) Swap(i, j int) { h.groups[i], h.groups[j] = h.groups[j], h.groups[i] }
+
+func (h *txsByTip) Push(x interface{}) { h.groups = append(h.groups, x.([]indexedTx)) }
+
+func (h *txsByTip) Pop() interface{} {
+	old := h.groups
+	n := len(old)
+	x := old[n-1]
+	h.groups = old[:n-1]
+	return x
+}
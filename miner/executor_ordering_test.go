@@ -0,0 +1,84 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestOrderTxs(t *testing.T) {
+	var (
+		other, _ = crypto.GenerateKey()
+		signer   = types.LatestSigner(params.TestChainConfig)
+	)
+	tx := func(key string, nonce uint64, price int64) *types.Transaction {
+		k := testBankKey
+		switch key {
+		case "user":
+			k = testUserKey
+		case "other":
+			k = other
+		}
+		return types.MustSignNewTx(k, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(price)})
+	}
+	txs := types.Transactions{
+		tx("user", 1, 5),
+		tx("bank", 0, 1),
+		tx("user", 0, 2),
+		tx("other", 0, 9),
+		tx("bank", 1, 10),
+	}
+	tests := []struct {
+		ordering params.TxOrdering
+		origins  []int
+		want     []int
+	}{
+		{ordering: "", want: []int{0, 1, 2, 3, 4}},
+		{ordering: params.TxOrderingNonce, want: []int{2, 0, 1, 4, 3}},
+		{ordering: params.TxOrderingTip, want: []int{3, 2, 0, 1, 4}},
+		{ordering: params.TxOrderingNonce, origins: []int{0, 0, 0, 1, 1}, want: []int{2, 0, 1, 3, 4}},
+	}
+	for _, tt := range tests {
+		config := *params.TestChainConfig
+		config.Executor = &params.ExecutorConfig{TxOrdering: tt.ordering}
+
+		e := &executor{chainConfig: &config}
+		env := &executor_env{signer: signer, header: &types.Header{}}
+
+		have := e.orderTxs(env, txs, tt.origins)
+		if len(have) != len(tt.want) {
+			t.Fatalf("ordering %q: tx count mismatch: have %d, want %d", tt.ordering, len(have), len(tt.want))
+		}
+		for i, idx := range tt.want {
+			if have[i] != txs[idx] {
+				t.Errorf("ordering %q: tx %d mismatch: have %x, want %x (tx %d)", tt.ordering, i, have[i].Hash(), txs[idx].Hash(), idx)
+			}
+		}
+	}
+}
+
+func TestHandshakeOrdering(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Executor = &params.ExecutorConfig{TxOrdering: params.TxOrderingTip}
+	es := &executorServer{executorPtr: &executor{chainConfig: &config}}
+
+	res, err := es.Handshake(context.Background(), &pb.HandshakeRequest{})
+	if err != nil {
+		t.Fatalf("failed to handshake: %v", err)
+	}
+	if res.Ordering != pb.TxOrdering_TIP_ORDER {
+		t.Fatalf("ordering mismatch: have %v, want %v", res.Ordering, pb.TxOrdering_TIP_ORDER)
+	}
+	if _, err := es.Handshake(context.Background(), &pb.HandshakeRequest{Ordering: pb.TxOrdering_TIP_ORDER}); err != nil {
+		t.Fatalf("failed to handshake with matching ordering: %v", err)
+	}
+	if _, err := es.Handshake(context.Background(), &pb.HandshakeRequest{Ordering: pb.TxOrdering_CONSENSUS_ORDER}); !errors.Is(err, errOrderingMismatch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errOrderingMismatch)
+	}
+}
@@ -0,0 +1,106 @@
+package miner
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxOriginSources is the number of origins the forwarding and inclusion stats
+// are kept apart for, the txs of any further source are accounted together.
+const maxOriginSources = 1024
+
+// otherSource is the source the txs of the origins beyond maxOriginSources are
+// accounted under.
+const otherSource = "other"
+
+// OriginReport summarizes the txs of a single origin forwarded to consensus,
+// for attributing spam and measuring the inclusion latency per source.
+type OriginReport struct {
+	Kind      string  `json:"kind"`      // channel the txs came through
+	Source    string  `json:"source"`    // RPC client IP or devp2p peer id
+	Forwarded uint64  `json:"forwarded"` // txs forwarded to consensus since startup
+	Included  uint64  `json:"included"`  // forwarded txs included in a block
+	Mean      float64 `json:"meanms"`    // mean inclusion latency in milliseconds
+}
+
+// sourceStats is the forwarding and inclusion stats of an origin.
+type sourceStats struct {
+	forwarded uint64
+	included  uint64
+	latency   time.Duration // sum of the inclusion latencies
+}
+
+func (s *sourceStats) include(latency time.Duration) {
+	s.included++
+	s.latency += latency
+}
+
+// source returns the stats of an origin, creating them if there's room left.
+// The caller must hold the lock.
+func (t *inclusionTracker) source(origin txpool.Origin) *sourceStats {
+	if stats, ok := t.sources[origin]; ok {
+		return stats
+	}
+	if len(t.sources) >= maxOriginSources {
+		origin.Source = otherSource
+		if stats, ok := t.sources[origin]; ok {
+			return stats
+		}
+	}
+	stats := new(sourceStats)
+	t.sources[origin] = stats
+	return stats
+}
+
+// originReports returns the stats of every origin, the most forwarded first.
+func (t *inclusionTracker) originReports() []OriginReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reports := make([]OriginReport, 0, len(t.sources))
+	for origin, stats := range t.sources {
+		report := OriginReport{
+			Kind:      origin.Kind,
+			Source:    origin.Source,
+			Forwarded: stats.forwarded,
+			Included:  stats.included,
+		}
+		if stats.included > 0 {
+			report.Mean = milliseconds(stats.latency / time.Duration(stats.included))
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Forwarded != reports[j].Forwarded {
+			return reports[i].Forwarded > reports[j].Forwarded
+		}
+		return reports[i].Source < reports[j].Source
+	})
+	return reports
+}
+
+// txOrigin returns where the local pool first received a tx from, zero if the
+// tx never went through it or there's no pool, as in the offline execution.
+func (e *executor) txOrigin(tx *types.Transaction) txpool.Origin {
+	pool := e.eth.TxPool()
+	if pool == nil {
+		return txpool.Origin{}
+	}
+	origin, _ := pool.Origin(tx.Hash())
+	return origin
+}
+
+// countOrigins accounts the included txs of a block per origin in its report.
+func (e *executor) countOrigins(r *batchReport, txs types.Transactions) {
+	for _, tx := range txs {
+		switch e.txOrigin(tx).Kind {
+		case txpool.OriginRPC:
+			r.fromRPC++
+		case txpool.OriginPeer:
+			r.fromPeer++
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	staleParentMeter = metrics.NewRegisteredMeter("executor/parent/stale", nil)
+
+	errStaleParent = errors.New("stale parent")
+	errParentHash  = errors.New("invalid parent hash")
+)
+
+// staleParentError is the failure of a consensus block whose expected parent
+// isn't the head of the executor, e.g. after a local rollback. It's returned to
+// consensus as a FAILED_PRECONDITION status detailing both blocks.
+type staleParentError struct {
+	parentHash   common.Hash
+	parentNumber uint64
+	head         *types.Header
+}
+
+func (err *staleParentError) Error() string {
+	return fmt.Sprintf("%v: have head %d [%x], want parent %d [%x]", errStaleParent, err.head.Number, err.head.Hash(), err.parentNumber, err.parentHash)
+}
+
+func (err *staleParentError) Unwrap() error {
+	return errStaleParent
+}
+
+// GRPCStatus converts the failure for consensus.
+func (err *staleParentError) GRPCStatus() *status.Status {
+	s := status.New(codes.FailedPrecondition, err.Error())
+	detailed, derr := s.WithDetails(&pb.StaleParent{
+		ParentHash:   err.parentHash.Bytes(),
+		ParentNumber: err.parentNumber,
+		HeadHash:     err.head.Hash().Bytes(),
+		HeadNumber:   err.head.Number.Uint64(),
+	})
+	if derr != nil {
+		return s
+	}
+	return detailed
+}
+
+// checkParent fails a consensus block expecting another parent than the given
+// head. Blocks without an expected parent are executed on any head.
+func checkParent(req *execReq, head *types.Header) error {
+	if req.parentHash == (common.Hash{}) {
+		return nil
+	}
+	if req.parentHash == head.Hash() && req.parentNumber == head.Number.Uint64() {
+		return nil
+	}
+	return &staleParentError{parentHash: req.parentHash, parentNumber: req.parentNumber, head: head}
+}
+
+// checkDelivered rejects a delivered consensus block whose expected parent was
+// already overtaken by the head, before it's queued. Parents above the head may
+// still be queued for execution, those are checked once the block executes.
+func (e *executor) checkDelivered(req *execReq) error {
+	if req == nil || req.parentHash == (common.Hash{}) {
+		return nil
+	}
+	head := e.eth.BlockChain().CurrentBlock()
+	if req.parentNumber > head.Number.Uint64() {
+		return nil
+	}
+	if err := checkParent(req, head); err != nil {
+		staleParentMeter.Mark(1)
+		return err
+	}
+	return nil
+}
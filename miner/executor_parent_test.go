@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStaleParent(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	client := new(testConsensusClient)
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, execClient: &executorClient{consensusClient: client}, alerter: newAlerter(testConfig)}
+
+	signer := types.LatestSigner(&config)
+	txs := make([]*types.Transaction, 3)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	execute := func(tx *types.Transaction, parent *types.Header) error {
+		block := newTestExecBlock(t, tx)
+		block.ParentHash, block.ParentNumber = parent.Hash().Bytes(), parent.Number.Uint64()
+		req, _, err := decodeExecBlock(block, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		return e.executeNewTxBatch(req)
+	}
+	genesis := backend.chain.CurrentBlock()
+	if err := execute(txs[0], genesis); err != nil {
+		t.Fatalf("failed to execute block on its parent: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	if head.Number.Uint64() != 1 {
+		t.Fatalf("head mismatch: have %d, want 1", head.Number)
+	}
+	// Blocks expecting an overtaken parent are skipped and reported
+	err := execute(txs[1], genesis)
+	if !errors.Is(err, errStaleParent) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errStaleParent)
+	}
+	if have := backend.chain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", have, head.Hash())
+	}
+	if len(client.faults) != 1 || client.faults[0].Type != pb.FaultType_STALE_PARENT || client.faults[0].BlockNumber != 2 {
+		t.Fatalf("fault mismatch: have %v, want %v at 2", client.faults, pb.FaultType_STALE_PARENT)
+	}
+	// Consensus is told which chains to reconcile
+	s := status.Convert(err)
+	if s.Code() != codes.FailedPrecondition {
+		t.Fatalf("status code mismatch: have %v, want %v", s.Code(), codes.FailedPrecondition)
+	}
+	if len(s.Details()) != 1 {
+		t.Fatalf("status details mismatch: have %d, want 1", len(s.Details()))
+	}
+	detail, ok := s.Details()[0].(*pb.StaleParent)
+	if !ok {
+		t.Fatalf("status detail mismatch: have %T, want %T", s.Details()[0], detail)
+	}
+	if detail.HeadNumber != 1 || common.BytesToHash(detail.HeadHash) != head.Hash() || detail.ParentNumber != 0 || common.BytesToHash(detail.ParentHash) != genesis.Hash() {
+		t.Fatalf("status detail mismatch: have %v", detail)
+	}
+	// Overtaken parents are rejected on delivery, parents still to be executed
+	// are checked on execution
+	if err := e.checkDelivered(&execReq{parentHash: genesis.Hash()}); !errors.Is(err, errStaleParent) {
+		t.Fatalf("delivery error mismatch: have %v, want %v", err, errStaleParent)
+	}
+	if err := e.checkDelivered(&execReq{parentHash: common.Hash{0x01}, parentNumber: 2}); err != nil {
+		t.Fatalf("failed to deliver block on queued parent: %v", err)
+	}
+	if err := execute(txs[1], head); err != nil {
+		t.Fatalf("failed to execute block on its parent: %v", err)
+	}
+	// Malformed parents are rejected
+	block := newTestExecBlock(t, txs[2])
+	block.ParentHash = []byte{0x01}
+	if _, _, err := decodeExecBlock(block, nil); !errors.Is(err, errParentHash) {
+		t.Fatalf("decode error mismatch: have %v, want %v", err, errParentHash)
+	}
+}
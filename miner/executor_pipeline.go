@@ -0,0 +1,203 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	pipelineWaitTimer  = metrics.NewRegisteredTimer("executor/pipeline/wait", nil)
+	pipelineStaleMeter = metrics.NewRegisteredMeter("executor/pipeline/stale", nil)
+)
+
+// inflightWrite is an executed consensus block written to the chain in the
+// background while the next one is executed on top of it.
+//
+// The pipeline is one block deep. The state of a block is committed to the
+// trie database by the execution loop before handing the block off, so the next
+// block can open it right away. Writing the block, its receipts and the new head
+// along with flushing and garbage collecting the tries then overlaps with the
+// execution of the next block. That one is only committed once its parent
+// landed, so the blocks reach WriteCommittedBlockAndSetHead in order and the
+// tries are never mutated by both at the same time.
+type inflightWrite struct {
+	req    *execReq
+	parent *types.Header // head the block was executed on
+	start  time.Time     // start of the execution of the block
+	env    *executor_env
+	block  *types.Block
+
+	err  error         // outcome of the write, set once done is closed
+	done chan struct{} // closed once the write finished
+}
+
+// pipelineChain is the chain extended by the block being written, serving its
+// header to the block executed on top of it meanwhile, e.g. for BLOCKHASH.
+type pipelineChain struct {
+	*core.BlockChain
+	head *types.Header
+	hash common.Hash
+}
+
+func (c *pipelineChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *pipelineChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == c.hash && number == c.head.Number.Uint64() {
+		return c.head
+	}
+	return c.BlockChain.GetHeader(hash, number)
+}
+
+func (c *pipelineChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	if hash == c.hash {
+		return c.head
+	}
+	return c.BlockChain.GetHeaderByHash(hash)
+}
+
+func (c *pipelineChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number == c.head.Number.Uint64() {
+		return c.head
+	}
+	return c.BlockChain.GetHeaderByNumber(number)
+}
+
+// inflightDone returns the channel closed once the block being written landed,
+// nil if none is.
+func (e *executor) inflightDone() <-chan struct{} {
+	if e.inflight == nil {
+		return nil
+	}
+	return e.inflight.done
+}
+
+// pipelineHead returns the header the next block is executed on top of, the
+// block being written if any.
+func (e *executor) pipelineHead() *types.Header {
+	if e.inflight != nil {
+		return e.inflight.block.Header()
+	}
+	return e.eth.BlockChain().CurrentBlock()
+}
+
+// preparePipelinedWork prepares the environment of a block on top of the block
+// being written, whose state was already committed.
+func (e *executor) preparePipelinedWork(w *inflightWrite, timestamp uint64, coinbase common.Address, batchSize int) (*executor_env, error) {
+	tmpl := e.newTemplate(w.block.Header())
+	if tmpl.parent.Time >= timestamp {
+		timestamp = tmpl.parent.Time + 1
+	}
+	prefetch := batchSize > 0 && batchSize >= e.config.PrefetchThreshold
+	env, err := e.makeEnv(tmpl.parent, tmpl.header(timestamp, coinbase), e.signerAt(tmpl, timestamp), coinbase, nil, prefetch)
+	if err != nil {
+		return nil, err
+	}
+	env.chain = &pipelineChain{BlockChain: e.eth.BlockChain(), head: tmpl.parent, hash: tmpl.hash}
+
+	// The block being written is committed, its state is the boundary one
+	if err := e.applyGasGovernor(tmpl.parent, env, env.state, true); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// pipelineBatch executes a consensus block on top of the block being written,
+// and hands it off to be written in the background once its parent landed.
+// The outcome of a handed off block is only reported once written.
+func (e *executor) pipelineBatch(req *execReq) error {
+	parent := e.pipelineHead()
+	start := time.Now()
+
+	e.execStats.begin(req)
+	work, block, err := e.executeBatch(req)
+
+	// Blocks are committed in order, wait for the parent to land
+	wait := time.Now()
+	e.settleWrite()
+	pipelineWaitTimer.UpdateSince(wait)
+
+	if head := e.eth.BlockChain().CurrentBlock(); head.Hash() != parent.Hash() {
+		// The parent failed to land, execute the block again on the actual
+		// head unless the failure halted the execution or the block expects
+		// the lost parent
+		log.Warn("Executing consensus block again, parent not written", "sequence", req.sequence, "parent", parent.Hash(), "head", head.Hash())
+		pipelineStaleMeter.Mark(1)
+
+		parent, err = head, errExecutorHalted
+		if !e.halted.Load() {
+			if err = checkParent(req, head); err == nil {
+				err = e.executeAndWrite(req)
+			}
+		}
+	} else if err == nil {
+		if err = e.handOff(req, parent, start, work, block); err == nil {
+			return nil
+		}
+	}
+	err = e.finishBatch(req, parent, start, err)
+	e.execStats.end(e.eth.BlockChain().CurrentBlock().Number.Uint64(), err)
+	return err
+}
+
+// handOff commits the state of an executed block and starts writing the block
+// in the background.
+func (e *executor) handOff(req *execReq, parent *types.Header, start time.Time, work *executor_env, block *types.Block) error {
+	var (
+		committed *core.CommittedState
+		err       error
+	)
+	profileStage(stageCommit, len(req.txs), func() {
+		committed, err = e.eth.BlockChain().CommitBlockState(block, work.state)
+	})
+	if err != nil {
+		return err
+	}
+	w := &inflightWrite{
+		req:    req,
+		parent: parent,
+		start:  start,
+		env:    work,
+		block:  block,
+		done:   make(chan struct{}),
+	}
+	e.inflight = w
+	e.execStats.handOff()
+
+	go func() {
+		defer close(w.done)
+
+		start := time.Now()
+		profileStage(stageCommit, len(req.txs), func() {
+			w.err = e.writeBlock(work, block, committed)
+		})
+		req.report.write = time.Since(start)
+	}()
+	return nil
+}
+
+// settleWrite waits for the block being written in the background to land and
+// finishes it like a block written in the foreground, returning the outcome.
+func (e *executor) settleWrite() error {
+	w := e.inflight
+	if w == nil {
+		return nil
+	}
+	<-w.done
+	e.inflight = nil
+
+	e.trackWrite(w.err)
+	if w.err == nil {
+		e.recordWritten(w.req, w.env, w.block)
+	}
+	err := e.finishBatch(w.req, w.parent, w.start, w.err)
+	e.execStats.endWrite(e.eth.BlockChain().CurrentBlock().Number.Uint64(), err)
+	if w.req.result != nil {
+		e.deliverResult(w.req, &blockResult{result: e.blockResult(w.req), err: err})
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// stallingDB holds back the first block write for a while, so the next block
+// is executed before its parent landed.
+type stallingDB struct {
+	ethdb.Database
+	once sync.Once
+}
+
+func (db *stallingDB) Put(key []byte, value []byte) error {
+	// The consensus metadata is the first thing written of a block
+	if len(key) == 1+common.HashLength && key[0] == 'x' {
+		db.once.Do(func() { time.Sleep(200 * time.Millisecond) })
+	}
+	return db.Database.Put(key, value)
+}
+
+func TestPipelinedWrites(t *testing.T) {
+	config := postMergeConfig()
+
+	// Every block deploys a contract storing the hash of its grandparent. The
+	// parent is still being written while the pipelined block executes, the
+	// EVM walks it up to the grandparent
+	var (
+		signer   = types.LatestSigner(&config)
+		initcode = common.FromHex("0x6002430340600055") // sstore(0, blockhash(number - 2))
+		start    = time.Now().UnixNano()
+		blocks   = 4
+	)
+	deploy := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, Gas: 200000, GasPrice: big.NewInt(params.InitialBaseFee), Data: initcode})
+	}
+	run := func(pipelined bool) []common.Hash {
+		backend := newTestExecBackend(&config, ethash.NewFaker(), &stallingDB{Database: rawdb.NewMemoryDatabase()}, 0)
+		defer backend.close()
+
+		cfg := *testConfig
+		cfg.PipelineWrites = pipelined
+		e := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+
+		for i := 0; i < blocks; i++ {
+			req, _, err := decodeExecBlock(newTestExecBlock(t, deploy(uint64(i))), nil)
+			if err != nil {
+				t.Fatalf("failed to decode block: %v", err)
+			}
+			req.timestamp = start + int64(i)*int64(time.Second)
+			req.meta = &types.ConsensusMeta{Sequence: uint64(i + 1)}
+			if err := e.executeNewTxBatch(req); err != nil {
+				t.Fatalf("failed to execute block %d: %v", i, err)
+			}
+			if pipelined && e.inflight == nil {
+				t.Fatalf("block %d not handed off to the write pipeline", i)
+			}
+		}
+		if err := e.settleWrite(); err != nil {
+			t.Fatalf("failed to write last block: %v", err)
+		}
+		if e.inflight != nil {
+			t.Fatalf("block still in flight after settling")
+		}
+		chain := backend.chain
+		if head := chain.CurrentBlock().Number.Uint64(); head != uint64(blocks) {
+			t.Fatalf("head mismatch: have %d, want %d", head, blocks)
+		}
+		hashes := make([]common.Hash, blocks)
+		for i := range hashes {
+			block := chain.GetBlockByNumber(uint64(i + 1))
+			if len(block.Transactions()) != 1 {
+				t.Fatalf("block %d txs mismatch: have %d, want 1", i+1, len(block.Transactions()))
+			}
+			hashes[i] = block.Hash()
+		}
+		// The contracts saw the hashes of the canonical grandparents
+		state, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		for i := 1; i < blocks; i++ {
+			contract := crypto.CreateAddress(testBankAddress, uint64(i))
+			if have, want := state.GetState(contract, common.Hash{}), chain.GetBlockByNumber(uint64(i-1)).Hash(); have != want {
+				t.Errorf("block %d grandparent hash mismatch: have %x, want %x", i+1, have, want)
+			}
+		}
+		return hashes
+	}
+	sequential, pipelined := run(false), run(true)
+	for i := range sequential {
+		if sequential[i] != pipelined[i] {
+			t.Errorf("block %d mismatch: have %x, want %x", i+1, pipelined[i], sequential[i])
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package miner
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// postProcessChunk is the minimum number of receipts handed to a worker, below
+// which spinning up goroutines costs more than it saves.
+const postProcessChunk = 256
+
+// parallelize runs fn over [0, n) split into contiguous chunks, one per worker.
+// The number of workers adapts to n and is capped by PostProcessWorkers, small
+// blocks are processed on the calling goroutine.
+func (e *executor) parallelize(n int, fn func(start, end int)) {
+	workers := e.config.PostProcessWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if limit := (n + postProcessChunk - 1) / postProcessChunk; workers > limit {
+		workers = limit
+	}
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+	var (
+		size = (n + workers - 1) / workers
+		wg   sync.WaitGroup
+	)
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+// encodedReceipts is a list of receipts encoded ahead of the hashing.
+type encodedReceipts [][]byte
+
+func (l encodedReceipts) Len() int                           { return len(l) }
+func (l encodedReceipts) EncodeIndex(i int, w *bytes.Buffer) { w.Write(l[i]) }
+
+// finalize runs the finalization of the engine on the block of the environment,
+// followed by the fee split, so the splitter distributes the block reward the
+// engine credited along with the fees. It returns the withdrawals of the block:
+// proof-of-stake blocks carry an empty list since Shanghai.
+func (e *executor) finalize(work *executor_env) []*types.Withdrawal {
+	header := work.header
+
+	var withdrawals []*types.Withdrawal
+	if b, ok := e.engine.(*beacon.Beacon); ok && b.IsPoSHeader(header) && e.chainConfig.IsShanghai(header.Number, header.Time) {
+		withdrawals = make([]*types.Withdrawal, 0)
+	}
+	e.engine.Finalize(work.chain, header, work.state, work.txs, nil, withdrawals)
+	e.applyFeeSplit(work)
+	return withdrawals
+}
+
+// finalizeAndAssemble finalizes the block of the environment and assembles it
+// with the hash of the post state, like the engines do.
+func (e *executor) finalizeAndAssemble(work *executor_env, receipts []*types.Receipt) *types.Block {
+	withdrawals := e.finalize(work)
+	work.header.Root = work.state.IntermediateRoot(e.chainConfig.DeleteEmptyAccounts(work.header.Number))
+	return types.NewBlockWithWithdrawals(work.header, work.txs, nil, receipts, withdrawals, trie.NewStackTrie(nil))
+}
+
+// assemble finalizes the block of the environment. The engine assembles the
+// block without receipts, their encoding and bloom are computed in parallel
+// and attached to the header afterwards, which is what the engines would do
+// serially. If the chain defers its state roots, the post state isn't hashed
+// at all.
+func (e *executor) assemble(work *executor_env) (*types.Block, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	if e.deferredRoots(work.header.Number) {
+		block, err = e.finalizeDeferred(work)
+	} else {
+		block = e.finalizeAndAssemble(work, nil)
+	}
+	if err != nil || len(work.receipts) == 0 {
+		return block, err
+	}
+	var (
+		receipts = types.Receipts(work.receipts)
+		encoded  = make(encodedReceipts, len(receipts))
+		bloom    types.Bloom
+		lock     sync.Mutex
+	)
+	e.parallelize(len(receipts), func(start, end int) {
+		var buf bytes.Buffer
+		for i := start; i < end; i++ {
+			buf.Reset()
+			receipts.EncodeIndex(i, &buf)
+			encoded[i] = bytes.Clone(buf.Bytes())
+		}
+		partial := types.CreateBloom(receipts[start:end])
+
+		lock.Lock()
+		defer lock.Unlock()
+		for i := range bloom {
+			bloom[i] |= partial[i]
+		}
+	})
+	header := block.Header()
+	header.ReceiptHash = types.DeriveSha(encoded, trie.NewStackTrie(nil))
+	header.Bloom = bloom
+	return types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles()), nil
+}
+
+// deriveReceipts copies the receipts of the environment with the fields
+// derived from the block filled in, returning them along with their logs.
+func (e *executor) deriveReceipts(env *executor_env, block *types.Block) (types.Receipts, []*types.Log) {
+	var (
+		hash     = block.Hash()
+		receipts = make(types.Receipts, len(env.receipts))
+	)
+	e.parallelize(len(receipts), func(start, end int) {
+		for i := start; i < end; i++ {
+			receipt := new(types.Receipt)
+			*receipt = *env.receipts[i]
+
+			receipt.BlockHash = hash
+			receipt.BlockNumber = block.Number()
+			receipt.TransactionIndex = uint(i)
+
+			receipt.Logs = make([]*types.Log, len(env.receipts[i].Logs))
+			for j, envLog := range env.receipts[i].Logs {
+				log := new(types.Log)
+				*log = *envLog
+				log.BlockHash = hash
+				receipt.Logs[j] = log
+			}
+			receipts[i] = receipt
+		}
+	})
+	var logs []*types.Log
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs...)
+	}
+	return receipts, logs
+}
@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// makePostProcessEnv prepares an environment on top of the backend with n
+// synthetic receipts of two logs each.
+func makePostProcessEnv(tb testing.TB, e *executor, n int) *executor_env {
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), coinbase: testUserAddress}, 0)
+	if err != nil {
+		tb.Fatalf("failed to prepare work: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		receipt := &types.Receipt{Type: types.LegacyTxType, Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i+1) * params.TxGas, TxHash: common.Hash{byte(i), byte(i >> 8)}}
+		for j := 0; j < 2; j++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{
+				Address: common.Address{byte(i), byte(j)},
+				Topics:  []common.Hash{{byte(i >> 8), byte(i), byte(j)}},
+				Data:    make([]byte, 64),
+				TxHash:  receipt.TxHash,
+				Index:   uint(2*i + j),
+			})
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		work.receipts = append(work.receipts, receipt)
+	}
+	return work
+}
+
+func TestParallelAssemble(t *testing.T) {
+	backend := newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	for _, workers := range []int{1, 4} {
+		config := *testConfig
+		config.PostProcessWorkers = workers
+		e := &executor{config: &config, chainConfig: params.TestChainConfig, engine: ethash.NewFaker(), eth: backend}
+
+		work := makePostProcessEnv(t, e, 1000)
+		ref := work.copy()
+		want, err := e.engine.FinalizeAndAssemble(ref.chain, ref.header, ref.state, ref.txs, nil, ref.receipts, nil)
+		if err != nil {
+			t.Fatalf("workers %d: failed to assemble reference block: %v", workers, err)
+		}
+		block, err := e.assemble(work)
+		if err != nil {
+			t.Fatalf("workers %d: failed to assemble block: %v", workers, err)
+		}
+		if block.ReceiptHash() != want.ReceiptHash() || block.Bloom() != want.Bloom() || block.Hash() != want.Hash() {
+			t.Fatalf("workers %d: block mismatch: have %x (receipts %x), want %x (receipts %x)", workers, block.Hash(), block.ReceiptHash(), want.Hash(), want.ReceiptHash())
+		}
+		receipts, logs := e.deriveReceipts(work, block)
+		for i, receipt := range receipts {
+			if receipt.BlockHash != block.Hash() || receipt.TransactionIndex != uint(i) || receipt == work.receipts[i] {
+				t.Fatalf("workers %d: receipt %d not derived", workers, i)
+			}
+		}
+		if len(logs) != 2000 || logs[1999].Index != 1999 || logs[1999].BlockHash != block.Hash() {
+			t.Fatalf("workers %d: logs mismatch", workers)
+		}
+	}
+}
+
+func BenchmarkPostProcess(b *testing.B) {
+	backend := newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			config := *testConfig
+			config.PostProcessWorkers = workers
+			e := &executor{config: &config, chainConfig: params.TestChainConfig, engine: ethash.NewFaker(), eth: backend}
+			work := makePostProcessEnv(b, e, 5000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				block, err := e.assemble(work)
+				if err != nil {
+					b.Fatalf("failed to assemble block: %v", err)
+				}
+				e.deriveReceipts(work, block)
+			}
+		})
+	}
+}
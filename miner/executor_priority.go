@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var sendPreemptedMeter = metrics.NewRegisteredMeter("executor/send/preempted", nil)
+
+// beginSend registers the interrupt of a forwarding round, reporting whether
+// it may run. Rounds are held back while a consensus block executes, both
+// select on the head state and the execution must not wait for the forwarding.
+// Rounds held back are run again once the block executed.
+func (e *executor) beginSend(interrupt *atomic.Int32) bool {
+	if interrupt == nil {
+		return !e.executing.Load()
+	}
+	// Publish the round before checking, so a block arriving meanwhile either
+	// sees and aborts it or is seen by it
+	e.sending.Store(interrupt)
+	if e.executing.Load() {
+		e.sending.CompareAndSwap(interrupt, nil)
+		e.sendMissed.Store(true)
+
+		// The block may have executed meanwhile, without seeing the round
+		if !e.executing.Load() {
+			e.resend()
+		}
+		return false
+	}
+	return true
+}
+
+// endSend unregisters the interrupt of a finished forwarding round.
+func (e *executor) endSend(interrupt *atomic.Int32) {
+	if interrupt != nil {
+		e.sending.CompareAndSwap(interrupt, nil)
+	}
+}
+
+// preemptSend marks a consensus block as executing, aborting the forwarding
+// round in flight. The returned function ends the execution, running the
+// rounds aborted or held back meanwhile again.
+func (e *executor) preemptSend() func() {
+	e.executing.Store(true)
+	if interrupt := e.sending.Load(); interrupt != nil && interrupt.CompareAndSwap(commitInterruptNone, commitInterruptPreempted) {
+		log.Debug("Aborted tx forwarding for consensus block")
+		sendPreemptedMeter.Mark(1)
+		e.sendMissed.Store(true)
+	}
+	return func() {
+		e.executing.Store(false)
+		if e.sendMissed.Load() {
+			e.resend()
+		}
+	}
+}
+
+// resend asks the forwarding loop for a round in place of the one which gave
+// way to a consensus block, instead of waiting for the next recommit.
+func (e *executor) resend() {
+	if e.sendMissed.Swap(false) && e.resendCh != nil {
+		select {
+		case e.resendCh <- struct{}{}:
+		default:
+		}
+	}
+}
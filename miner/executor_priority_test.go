@@ -0,0 +1,63 @@
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestExecutionPriority(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig), resendCh: make(chan struct{}, 1)}
+
+	// A consensus block arriving aborts the forwarding round in flight
+	interrupt := new(atomic.Int32)
+	if !e.beginSend(interrupt) {
+		t.Fatalf("forwarding held back without consensus block")
+	}
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(&config), &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	e.execute(&execReq{txs: types.Transactions{tx}, timestamp: time.Now().UnixNano()})
+	if head := backend.chain.CurrentBlock().Number.Uint64(); head != 1 {
+		t.Fatalf("head mismatch: have %d, want 1", head)
+	}
+	if signal := interrupt.Load(); signal != commitInterruptPreempted {
+		t.Errorf("round signal mismatch: have %d, want %d", signal, commitInterruptPreempted)
+	}
+	// The aborted round runs again once the block executed
+	select {
+	case <-e.resendCh:
+	default:
+		t.Errorf("aborted round not run again")
+	}
+	e.endSend(interrupt)
+	if e.sending.Load() != nil {
+		t.Errorf("finished round still registered")
+	}
+	// Rounds don't start while a consensus block executes
+	done := e.preemptSend()
+	if e.beginSend(new(atomic.Int32)) {
+		t.Errorf("forwarding started during execution")
+	}
+	if e.sending.Load() != nil {
+		t.Errorf("held back round registered")
+	}
+	done()
+	select {
+	case <-e.resendCh:
+	default:
+		t.Errorf("held back round not run again")
+	}
+	if !e.beginSend(new(atomic.Int32)) {
+		t.Errorf("forwarding held back after execution")
+	}
+}
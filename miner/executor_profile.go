@@ -0,0 +1,39 @@
+package miner
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Stages of the execution pipeline the CPU profiles are labelled with.
+const (
+	stageDecode   = "decode"
+	stageExecute  = "execute"
+	stageAssemble = "assemble"
+	stageCommit   = "commit"
+)
+
+// batchBucket buckets the number of txs of a block by order of magnitude,
+// keeping the number of distinct profile labels low.
+func batchBucket(txs int) string {
+	switch {
+	case txs == 0:
+		return "0"
+	case txs < 10:
+		return "1-9"
+	case txs < 100:
+		return "10-99"
+	case txs < 1000:
+		return "100-999"
+	default:
+		return "1000+"
+	}
+}
+
+// profileStage runs fn with the pipeline stage and the batch size bucket as
+// pprof labels, so the CPU profiles of a node can be broken down by stage.
+// Goroutines started by fn inherit the labels.
+func profileStage(stage string, txs int, fn func()) {
+	labels := pprof.Labels("stage", stage, "batch", batchBucket(txs))
+	pprof.Do(context.Background(), labels, func(context.Context) { fn() })
+}
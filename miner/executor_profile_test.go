@@ -0,0 +1,17 @@
+package miner
+
+import "testing"
+
+func TestBatchBucket(t *testing.T) {
+	tests := []struct {
+		txs  int
+		want string
+	}{
+		{0, "0"}, {1, "1-9"}, {9, "1-9"}, {10, "10-99"}, {999, "100-999"}, {1000, "1000+"}, {50000, "1000+"},
+	}
+	for _, tt := range tests {
+		if have := batchBucket(tt.txs); have != tt.want {
+			t.Errorf("bucket of %d txs mismatch: have %s, want %s", tt.txs, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxQuarantined is the maximum number of failed consensus blocks kept, so a
+// consensus delivering the same invalid block over and over can't fill up the
+// disk.
+const maxQuarantined = 1024
+
+var (
+	quarantinedMeter       = metrics.NewRegisteredMeter("executor/quarantine/added", nil)
+	quarantineDroppedMeter = metrics.NewRegisteredMeter("executor/quarantine/dropped", nil)
+	quarantineRetriedMeter = metrics.NewRegisteredMeter("executor/quarantine/retried", nil)
+
+	errNotQuarantined = errors.New("consensus block not quarantined")
+)
+
+// quarantineEntry is a consensus block the executor failed to execute
+// irrecoverably, persisted along with the failure for the operator to retry
+// once the cause is fixed.
+type quarantineEntry struct {
+	Time     uint64   // time of the failure in unix nanoseconds
+	Number   uint64   // height the block failed at
+	Sequence uint64   // consensus height of the block, zero if unknown
+	Reason   string   // failure of the block
+	Blocks   [][]byte // protobuf encoding of the consensus blocks, several if coalesced
+}
+
+// QuarantinedBlock is a consensus block the executor failed to execute
+// irrecoverably.
+type QuarantinedBlock struct {
+	ID       uint64    `json:"id"`
+	Time     time.Time `json:"time"`     // time of the last failure
+	Number   uint64    `json:"number"`   // height the block failed at
+	Sequence uint64    `json:"sequence"` // consensus height of the block, zero if unknown
+	Reason   string    `json:"reason"`   // last failure of the block
+	Blocks   int       `json:"blocks"`   // consensus blocks, several if they were coalesced
+	Size     int       `json:"size"`     // size of the consensus blocks in bytes
+}
+
+// RetryResult is the outcome of retrying a quarantined consensus block.
+type RetryResult struct {
+	Executed int         `json:"executed"` // consensus blocks executed
+	Skipped  int         `json:"skipped"`  // consensus blocks whose txs were included meanwhile
+	Number   uint64      `json:"number"`   // last block written, zero if none
+	Hash     common.Hash `json:"hash"`
+}
+
+// quarantine allocates the ids of the quarantined consensus blocks.
+type quarantine struct {
+	lock     sync.Mutex
+	loaded   bool                     // whether the quarantine was read from the database
+	next     uint64                   // id of the next quarantined block
+	count    int                      // number of quarantined blocks
+	retrying map[uint64]chan struct{} // entries being retried, closed once done
+}
+
+// quarantineBlocks persists the consensus blocks of a request which failed
+// irrecoverably at the given height.
+func (e *executor) quarantineBlocks(req *execReq, number uint64, failure error) {
+	if !e.config.Quarantine || len(req.raw) == 0 {
+		return
+	}
+	blocks := make([][]byte, 0, len(req.raw))
+	for _, pbBlock := range req.raw {
+		block, err := proto.Marshal(pbBlock)
+		if err != nil {
+			log.Error("Failed to encode consensus block for quarantine", "sequence", req.sequence, "err", err)
+			return
+		}
+		blocks = append(blocks, block)
+	}
+	e.storeQuarantine(&quarantineEntry{
+		Time:     uint64(e.now().UnixNano()),
+		Number:   number,
+		Sequence: req.sequence,
+		Reason:   failure.Error(),
+		Blocks:   blocks,
+	})
+}
+
+// quarantineBlock persists a consensus block which couldn't be decoded.
+func (e *executor) quarantineBlock(pbBlock *pb.ExecBlock, failure error) {
+	if !e.config.Quarantine {
+		return
+	}
+	block, err := proto.Marshal(pbBlock)
+	if err != nil {
+		log.Error("Failed to encode consensus block for quarantine", "sequence", pbBlock.GetSequence(), "err", err)
+		return
+	}
+	e.storeQuarantine(&quarantineEntry{
+		Time:     uint64(e.now().UnixNano()),
+		Number:   e.eth.BlockChain().CurrentBlock().Number.Uint64() + 1,
+		Sequence: pbBlock.GetSequence(),
+		Reason:   failure.Error(),
+		Blocks:   [][]byte{block},
+	})
+}
+
+// storeQuarantine persists a new quarantine entry under the next id.
+func (e *executor) storeQuarantine(entry *quarantineEntry) {
+	blob, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Error("Failed to encode quarantine entry", "err", err)
+		return
+	}
+	q := &e.quarantine
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	chain := e.eth.BlockChain()
+	if !q.loaded {
+		ids, _ := chain.GetExecutorQuarantine()
+		if len(ids) > 0 {
+			q.next = ids[len(ids)-1]
+		}
+		q.count, q.loaded = len(ids), true
+	}
+	if q.count >= maxQuarantined {
+		log.Warn("Quarantine full, dropping failed consensus block", "number", entry.Number, "sequence", entry.Sequence, "reason", entry.Reason)
+		quarantineDroppedMeter.Mark(1)
+		return
+	}
+	q.next++
+	q.count++
+	chain.WriteExecutorQuarantine(q.next, blob)
+	quarantinedMeter.Mark(1)
+	log.Warn("Quarantined failed consensus block", "id", q.next, "number", entry.Number, "sequence", entry.Sequence, "reason", entry.Reason)
+}
+
+// dropQuarantine removes an entry from the quarantine.
+func (e *executor) dropQuarantine(id uint64) {
+	q := &e.quarantine
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	e.eth.BlockChain().DeleteExecutorQuarantine(id)
+	if q.loaded && q.count > 0 {
+		q.count--
+	}
+}
+
+// listQuarantine returns the quarantined consensus blocks by id.
+func (e *executor) listQuarantine() []QuarantinedBlock {
+	ids, blobs := e.eth.BlockChain().GetExecutorQuarantine()
+	blocks := make([]QuarantinedBlock, 0, len(ids))
+	for i, id := range ids {
+		var entry quarantineEntry
+		if err := rlp.DecodeBytes(blobs[i], &entry); err != nil {
+			log.Warn("Skipping invalid quarantine entry", "id", id, "err", err)
+			continue
+		}
+		block := QuarantinedBlock{
+			ID:       id,
+			Time:     time.Unix(0, int64(entry.Time)),
+			Number:   entry.Number,
+			Sequence: entry.Sequence,
+			Reason:   entry.Reason,
+			Blocks:   len(entry.Blocks),
+		}
+		for _, blob := range entry.Blocks {
+			block.Size += len(blob)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// retryQuarantined executes the consensus blocks of a quarantine entry again
+// on top of the chain head, in order, skipping the ones whose txs were included
+// meanwhile. The entry is dropped once all of them landed. Otherwise it's kept
+// with the blocks left and the new failure.
+func (e *executor) retryQuarantined(id uint64) (*RetryResult, error) {
+	if e.halted.Load() {
+		return nil, errExecutorHalted
+	}
+	// Retries of the same entry run one after the other, so its blocks aren't
+	// dispatched twice before the first ones landed
+	done, err := e.lockRetry(id)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	blob := e.eth.BlockChain().GetExecutorQuarantined(id)
+	if blob == nil {
+		return nil, fmt.Errorf("%w: %d", errNotQuarantined, id)
+	}
+	var entry quarantineEntry
+	if err := rlp.DecodeBytes(blob, &entry); err != nil {
+		return nil, err
+	}
+	quarantineRetriedMeter.Mark(1)
+	log.Info("Retrying quarantined consensus block", "id", id, "number", entry.Number, "sequence", entry.Sequence, "blocks", len(entry.Blocks))
+
+	result := new(RetryResult)
+	for i, block := range entry.Blocks {
+		res, err := e.retryBlock(id, block)
+		if err != nil {
+			e.updateQuarantine(id, &entry, entry.Blocks[i:], err)
+			return nil, err
+		}
+		if res == nil {
+			result.Skipped++
+			continue
+		}
+		result.Executed++
+		result.Number, result.Hash = res.Number, common.BytesToHash(res.Hash)
+	}
+	e.dropQuarantine(id)
+	log.Info("Executed quarantined consensus block", "id", id, "executed", result.Executed, "skipped", result.Skipped)
+	return result, nil
+}
+
+// lockRetry waits for the running retry of a quarantine entry to finish, then
+// marks the entry as being retried. The returned function ends the retry.
+func (e *executor) lockRetry(id uint64) (func(), error) {
+	q := &e.quarantine
+	for {
+		q.lock.Lock()
+		running, ok := q.retrying[id]
+		if !ok {
+			break
+		}
+		q.lock.Unlock()
+
+		select {
+		case <-running:
+		case <-e.exitCh:
+			return nil, errExecutorStopping
+		}
+	}
+	defer q.lock.Unlock()
+
+	if q.retrying == nil {
+		q.retrying = make(map[uint64]chan struct{})
+	}
+	done := make(chan struct{})
+	q.retrying[id] = done
+
+	return func() {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+
+		delete(q.retrying, id)
+		close(done)
+	}, nil
+}
+
+// retryBlock executes a quarantined consensus block, returning its outcome or
+// nil if there's nothing left to execute.
+func (e *executor) retryBlock(id uint64, block []byte) (*pb.BlockResult, error) {
+	pbBlock := new(pb.ExecBlock)
+	if err := proto.Unmarshal(block, pbBlock); err != nil {
+		return nil, err
+	}
+	req, _, err := decodeExecBlock(pbBlock, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || e.executedTxs(req.txs) {
+		return nil, nil
+	}
+	req.timestamp = e.now().UnixNano()
+	req.result = make(chan *blockResult, 1)
+	req.quarantined = id
+
+	// The block is executed on top of the head, its consensus predecessors
+	// landed long ago
+	req.parentHash, req.parentNumber = common.Hash{}, 0
+	if err := e.dispatchBlock(req.sequence, req); err != nil {
+		return nil, err
+	}
+	select {
+	case res := <-req.result:
+		return res.result, res.err
+	case <-e.exitCh:
+		return nil, errExecutorStopping
+	}
+}
+
+// updateQuarantine records the new failure of a retried quarantine entry,
+// keeping the blocks which didn't land.
+func (e *executor) updateQuarantine(id uint64, entry *quarantineEntry, blocks [][]byte, failure error) {
+	entry.Time = uint64(e.now().UnixNano())
+	entry.Number = e.eth.BlockChain().CurrentBlock().Number.Uint64() + 1
+	entry.Reason = failure.Error()
+	entry.Blocks = blocks
+
+	blob, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Error("Failed to encode quarantine entry", "id", id, "err", err)
+		return
+	}
+	e.eth.BlockChain().WriteExecutorQuarantine(id, blob)
+	log.Warn("Quarantined consensus block failed again", "id", id, "number", entry.Number, "reason", entry.Reason)
+}
@@ -0,0 +1,113 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestQuarantine(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.Quarantine = true
+	cfg.UnsetEtherbase = EtherbaseRefuse
+	e := &executor{
+		config:      &cfg,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		alerter:     newAlerter(&cfg),
+	}
+	// Executing without an etherbase fails every time
+	e.running.Store(true)
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	signer := types.LatestSigner(&config)
+	tx := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	block := newTestExecBlock(t, tx)
+	block.Sequence = 5
+	req, _, err := decodeExecBlock(block, nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	req.raw = []*pb.ExecBlock{block}
+	if err := e.executeNewTxBatch(req); !errors.Is(err, errMissingEtherbase) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingEtherbase)
+	}
+	// Blocks failing irrecoverably are kept for the operator
+	blocks := e.listQuarantine()
+	if len(blocks) != 1 {
+		t.Fatalf("quarantined blocks mismatch: have %d, want 1", len(blocks))
+	}
+	if q := blocks[0]; q.ID != 1 || q.Number != 1 || q.Sequence != 5 || q.Blocks != 1 || q.Reason != errMissingEtherbase.Error() {
+		t.Errorf("quarantined block mismatch: have %+v", q)
+	}
+	// Retries failing again keep the block in the quarantine
+	if _, err := e.retryQuarantined(1); !errors.Is(err, errMissingEtherbase) {
+		t.Fatalf("retry error mismatch: have %v, want %v", err, errMissingEtherbase)
+	}
+	if blocks := e.listQuarantine(); len(blocks) != 1 || blocks[0].ID != 1 {
+		t.Fatalf("quarantine after failed retry mismatch: have %+v", blocks)
+	}
+	// Once the cause is fixed the block lands and leaves the quarantine
+	e.mu.Lock()
+	e.coinbase = testUserAddress
+	e.mu.Unlock()
+	// Concurrent retries of the same entry execute its blocks once
+	type retry struct {
+		res *RetryResult
+		err error
+	}
+	retries := make(chan retry, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			res, err := e.retryQuarantined(1)
+			retries <- retry{res, err}
+		}()
+	}
+	var res *RetryResult
+	for i := 0; i < 2; i++ {
+		r := <-retries
+		switch {
+		case r.err == nil:
+			if res != nil {
+				t.Fatalf("block retried twice")
+			}
+			res = r.res
+		case !errors.Is(r.err, errNotQuarantined):
+			t.Fatalf("failed to retry block: %v", r.err)
+		}
+	}
+	if res == nil {
+		t.Fatalf("block not retried")
+	}
+	head := backend.chain.CurrentBlock()
+	if res.Executed != 1 || res.Number != 1 || res.Hash != head.Hash() {
+		t.Errorf("retry result mismatch: have %+v, want block 1 %v", res, head.Hash())
+	}
+	if blocks := e.listQuarantine(); len(blocks) != 0 {
+		t.Errorf("retried block left in the quarantine: %+v", blocks)
+	}
+	if _, err := e.retryQuarantined(1); !errors.Is(err, errNotQuarantined) {
+		t.Errorf("retry error mismatch: have %v, want %v", err, errNotQuarantined)
+	}
+}
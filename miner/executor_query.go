@@ -0,0 +1,162 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var errInvalidAddress = errors.New("invalid address")
+
+// queryServer serves gas-free, read-only queries against the head state of
+// the executed chain, mirroring the most common JSON-RPC reads for consensus
+// layer components written against protobuf.
+type queryServer struct {
+	executorPtr                 *executor
+	pb.UnimplementedQueryServer // indicated executor can be a grpc query server
+}
+
+// toAddress converts a protobuf encoded address, rejecting malformed ones.
+func toAddress(b []byte) (common.Address, error) {
+	if len(b) != common.AddressLength {
+		return common.Address{}, errInvalidAddress
+	}
+	return common.BytesToAddress(b), nil
+}
+
+func (qs *queryServer) GetBalance(ctx context.Context, req *pb.AccountRequest) (*pb.BalanceResponse, error) {
+	addr, err := toAddress(req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := qs.executorPtr.eth.BlockChain().State()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BalanceResponse{Balance: statedb.GetBalance(addr).Bytes()}, nil
+}
+
+func (qs *queryServer) GetNonce(ctx context.Context, req *pb.AccountRequest) (*pb.NonceResponse, error) {
+	addr, err := toAddress(req.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := qs.executorPtr.eth.BlockChain().State()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.NonceResponse{Nonce: statedb.GetNonce(addr)}, nil
+}
+
+// Call executes a message call on top of the head state without paying for
+// gas. The state changes are discarded.
+func (qs *queryServer) Call(ctx context.Context, req *pb.CallRequest) (*pb.CallResponse, error) {
+	var (
+		chain  = qs.executorPtr.eth.BlockChain()
+		header = chain.CurrentBlock()
+	)
+	statedb, err := chain.StateAt(chain.PostStateRoot(header))
+	if err != nil {
+		return nil, err
+	}
+	from := common.Address{}
+	if len(req.GetFrom()) != 0 {
+		if from, err = toAddress(req.GetFrom()); err != nil {
+			return nil, err
+		}
+	}
+	var to *common.Address
+	if len(req.GetTo()) != 0 {
+		addr, err := toAddress(req.GetTo())
+		if err != nil {
+			return nil, err
+		}
+		to = &addr
+	}
+	gas := req.GetGas()
+	if gas == 0 || gas > header.GasLimit {
+		gas = header.GasLimit
+	}
+	msg := &core.Message{
+		From:              from,
+		To:                to,
+		Value:             new(big.Int).SetBytes(req.GetValue()),
+		GasLimit:          gas,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              req.GetData(),
+		SkipAccountChecks: true,
+	}
+	var (
+		blockCtx = core.NewEVMBlockContext(header, chain, nil)
+		vmConfig = vm.Config{NoBaseFee: true}
+		evm      = vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, qs.executorPtr.chainConfig, vmConfig)
+	)
+	// Abort the call if the consensus layer gives up waiting
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(gas))
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.CallResponse{
+		ReturnData: result.Return(),
+		GasUsed:    result.UsedGas,
+	}
+	if result.Err != nil {
+		res.ReturnData = result.Revert()
+		res.Err = result.Err.Error()
+	}
+	return res, nil
+}
+
+func (qs *queryServer) GetReceipt(ctx context.Context, req *pb.ReceiptRequest) (*pb.ReceiptResponse, error) {
+	chain := qs.executorPtr.eth.BlockChain()
+
+	lookup, _, err := chain.GetTransactionLookup(common.BytesToHash(req.GetTxHash()))
+	if err != nil {
+		return nil, err
+	}
+	if lookup == nil {
+		return &pb.ReceiptResponse{Found: false}, nil
+	}
+	receipts := chain.GetReceiptsByHash(lookup.BlockHash)
+	if uint64(len(receipts)) <= lookup.Index {
+		return &pb.ReceiptResponse{Found: false}, nil
+	}
+	receipt := receipts[lookup.Index]
+
+	res := &pb.ReceiptResponse{
+		Found:             true,
+		Status:            receipt.Status,
+		GasUsed:           receipt.GasUsed,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		BlockHash:         lookup.BlockHash.Bytes(),
+		BlockNumber:       lookup.BlockIndex,
+		TransactionIndex:  uint32(lookup.Index),
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		res.ContractAddress = receipt.ContractAddress.Bytes()
+	}
+	for _, l := range receipt.Logs {
+		topics := make([][]byte, len(l.Topics))
+		for i, topic := range l.Topics {
+			topics[i] = topic.Bytes()
+		}
+		res.Logs = append(res.Logs, &pb.Log{
+			Address: l.Address.Bytes(),
+			Topics:  topics,
+			Data:    l.Data,
+			Index:   uint32(l.Index),
+		})
+	}
+	return res, nil
+}
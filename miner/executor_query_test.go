@@ -0,0 +1,56 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestQueryServer(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), db, 0)
+		qs      = &queryServer{executorPtr: &executor{eth: backend, chainConfig: params.TestChainConfig}}
+		ctx     = context.Background()
+	)
+	defer backend.close()
+
+	balance, err := qs.GetBalance(ctx, &pb.AccountRequest{Address: testBankAddress.Bytes()})
+	if err != nil {
+		t.Fatalf("failed to query balance: %v", err)
+	}
+	if have := new(big.Int).SetBytes(balance.Balance); have.Cmp(testBankFunds) != 0 {
+		t.Errorf("balance mismatch: have %v, want %v", have, testBankFunds)
+	}
+	nonce, err := qs.GetNonce(ctx, &pb.AccountRequest{Address: testBankAddress.Bytes()})
+	if err != nil {
+		t.Fatalf("failed to query nonce: %v", err)
+	}
+	if nonce.Nonce != 0 {
+		t.Errorf("nonce mismatch: have %d, want %d", nonce.Nonce, 0)
+	}
+	if _, err := qs.GetBalance(ctx, &pb.AccountRequest{Address: []byte{0x01}}); err != errInvalidAddress {
+		t.Errorf("malformed address accepted: %v", err)
+	}
+	// Calls are gas-free, even from accounts without any funds
+	call, err := qs.Call(ctx, &pb.CallRequest{From: testUserAddress.Bytes(), To: testBankAddress.Bytes()})
+	if err != nil {
+		t.Fatalf("failed to execute call: %v", err)
+	}
+	if call.Err != "" || call.GasUsed != params.TxGas {
+		t.Errorf("call result mismatch: err %q, gas %d", call.Err, call.GasUsed)
+	}
+	receipt, err := qs.GetReceipt(ctx, &pb.ReceiptRequest{TxHash: common.Hash{0x01}.Bytes()})
+	if err != nil {
+		t.Fatalf("failed to query receipt: %v", err)
+	}
+	if receipt.Found {
+		t.Errorf("unknown receipt found")
+	}
+}
@@ -0,0 +1,85 @@
+package miner
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// readyPoll is the interval the backend is checked at until its chain and pool
+// are initialized.
+const readyPoll = 100 * time.Millisecond
+
+var errBackendNotReady = errors.New("backend not ready")
+
+// ReadyBackend is a Backend signalling once its chain and pool are initialized,
+// which may be after the miner was created. Backends not implementing it are
+// polled instead.
+type ReadyBackend interface {
+	Backend
+
+	// Ready returns a channel closed once the chain head is loaded and the pool
+	// is initialized.
+	Ready() <-chan struct{}
+}
+
+// backendReady reports whether the chain head is loaded and the pool is
+// initialized.
+func (e *executor) backendReady() bool {
+	chain := e.eth.BlockChain()
+	return chain != nil && chain.CurrentBlock() != nil && e.eth.TxPool() != nil
+}
+
+// initLoop waits for the backend to be ready, then restores the state left by
+// the previous run before letting the other loops and the gRPC API start.
+func (e *executor) initLoop() {
+	defer e.wg.Done()
+
+	if backend, ok := e.eth.(ReadyBackend); ok {
+		select {
+		case <-backend.Ready():
+		case <-e.exitCh:
+			return
+		}
+	}
+	for !e.backendReady() {
+		log.Debug("Waiting for backend to initialize")
+		select {
+		case <-time.After(readyPoll):
+		case <-e.exitCh:
+			return
+		}
+	}
+	e.restoreInFlight()
+
+	// Execute the consensus blocks acknowledged before going down, in consensus
+	// order from the head on
+	e.resumeOrder()
+	e.replayWAL()
+
+	close(e.ready)
+	e.updateHealth()
+	log.Info("Executor ready", "head", e.eth.BlockChain().CurrentBlock().Number)
+}
+
+// waitReady blocks until the executor is initialized, returning false if it's
+// closed first. Executors without readiness signal are ready right away.
+func (e *executor) waitReady() bool {
+	if e.ready == nil {
+		return true
+	}
+	select {
+	case <-e.ready:
+		return true
+	case <-e.exitCh:
+		return false
+	}
+}
+
+// serve serves the gRPC API on the listener. Health checks are answered right
+// away, while calls into the executor made before it's initialized wait for it.
+func (e *executor) serve(listener net.Listener) {
+	e.server.Serve(listener)
+}
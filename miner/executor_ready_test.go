@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// lateBackend is a backend signalling its readiness on demand.
+type lateBackend struct {
+	*testWorkerBackend
+	ready chan struct{}
+}
+
+func (b *lateBackend) Ready() <-chan struct{} { return b.ready }
+
+func TestExecutorReadiness(t *testing.T) {
+	backend := newTestExecBackend(ethashChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	late := &lateBackend{testWorkerBackend: backend, ready: make(chan struct{})}
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		engine:      ethash.NewFaker(),
+		eth:         late,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		ready:       make(chan struct{}),
+		alerter:     newAlerter(testConfig),
+	}
+	e.wg.Add(1)
+	go e.initLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	// Nothing starts until the backend signals readiness
+	select {
+	case <-e.ready:
+		t.Fatalf("executor ready before backend")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(late.ready)
+	select {
+	case <-e.ready:
+	case <-time.After(time.Second):
+		t.Fatalf("executor not ready after backend")
+	}
+	if !e.waitReady() {
+		t.Errorf("ready executor reported as closed")
+	}
+	// Forwarding without pool fails instead of crashing
+	bare := &executor{config: testConfig, chainConfig: ethashChainConfig, eth: &testWorkerBackend{chain: backend.chain}}
+	if err := bare.fillTransactions(nil, new(executor_env)); !errors.Is(err, errBackendNotReady) {
+		t.Errorf("forwarding error mismatch: have %v, want %v", err, errBackendNotReady)
+	}
+	// Stats are served without pool
+	bare.alerter, bare.inclusion = newAlerter(testConfig), newInclusionTracker(nil)
+	if stats := bare.stats(); stats.Queues.Pending != 0 {
+		t.Errorf("pending txs mismatch without pool: have %d, want 0", stats.Queues.Pending)
+	}
+	// Executors closed while waiting give up
+	closed := &executor{config: testConfig, eth: &lateBackend{testWorkerBackend: backend, ready: make(chan struct{})}, exitCh: make(chan struct{}), ready: make(chan struct{})}
+	closed.wg.Add(1)
+	go closed.initLoop()
+	close(closed.exitCh)
+	closed.wg.Wait()
+	if closed.waitReady() {
+		t.Errorf("closed executor reported as ready")
+	}
+}
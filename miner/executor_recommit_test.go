@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExecutorRecommit(t *testing.T) {
+	type interval struct{ min, recommit time.Duration }
+	var (
+		intervals = make(chan interval, 1)
+		e         = &executor{
+			startCh:            make(chan struct{}, 1),
+			exitCh:             make(chan struct{}),
+			newWorkCh:          make(chan *newWorkReq),
+			resubmitIntervalCh: make(chan time.Duration),
+			resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+			resubmitHook: func(min, recommit time.Duration) {
+				intervals <- interval{min, recommit}
+			},
+		}
+	)
+	e.running.Store(true)
+	e.wg.Add(1)
+	go e.newExecLoop(minRecommitInterval)
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	// Pending txs are forwarded again after the recommit interval, aborting the
+	// previous round if still running
+	e.startCh <- struct{}{}
+	first := <-e.newWorkCh
+	start := time.Now()
+	select {
+	case <-e.newWorkCh:
+		if elapsed := time.Since(start); elapsed < minRecommitInterval/2 {
+			t.Errorf("forwarded again after %v, want %v", elapsed, minRecommitInterval)
+		}
+	case <-time.After(5 * minRecommitInterval):
+		t.Fatalf("pending txs not forwarded again")
+	}
+	if signal := first.interrupt.Load(); signal != commitInterruptResubmit {
+		t.Errorf("previous round signal mismatch: have %d, want %d", signal, commitInterruptResubmit)
+	}
+	// The interval is set by the user, and slowed down by rounds which couldn't
+	// forward everything in time
+	e.setRecommitInterval(3 * time.Second)
+	if have := <-intervals; have != (interval{3 * time.Second, 3 * time.Second}) {
+		t.Errorf("user interval mismatch: have %v, want 3s", have)
+	}
+	e.adjustResubmitInterval(&intervalAdjust{ratio: 0.5, inc: true})
+	origin := float64(3 * time.Second.Nanoseconds())
+	slowed := time.Duration(origin*(1-intervalAdjustRatio) + intervalAdjustRatio*(origin/0.5+intervalAdjustBias))
+	if have := <-intervals; have != (interval{3 * time.Second, slowed}) {
+		t.Errorf("slowed interval mismatch: have %v, want %v", have, slowed)
+	}
+	// Rounds forwarding everything bring it back down to the user's
+	e.adjustResubmitInterval(&intervalAdjust{inc: false})
+	if have := <-intervals; have.recommit >= slowed || have.recommit < 3*time.Second {
+		t.Errorf("sped up interval mismatch: have %v, want within [3s, %v)", have, slowed)
+	}
+	// Too short intervals are sanitized
+	e.setRecommitInterval(time.Millisecond)
+	if have := <-intervals; have != (interval{minRecommitInterval, minRecommitInterval}) {
+		t.Errorf("sanitized interval mismatch: have %v, want %v", have, minRecommitInterval)
+	}
+}
@@ -0,0 +1,190 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReexecOverrides are the changes a historical block is re-executed with.
+type ReexecOverrides struct {
+	Coinbase *common.Address `json:"coinbase,omitempty"` // recipient of the fees instead of the one of the block
+	GasLimit *hexutil.Uint64 `json:"gasLimit,omitempty"` // gas limit instead of the one of the block
+	Disabled []common.Hash   `json:"disabled,omitempty"` // txs of the block left out
+}
+
+// ReexecTx is the outcome of a tx of the re-executed block.
+type ReexecTx struct {
+	Hash    common.Hash     `json:"hash"`
+	Status  *hexutil.Uint64 `json:"status"`  // status with the overrides, nil if left out of the block
+	GasUsed hexutil.Uint64  `json:"gasUsed"` // gas used with the overrides
+	Changed bool            `json:"changed"` // whether the outcome differs from the one without overrides
+}
+
+// AccountDiff is an account the overrides leave in a different state.
+type AccountDiff struct {
+	Address common.Address              `json:"address"`
+	Balance *hexutil.Big                `json:"balance,omitempty"` // balance with the overrides, if different
+	Delta   *hexutil.Big                `json:"delta,omitempty"`   // change of the balance caused by the overrides
+	Nonce   *hexutil.Uint64             `json:"nonce,omitempty"`   // nonce with the overrides, if different
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"` // slots with a different value with the overrides
+}
+
+// ReexecResult is the outcome of re-executing a historical block with
+// overrides, compared to re-executing it without.
+type ReexecResult struct {
+	BlockHash  common.Hash    `json:"blockHash"`
+	Number     hexutil.Uint64 `json:"number"`
+	Reproduced bool           `json:"reproduced"` // whether the re-execution without overrides reaches the post state root of the block
+	Root       common.Hash    `json:"root"`       // state root reached with the overrides
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`    // gas used with the overrides
+	Fees       *hexutil.Big   `json:"fees"`       // priority fees credited to the coinbase with the overrides
+	Txs        []ReexecTx     `json:"txs"`
+	Accounts   []AccountDiff  `json:"accounts"` // accounts left in a different state by the overrides, sorted by address
+}
+
+// reexecRun is the outcome of executing the txs of a block on its parent state.
+type reexecRun struct {
+	env   *executor_env
+	block *types.Block
+}
+
+// reexecute re-executes a historical block on its parent state with the given
+// overrides, for what-if analysis of fee policies and incident forensics. The
+// outcome is compared to re-executing the block without overrides, as the
+// system calls of the block can't be replayed: consensus inputs aren't kept.
+func (e *executor) reexecute(hash common.Hash, overrides ReexecOverrides) (*ReexecResult, error) {
+	chain := e.eth.BlockChain()
+	block := chain.GetBlockByHash(hash)
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, errors.New("parent not found")
+	}
+	disabled := make(map[common.Hash]bool, len(overrides.Disabled))
+	for _, hash := range overrides.Disabled {
+		disabled[hash] = true
+	}
+	base, err := e.reexecRun(parent, block, ReexecOverrides{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	run, err := e.reexecRun(parent, block, overrides, disabled)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReexecResult{
+		BlockHash:  hash,
+		Number:     hexutil.Uint64(block.NumberU64()),
+		Reproduced: base.block.Root() == chain.PostStateRoot(block.Header()),
+		Root:       run.block.Root(),
+		GasUsed:    hexutil.Uint64(run.block.GasUsed()),
+		Fees:       (*hexutil.Big)(totalFees(run.block, run.env.receipts)),
+		Txs:        make([]ReexecTx, 0, len(block.Transactions())),
+		Accounts:   diffAccounts(run.env.state, base.env.state),
+	}
+	var (
+		baseReceipts = receiptsByTx(base.env)
+		runReceipts  = receiptsByTx(run.env)
+	)
+	for _, tx := range block.Transactions() {
+		have, want := runReceipts[tx.Hash()], baseReceipts[tx.Hash()]
+		rtx := ReexecTx{Hash: tx.Hash(), Changed: (have == nil) != (want == nil)}
+		if have != nil {
+			status := hexutil.Uint64(have.Status)
+			rtx.Status, rtx.GasUsed = &status, hexutil.Uint64(have.GasUsed)
+			rtx.Changed = want == nil || have.Status != want.Status || have.GasUsed != want.GasUsed || have.Bloom != want.Bloom
+		}
+		result.Txs = append(result.Txs, rtx)
+	}
+	return result, nil
+}
+
+// reexecRun executes the txs of a block on top of its parent state with the
+// given overrides, leaving out the disabled txs.
+func (e *executor) reexecRun(parent *types.Header, block *types.Block, overrides ReexecOverrides, disabled map[common.Hash]bool) (*reexecRun, error) {
+	chain := e.eth.BlockChain()
+	statedb, err := chain.StateAt(chain.PostStateRoot(parent))
+	if err != nil {
+		return nil, fmt.Errorf("parent state unavailable: %w", err)
+	}
+	header := types.CopyHeader(block.Header())
+	header.GasUsed = 0
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	env := &executor_env{
+		signer:   types.MakeSigner(e.chainConfig, header.Number, header.Time),
+		state:    statedb,
+		chain:    chain,
+		coinbase: header.Coinbase,
+		header:   header,
+	}
+	txs := make(types.Transactions, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		if !disabled[tx.Hash()] {
+			txs = append(txs, tx)
+		}
+	}
+	e.prepareCalldataFee(env)
+	e.executeTransactions(env, txs)
+	e.updateCalldataExcess(env)
+
+	return &reexecRun{env: env, block: e.finalizeAndAssemble(env, env.receipts)}, nil
+}
+
+// receiptsByTx indexes the receipts of an execution by tx.
+func receiptsByTx(env *executor_env) map[common.Hash]*types.Receipt {
+	receipts := make(map[common.Hash]*types.Receipt, len(env.txs))
+	for i, tx := range env.txs {
+		receipts[tx.Hash()] = env.receipts[i]
+	}
+	return receipts
+}
+
+// diffAccounts compares the accounts accessed by either execution, returning
+// those left in a different state by the first one.
+func diffAccounts(have, want *state.StateDB) []AccountDiff {
+	accessed := have.WorkingSet()
+	for addr, slots := range want.WorkingSet() {
+		accessed[addr] = append(accessed[addr], slots...)
+	}
+	var diffs []AccountDiff
+	for addr, slots := range accessed {
+		diff := AccountDiff{Address: addr}
+		if hb, wb := have.GetBalance(addr).ToBig(), want.GetBalance(addr).ToBig(); hb.Cmp(wb) != 0 {
+			diff.Balance, diff.Delta = (*hexutil.Big)(hb), (*hexutil.Big)(new(big.Int).Sub(hb, wb))
+		}
+		if hn := have.GetNonce(addr); hn != want.GetNonce(addr) {
+			nonce := hexutil.Uint64(hn)
+			diff.Nonce = &nonce
+		}
+		for _, slot := range slots {
+			if hv := have.GetState(addr, slot); hv != want.GetState(addr, slot) {
+				if diff.Storage == nil {
+					diff.Storage = make(map[common.Hash]common.Hash)
+				}
+				diff.Storage[slot] = hv
+			}
+		}
+		if diff.Balance != nil || diff.Nonce != nil || diff.Storage != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return bytes.Compare(diffs[i].Address[:], diffs[j].Address[:]) < 0
+	})
+	return diffs
+}
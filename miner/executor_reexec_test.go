@@ -0,0 +1,104 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestReexecute(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	var (
+		signer = types.LatestSigner(&config)
+		value  = big.NewInt(1000)
+		tip    = big.NewInt(params.GWei)
+		txs    = make(types.Transactions, 2)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{ChainID: config.ChainID, Nonce: uint64(i), To: &testUserAddress, Value: value, Gas: params.TxGas, GasTipCap: tip, GasFeeCap: big.NewInt(2 * params.InitialBaseFee)})
+	}
+	req, _, err := decodeExecBlock(newTestExecBlock(t, txs...), nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = e.now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	block := backend.chain.CurrentBlock()
+	if block.Number.Uint64() != 1 {
+		t.Fatalf("head mismatch: have %d, want 1", block.Number)
+	}
+	// Without overrides nothing changes
+	res, err := e.reexecute(block.Hash(), ReexecOverrides{})
+	if err != nil {
+		t.Fatalf("failed to re-execute block: %v", err)
+	}
+	if !res.Reproduced || res.Root != block.Root || len(res.Accounts) != 0 || len(res.Txs) != 2 {
+		t.Fatalf("plain re-execution mismatch: have %+v", res)
+	}
+	// The fees end up with another coinbase
+	coinbase := common.HexToAddress("0xfee")
+	res, err = e.reexecute(block.Hash(), ReexecOverrides{Coinbase: &coinbase})
+	if err != nil {
+		t.Fatalf("failed to re-execute block with coinbase: %v", err)
+	}
+	fees := new(big.Int).Mul(tip, new(big.Int).SetUint64(2*params.TxGas))
+	if res.Fees.ToInt().Cmp(fees) != 0 {
+		t.Errorf("fees mismatch: have %v, want %v", res.Fees, fees)
+	}
+	var credited bool
+	for _, diff := range res.Accounts {
+		if diff.Address == coinbase {
+			credited = diff.Delta.ToInt().Cmp(fees) >= 0
+		}
+	}
+	if !credited || res.Root == block.Root {
+		t.Errorf("coinbase override mismatch: have %+v", res)
+	}
+	// A lower gas limit leaves the later txs out
+	limit := hexutil.Uint64(params.TxGas)
+	res, err = e.reexecute(block.Hash(), ReexecOverrides{GasLimit: &limit})
+	if err != nil {
+		t.Fatalf("failed to re-execute block with gas limit: %v", err)
+	}
+	if res.Txs[0].Status == nil || res.Txs[0].Changed || res.Txs[1].Status != nil || !res.Txs[1].Changed || res.GasUsed != limit {
+		t.Errorf("gas limit override mismatch: have %+v", res)
+	}
+	// Disabled txs are left out along with their effects, the later txs of the
+	// sender fail the nonce check without them
+	res, err = e.reexecute(block.Hash(), ReexecOverrides{Disabled: []common.Hash{txs[0].Hash()}})
+	if err != nil {
+		t.Fatalf("failed to re-execute block with disabled txs: %v", err)
+	}
+	if res.Txs[0].Status != nil || !res.Txs[0].Changed || res.Txs[1].Status != nil || res.GasUsed != 0 {
+		t.Errorf("disabled tx mismatch: have %+v", res)
+	}
+	for _, diff := range res.Accounts {
+		switch diff.Address {
+		case testUserAddress:
+			if want := new(big.Int).Mul(value, big.NewInt(-2)); diff.Delta.ToInt().Cmp(want) != 0 {
+				t.Errorf("user balance delta mismatch: have %v, want %v", diff.Delta, want)
+			}
+		case testBankAddress:
+			if diff.Nonce == nil || *diff.Nonce != 0 {
+				t.Errorf("sender nonce mismatch: have %v, want 0", diff.Nonce)
+			}
+		}
+	}
+	if _, err := e.reexecute(common.Hash{0x01}, ReexecOverrides{}); err == nil {
+		t.Errorf("unknown block re-executed")
+	}
+}
@@ -0,0 +1,62 @@
+package miner
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// batchReport is the breakdown of the execution of a consensus block, logged as
+// one summary line per block. The fields are flat and numeric so the lines can
+// be parsed into dashboards, especially with the JSON log format.
+type batchReport struct {
+	number   uint64      // chain block the consensus block was executed as
+	hash     common.Hash // hash of the written block
+	included int         // txs included in the block
+	gas      uint64      // gas used by the block
+	warmed   int         // txs whose state was pre-loaded before the execution started
+	fees     *big.Int    // priority fees credited to the coinbase in wei, nil if the block wasn't written
+	fromRPC  int         // included txs first received by the local pool via JSON-RPC
+	fromPeer int         // included txs first received by the local pool from a devp2p peer
+
+	decode   time.Duration // decoding the consensus block
+	exec     time.Duration // executing the txs, system calls included
+	assemble time.Duration // finalizing and assembling the block
+	write    time.Duration // writing the block to the chain
+	total    time.Duration // whole execution, from picking up the block to its write
+
+	snapReads time.Duration // state reads served by the snapshot
+	trieReads time.Duration // state reads falling through to the trie
+}
+
+// recordReads accounts the state reads of the execution.
+func (r *batchReport) recordReads(statedb *state.StateDB) {
+	r.snapReads = statedb.SnapshotAccountReads + statedb.SnapshotStorageReads
+	r.trieReads = statedb.AccountReads + statedb.StorageReads
+}
+
+// logReport emits the summary line of an executed consensus block.
+func (e *executor) logReport(req *execReq, err error) {
+	r := &req.report
+	fees := r.fees
+	if fees == nil {
+		fees = new(big.Int)
+	}
+	ctx := []interface{}{
+		"sequence", req.sequence, "number", r.number, "hash", r.hash,
+		"txs", len(req.txs), "included", r.included, "gas", r.gas, "fees", fees, "warmed", r.warmed,
+		"rpctxs", r.fromRPC, "peertxs", r.fromPeer,
+		"decodems", milliseconds(r.decode), "execms", milliseconds(r.exec),
+		"assemblems", milliseconds(r.assemble), "writems", milliseconds(r.write),
+		"totalms", milliseconds(r.total),
+		"snapreadms", milliseconds(r.snapReads), "triereadms", milliseconds(r.trieReads),
+	}
+	if err != nil {
+		log.Warn("Failed consensus block", append(ctx, "err", err)...)
+		return
+	}
+	log.Info("Executed consensus block", ctx...)
+}
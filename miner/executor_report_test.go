@@ -0,0 +1,65 @@
+package miner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"golang.org/x/exp/slog"
+)
+
+func TestBatchReport(t *testing.T) {
+	// Capture the logs in the JSON format dashboards ingest
+	var buf bytes.Buffer
+	defer log.SetDefault(log.Root())
+	log.SetDefault(log.NewLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}, sequence: 7}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	var report map[string]interface{}
+	for scanner := bufio.NewScanner(&buf); scanner.Scan(); {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err == nil && line["msg"] == "Executed consensus block" {
+			report = line
+		}
+	}
+	if report == nil {
+		t.Fatalf("no report logged: %s", buf.String())
+	}
+	want := map[string]float64{"sequence": 7, "number": 1, "txs": 1, "included": 1, "gas": float64(params.TxGas)}
+	for key, value := range want {
+		if report[key] != value {
+			t.Errorf("%s mismatch: have %v, want %v", key, report[key], value)
+		}
+	}
+	// The fees are reported in wei, as credited to the coinbase
+	head := backend.chain.CurrentBlock()
+	block := backend.chain.GetBlock(head.Hash(), head.Number.Uint64())
+	fees := PriorityFees(block, backend.chain.GetReceiptsByHash(head.Hash()))
+	if fees.Sign() == 0 {
+		t.Fatalf("block without priority fees")
+	}
+	if want, _ := new(big.Float).SetInt(fees).Float64(); report["fees"] != want {
+		t.Errorf("fees mismatch: have %v, want %v", report["fees"], want)
+	}
+	for _, key := range []string{"decodems", "execms", "assemblems", "writems", "totalms", "snapreadms", "triereadms"} {
+		if _, ok := report[key].(float64); !ok {
+			t.Errorf("%s missing or not numeric: %v", key, report[key])
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxReconnectBackoff is the maximum delay between the attempts to reach
+	// consensus again.
+	maxReconnectBackoff = 30 * time.Second
+
+	// resendTimeout is the maximum time allowance for sending a queued tx.
+	resendTimeout = 5 * time.Second
+)
+
+var (
+	resendQueuedMeter  = metrics.NewRegisteredMeter("executor/resend/queued", nil)
+	resendSentMeter    = metrics.NewRegisteredMeter("executor/resend/sent", nil)
+	resendDroppedMeter = metrics.NewRegisteredMeter("executor/resend/dropped", nil)
+	resendQueueGauge   = metrics.NewRegisteredGauge("executor/resend/size", nil)
+
+	errTxQueued = errors.New("consensus unreachable, tx queued")
+)
+
+// transientErr reports whether a failed call to consensus may succeed once
+// it's reachable again.
+func transientErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// reconnectParams returns the dial option reconnecting to consensus with an
+// exponential backoff from the given delay on.
+func reconnectParams(base time.Duration) grpc.DialOption {
+	if base <= 0 {
+		base = DefaultConfig.ReconnectBackoff
+	}
+	config := backoff.DefaultConfig
+	config.BaseDelay = base
+	config.MaxDelay = maxReconnectBackoff
+	return grpc.WithConnectParams(grpc.ConnectParams{Backoff: config, MinConnectTimeout: resendTimeout})
+}
+
+// resendEntry is a tx which failed to reach consensus.
+type resendEntry struct {
+	hash   common.Hash
+	packet *pb.Packet
+}
+
+// resendQueue holds the txs which failed to reach consensus on a transient
+// outage, sent again in order once it's back. The oldest ones are dropped once
+// full, the pool still holds them for the later forwarding rounds.
+type resendQueue struct {
+	lock    sync.Mutex
+	entries []*resendEntry
+	queued  map[common.Hash]struct{}
+	limit   int
+
+	wake      chan struct{} // signalled on every queued tx
+	connected chan struct{} // signalled once the connection to consensus is back
+}
+
+// newResendQueue creates a queue of the given capacity, nil if zero.
+func newResendQueue(limit int) *resendQueue {
+	if limit <= 0 {
+		return nil
+	}
+	return &resendQueue{
+		queued:    make(map[common.Hash]struct{}),
+		limit:     limit,
+		wake:      make(chan struct{}, 1),
+		connected: make(chan struct{}, 1),
+	}
+}
+
+// add queues a tx, reporting false if it's queued already.
+func (q *resendQueue) add(hash common.Hash, packet *pb.Packet) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, ok := q.queued[hash]; ok {
+		return false
+	}
+	if len(q.entries) >= q.limit {
+		delete(q.queued, q.entries[0].hash)
+		q.entries = q.entries[1:]
+		resendDroppedMeter.Mark(1)
+	}
+	q.entries = append(q.entries, &resendEntry{hash: hash, packet: packet})
+	q.queued[hash] = struct{}{}
+	resendQueuedMeter.Mark(1)
+	resendQueueGauge.Update(int64(len(q.entries)))
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// peek returns the oldest queued tx, nil if none.
+func (q *resendQueue) peek() *resendEntry {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.entries) == 0 {
+		return nil
+	}
+	return q.entries[0]
+}
+
+// remove drops a sent tx from the queue, unless it was dropped meanwhile.
+func (q *resendQueue) remove(entry *resendEntry) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.entries) > 0 && q.entries[0] == entry {
+		delete(q.queued, entry.hash)
+		q.entries = q.entries[1:]
+	}
+	resendQueueGauge.Update(int64(len(q.entries)))
+}
+
+// len returns the number of queued txs.
+func (q *resendQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.entries)
+}
+
+// flush sends the queued txs in order until one fails, returning the number
+// sent.
+func (ec *executorClient) flush(exit <-chan struct{}) (int, error) {
+	var sent int
+	for entry := ec.resend.peek(); entry != nil; entry = ec.resend.peek() {
+		select {
+		case <-exit:
+			return sent, errExecutorStopping
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), resendTimeout)
+		_, err := ec.p2pClient.Send(ctx, entry.packet)
+		cancel()
+		if err != nil && transientErr(err) {
+			return sent, err
+		}
+		// Txs consensus rejected are dropped, it won't take them later either
+		if err != nil {
+			log.Debug("Consensus rejected queued transaction", "hash", entry.hash, "err", err)
+		}
+		ec.resend.remove(entry)
+		resendSentMeter.Mark(1)
+		sent++
+	}
+	return sent, nil
+}
+
+// resendLoop sends the txs which failed to reach consensus again, backing off
+// exponentially while it stays unreachable.
+func (e *executor) resendLoop() {
+	defer e.wg.Done()
+
+	q := e.execClient.resend
+	if q == nil {
+		return
+	}
+	base := e.config.ReconnectBackoff
+	if base <= 0 {
+		base = DefaultConfig.ReconnectBackoff
+	}
+	var (
+		delay = base
+		armed bool
+		timer = time.NewTimer(0)
+	)
+	defer timer.Stop()
+	<-timer.C // discard the initial tick
+
+	for {
+		select {
+		case <-q.wake:
+			// Txs only fail while consensus is unreachable, give it some time
+			if !armed {
+				timer.Reset(delay)
+				armed = true
+			}
+			continue
+
+		case <-q.connected:
+			// Consensus is back, don't wait for the backoff
+			if armed && !timer.Stop() {
+				<-timer.C
+			}
+			armed, delay = false, base
+
+		case <-timer.C:
+			armed = false
+
+		case <-e.exitCh:
+			if queued := q.len(); queued > 0 {
+				log.Warn("Dropping transactions queued for consensus", "txs", queued)
+			}
+			return
+		}
+		sent, err := e.execClient.flush(e.exitCh)
+		if sent > 0 || err != nil {
+			e.trackLink(err)
+		}
+		if err != nil {
+			if errors.Is(err, errExecutorStopping) {
+				continue
+			}
+			log.Debug("Failed to resend transactions to consensus", "sent", sent, "queued", q.len(), "retry", delay, "err", err)
+			timer.Reset(delay)
+			armed = true
+			if delay *= 2; delay > maxReconnectBackoff {
+				delay = maxReconnectBackoff
+			}
+			continue
+		}
+		if sent > 0 {
+			log.Info("Resent queued transactions to consensus", "txs", sent)
+		}
+		delay = base
+	}
+}
+
+// watchConn logs the health of a connection to consensus, flushing the txs
+// queued meanwhile once it's back. Changes of the health are reported to the
+// given callback.
+func (e *executor) watchConn(conn *grpc.ClientConn, health func(bool)) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-e.exitCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		conn.Connect()
+		for state := conn.GetState(); conn.WaitForStateChange(ctx, state); {
+			prev := state
+			state = conn.GetState()
+
+			switch state {
+			case connectivity.Ready:
+				log.Info("Connected to consensus", "target", conn.Target())
+				health(true)
+				if q := e.execClient.resend; q != nil {
+					select {
+					case q.connected <- struct{}{}:
+					default:
+					}
+				}
+			case connectivity.TransientFailure:
+				if prev != connectivity.TransientFailure {
+					log.Warn("Lost connection to consensus, reconnecting", "target", conn.Target())
+				}
+				health(false)
+			case connectivity.Idle:
+				conn.Connect()
+			}
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyP2PClient fails the txs sent to consensus with the set error.
+type flakyP2PClient struct {
+	lock sync.Mutex
+	err  error
+	sent [][]byte
+}
+
+func (c *flakyP2PClient) Send(ctx context.Context, in *pb.Packet, opts ...grpc.CallOption) (*pb.Empty, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.sent = append(c.sent, in.Msg)
+	return &pb.Empty{}, nil
+}
+
+func (c *flakyP2PClient) fail(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.err = err
+}
+
+func (c *flakyP2PClient) delivered() [][]byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([][]byte{}, c.sent...)
+}
+
+func TestResendQueue(t *testing.T) {
+	signer := types.LatestSigner(ethashChainConfig)
+	txs := make(types.Transactions, 4)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	newExec := func(backoff time.Duration) (*executor, *flakyP2PClient) {
+		cfg := *testConfig
+		cfg.ReconnectBackoff = backoff
+		cfg.AlertLinkTimeout = time.Hour
+
+		p2p := &flakyP2PClient{err: status.Error(codes.Unavailable, "connection refused")}
+		e := &executor{
+			config:     &cfg,
+			exitCh:     make(chan struct{}),
+			execClient: &executorClient{p2pClient: p2p, resend: newResendQueue(2)},
+			alerter:    newAlerter(&cfg),
+		}
+		e.wg.Add(1)
+		go e.resendLoop()
+		return e, p2p
+	}
+	awaitSent := func(p2p *flakyP2PClient, want ...*types.Transaction) {
+		t.Helper()
+		for start := time.Now(); len(p2p.delivered()) < len(want); time.Sleep(5 * time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatalf("queued txs not sent: have %d, want %d", len(p2p.delivered()), len(want))
+			}
+		}
+		for i, msg := range p2p.delivered() {
+			if packet, _ := txPacket(want[i]); !bytes.Equal(msg, packet.Msg) {
+				t.Errorf("sent tx %d mismatch, want %x", i, want[i].Hash())
+			}
+		}
+	}
+	e, p2p := newExec(20 * time.Millisecond)
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+	// Txs failing while consensus is unreachable are queued once, the oldest
+	// ones dropped if full
+	for _, tx := range append(txs[:3:3], txs[1]) {
+		if _, err := e.execClient.sendTx(tx); !errors.Is(err, errTxQueued) {
+			t.Fatalf("send error mismatch: have %v, want %v", err, errTxQueued)
+		}
+	}
+	if queued := e.execClient.resend.len(); queued != 2 {
+		t.Fatalf("queued txs mismatch: have %d, want 2", queued)
+	}
+	// Txs consensus rejects are not
+	p2p.fail(status.Error(codes.InvalidArgument, "invalid tx"))
+	if _, err := e.execClient.sendTx(txs[3]); err == nil || errors.Is(err, errTxQueued) {
+		t.Errorf("rejected tx queued: %v", err)
+	}
+	// Queued txs are sent in order once consensus is back
+	p2p.fail(nil)
+	awaitSent(p2p, txs[1], txs[2])
+	if queued := e.execClient.resend.len(); queued != 0 {
+		t.Errorf("sent txs left in the queue: %d", queued)
+	}
+	// The reconnect flushes the queue without waiting for the backoff
+	slow, p2p := newExec(time.Hour)
+	defer func() {
+		close(slow.exitCh)
+		slow.wg.Wait()
+	}()
+	if _, err := slow.execClient.sendTx(txs[3]); !errors.Is(err, errTxQueued) {
+		t.Fatalf("send error mismatch: have %v, want %v", err, errTxQueued)
+	}
+	p2p.fail(nil)
+	slow.execClient.resend.connected <- struct{}{}
+	awaitSent(p2p, txs[3])
+}
@@ -0,0 +1,32 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var reservedSkippedMeter = metrics.NewRegisteredMeter("executor/reserved/skipped", nil)
+
+// isReserved reports whether a tx may use the gas reserved for governance, the
+// txs consensus delivered as UPGRADE txs. Txs calling system contracts don't,
+// anyone could send those to eat up the reservation.
+func (e *executor) isReserved(env *executor_env, tx *types.Transaction) bool {
+	_, ok := env.upgrades[tx.Hash()]
+	return ok
+}
+
+// fitsUnreserved reports whether a tx fits in the block without eating into
+// the gas reserved for governance. Reserved txs may use the whole block.
+func (e *executor) fitsUnreserved(env *executor_env, tx *types.Transaction) bool {
+	reserved := e.chainConfig.Executor.ReservedGas(env.header.GasLimit)
+	if reserved == 0 || e.isReserved(env, tx) {
+		return true
+	}
+	if env.unreservedGas+tx.Gas() <= env.header.GasLimit-reserved {
+		return true
+	}
+	log.Trace("Not enough unreserved gas left for transaction", "hash", tx.Hash(), "used", env.unreservedGas, "reserved", reserved, "needed", tx.Gas())
+	reservedSkippedMeter.Mark(1)
+	return false
+}
@@ -0,0 +1,105 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReservedGas(t *testing.T) {
+	signer := types.LatestSigner(ethashChainConfig)
+	transfer := func(nonce uint64) *types.Transaction {
+		return types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	tests := []struct {
+		percent uint64
+		want    int
+	}{
+		{percent: 0, want: 4},
+		// Only two regular transfers fit outside the reservation, the governance
+		// tx still makes it in
+		{percent: 99, want: 3},
+	}
+	for _, tt := range tests {
+		config := postMergeConfig()
+		config.Executor = &params.ExecutorConfig{ReservedGasPercent: tt.percent}
+
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+		block := new(pb.ExecBlock)
+		for i, kind := range []pb.TransactionType{pb.TransactionType_NORMAL, pb.TransactionType_NORMAL, pb.TransactionType_UPGRADE, pb.TransactionType_NORMAL} {
+			payload, _ := transfer(uint64(i)).MarshalBinary()
+			blob, _ := proto.Marshal(&pb.Transaction{Type: kind, Payload: payload})
+			block.Txs = append(block.Txs, blob)
+		}
+		req, _, err := decodeExecBlock(block, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("reserved %d%%: failed to execute block: %v", tt.percent, err)
+		}
+		head := backend.chain.GetBlockByHash(backend.chain.CurrentBlock().Hash())
+		if have := len(head.Transactions()); have != tt.want {
+			t.Errorf("reserved %d%%: included txs mismatch: have %d, want %d", tt.percent, have, tt.want)
+		}
+	}
+}
+
+func TestReservedGasForwarding(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Executor = &params.ExecutorConfig{ReservedGasPercent: 50}
+
+	var (
+		signer = types.LatestSigner(&config)
+		head   = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.GWei)}
+		txs    types.Transactions
+	)
+	for i := 0; i < 4; i++ {
+		txs = append(txs, types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			Nonce:     uint64(i),
+			To:        &testUserAddress,
+			Gas:       params.TxGas,
+			GasFeeCap: big.NewInt(2 * params.GWei),
+			GasTipCap: big.NewInt(params.GWei),
+		}))
+	}
+	pending := map[common.Address]types.Transactions{testBankAddress: txs}
+	report := SimulateForwarding(&config, head, pending, ForwardingPolicy{GasLimit: 4 * params.TxGas, Locals: []common.Address{testBankAddress}})
+	if report.Txs != 2 {
+		t.Errorf("forwarded txs mismatch: have %d, want %d", report.Txs, 2)
+	}
+}
+
+func TestReservedSystemContract(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Executor = &params.ExecutorConfig{
+		ReservedGasPercent: 50,
+		SystemCalls:        []params.SystemCall{{Address: testUserAddress}},
+	}
+	e := &executor{chainConfig: &config}
+
+	// Anyone may call a system contract, only UPGRADE txs may use the reservation
+	tx := types.MustSignNewTx(testBankKey, types.LatestSigner(&config), &types.LegacyTx{To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	env := new(executor_env)
+	if e.isReserved(env, tx) {
+		t.Errorf("system contract call reserved")
+	}
+	env.upgrades = map[common.Hash]struct{}{tx.Hash(): {}}
+	if !e.isReserved(env, tx) {
+		t.Errorf("upgrade tx not reserved")
+	}
+}
@@ -0,0 +1,153 @@
+package miner
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// resultQueue is the number of execution results buffered for consensus,
+// further ones are dropped until it catches up.
+const resultQueue = 1024
+
+var (
+	resultDroppedMeter = metrics.NewRegisteredMeter("executor/result/dropped", nil)
+	resultFailedMeter  = metrics.NewRegisteredMeter("executor/result/failed", nil)
+)
+
+// blockResult is the outcome of executing a block consensus awaits.
+type blockResult struct {
+	result *pb.BlockResult
+	err    error
+}
+
+// awaitResult waits for the execution of a consensus block queued by
+// CommitBlock, returning the outcome of each of its txs. Blocks without
+// anything to execute only report their dropped txs.
+func (es *executorServer) awaitResult(ctx context.Context, req *execReq, dropped map[int]error) (*pb.BlockResult, error) {
+	if req == nil {
+		return &pb.BlockResult{Txs: txResults(nil, dropped, nil)}, nil
+	}
+	select {
+	case res := <-req.result:
+		if res.err != nil {
+			return &pb.BlockResult{}, res.err
+		}
+		return res.result, nil
+	case <-es.executorPtr.exitCh:
+		return &pb.BlockResult{}, errExecutorStopping
+	case <-ctx.Done():
+		return &pb.BlockResult{}, ctx.Err()
+	}
+}
+
+// blockResult collects the outcome of an executed consensus block from the
+// receipts of the block written to the chain.
+func (e *executor) blockResult(req *execReq) *pb.BlockResult {
+	if req.report.hash == (common.Hash{}) {
+		return &pb.BlockResult{Txs: txResults(req.txs, req.dropped, nil)}
+	}
+	receipts := e.eth.BlockChain().GetReceiptsByHash(req.report.hash)
+	return &pb.BlockResult{
+		Hash:    req.report.hash.Bytes(),
+		Number:  req.report.number,
+		GasUsed: req.report.gas,
+		Txs:     txResults(req.txs, req.dropped, receipts),
+	}
+}
+
+// txResults lists the outcome of every tx of a consensus block in its order,
+// interleaving the decoded txs with the dropped ones. Decoded txs without a
+// receipt were skipped by the execution.
+func txResults(txs types.Transactions, dropped map[int]error, receipts types.Receipts) []*pb.TxResult {
+	included := make(map[common.Hash]*types.Receipt, len(receipts))
+	for _, receipt := range receipts {
+		included[receipt.TxHash] = receipt
+	}
+	results := make([]*pb.TxResult, 0, len(txs)+len(dropped))
+	for i, next := 0, 0; i < len(txs)+len(dropped); i++ {
+		if err, ok := dropped[i]; ok {
+			results = append(results, &pb.TxResult{Status: pb.TxStatus_TX_DROPPED, Reason: err.Error()})
+			continue
+		}
+		tx := txs[next]
+		next++
+
+		result := &pb.TxResult{Hash: tx.Hash().Bytes(), Status: pb.TxStatus_TX_SKIPPED}
+		if receipt := included[tx.Hash()]; receipt != nil {
+			// A tx repeated in the block is only included once
+			delete(included, tx.Hash())
+
+			result.GasUsed = receipt.GasUsed
+			if receipt.Status == types.ReceiptStatusSuccessful {
+				result.Status = pb.TxStatus_TX_SUCCEEDED
+			} else {
+				result.Status = pb.TxStatus_TX_REVERTED
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// reportResult queues the outcome of a written block for consensus. Unlike the
+// heads, the result of every block is queued, consensus compares each of them
+// across the replicas. Delivery is best effort though: results are dropped
+// while consensus is resultQueue results behind, and results failing to reach
+// it aren't sent again. Both are metered and logged, consensus notices them by
+// the gap in the numbers.
+func (e *executor) reportResult(block *types.Block, meta *types.ConsensusMeta) {
+	if e.resultCh == nil {
+		return
+	}
+	result := &pb.ExecutionResult{
+		Number:       block.NumberU64(),
+		Hash:         block.Hash().Bytes(),
+		StateRoot:    block.Root().Bytes(),
+		ReceiptsRoot: block.ReceiptHash().Bytes(),
+		GasUsed:      block.GasUsed(),
+	}
+	if meta != nil {
+		result.Sequence = meta.Sequence
+	}
+	select {
+	case e.resultCh <- result:
+	default:
+		resultDroppedMeter.Mark(1)
+		log.Warn("Dropping execution result, consensus not keeping up", "number", block.NumberU64(), "hash", block.Hash())
+	}
+}
+
+// resultLoop reports the outcome of the written blocks to consensus, in order.
+func (e *executor) resultLoop() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case result := <-e.resultCh:
+			e.sendResult(result)
+		case <-e.exitCh:
+			return
+		}
+	}
+}
+
+// sendResult sends the outcome of a written block to consensus.
+func (e *executor) sendResult(result *pb.ExecutionResult) {
+	if e.execClient == nil || e.execClient.consensusClient == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), headTimeout)
+	defer cancel()
+
+	_, err := e.execClient.consensusClient.ReportBlockResult(ctx, result)
+	e.trackLink(err)
+	if err != nil {
+		resultFailedMeter.Mark(1)
+		log.Warn("Failed to report execution result to consensus", "number", result.Number, "err", err)
+	}
+}
@@ -0,0 +1,140 @@
+package miner
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestCommitBlockResults(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		alerter:     newAlerter(testConfig),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	signer := types.LatestSigner(&config)
+	var (
+		transfer = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 0, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		reverted = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, Gas: 100000, GasPrice: big.NewInt(params.InitialBaseFee), Data: common.FromHex("60006000fd")})
+		skipped  = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	)
+	block := newTestExecBlock(t, transfer, reverted, skipped)
+	block.Txs = append(block.Txs[:1], append([][]byte{{0xff}}, block.Txs[1:]...)...)
+
+	es := &executorServer{executorPtr: e}
+	block.AwaitResult = true
+	res, err := es.CommitBlock(context.Background(), block)
+	if err != nil {
+		t.Fatalf("failed to commit block: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	if common.BytesToHash(res.Hash) != head.Hash() || res.Number != head.Number.Uint64() || res.GasUsed != head.GasUsed {
+		t.Errorf("block mismatch: have #%d %x gas %d, want #%d %x gas %d", res.Number, res.Hash, res.GasUsed, head.Number, head.Hash(), head.GasUsed)
+	}
+	// Every tx of the consensus block is reported in order
+	want := []struct {
+		hash   common.Hash
+		status pb.TxStatus
+	}{
+		{transfer.Hash(), pb.TxStatus_TX_SUCCEEDED},
+		{common.Hash{}, pb.TxStatus_TX_DROPPED},
+		{reverted.Hash(), pb.TxStatus_TX_REVERTED},
+		{skipped.Hash(), pb.TxStatus_TX_SKIPPED},
+	}
+	if len(res.Txs) != len(want) {
+		t.Fatalf("tx result count mismatch: have %d, want %d", len(res.Txs), len(want))
+	}
+	var gas uint64
+	for i, tx := range res.Txs {
+		if common.BytesToHash(tx.Hash) != want[i].hash || tx.Status != want[i].status {
+			t.Errorf("tx %d: result mismatch: have %x %v, want %x %v", i, tx.Hash, tx.Status, want[i].hash, want[i].status)
+		}
+		gas += tx.GasUsed
+	}
+	if res.Txs[1].Reason == "" {
+		t.Errorf("dropped tx without reason")
+	}
+	if res.Txs[3].GasUsed != 0 {
+		t.Errorf("skipped tx used gas: %d", res.Txs[3].GasUsed)
+	}
+	if gas != res.GasUsed {
+		t.Errorf("tx gas mismatch: have %d, want %d", gas, res.GasUsed)
+	}
+	// Blocks not awaited return once queued, failing on the dropped txs
+	block.AwaitResult = false
+	if res, err := es.CommitBlock(context.Background(), block); err == nil || len(res.Txs) != 0 {
+		t.Errorf("outcome of unawaited block reported: %v %v", res, err)
+	}
+}
+
+func TestReportBlockResult(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	client := new(testConsensusClient)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		resultCh:    make(chan *pb.ExecutionResult, resultQueue),
+		execClient:  &executorClient{consensusClient: client},
+		alerter:     newAlerter(testConfig),
+	}
+	// Unlike the heads, the result of every written block is reported
+	var written []*types.Header
+	for i, tx := range []*types.Transaction{pendingTxs[0], newTxs[0]} {
+		req := &execReq{
+			timestamp: time.Now().UnixNano(),
+			txs:       types.Transactions{tx},
+			sequence:  uint64(10 + i),
+			meta:      &types.ConsensusMeta{Sequence: uint64(10 + i)},
+		}
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block %d: %v", i, err)
+		}
+		written = append(written, backend.chain.CurrentBlock())
+	}
+	for len(e.resultCh) > 0 {
+		e.sendResult(<-e.resultCh)
+	}
+	if len(client.results) != len(written) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(client.results), len(written))
+	}
+	for i, header := range written {
+		have := client.results[i]
+		if have.Sequence != uint64(10+i) || have.Number != header.Number.Uint64() || !bytes.Equal(have.Hash, header.Hash().Bytes()) {
+			t.Errorf("result %d: block mismatch: have #%d %x sequence %d, want #%d %x sequence %d", i, have.Number, have.Hash, have.Sequence, header.Number, header.Hash(), 10+i)
+		}
+		if !bytes.Equal(have.StateRoot, header.Root.Bytes()) || !bytes.Equal(have.ReceiptsRoot, header.ReceiptHash.Bytes()) || have.GasUsed != header.GasUsed {
+			t.Errorf("result %d: outcome mismatch: have root %x receipts %x gas %d, want root %x receipts %x gas %d", i, have.StateRoot, have.ReceiptsRoot, have.GasUsed, header.Root, header.ReceiptHash, header.GasUsed)
+		}
+	}
+}
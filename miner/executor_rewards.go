@@ -0,0 +1,27 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// feesCounter accumulates the priority fees credited to the coinbase in gwei,
+// the only revenue of the executor as the chain has no block subsidy.
+var feesCounter = metrics.NewRegisteredCounter("executor/fees", nil)
+
+// PriorityFees returns the priority fees of a block credited to its coinbase in
+// wei. The receipts have to be in the order of the txs of the block.
+func PriorityFees(block *types.Block, receipts types.Receipts) *big.Int {
+	return totalFees(block, receipts)
+}
+
+// accountFees records the priority fees of a written block in its report and
+// the fee metrics.
+func (e *executor) accountFees(req *execReq, block *types.Block, receipts []*types.Receipt) {
+	fees := totalFees(block, receipts)
+	req.report.fees = fees
+	feesCounter.Inc(new(big.Int).Div(fees, big.NewInt(params.GWei)).Int64())
+}
@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// journalEntrySize is the memory accounted to a journalled state modification,
+// an estimate of the entry along with the dirty state it refers to.
+const journalEntrySize = 256
+
+var (
+	errBlockMemoryLimit = errors.New("block memory limit exceeded")
+	errExecutionPanic   = errors.New("execution panicked")
+)
+
+var (
+	sandboxTrippedMeter = metrics.NewRegisteredMeter("executor/sandbox/tripped", nil)
+	sandboxMemoryGauge  = metrics.NewRegisteredGauge("executor/sandbox/memory", nil)
+)
+
+// sandboxed runs the EVM execution of a consensus block, converting a panic
+// in the execution into an error failing the block instead of crashing the
+// node.
+func sandboxed(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("Block execution panicked", "err", r)
+			err = fmt.Errorf("%w: %v", errExecutionPanic, r)
+		}
+	}()
+	return fn()
+}
+
+// memoryBudget accounts the memory the execution of a block holds on to. The
+// accounting relies on deterministic measures only, the state journal and the
+// logs, so every executor trips on the same tx of the same block.
+type memoryBudget struct {
+	limit   uint64
+	used    uint64
+	journal int   // journal entries of the state when the budget was created
+	err     error // set once the limit was exceeded
+}
+
+func newMemoryBudget(limit uint64, statedb *state.StateDB) *memoryBudget {
+	return &memoryBudget{limit: limit, journal: statedb.JournalEntries()}
+}
+
+// charge accounts the state changes and logs of an executed tx, returning an
+// error if the block went over the limit.
+func (b *memoryBudget) charge(statedb *state.StateDB, logs []*types.Log) error {
+	if b == nil {
+		return nil
+	}
+	journal := statedb.JournalEntries()
+	b.used += uint64(journal-b.journal) * journalEntrySize
+	b.journal = journal
+
+	for _, l := range logs {
+		b.used += uint64(len(l.Data) + len(l.Topics)*32 + 20)
+	}
+	if b.limit != 0 && b.used > b.limit && b.err == nil {
+		b.err = fmt.Errorf("%w: have %d, want at most %d", errBlockMemoryLimit, b.used, b.limit)
+	}
+	return b.err
+}
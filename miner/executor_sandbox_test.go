@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestBlockMemoryLimit(t *testing.T) {
+	tests := []struct {
+		limit  uint64
+		number uint64
+		faults int
+	}{
+		{limit: 0, number: 1},
+		{limit: 1 << 20, number: 1},
+		{limit: 1, number: 0, faults: 1},
+	}
+	for _, tt := range tests {
+		config := postMergeConfig()
+		config.Executor = &params.ExecutorConfig{MaxBlockMemory: tt.limit}
+
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		// Rejected blocks must not go through the failure policy
+		cfg := *testConfig
+		cfg.FailurePolicy = FailureHalt
+		client := new(testConsensusClient)
+		e := &executor{
+			config:      &cfg,
+			chainConfig: &config,
+			engine:      ethash.NewFaker(),
+			eth:         backend,
+			execClient:  &executorClient{consensusClient: client},
+			alerter:     newAlerter(&cfg),
+		}
+		err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0], newTxs[0]}, sequence: 5})
+		if tt.faults == 0 && err != nil {
+			t.Fatalf("limit %d: failed to execute block: %v", tt.limit, err)
+		}
+		if tt.faults > 0 && !errors.Is(err, errBlockMemoryLimit) {
+			t.Fatalf("limit %d: error mismatch: have %v, want %v", tt.limit, err, errBlockMemoryLimit)
+		}
+		if have := backend.chain.CurrentBlock().Number.Uint64(); have != tt.number {
+			t.Errorf("limit %d: head mismatch: have %d, want %d", tt.limit, have, tt.number)
+		}
+		if len(client.faults) != tt.faults {
+			t.Fatalf("limit %d: fault count mismatch: have %d, want %d", tt.limit, len(client.faults), tt.faults)
+		}
+		if tt.faults > 0 && (client.faults[0].Type != pb.FaultType_REJECTED || client.faults[0].Sequence != 5) {
+			t.Errorf("limit %d: fault mismatch: have %v, want %v", tt.limit, client.faults[0], pb.FaultType_REJECTED)
+		}
+		if e.halted.Load() {
+			t.Errorf("limit %d: executor halted", tt.limit)
+		}
+	}
+}
+
+func TestSandboxPanic(t *testing.T) {
+	err := sandboxed(func() error { panic("boom") })
+	if !errors.Is(err, errExecutionPanic) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errExecutionPanic)
+	}
+}
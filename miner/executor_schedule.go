@@ -0,0 +1,118 @@
+package miner
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// decodeSchedule converts the schedule hints of a consensus block, referencing
+// txs by their position in the block, into a schedule referencing the txs that
+// could be decoded. Txs dropped while decoding are dropped from the schedule
+// too, while references to non-existent txs are kept as -1 so the schedule is
+// rejected later.
+func decodeSchedule(groups []*pb.TxGroup, positions map[int]int, total int) [][]int {
+	if len(groups) == 0 {
+		return nil
+	}
+	schedule := make([][]int, 0, len(groups))
+	for _, group := range groups {
+		var txs []int
+		for _, pos := range group.GetTxs() {
+			if int(pos) >= total {
+				txs = append(txs, -1)
+				continue
+			}
+			if idx, ok := positions[int(pos)]; ok {
+				txs = append(txs, idx)
+			}
+		}
+		if len(txs) > 0 {
+			schedule = append(schedule, txs)
+		}
+	}
+	return schedule
+}
+
+// verifySchedule checks that the schedule references every tx exactly once and
+// that the txs grouped together are independent of each other, i.e. none of
+// them share a sender or recipient.
+func verifySchedule(signer types.Signer, txs types.Transactions, schedule [][]int) error {
+	seen := make([]bool, len(txs))
+	for i, group := range schedule {
+		touched := make(map[common.Address]struct{})
+		for _, idx := range group {
+			if idx < 0 || idx >= len(txs) {
+				return fmt.Errorf("group %d: unknown tx", i)
+			}
+			if seen[idx] {
+				return fmt.Errorf("group %d: tx %d scheduled multiple times", i, idx)
+			}
+			seen[idx] = true
+
+			from, err := types.Sender(signer, txs[idx])
+			if err != nil {
+				return fmt.Errorf("group %d: tx %d: %v", i, idx, err)
+			}
+			addrs := []common.Address{from}
+			if to := txs[idx].To(); to != nil && *to != from {
+				addrs = append(addrs, *to)
+			}
+			for _, addr := range addrs {
+				if _, ok := touched[addr]; ok {
+					return fmt.Errorf("group %d: tx %d conflicts on %v", i, idx, addr)
+				}
+				touched[addr] = struct{}{}
+			}
+		}
+	}
+	for idx, ok := range seen {
+		if !ok {
+			return fmt.Errorf("tx %d not scheduled", idx)
+		}
+	}
+	return nil
+}
+
+// prefetchSchedule speculatively executes the independent txs of every group
+// of the schedule hints in parallel on throwaway state copies, so the canonical
+// serial execution finds the state it needs already cached. The txs are still
+// executed in consensus order, hints differing between replicas can't change
+// the block. Invalid hints are ignored.
+func (e *executor) prefetchSchedule(env *executor_env, txs types.Transactions, schedule [][]int) {
+	if schedule == nil {
+		return
+	}
+	if err := verifySchedule(env.signer, txs, schedule); err != nil {
+		log.Warn("Ignoring invalid schedule hints", "txs", len(txs), "err", err)
+		return
+	}
+	var (
+		pend  sync.WaitGroup
+		limit = make(chan struct{}, runtime.NumCPU())
+	)
+	for _, group := range schedule {
+		for _, idx := range group {
+			var (
+				tx      = txs[idx]
+				statedb = env.state.Copy()
+				header  = types.CopyHeader(env.header)
+			)
+			pend.Add(1)
+			limit <- struct{}{}
+			go func() {
+				defer func() { <-limit; pend.Done() }()
+
+				gp := new(core.GasPool).AddGas(header.GasLimit)
+				core.ApplyTransaction(e.chainConfig, env.chain, &env.coinbase, gp, statedb, header, tx, new(uint64), *e.eth.BlockChain().GetVMConfig())
+			}()
+		}
+	}
+	pend.Wait()
+}
@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestDecodeSchedule(t *testing.T) {
+	// Tx 1 of the consensus block was dropped while decoding
+	positions := map[int]int{0: 0, 2: 1, 3: 2}
+	groups := []*pb.TxGroup{{Txs: []uint32{0, 1}}, {Txs: []uint32{1}}, {Txs: []uint32{3, 2, 9}}}
+
+	have := decodeSchedule(groups, positions, 4)
+	want := [][]int{{0}, {2, 1, -1}}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("schedule mismatch: have %v, want %v", have, want)
+	}
+	if decodeSchedule(nil, positions, 4) != nil {
+		t.Fatalf("empty schedule decoded")
+	}
+}
+
+func TestVerifySchedule(t *testing.T) {
+	var (
+		signer = types.LatestSigner(params.TestChainConfig)
+		txs    types.Transactions
+	)
+	// Tx 0 and 3 are sent by the same account, 1 and 2 by unrelated ones
+	txs = append(txs, types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(1)}))
+	for i := 1; i <= 2; i++ {
+		key, _ := crypto.GenerateKey()
+		to := common.BigToAddress(big.NewInt(int64(i)))
+		txs = append(txs, types.MustSignNewTx(key, signer, &types.LegacyTx{To: &to, Gas: params.TxGas, GasPrice: big.NewInt(1)}))
+	}
+	txs = append(txs, types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 1, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(1)}))
+
+	tests := []struct {
+		schedule [][]int
+		valid    bool
+	}{
+		{[][]int{{0, 1, 2}, {3}}, true},
+		{[][]int{{3}, {1}, {2, 0}}, true},
+		{[][]int{{0, 1, 2, 3}}, false}, // same sender in one group
+		{[][]int{{0, 1}, {2}}, false},  // tx 3 missing
+		{[][]int{{0, 1}, {1, 2}, {3}}, false},
+		{[][]int{{0, 1, 2}, {3, -1}}, false},
+	}
+	for i, tt := range tests {
+		if err := verifySchedule(signer, txs, tt.schedule); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, err, tt.valid)
+		}
+	}
+}
+
+func TestScheduleKeepsOrder(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	// The hints run nonce 0 first, consensus delivered nonce 1 first
+	block := newTestExecBlock(t, newTxs[0], pendingTxs[0])
+	block.Schedule = []*pb.TxGroup{{Txs: []uint32{1}}, {Txs: []uint32{0}}}
+	req, _, err := decodeExecBlock(block, nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.GetBlockByHash(backend.chain.CurrentBlock().Hash())
+	if txs := head.Transactions(); len(txs) != 1 || txs[0].Hash() != pendingTxs[0].Hash() {
+		t.Fatalf("executed txs mismatch: have %d txs, want only nonce 0", len(txs))
+	}
+}
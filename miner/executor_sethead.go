@@ -0,0 +1,193 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+var (
+	rewindMeter     = metrics.NewRegisteredMeter("executor/sethead/rewinds", nil)
+	rewoundMeter    = metrics.NewRegisteredMeter("executor/sethead/blocks", nil)
+	reinjectedMeter = metrics.NewRegisteredMeter("executor/sethead/reinjected", nil)
+
+	errUnknownHead  = errors.New("unknown block")
+	errNotCanonical = errors.New("block not in the canonical chain")
+	errRevoked      = errors.New("consensus block revoked by rewind")
+)
+
+// setHeadReq is a block consensus asked to rewind the chain to.
+type setHeadReq struct {
+	hash   common.Hash // block to rewind to, the number is used if zero
+	number uint64
+	result chan *setHeadResult
+}
+
+type setHeadResult struct {
+	head *types.Header // chain head after the rewind
+	err  error
+}
+
+// SetHead rewinds the chain to a block after consensus revoked or replaced the
+// blocks on top of it. The txs of the rewound blocks are returned to the pool,
+// and the execution continues with the consensus sequence of the new head. The
+// blocks delivered before but not executed yet are revoked.
+func (es *executorServer) SetHead(ctx context.Context, req *pb.SetHeadRequest) (*pb.Head, error) {
+	e := es.executorPtr
+	if e.config.Stateless {
+		return nil, errStateless
+	}
+	rewind := &setHeadReq{
+		hash:   common.BytesToHash(req.GetHash()),
+		number: req.GetNumber(),
+		result: make(chan *setHeadResult, 1),
+	}
+	select {
+	case e.setHeadCh <- rewind:
+	case <-e.exitCh:
+		return nil, errExecutorStopping
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res := <-rewind.result
+	if res.err != nil {
+		return nil, res.err
+	}
+	head := &pb.Head{
+		Hash:      res.head.Hash().Bytes(),
+		Number:    res.head.Number.Uint64(),
+		StateRoot: res.head.Root.Bytes(),
+	}
+	// Blocks queued from now on follow the new head
+	var seq uint64
+	if meta := e.eth.BlockChain().GetConsensusMeta(res.head.Hash()); meta != nil {
+		head.Sequence, seq = meta.Sequence, meta.Sequence
+	}
+	e.resetOrder(seq)
+	return head, nil
+}
+
+// setHead rewinds the chain to the requested block, discarding the blocks held
+// aside of the chain on top of the previous head. It's run by the execution
+// loop once the blocks written in the background landed.
+func (e *executor) setHead(req *setHeadReq) (*types.Header, error) {
+	chain := e.eth.BlockChain()
+	var target *types.Header
+	if req.hash != (common.Hash{}) {
+		target = chain.GetHeaderByHash(req.hash)
+	} else {
+		target = chain.GetHeaderByNumber(req.number)
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: hash %x, number %d", errUnknownHead, req.hash, req.number)
+	}
+	number := target.Number.Uint64()
+	if chain.GetCanonicalHash(number) != target.Hash() {
+		return nil, fmt.Errorf("%w: %x", errNotCanonical, target.Hash())
+	}
+	// The block deferred by the coalescing was delivered for the previous
+	// lineage, consensus delivers the blocks following the new head again
+	if e.deferred != nil {
+		e.revoke(e.deferred)
+		e.deferred = nil
+	}
+	head := chain.CurrentBlock()
+	if head.Hash() == target.Hash() {
+		return head, nil
+	}
+	// The tentative block and the branches fork off the previous head
+	if e.pending != nil {
+		log.Info("Discarded tentative block on rewind", "number", e.pending.block.Number(), "hash", e.pending.block.Hash())
+		e.pending = nil
+	}
+	for id := range e.branches {
+		e.discardBranch(id)
+	}
+	var orphans types.Transactions
+	for n := number + 1; n <= head.Number.Uint64(); n++ {
+		if block := chain.GetBlockByNumber(n); block != nil {
+			orphans = append(orphans, block.Transactions()...)
+		}
+	}
+	if err := chain.SetHead(number); err != nil {
+		return nil, err
+	}
+	rewindMeter.Mark(1)
+	rewoundMeter.Mark(int64(head.Number.Uint64() - number))
+
+	// The chain may have to go further back to a block it has the state of
+	current := chain.CurrentBlock()
+	if current.Hash() != target.Hash() {
+		log.Warn("Rewound chain below requested block", "number", current.Number, "hash", current.Hash(), "requested", number)
+	}
+	e.env.Store(&verifyEnv{header: current, signer: types.MakeSigner(e.chainConfig, current.Number, current.Time)})
+	e.executedSeq.Store(0)
+	if meta := chain.GetConsensusMeta(current.Hash()); meta != nil {
+		e.executedSeq.Store(meta.Sequence)
+	}
+	executedHeadGauge.Update(int64(e.executedSeq.Load()))
+	e.submissions.reset()
+
+	reinjected := e.reinject(orphans)
+	log.Warn("Rewound chain on consensus request", "from", head.Number, "to", current.Number, "hash", current.Hash(), "txs", len(orphans), "reinjected", reinjected)
+	return current, nil
+}
+
+// reinject returns the txs of the rewound blocks to the pool, once it caught up
+// with the new head. It returns the number of txs the pool took.
+func (e *executor) reinject(txs types.Transactions) int {
+	pool := e.eth.TxPool()
+	if pool == nil || len(txs) == 0 {
+		return 0
+	}
+	if err := pool.Sync(); err != nil {
+		log.Warn("Failed to sync pool with rewound chain", "err", err)
+	}
+	var added int
+	for _, err := range pool.Add(txs, false, true) {
+		if err == nil {
+			added++
+		}
+	}
+	reinjectedMeter.Mark(int64(added))
+	return added
+}
+
+// resetOrder continues the consensus sequence after the given one, or with the
+// next block delivered if unknown. The blocks held back for their predecessors
+// belong to the previous lineage and are revoked.
+func (e *executor) resetOrder(seq uint64) {
+	o := &e.order
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.next = 0
+	if seq != 0 {
+		o.next = seq + 1
+	}
+	if len(o.held) > 0 {
+		log.Warn("Revoked consensus blocks awaiting predecessors on rewind", "held", len(o.held), "next", o.next)
+	}
+	for held, req := range o.held {
+		e.revoke(req)
+		delete(o.held, held)
+	}
+	heldBlocksGauge.Update(0)
+}
+
+// revoke drops a consensus block delivered for a lineage consensus rewound,
+// failing it for consensus awaiting it and dropping it from the write-ahead log.
+func (e *executor) revoke(req *execReq) {
+	if req == nil {
+		return
+	}
+	e.abandonResult(req, errRevoked)
+	e.deleteWAL(req.wal)
+	req.wal = nil
+}
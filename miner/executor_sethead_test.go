@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestSetHead(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.BlockWAL = true
+	e := &executor{
+		config:      &cfg,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		confirmCh:   make(chan *confirmReq),
+		setHeadCh:   make(chan *setHeadReq),
+		alerter:     newAlerter(&cfg),
+		submissions: newSubmissions(16),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	var (
+		es     = &executorServer{executorPtr: e}
+		signer = types.LatestSigner(&config)
+		chain  = backend.chain
+		txs    = make(types.Transactions, 3)
+	)
+	block := func(seq uint64, txs ...*types.Transaction) *pb.ExecBlock {
+		pbBlock := newTestExecBlock(t, txs...)
+		pbBlock.Sequence, pbBlock.AwaitResult = seq, true
+		return pbBlock
+	}
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		if _, err := es.CommitBlock(context.Background(), block(uint64(i+1), txs[i])); err != nil {
+			t.Fatalf("failed to commit block %d: %v", i+1, err)
+		}
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 3 {
+		t.Fatalf("head mismatch: have %d, want 3", head)
+	}
+	// Blocks held back for their predecessors belong to the revoked lineage
+	ahead := block(5, types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: 4, To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)}))
+	ahead.AwaitResult = false
+	if _, err := es.CommitBlock(context.Background(), ahead); err != nil {
+		t.Fatalf("failed to commit block ahead: %v", err)
+	}
+	if indices, _ := chain.GetExecutorWAL(); len(indices) != 1 {
+		t.Fatalf("logged blocks mismatch: have %d, want 1", len(indices))
+	}
+	// Consensus revokes the blocks on top of the first one
+	first := chain.GetBlockByNumber(1)
+	head, err := es.SetHead(context.Background(), &pb.SetHeadRequest{Hash: first.Hash().Bytes()})
+	if err != nil {
+		t.Fatalf("failed to set head: %v", err)
+	}
+	if common.BytesToHash(head.Hash) != first.Hash() || head.Number != 1 || head.Sequence != 1 {
+		t.Fatalf("new head mismatch: have %x number %d sequence %d, want %x", head.Hash, head.Number, head.Sequence, first.Hash())
+	}
+	if chain.CurrentBlock().Hash() != first.Hash() || e.executedSeq.Load() != 1 {
+		t.Fatalf("chain not rewound: head %d, executed sequence %d", chain.CurrentBlock().Number, e.executedSeq.Load())
+	}
+	if held := len(e.order.held); held != 0 {
+		t.Errorf("held blocks mismatch after rewind: have %d, want 0", held)
+	}
+	if indices, _ := chain.GetExecutorWAL(); len(indices) != 0 {
+		t.Errorf("revoked blocks left in the log: %v", indices)
+	}
+	// The txs of the rewound blocks are back in the pool
+	for _, tx := range txs[1:] {
+		if !backend.txPool.Has(tx.Hash()) {
+			t.Errorf("rewound tx %x not reinjected", tx.Hash())
+		}
+	}
+	// The replacement of the revoked blocks is executed on the new head, even if
+	// consensus delivers the same block again
+	if _, err := es.CommitBlock(context.Background(), block(2, txs[1])); err != nil {
+		t.Fatalf("failed to commit replacement block: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Number.Uint64() != 2 || chain.GetBlockByNumber(2).Transactions()[0].Hash() != txs[1].Hash() {
+		t.Fatalf("replacement block mismatch: head %d", head.Number)
+	}
+	// Blocks ahead of the head or off the chain can't be rewound to
+	if _, err := es.SetHead(context.Background(), &pb.SetHeadRequest{Number: 5}); !errors.Is(err, errUnknownHead) {
+		t.Errorf("rewind beyond head error mismatch: have %v, want %v", err, errUnknownHead)
+	}
+	if _, err := es.SetHead(context.Background(), &pb.SetHeadRequest{Hash: common.Hash{0x01}.Bytes()}); !errors.Is(err, errUnknownHead) {
+		t.Errorf("unknown block error mismatch: have %v, want %v", err, errUnknownHead)
+	}
+}
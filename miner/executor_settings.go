@@ -0,0 +1,158 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// redacted replaces the settings executor_getConfig doesn't disclose.
+const redacted = "<redacted>"
+
+var errInvalidSetting = errors.New("invalid setting")
+
+// txTypeNames are the names the accepted tx types are reported by.
+var txTypeNames = map[int]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "accessList",
+	types.DynamicFeeTxType: "dynamicFee",
+	types.BlobTxType:       "blob",
+}
+
+// LiveConfig is the subset of the executor configuration which can be changed
+// while running via executor_setConfig, named like the fields of Config. Nil
+// fields are left unchanged. Durations are in nanoseconds, as reported by
+// executor_getConfig.
+type LiveConfig struct {
+	MinTip *hexutil.Big // minimum effective tip of the forwarded txs, like miner_setGasPrice
+
+	AdmissionMaxPending     *int
+	AdmissionRejectLinkDown *bool
+
+	MaxHeadLag    *uint64
+	ThrottleOnLag *bool
+	SyncLag       *uint64
+
+	PauseOnSlowDisk     *bool
+	CoalesceWindow      *time.Duration
+	InFlightRetry       *time.Duration
+	AllowUnprotectedTxs *bool
+	ForwardExclude      *[]common.Address // replaces the whole list, empty to forward to every contract
+}
+
+// ConfigSnapshot is the effective configuration of the executor, taken at once.
+type ConfigSnapshot struct {
+	Config    Config         `json:"config"`    // startup configuration along with the changes made while running, redacted
+	Etherbase common.Address `json:"etherbase"` // recipient of the fees, as last set
+	MinTip    *hexutil.Big   `json:"minTip"`    // minimum effective tip of the forwarded txs, as last set
+	TxTypes   []string       `json:"txTypes"`   // tx types verified and forwarded
+}
+
+// settings returns the current executor configuration, including the changes
+// made while running. The returned config must not be modified.
+func (e *executor) settings() *Config {
+	if config := e.live.Load(); config != nil {
+		return config
+	}
+	return e.config
+}
+
+// configSnapshot returns the effective configuration of the executor.
+func (e *executor) configSnapshot() ConfigSnapshot {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+	return e.snapshot()
+}
+
+// snapshot collects the effective configuration, the settings lock held.
+func (e *executor) snapshot() ConfigSnapshot {
+	snapshot := ConfigSnapshot{
+		Config:    redactConfig(*e.settings()),
+		Etherbase: e.etherbase(),
+		MinTip:    (*hexutil.Big)(e.getMinTip()),
+	}
+	if e.forwardOpts != nil {
+		for kind, name := range txTypeNames {
+			if e.forwardOpts.Accept&(1<<kind) != 0 {
+				snapshot.TxTypes = append(snapshot.TxTypes, name)
+			}
+		}
+		sort.Strings(snapshot.TxTypes)
+	}
+	return snapshot
+}
+
+// redactConfig blanks out the settings which may carry credentials or command
+// lines, the alert hooks and export sinks, and identifies the API keys of the
+// submitters by their hash like the tx origins do.
+func redactConfig(config Config) Config {
+	for _, field := range []*string{&config.AlertWebhook, &config.AlertCommand, &config.ReceiptExport, &config.EpochExport} {
+		if *field != "" {
+			*field = redacted
+		}
+	}
+	if len(config.Submitters) > 0 {
+		submitters := make([]SubmitterQuota, len(config.Submitters))
+		for i, quota := range config.Submitters {
+			if key, ok := strings.CutPrefix(quota.Source, "key:"); ok {
+				quota.Source = txpool.APIKeySource(key)
+			}
+			submitters[i] = quota
+		}
+		config.Submitters = submitters
+	}
+	return config
+}
+
+// updateConfig applies a change of the live settings, all or none of them.
+func (e *executor) updateConfig(update LiveConfig) (ConfigSnapshot, error) {
+	if update.MinTip != nil && update.MinTip.ToInt().Sign() < 0 {
+		return ConfigSnapshot{}, fmt.Errorf("%w: negative MinTip %v", errInvalidSetting, update.MinTip)
+	}
+	if update.AdmissionMaxPending != nil && *update.AdmissionMaxPending < 0 {
+		return ConfigSnapshot{}, fmt.Errorf("%w: negative AdmissionMaxPending %d", errInvalidSetting, *update.AdmissionMaxPending)
+	}
+	for name, d := range map[string]*time.Duration{"CoalesceWindow": update.CoalesceWindow, "InFlightRetry": update.InFlightRetry} {
+		if d != nil && *d < 0 {
+			return ConfigSnapshot{}, fmt.Errorf("%w: negative %s %v", errInvalidSetting, name, *d)
+		}
+	}
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+
+	config := *e.settings()
+	overwrite(&config.AdmissionMaxPending, update.AdmissionMaxPending)
+	overwrite(&config.AdmissionRejectLinkDown, update.AdmissionRejectLinkDown)
+	overwrite(&config.MaxHeadLag, update.MaxHeadLag)
+	overwrite(&config.ThrottleOnLag, update.ThrottleOnLag)
+	overwrite(&config.SyncLag, update.SyncLag)
+	overwrite(&config.PauseOnSlowDisk, update.PauseOnSlowDisk)
+	overwrite(&config.CoalesceWindow, update.CoalesceWindow)
+	overwrite(&config.InFlightRetry, update.InFlightRetry)
+	overwrite(&config.AllowUnprotectedTxs, update.AllowUnprotectedTxs)
+	if update.ForwardExclude != nil {
+		// The update is owned by the caller
+		config.ForwardExclude = append([]common.Address(nil), *update.ForwardExclude...)
+	}
+	e.live.Store(&config)
+
+	if update.MinTip != nil {
+		e.setMinTip((*big.Int)(update.MinTip))
+	}
+	return e.snapshot(), nil
+}
+
+// overwrite changes a setting if a new value is given.
+func overwrite[T any](setting *T, value *T) {
+	if value != nil {
+		*setting = *value
+	}
+}
@@ -0,0 +1,118 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	shadowMatchMeter   = metrics.NewRegisteredMeter("executor/shadow/match", nil)
+	shadowDiffMeter    = metrics.NewRegisteredMeter("executor/shadow/diff", nil)
+	shadowSkippedMeter = metrics.NewRegisteredMeter("executor/shadow/skipped", nil)
+	shadowTimer        = metrics.NewRegisteredTimer("executor/shadow/time", nil)
+)
+
+// shadowRun is the input of the shadow execution of a block: the state and
+// header right before its txs were executed, and the state root right after.
+type shadowRun struct {
+	state       *state.StateDB
+	header      *types.Header
+	calldataFee *big.Int // fee per calldata byte of the block, nil if none
+	root        common.Hash
+}
+
+// shadowEnabled reports whether committed blocks are replayed with the
+// alternative VM config.
+func (e *executor) shadowEnabled() bool {
+	return len(e.config.ShadowEIPs) > 0 && !e.config.Stateless
+}
+
+// shadowExecute replays the txs of a committed block in the background with
+// the alternative VM config, reporting the differences in the outcome. Only
+// one block is replayed at a time, blocks committed in the meantime are
+// skipped so the shadow execution never holds back the real one.
+func (e *executor) shadowExecute(env *executor_env) {
+	if env.shadow == nil {
+		return
+	}
+	select {
+	case e.shadowSem <- struct{}{}:
+	default:
+		shadowSkippedMeter.Mark(1)
+		return
+	}
+	var (
+		run      = env.shadow
+		coinbase = env.coinbase
+		txs      = env.txs
+		receipts = env.receipts
+	)
+	e.wg.Add(1)
+	go func() {
+		defer func() { <-e.shadowSem; e.wg.Done() }()
+
+		start := time.Now()
+		diffs := e.replay(run, coinbase, txs, receipts)
+		shadowTimer.UpdateSince(start)
+
+		if len(diffs) == 0 {
+			shadowMatchMeter.Mark(1)
+			log.Debug("Shadow execution matched", "number", run.header.Number, "txs", len(txs), "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		}
+		shadowDiffMeter.Mark(1)
+		for _, diff := range diffs {
+			log.Warn("Shadow execution diverged", "number", run.header.Number, "eips", e.config.ShadowEIPs, "diff", diff)
+		}
+	}()
+}
+
+// replay executes the txs on the pre-state of the shadow run with the
+// alternative VM config, returning the differences from the real receipts and
+// post-state.
+func (e *executor) replay(run *shadowRun, coinbase common.Address, txs types.Transactions, receipts []*types.Receipt) []string {
+	var (
+		chain   = e.eth.BlockChain()
+		config  = *chain.GetVMConfig()
+		header  = types.CopyHeader(run.header)
+		gasPool = new(core.GasPool).AddGas(header.GasLimit)
+		signer  = types.MakeSigner(e.chainConfig, header.Number, header.Time)
+		diffs   []string
+	)
+	config.Tracer = nil
+	config.ExtraEips = append(append([]int{}, config.ExtraEips...), e.config.ShadowEIPs...)
+
+	for i, tx := range txs {
+		run.state.SetTxContext(tx.Hash(), i)
+		if err := chargeCalldata(run.state, signer, run.calldataFee, tx); err != nil {
+			return append(diffs, fmt.Sprintf("tx %d (%x): rejected: %v", i, tx.Hash(), err))
+		}
+		have, err := core.ApplyTransaction(e.chainConfig, chain, &coinbase, gasPool, run.state, header, tx, &header.GasUsed, config)
+		if err != nil {
+			// The state is unusable from here on, stop comparing
+			return append(diffs, fmt.Sprintf("tx %d (%x): rejected: %v", i, tx.Hash(), err))
+		}
+		want := receipts[i]
+		if have.Status != want.Status {
+			diffs = append(diffs, fmt.Sprintf("tx %d (%x): status mismatch: have %d, want %d", i, tx.Hash(), have.Status, want.Status))
+		}
+		if have.GasUsed != want.GasUsed {
+			diffs = append(diffs, fmt.Sprintf("tx %d (%x): gas used mismatch: have %d, want %d", i, tx.Hash(), have.GasUsed, want.GasUsed))
+		}
+		if have.Bloom != want.Bloom || len(have.Logs) != len(want.Logs) {
+			diffs = append(diffs, fmt.Sprintf("tx %d (%x): logs mismatch: have %d, want %d", i, tx.Hash(), len(have.Logs), len(want.Logs)))
+		}
+	}
+	if root := run.state.IntermediateRoot(e.chainConfig.DeleteEmptyAccounts(header.Number)); root != run.root {
+		diffs = append(diffs, fmt.Sprintf("state root mismatch: have %x, want %x", root, run.root))
+	}
+	return diffs
+}
@@ -0,0 +1,77 @@
+package miner
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestShadowExecution(t *testing.T) {
+	// PUSH0 STOP, invalid before Shanghai unless EIP-3855 is enabled
+	contract := common.HexToAddress("0x5f5f")
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			testBankAddress: {Balance: testBankFunds},
+			contract:        {Code: common.FromHex("0x5f00"), Balance: common.Big0},
+		},
+	}
+	engine := ethash.NewFaker()
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	signer := types.LatestSigner(params.TestChainConfig)
+	call := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    0,
+		To:       &contract,
+		Gas:      50_000,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+	})
+	transfer := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    0,
+		To:       &testUserAddress,
+		Value:    big.NewInt(1000),
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+	})
+	tests := []struct {
+		eips  []int
+		tx    *types.Transaction
+		diffs []string
+	}{
+		{eips: []int{3855}, tx: transfer},
+		{eips: []int{3855}, tx: call, diffs: []string{"status mismatch", "gas used mismatch", "state root mismatch"}},
+	}
+	for i, tt := range tests {
+		e := &executor{config: &Config{ShadowEIPs: tt.eips}, chainConfig: params.TestChainConfig, engine: engine, eth: &testWorkerBackend{chain: chain}, coinbase: testUserAddress}
+		e.running.Store(true)
+
+		work, _, err := e.executeBatch(&execReq{timestamp: int64(chain.CurrentBlock().Time + 1), txs: types.Transactions{tt.tx}})
+		if err != nil {
+			t.Fatalf("test %d: failed to execute batch: %v", i, err)
+		}
+		if work.shadow == nil {
+			t.Fatalf("test %d: shadow run not captured", i)
+		}
+		diffs := e.replay(work.shadow, work.coinbase, work.txs, work.receipts)
+		if len(diffs) != len(tt.diffs) {
+			t.Fatalf("test %d: diff count mismatch: have %v, want %v", i, diffs, tt.diffs)
+		}
+		for j, diff := range diffs {
+			if !strings.Contains(diff, tt.diffs[j]) {
+				t.Errorf("test %d: diff %d mismatch: have %q, want %q", i, j, diff, tt.diffs[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package miner
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// shedInterval is the time between two checks of the load of the executor.
+const shedInterval = time.Second
+
+var (
+	shedGauge = metrics.NewRegisteredGauge("executor/shed/active", nil)
+	shedMeter = metrics.NewRegisteredMeter("executor/shed/episodes", nil)
+)
+
+// sheddingEnabled reports whether tx forwarding is paused under load.
+func (e *executor) sheddingEnabled() bool {
+	return e.config.ShedCPU > 0 || e.config.ShedQueue > 0
+}
+
+// shedLoop periodically samples the CPU usage of the process and the number of
+// consensus blocks waiting to be executed, shedding the tx forwarding while
+// either is over its threshold so the execution keeps up with consensus.
+func (e *executor) shedLoop() {
+	defer e.wg.Done()
+	if !e.sheddingEnabled() {
+		return
+	}
+	var (
+		ticker = time.NewTicker(shedInterval)
+		prev   metrics.CPUStats
+		last   = time.Now()
+	)
+	defer ticker.Stop()
+	metrics.ReadCPUStats(&prev)
+
+	for {
+		select {
+		case <-ticker.C:
+			var (
+				now     metrics.CPUStats
+				elapsed = time.Since(last).Seconds()
+			)
+			metrics.ReadCPUStats(&now)
+			last = time.Now()
+
+			cpu := int((now.LocalTime - prev.LocalTime) / elapsed / float64(runtime.NumCPU()) * 100)
+			prev = now
+			e.updateShedding(cpu, int(e.execStats.queued.Load()))
+
+		case <-e.exitCh:
+			return
+		}
+	}
+}
+
+// updateShedding starts shedding once the CPU usage in percent of all cores or
+// the queued consensus blocks reach their threshold, and stops once both went
+// well below it again, so the forwarding doesn't flap around the thresholds.
+func (e *executor) updateShedding(cpu int, queued int) {
+	var (
+		overCPU   = e.config.ShedCPU > 0 && cpu >= e.config.ShedCPU
+		overQueue = e.config.ShedQueue > 0 && queued >= e.config.ShedQueue
+		healthy   = (e.config.ShedCPU == 0 || cpu*4 <= e.config.ShedCPU*3) && (e.config.ShedQueue == 0 || queued*2 <= e.config.ShedQueue)
+	)
+	switch {
+	case (overCPU || overQueue) && !e.shedding.Load():
+		e.shedding.Store(true)
+		shedGauge.Update(1)
+		shedMeter.Mark(1)
+		log.Warn("Shedding load, pausing tx forwarding", "cpu", cpu, "queued", queued)
+
+	case healthy && e.shedding.Load():
+		e.shedding.Store(false)
+		shedGauge.Update(0)
+		log.Info("Load back to normal, resuming tx forwarding", "cpu", cpu, "queued", queued)
+	}
+}
@@ -0,0 +1,40 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestLoadShedding(t *testing.T) {
+	config := &Config{ShedCPU: 80, ShedQueue: 4}
+	e := &executor{config: config, alerter: newAlerter(config)}
+	es := &executorServer{executorPtr: e}
+
+	steps := []struct {
+		cpu, queued int
+		shedding    bool
+	}{
+		{cpu: 50, queued: 0, shedding: false},
+		{cpu: 80, queued: 0, shedding: true},  // CPU threshold reached
+		{cpu: 70, queued: 0, shedding: true},  // not yet well below it
+		{cpu: 60, queued: 0, shedding: false}, // healthy again
+		{cpu: 10, queued: 4, shedding: true},  // queue threshold reached
+		{cpu: 10, queued: 3, shedding: true},
+		{cpu: 10, queued: 2, shedding: false},
+	}
+	for i, step := range steps {
+		e.updateShedding(step.cpu, step.queued)
+		if have := e.shedding.Load(); have != step.shedding {
+			t.Fatalf("step %d: shedding mismatch: have %v, want %v", i, have, step.shedding)
+		}
+		res, err := es.Heartbeat(context.Background(), &pb.HeartbeatRequest{})
+		if err != nil {
+			t.Fatalf("step %d: heartbeat failed: %v", i, err)
+		}
+		if res.Shedding != step.shedding {
+			t.Fatalf("step %d: reported shedding mismatch: have %v, want %v", i, res.Shedding, step.shedding)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package miner
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ForwardingPolicy is a configuration of the tx forwarding policy.
+type ForwardingPolicy struct {
+	MinTip   *big.Int         // minimum effective tip of the forwarded txs
+	GasLimit uint64           // gas limit of a forwarded batch
+	Locals   []common.Address // senders whose txs are forwarded first, nothing is forwarded without pending ones
+	Exclude  []common.Address // contracts whose txs aren't forwarded, nor the later txs of their senders
+
+	AllowUnprotectedTxs bool // whether txs without replay protection are forwarded
+}
+
+// ForwardingReport is the composition of a batch selected by the forwarding
+// policy.
+type ForwardingReport struct {
+	Policy ForwardingPolicy
+
+	Txs     int // number of txs forwarded
+	Locals  int // number of txs of local senders forwarded
+	Senders int // number of distinct senders forwarded
+	Held    int // number of txs held back by the exclusion list
+
+	Gas  uint64   // total gas limit of the forwarded txs
+	Fees *big.Int // tips the forwarded txs pay if they use their whole gas limit
+}
+
+// Utilization returns the share of the gas limit the batch fills.
+func (r *ForwardingReport) Utilization() float64 {
+	if r.Policy.GasLimit == 0 {
+		return 0
+	}
+	return float64(r.Gas) / float64(r.Policy.GasLimit)
+}
+
+// SimulateForwarding runs one round of the tx forwarding of the executor on a
+// snapshot of the pending txs of the pool without sending anything, reporting
+// the batch the policy selects on top of the given head.
+func SimulateForwarding(chainConfig *params.ChainConfig, head *types.Header, pending map[common.Address]types.Transactions, policy ForwardingPolicy) *ForwardingReport {
+	e := &executor{
+		config:      &Config{AllowUnprotectedTxs: policy.AllowUnprotectedTxs},
+		chainConfig: chainConfig,
+	}
+	minTip := new(big.Int)
+	if policy.MinTip != nil {
+		minTip.Set(policy.MinTip)
+	}
+	e.minTip.Store(minTip)
+
+	var (
+		env = &executor_env{
+			signer: types.MakeSigner(chainConfig, head.Number, head.Time),
+			header: &types.Header{
+				Number:   head.Number,
+				Time:     head.Time,
+				BaseFee:  head.BaseFee,
+				GasLimit: policy.GasLimit,
+			},
+		}
+		lazyTxs   = make(map[common.Address][]*txpool.LazyTransaction)
+		localTxs  = make(map[common.Address][]*txpool.LazyTransaction)
+		remoteTxs = make(map[common.Address][]*txpool.LazyTransaction)
+		locals    = make(map[common.Address]bool)
+	)
+	for _, account := range policy.Locals {
+		locals[account] = true
+	}
+	for from, txs := range pending {
+		lazies := make([]*txpool.LazyTransaction, len(txs))
+		for i, tx := range txs {
+			lazies[i] = &txpool.LazyTransaction{
+				Hash:      tx.Hash(),
+				Tx:        tx,
+				GasFeeCap: tx.GasFeeCap(),
+				GasTipCap: tx.GasTipCap(),
+				Gas:       tx.Gas(),
+			}
+		}
+		lazyTxs[from] = lazies
+	}
+	report := &ForwardingReport{Policy: policy, Fees: new(big.Int)}
+
+	// Like fillTransactions, the txs of excluded contracts are held back before
+	// the locals are picked
+	for _, held := range excludeTxs(lazyTxs, policy.Exclude) {
+		report.Held += len(held.txs)
+	}
+	for from, lazies := range lazyTxs {
+		if locals[from] {
+			localTxs[from] = lazies
+		} else {
+			remoteTxs[from] = lazies
+		}
+	}
+	// Like fillTransactions, nothing is forwarded without local txs
+	if len(localTxs) == 0 {
+		return report
+	}
+	senders := make(map[common.Address]struct{})
+
+	e.forwardTransactions(nil, env, localTxs, remoteTxs, func(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error {
+		from, _ := types.Sender(env.signer, tx)
+		senders[from] = struct{}{}
+
+		report.Txs++
+		if local {
+			report.Locals++
+		}
+		report.Gas += tx.Gas()
+		if tip, err := tx.EffectiveGasTip(head.BaseFee); err == nil {
+			report.Fees.Add(report.Fees, new(big.Int).Mul(tip, new(big.Int).SetUint64(tx.Gas())))
+		}
+		return nil
+	})
+	report.Senders = len(senders)
+	return report
+}
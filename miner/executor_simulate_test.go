@@ -0,0 +1,79 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSimulateForwarding(t *testing.T) {
+	var (
+		local, _  = crypto.GenerateKey()
+		remote, _ = crypto.GenerateKey()
+		signer    = types.LatestSigner(params.TestChainConfig)
+		head      = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.GWei)}
+	)
+	transfers := func(tip int64, n int) types.Transactions {
+		var txs types.Transactions
+		key := local
+		if tip != 2 {
+			key = remote
+		}
+		for i := 0; i < n; i++ {
+			txs = append(txs, types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   params.TestChainConfig.ChainID,
+				Nonce:     uint64(i),
+				To:        &testUserAddress,
+				Gas:       params.TxGas,
+				GasFeeCap: big.NewInt(10 * params.GWei),
+				GasTipCap: big.NewInt(tip * params.GWei),
+			}))
+		}
+		return txs
+	}
+	pending := map[common.Address]types.Transactions{
+		crypto.PubkeyToAddress(local.PublicKey):  transfers(2, 3),
+		crypto.PubkeyToAddress(remote.PublicKey): transfers(5, 3),
+	}
+	locals := []common.Address{crypto.PubkeyToAddress(local.PublicKey)}
+
+	tests := []struct {
+		policy  ForwardingPolicy
+		txs     int
+		locals  int
+		senders int
+		fees    int64 // gwei
+	}{
+		// Everything fits, every tx is forwarded once
+		{ForwardingPolicy{GasLimit: 10 * params.TxGas, Locals: locals}, 6, 3, 2, 3*2*21000 + 3*5*21000},
+		// Locals go first
+		{ForwardingPolicy{GasLimit: 4 * params.TxGas, Locals: locals}, 4, 3, 2, 3*2*21000 + 5*21000},
+		// Without locals nothing is forwarded
+		{ForwardingPolicy{GasLimit: 4 * params.TxGas}, 0, 0, 0, 0},
+		// Excluded txs are held back, the locals along with them
+		{ForwardingPolicy{GasLimit: 10 * params.TxGas, Locals: locals, Exclude: []common.Address{testUserAddress}}, 0, 0, 0, 0},
+		// Underpriced txs are not forwarded
+		{ForwardingPolicy{GasLimit: 10 * params.TxGas, MinTip: big.NewInt(3 * params.GWei), Locals: locals}, 3, 0, 1, 3 * 5 * 21000},
+	}
+	for i, tt := range tests {
+		report := SimulateForwarding(params.TestChainConfig, head, pending, tt.policy)
+		if report.Txs != tt.txs || report.Locals != tt.locals || report.Senders != tt.senders {
+			t.Errorf("test %d: composition mismatch: have %d/%d/%d, want %d/%d/%d", i, report.Txs, report.Locals, report.Senders, tt.txs, tt.locals, tt.senders)
+		}
+		if want := uint64(tt.txs) * params.TxGas; report.Gas != want {
+			t.Errorf("test %d: gas mismatch: have %d, want %d", i, report.Gas, want)
+		}
+		if want := new(big.Int).Mul(big.NewInt(tt.fees), big.NewInt(params.GWei)); report.Fees.Cmp(want) != 0 {
+			t.Errorf("test %d: fees mismatch: have %v, want %v", i, report.Fees, want)
+		}
+	}
+	// Held back txs are reported
+	report := SimulateForwarding(params.TestChainConfig, head, pending, ForwardingPolicy{GasLimit: 10 * params.TxGas, Locals: locals, Exclude: []common.Address{testUserAddress}})
+	if report.Held != 6 {
+		t.Errorf("held txs mismatch: have %d, want 6", report.Held)
+	}
+}
@@ -0,0 +1,136 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+// stakingQueue is the number of blocks of staking events buffered for a
+// consensus client before it is considered lagging.
+const stakingQueue = 16
+
+var (
+	// errStakingLagging is returned to a consensus client which fell so far
+	// behind the written blocks that staking events had to be dropped.
+	errStakingLagging = errors.New("staking events dropped, subscriber lagging")
+
+	stakingDroppedMeter = metrics.NewRegisteredMeter("executor/staking/dropped", nil)
+
+	// stakingDepositTopic is the topic of `Deposit(address indexed account, uint256 amount)`
+	// emitted by the staking contract.
+	stakingDepositTopic = crypto.Keccak256Hash([]byte("Deposit(address,uint256)"))
+
+	// stakingWithdrawTopic is the topic of `Withdraw(address indexed account, uint256 amount)`
+	// emitted by the staking contract.
+	stakingWithdrawTopic = crypto.Keccak256Hash([]byte("Withdraw(address,uint256)"))
+)
+
+// stakingEvents filters the staking contract events out of the logs of a
+// freshly written block. Logs which are not emitted by the configured staking
+// contract, or which don't match the expected event layout, are ignored.
+func stakingEvents(contract common.Address, logs []*types.Log) []*pb.StakingEvent {
+	if contract == (common.Address{}) {
+		return nil
+	}
+	var events []*pb.StakingEvent
+	for _, l := range logs {
+		if l.Address != contract || l.Removed || len(l.Topics) != 2 || len(l.Data) != 32 {
+			continue
+		}
+		var typ pb.StakingEventType
+		switch l.Topics[0] {
+		case stakingDepositTopic:
+			typ = pb.StakingEventType_DEPOSIT
+		case stakingWithdrawTopic:
+			typ = pb.StakingEventType_WITHDRAW
+		default:
+			continue
+		}
+		events = append(events, &pb.StakingEvent{
+			Type:        typ,
+			Account:     common.BytesToAddress(l.Topics[1].Bytes()).Bytes(),
+			Amount:      new(big.Int).SetBytes(l.Data).Bytes(),
+			BlockNumber: l.BlockNumber,
+			BlockHash:   l.BlockHash.Bytes(),
+			TxHash:      l.TxHash.Bytes(),
+		})
+	}
+	return events
+}
+
+// stakingFanout fans the staking events of the written blocks out to the
+// consensus clients. It never blocks the execution, but unlike the state diffs
+// the events can't be skipped: a client which can't keep up is disconnected
+// and has to resubscribe, knowing it missed validator-set changes.
+type stakingFanout struct {
+	mu   sync.Mutex
+	subs map[chan []*pb.StakingEvent]struct{}
+}
+
+// subscribe registers a consensus client, the returned function unregisters
+// it. The channel is closed if the client is dropped for lagging behind.
+func (f *stakingFanout) subscribe() (chan []*pb.StakingEvent, func()) {
+	ch := make(chan []*pb.StakingEvent, stakingQueue)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[chan []*pb.StakingEvent]struct{})
+	}
+	f.subs[ch] = struct{}{}
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.subs, ch)
+	}
+}
+
+// publish sends the staking events of a block to every consensus client,
+// dropping the ones with no room left for them.
+func (f *stakingFanout) publish(events []*pb.StakingEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- events:
+		default:
+			stakingDroppedMeter.Mark(1)
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// StakingEvents streams the staking contract events of every block written by
+// the executor to the consensus layer, so validator-set changes originate
+// on-chain and propagate automatically. A client lagging too far behind is
+// disconnected with errStakingLagging rather than stalling the block writes.
+func (es *executorServer) StakingEvents(_ *pb.Empty, stream pb.Executor_StakingEventsServer) error {
+	ch, unsubscribe := es.executorPtr.stakingSubs.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case events, ok := <-ch:
+			if !ok {
+				return errStakingLagging
+			}
+			for _, ev := range events {
+				if err := stream.Send(ev); err != nil {
+					return err
+				}
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-es.executorPtr.exitCh:
+			return nil
+		}
+	}
+}
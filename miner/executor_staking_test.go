@@ -0,0 +1,83 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestStakingEvents(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x1000")
+		account  = common.HexToAddress("0x2000")
+		amount   = common.BigToHash(big.NewInt(1000))
+	)
+	logs := []*types.Log{
+		// A valid deposit
+		{Address: contract, Topics: []common.Hash{stakingDepositTopic, common.BytesToHash(account.Bytes())}, Data: amount.Bytes(), BlockNumber: 1},
+		// A valid withdrawal
+		{Address: contract, Topics: []common.Hash{stakingWithdrawTopic, common.BytesToHash(account.Bytes())}, Data: amount.Bytes(), BlockNumber: 1},
+		// Emitted by another contract
+		{Address: account, Topics: []common.Hash{stakingDepositTopic, common.BytesToHash(account.Bytes())}, Data: amount.Bytes()},
+		// Unknown event of the staking contract
+		{Address: contract, Topics: []common.Hash{{0x01}, common.BytesToHash(account.Bytes())}, Data: amount.Bytes()},
+		// Malformed payload
+		{Address: contract, Topics: []common.Hash{stakingDepositTopic}, Data: amount.Bytes()},
+	}
+	if events := stakingEvents(common.Address{}, logs); len(events) != 0 {
+		t.Fatalf("events forwarded without staking contract: %d", len(events))
+	}
+	events := stakingEvents(contract, logs)
+	if len(events) != 2 {
+		t.Fatalf("event count mismatch: have %d, want %d", len(events), 2)
+	}
+	for i, want := range []pb.StakingEventType{pb.StakingEventType_DEPOSIT, pb.StakingEventType_WITHDRAW} {
+		if events[i].Type != want {
+			t.Errorf("event %d: type mismatch: have %v, want %v", i, events[i].Type, want)
+		}
+		if common.BytesToAddress(events[i].Account) != account {
+			t.Errorf("event %d: account mismatch: have %x, want %x", i, events[i].Account, account)
+		}
+		if new(big.Int).SetBytes(events[i].Amount).Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("event %d: amount mismatch: have %x", i, events[i].Amount)
+		}
+	}
+}
+
+func TestStakingFanout(t *testing.T) {
+	var (
+		fanout      stakingFanout
+		fast, _     = fanout.subscribe()
+		slow, unsub = fanout.subscribe()
+		events      = []*pb.StakingEvent{{Type: pb.StakingEventType_DEPOSIT}}
+	)
+	defer unsub()
+
+	// Fill the slow subscriber's queue while draining the fast one
+	for i := 0; i < stakingQueue; i++ {
+		fanout.publish(events)
+		<-fast
+	}
+	// The next block must not block, but drop the slow subscriber
+	fanout.publish(events)
+	if len(fast) != 1 {
+		t.Fatalf("fast subscriber queue mismatch: have %d, want %d", len(fast), 1)
+	}
+	for i := 0; i < stakingQueue; i++ {
+		if _, ok := <-slow; !ok {
+			t.Fatalf("queued events lost at %d", i)
+		}
+	}
+	if _, ok := <-slow; ok {
+		t.Fatalf("lagging subscriber not dropped")
+	}
+	// Unsubscribing a dropped subscriber is a noop
+	unsub()
+	fanout.publish(events)
+	if len(fast) != 2 {
+		t.Fatalf("fast subscriber queue mismatch: have %d, want %d", len(fast), 2)
+	}
+}
@@ -0,0 +1,203 @@
+package miner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+)
+
+const (
+	// diffQueue is the number of state diffs buffered for a standby, further
+	// ones are dropped until it catches up.
+	diffQueue = 16
+
+	// standbyRetry is the delay before a standby reconnects to its primary.
+	standbyRetry = 5 * time.Second
+)
+
+var (
+	diffDroppedMeter  = metrics.NewRegisteredMeter("executor/standby/dropped", nil)
+	standbyWarmMeter  = metrics.NewRegisteredMeter("executor/standby/warmed", nil)
+	standbyErrorMeter = metrics.NewRegisteredMeter("executor/standby/errors", nil)
+)
+
+// diffBroadcaster fans the state diffs of the written blocks out to the
+// standbys. It never blocks the execution: the diffs a standby can't keep up
+// with are dropped, leaving its caches a bit colder.
+type diffBroadcaster struct {
+	mu   sync.Mutex
+	subs map[chan *pb.StateDiff]struct{}
+}
+
+// subscribe registers a standby, the returned function unregisters it.
+func (b *diffBroadcaster) subscribe() (chan *pb.StateDiff, func()) {
+	ch := make(chan *pb.StateDiff, diffQueue)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[chan *pb.StateDiff]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, ch)
+	}
+}
+
+// active returns whether any standby is subscribed.
+func (b *diffBroadcaster) active() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs) > 0
+}
+
+// publish sends a state diff to every standby with room for it.
+func (b *diffBroadcaster) publish(diff *pb.StateDiff) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- diff:
+		default:
+			diffDroppedMeter.Mark(1)
+		}
+	}
+}
+
+// newStateDiff collects the state a block accessed during its execution.
+func newStateDiff(block *types.Block, statedb *state.StateDB) *pb.StateDiff {
+	diff := &pb.StateDiff{
+		Number: block.NumberU64(),
+		Hash:   block.Hash().Bytes(),
+		Root:   block.Root().Bytes(),
+	}
+	for addr, slots := range statedb.WorkingSet() {
+		account := &pb.AccountAccess{Address: addr.Bytes(), Slots: make([][]byte, len(slots))}
+		for i, slot := range slots {
+			account.Slots[i] = slot.Bytes()
+		}
+		diff.Accounts = append(diff.Accounts, account)
+	}
+	return diff
+}
+
+// publishStateDiff streams the state accessed by a written block to the
+// standbys, if any.
+func (e *executor) publishStateDiff(block *types.Block, statedb *state.StateDB) {
+	if !e.standbys.active() {
+		return
+	}
+	e.standbys.publish(newStateDiff(block, statedb))
+}
+
+// StateDiffs streams the state accessed by every written block to a standby
+// executor.
+func (es *executorServer) StateDiffs(_ *pb.Empty, stream pb.Executor_StateDiffsServer) error {
+	ch, unsubscribe := es.executorPtr.standbys.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case diff := <-ch:
+			if err := stream.Send(diff); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-es.executorPtr.exitCh:
+			return nil
+		}
+	}
+}
+
+// standbyLoop keeps the state caches of a standby executor warm by loading the
+// state accessed by the blocks of the primary, so it executes at full speed
+// right away if consensus fails over to it.
+func (e *executor) standbyLoop() {
+	defer e.wg.Done()
+	if !e.waitReady() {
+		return
+	}
+
+	creds, err := clientCredentials(e.config)
+	if err != nil {
+		log.Error("Failed to set up standby TLS", "err", err)
+		return
+	}
+	conn, err := grpc.Dial(e.config.StandbyPrimary, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		log.Error("Failed to dial standby primary", "primary", e.config.StandbyPrimary, "err", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-e.exitCh
+		cancel()
+	}()
+	client := pb.NewExecutorClient(conn)
+	for {
+		if err := e.followPrimary(ctx, client); err != nil && ctx.Err() == nil {
+			log.Warn("Lost state diffs of the primary", "primary", e.config.StandbyPrimary, "err", err)
+			standbyErrorMeter.Mark(1)
+		}
+		select {
+		case <-time.After(standbyRetry):
+		case <-e.exitCh:
+			return
+		}
+	}
+}
+
+// followPrimary warms the state with the diffs streamed by the primary until
+// the stream breaks.
+func (e *executor) followPrimary(ctx context.Context, client pb.ExecutorClient) error {
+	stream, err := client.StateDiffs(ctx, &pb.Empty{})
+	if err != nil {
+		return err
+	}
+	for {
+		diff, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		e.warmStateDiff(diff)
+	}
+}
+
+// warmStateDiff loads the state accessed by a block of the primary on top of
+// the local head, pulling it into the state caches. The accessed accounts and
+// slots rarely move between consecutive blocks, so warming the local head is
+// as good as warming the state of the block itself.
+func (e *executor) warmStateDiff(diff *pb.StateDiff) int {
+	head := e.eth.BlockChain().CurrentBlock()
+	statedb, err := e.eth.BlockChain().StateAt(e.eth.BlockChain().PostStateRoot(head))
+	if err != nil {
+		log.Debug("Failed to open state to warm", "number", head.Number, "err", err)
+		return 0
+	}
+	var warmed int
+	for _, account := range diff.GetAccounts() {
+		addr := common.BytesToAddress(account.GetAddress())
+		statedb.GetCodeSize(addr)
+		for _, slot := range account.GetSlots() {
+			statedb.GetState(addr, common.BytesToHash(slot))
+		}
+		warmed += 1 + len(account.GetSlots())
+	}
+	standbyWarmMeter.Mark(int64(warmed))
+	log.Trace("Warmed state of the primary", "number", diff.GetNumber(), "local", head.Number, "accounts", len(diff.GetAccounts()), "loaded", warmed)
+	return warmed
+}
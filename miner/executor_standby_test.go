@@ -0,0 +1,108 @@
+package miner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestStandbyStateDiffs(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	primary := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+	server := grpc.NewServer()
+	pb.RegisterExecutorServer(server, &executorServer{executorPtr: primary})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pb.NewExecutorClient(conn).StateDiffs(ctx, &pb.Empty{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for !primary.standbys.active() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Blocks written by the primary are streamed with the state they accessed
+	req, _, err := decodeExecBlock(newTestExecBlock(t, pendingTxs[0]), nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := primary.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	diff, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("failed to receive state diff: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	if diff.Number != head.Number.Uint64() || common.BytesToHash(diff.Hash) != head.Hash() {
+		t.Errorf("block mismatch: have #%d %x, want #%d %x", diff.Number, diff.Hash, head.Number, head.Hash())
+	}
+	accessed := make(map[common.Address]bool)
+	for _, account := range diff.Accounts {
+		accessed[common.BytesToAddress(account.Address)] = true
+	}
+	for _, addr := range []common.Address{testBankAddress, testUserAddress} {
+		if !accessed[addr] {
+			t.Errorf("accessed account %x missing from the state diff", addr)
+		}
+	}
+	// Standbys load the accessed state on top of their own head
+	standbyBackend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer standbyBackend.close()
+
+	standby := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: standbyBackend}
+	if warmed := standby.warmStateDiff(diff); warmed < len(diff.Accounts) {
+		t.Errorf("warmed state mismatch: have %d, want at least %d", warmed, len(diff.Accounts))
+	}
+}
+
+func TestStateDiffBroadcast(t *testing.T) {
+	var b diffBroadcaster
+	if b.active() {
+		t.Fatalf("broadcaster active without standbys")
+	}
+	fast, unsubscribe := b.subscribe()
+	slow, _ := b.subscribe()
+
+	// Slow standbys lose diffs rather than stall the execution
+	for i := 0; i < diffQueue+1; i++ {
+		b.publish(&pb.StateDiff{Number: uint64(i)})
+		<-fast
+	}
+	if len(slow) != diffQueue {
+		t.Errorf("queued diff mismatch: have %d, want %d", len(slow), diffQueue)
+	}
+	if diff := <-slow; diff.Number != 0 {
+		t.Errorf("first diff mismatch: have %d, want 0", diff.Number)
+	}
+	unsubscribe()
+	b.publish(&pb.StateDiff{})
+	if len(fast) != 0 {
+		t.Errorf("diff sent to unsubscribed standby")
+	}
+}
@@ -0,0 +1,191 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errMissingWitness    = errors.New("missing witness")
+	errInvalidWitness    = errors.New("invalid witness")
+	errIncompleteWitness = errors.New("incomplete witness")
+	errInvalidBlock      = errors.New("invalid block")
+	errStateless         = errors.New("not supported by stateless executor")
+	errDeferredWitness   = errors.New("witnesses can't prove deferred state roots")
+)
+
+// executionChain is the chain a block is executed against, providing the
+// ancestor headers to the EVM and the consensus engine.
+type executionChain interface {
+	core.ChainContext
+	consensus.ChainHeaderReader
+}
+
+// witnessChain is the chain of the ancestor headers shipped in a witness.
+type witnessChain struct {
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	parent  *types.Header
+	headers map[common.Hash]*types.Header
+	numbers map[uint64]*types.Header
+}
+
+func (c *witnessChain) Config() *params.ChainConfig  { return c.config }
+func (c *witnessChain) Engine() consensus.Engine     { return c.engine }
+func (c *witnessChain) CurrentHeader() *types.Header { return c.parent }
+
+func (c *witnessChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+func (c *witnessChain) GetHeaderByNumber(number uint64) *types.Header  { return c.numbers[number] }
+func (c *witnessChain) GetHeaderByHash(hash common.Hash) *types.Header { return c.headers[hash] }
+func (c *witnessChain) GetTd(hash common.Hash, number uint64) *big.Int { return nil }
+
+// VerifyBlock re-executes a block on top of the pre-state proven by its
+// witness, reporting whether it reproduces the roots of the block header.
+func (es *executorServer) VerifyBlock(ctx context.Context, pbBlock *pb.ExecBlock) (*pb.Verification, error) {
+	return es.executorPtr.verifyBlock(pbBlock)
+}
+
+// verifyBlock executes a consensus block statelessly: the state the txs access
+// is served from the trie nodes and codes of the witness, the ancestors from
+// its headers. An error is returned if the witness can't be used, an invalid
+// verification if the block doesn't reproduce the roots of its header.
+func (e *executor) verifyBlock(pbBlock *pb.ExecBlock) (*pb.Verification, error) {
+	// The pre-state is proven against the root in the parent header, which
+	// isn't the one of the parent if the chain defers its state roots
+	if e.deferredRoots(e.eth.BlockChain().CurrentBlock().Number) {
+		return nil, errDeferredWitness
+	}
+	witness := pbBlock.GetWitness()
+	if witness == nil {
+		return nil, errMissingWitness
+	}
+	target, chain, err := e.decodeWitness(witness)
+	if err != nil {
+		return nil, err
+	}
+	req, _, err := decodeExecBlock(pbBlock, e.verified)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		req = &execReq{metadata: pbBlock.GetMetadata()}
+	}
+	// Serve the pre-state from the witness only, a missing node fails the
+	// execution instead of being resolved from the local database
+	db := rawdb.NewMemoryDatabase()
+	for _, node := range witness.GetNodes() {
+		rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(node), node)
+	}
+	for _, code := range witness.GetCodes() {
+		rawdb.WriteCode(db, crypto.Keccak256Hash(code), code)
+	}
+	statedb, err := state.New(chain.parent.Root, state.NewDatabase(db), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errIncompleteWitness, err)
+	}
+	header := types.CopyHeader(target)
+	header.GasUsed = 0
+
+	work := &executor_env{
+		signer:   types.MakeSigner(e.chainConfig, header.Number, header.Time),
+		state:    statedb,
+		chain:    chain,
+		coinbase: header.Coinbase,
+		header:   header,
+	}
+	block, err := e.processBlock(work, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errIncompleteWitness, err)
+	}
+	res := &pb.Verification{
+		Valid:       block.Hash() == target.Hash(),
+		StateRoot:   block.Root().Bytes(),
+		ReceiptRoot: block.ReceiptHash().Bytes(),
+		GasUsed:     block.GasUsed(),
+	}
+	switch {
+	case block.Root() != target.Root:
+		res.Reason = fmt.Sprintf("state root mismatch: have %x, want %x", block.Root(), target.Root)
+	case block.ReceiptHash() != target.ReceiptHash:
+		res.Reason = fmt.Sprintf("receipt root mismatch: have %x, want %x", block.ReceiptHash(), target.ReceiptHash)
+	case block.GasUsed() != target.GasUsed:
+		res.Reason = fmt.Sprintf("gas used mismatch: have %d, want %d", block.GasUsed(), target.GasUsed)
+	case !res.Valid:
+		res.Reason = fmt.Sprintf("block hash mismatch: have %x, want %x", block.Hash(), target.Hash())
+	}
+	log.Debug("Verified block statelessly", "number", target.Number, "hash", target.Hash(), "valid", res.Valid, "txs", len(block.Transactions()), "nodes", len(witness.GetNodes()))
+	return res, nil
+}
+
+// decodeWitness decodes the header of the block to verify and its ancestors,
+// checking that they are linked.
+func (e *executor) decodeWitness(witness *pb.Witness) (*types.Header, *witnessChain, error) {
+	target := new(types.Header)
+	if err := rlp.DecodeBytes(witness.GetHeader(), target); err != nil {
+		return nil, nil, fmt.Errorf("%w: header: %v", errInvalidWitness, err)
+	}
+	if len(witness.GetHeaders()) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing parent header", errInvalidWitness)
+	}
+	chain := &witnessChain{
+		config:  e.chainConfig,
+		engine:  e.engine,
+		headers: make(map[common.Hash]*types.Header),
+		numbers: make(map[uint64]*types.Header),
+	}
+	child := target
+	for i, blob := range witness.GetHeaders() {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(blob, header); err != nil {
+			return nil, nil, fmt.Errorf("%w: ancestor %d: %v", errInvalidWitness, i, err)
+		}
+		if hash := header.Hash(); child.ParentHash != hash {
+			return nil, nil, fmt.Errorf("%w: ancestor %d: hash mismatch: have %x, want %x", errInvalidWitness, i, hash, child.ParentHash)
+		}
+		if i == 0 {
+			chain.parent = header
+		}
+		chain.headers[header.Hash()] = header
+		chain.numbers[header.Number.Uint64()] = header
+		child = header
+	}
+	return target, chain, nil
+}
+
+// commitStateless verifies a consensus block delivered to a stateless executor
+// instead of executing it on the local state.
+func (e *executor) commitStateless(pbBlock *pb.ExecBlock) error {
+	res, err := e.verifyBlock(pbBlock)
+	if err != nil {
+		return err
+	}
+	if !res.Valid {
+		log.Warn("Rejected consensus block", "sequence", pbBlock.GetSequence(), "reason", res.Reason)
+		return fmt.Errorf("%w: %s", errInvalidBlock, res.Reason)
+	}
+	e.markExecuted(pbBlock.GetSequence())
+	return nil
+}
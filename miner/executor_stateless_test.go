@@ -0,0 +1,83 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestStatelessVerify(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	// Execute a block on the full state to verify statelessly
+	full := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+	parent := backend.chain.CurrentBlock()
+	if err := full.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+
+	// Ship the whole pre-state as the witness
+	tr, err := trie.New(trie.StateTrieID(parent.Root), backend.chain.StateCache().TrieDB())
+	if err != nil {
+		t.Fatalf("failed to open state trie: %v", err)
+	}
+	var nodes [][]byte
+	for it := tr.MustNodeIterator(nil); it.Next(true); {
+		if it.Hash() != (common.Hash{}) {
+			nodes = append(nodes, it.NodeBlob())
+		}
+	}
+	newBlock := func(target *types.Header, nodes [][]byte) *pb.ExecBlock {
+		block := newTestExecBlock(t, pendingTxs[0])
+		block.Sequence = 3
+		block.Witness = &pb.Witness{Nodes: nodes}
+		block.Witness.Header, _ = rlp.EncodeToBytes(target)
+		ancestor, _ := rlp.EncodeToBytes(parent)
+		block.Witness.Headers = [][]byte{ancestor}
+		return block
+	}
+	cfg := *testConfig
+	cfg.Stateless = true
+	stateless := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+	es := &executorServer{executorPtr: stateless}
+
+	res, err := es.VerifyBlock(context.Background(), newBlock(head, nodes))
+	if err != nil {
+		t.Fatalf("failed to verify block: %v", err)
+	}
+	if !res.Valid || common.BytesToHash(res.StateRoot) != head.Root {
+		t.Fatalf("verification mismatch: have %v (%s), want valid with root %x", res.Valid, res.Reason, head.Root)
+	}
+	if _, err := es.CommitBlock(context.Background(), newBlock(head, nodes)); err != nil {
+		t.Fatalf("failed to commit block: %v", err)
+	}
+	if seq := stateless.executedSeq.Load(); seq != 3 {
+		t.Errorf("executed sequence mismatch: have %d, want %d", seq, 3)
+	}
+	// Blocks not reproducing their header are rejected
+	forged := types.CopyHeader(head)
+	forged.Root = common.Hash{0x01}
+	if _, err := es.CommitBlock(context.Background(), newBlock(forged, nodes)); !errors.Is(err, errInvalidBlock) {
+		t.Errorf("forged block error mismatch: have %v, want %v", err, errInvalidBlock)
+	}
+	// Witnesses missing state are refused
+	if _, err := es.VerifyBlock(context.Background(), newBlock(head, nil)); !errors.Is(err, errIncompleteWitness) {
+		t.Errorf("incomplete witness error mismatch: have %v, want %v", err, errIncompleteWitness)
+	}
+	if _, err := es.VerifyBlock(context.Background(), newTestExecBlock(t, pendingTxs[0])); !errors.Is(err, errMissingWitness) {
+		t.Errorf("missing witness error mismatch: have %v, want %v", err, errMissingWitness)
+	}
+}
@@ -0,0 +1,180 @@
+package miner
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// statsBatches is the number of recently executed consensus blocks reported.
+const statsBatches = 10
+
+// BatchStat is the execution of a consensus block.
+type BatchStat struct {
+	Sequence uint64        `json:"sequence"`
+	BatchID  hexutil.Bytes `json:"batchId,omitempty"`
+	Number   uint64        `json:"number"`          // chain block the consensus block was executed as
+	Txs      int           `json:"txs"`             // txs delivered by consensus
+	Latency  float64       `json:"latencyms"`       // time spent executing and writing in milliseconds, so far if still running
+	Error    string        `json:"error,omitempty"` // reason the block failed, if it did
+}
+
+// QueueStats are the depths of the queues in front of the execution.
+type QueueStats struct {
+	Blocks  int32 `json:"blocks"`  // consensus blocks waiting for the execution loop
+	Heads   int   `json:"heads"`   // written heads waiting to be announced to consensus
+	Results int   `json:"results"` // execution results waiting to be reported to consensus
+	Pending int   `json:"pending"` // executable txs in the pool
+	Queued  int   `json:"queued"`  // non-executable txs in the pool
+}
+
+// Stats is a snapshot of the executor internals for quick inspection.
+type Stats struct {
+	Running  bool        `json:"running"`
+	Halted   bool        `json:"halted"`
+	Shedding bool        `json:"shedding"`            // whether tx forwarding is paused under load
+	SlowDisk bool        `json:"slowDisk"`            // whether tx forwarding is paused by slow block writes
+	LinkUp   bool        `json:"consensusLinkUp"`     // whether the last interaction with consensus succeeded
+	LinkDown float64     `json:"consensusLinkDownms"` // time consensus has been unreachable in milliseconds
+	InFlight int         `json:"inFlight"`            // forwarded txs not yet included
+	Head     HeadLag     `json:"head"`
+	Queues   QueueStats  `json:"queues"`
+	Current  *BatchStat  `json:"current"`           // consensus block being executed, nil if idle
+	Writing  *BatchStat  `json:"writing,omitempty"` // consensus block being written in the background, nil if none
+	Recent   []BatchStat `json:"recentBatches"`     // most recently executed consensus blocks, newest first
+}
+
+// execStats tracks the consensus blocks going through the execution.
+type execStats struct {
+	queued atomic.Int32 // consensus blocks handed over but not yet picked up by the execution loop
+
+	mu      sync.Mutex
+	current *BatchStat
+	started time.Time
+	writing *BatchStat // block handed off to the write pipeline, nil if none
+	handed  time.Time  // start of the execution of the block being written
+	recent  []BatchStat
+}
+
+// begin records the start of the execution of a consensus block.
+func (s *execStats) begin(req *execReq) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.current = &BatchStat{Sequence: req.sequence, Txs: len(req.txs)}
+	if req.meta != nil {
+		s.current.BatchID = req.meta.BatchID
+	}
+	s.started = time.Now()
+}
+
+// end records the outcome of the consensus block being executed, written as
+// the given chain block unless it failed.
+func (s *execStats) end(number uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(s.current, s.started, number, err)
+	s.current = nil
+}
+
+// handOff moves the consensus block being executed to the write pipeline,
+// making room for the next one.
+func (s *execStats) handOff() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writing, s.handed = s.current, s.started
+	s.current = nil
+}
+
+// endWrite records the outcome of the consensus block written in the
+// background.
+func (s *execStats) endWrite(number uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(s.writing, s.handed, number, err)
+	s.writing = nil
+}
+
+// record adds a finished consensus block to the recent ones.
+func (s *execStats) record(current *BatchStat, started time.Time, number uint64, err error) {
+	if current == nil {
+		return
+	}
+	blockExecTimer.UpdateSince(started)
+	stat := *current
+	stat.Latency = milliseconds(time.Since(started))
+	if err != nil {
+		stat.Error = err.Error()
+	} else {
+		stat.Number = number
+	}
+	s.recent = append([]BatchStat{stat}, s.recent...)
+	if len(s.recent) > statsBatches {
+		s.recent = s.recent[:statsBatches]
+	}
+}
+
+// stats returns a snapshot of the executor internals.
+func (e *executor) stats() Stats {
+	stats := Stats{
+		Running:  e.running.Load(),
+		Halted:   e.halted.Load(),
+		Shedding: e.shedding.Load(),
+		SlowDisk: e.slowDisk.Load(),
+		LinkUp:   e.linkDownSince.Load() == 0,
+		InFlight: e.inclusion.inFlight(),
+		Head:     e.lagStatus(),
+		Queues: QueueStats{
+			Blocks:  e.execStats.queued.Load(),
+			Heads:   len(e.headCh),
+			Results: len(e.resultCh),
+		},
+	}
+	if since := e.linkDownSince.Load(); since != 0 {
+		stats.LinkDown = milliseconds(time.Since(time.Unix(0, since)))
+	}
+	// The pool is only set once the backend is ready, the stats are served
+	// from the start
+	if e.eth != nil {
+		if pool := e.eth.TxPool(); pool != nil {
+			stats.Queues.Pending, stats.Queues.Queued = pool.Stats()
+		}
+	}
+	e.execStats.mu.Lock()
+	defer e.execStats.mu.Unlock()
+
+	if e.execStats.current != nil {
+		current := *e.execStats.current
+		current.Latency = milliseconds(time.Since(e.execStats.started))
+		stats.Current = &current
+	}
+	if e.execStats.writing != nil {
+		writing := *e.execStats.writing
+		writing.Latency = milliseconds(time.Since(e.execStats.handed))
+		stats.Writing = &writing
+	}
+	stats.Recent = append([]BatchStat{}, e.execStats.recent...)
+	return stats
+}
+
+var (
+	statsOnce   sync.Once
+	statsSource atomic.Pointer[executor]
+)
+
+// publishStats exposes the internals of the executor as the "executor" expvar,
+// served as JSON along with the metrics on /debug/metrics.
+func publishStats(e *executor) {
+	statsSource.Store(e)
+	statsOnce.Do(func() {
+		expvar.Publish("executor", expvar.Func(func() interface{} {
+			return statsSource.Load().stats()
+		}))
+	})
+}
@@ -0,0 +1,57 @@
+package miner
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestExecutorStats(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		alerter:     newAlerter(testConfig),
+		inclusion:   newInclusionTracker(nil),
+	}
+	publishStats(e)
+
+	for i, tx := range []*types.Transaction{pendingTxs[0], newTxs[0]} {
+		req := &execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{tx}, sequence: uint64(i + 1)}
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block %d: %v", i, err)
+		}
+	}
+	stats := e.stats()
+	if stats.Current != nil {
+		t.Errorf("current block mismatch: have %v, want nil", stats.Current)
+	}
+	if len(stats.Recent) != 2 {
+		t.Fatalf("recent block count mismatch: have %d, want %d", len(stats.Recent), 2)
+	}
+	if stats.Recent[0].Sequence != 2 || stats.Recent[0].Number != 2 || stats.Recent[1].Number != 1 {
+		t.Errorf("recent blocks mismatch: have %+v", stats.Recent)
+	}
+	if !stats.LinkUp {
+		t.Errorf("consensus link reported down")
+	}
+	// The stats are served as an expvar too
+	var published Stats
+	if err := json.Unmarshal([]byte(expvar.Get("executor").String()), &published); err != nil {
+		t.Fatalf("failed to decode expvar: %v", err)
+	}
+	if len(published.Recent) != 2 {
+		t.Errorf("published recent block count mismatch: have %d, want %d", len(published.Recent), 2)
+	}
+}
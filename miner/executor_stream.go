@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	errEmptyStream      = errors.New("block stream closed without chunks")
+	errStreamedTooLarge = errors.New("streamed block too large")
+)
+
+// CommitBlockStream receives a consensus block in chunks, assembling it before
+// queueing it for execution like CommitBlock. Blocks with tens of thousands of
+// txs don't fit into a single gRPC message, but each of their chunks does.
+func (es *executorServer) CommitBlockStream(stream pb.Executor_CommitBlockStreamServer) error {
+	var (
+		block *pb.ExecBlock
+		size  int
+	)
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// Bound the memory a consensus peer may make the executor hold, the
+		// same as a single compressed block may expand to
+		if size += proto.Size(chunk); size > maxDecompressedSize {
+			return fmt.Errorf("%w: %d bytes, limit %d", errStreamedTooLarge, size, maxDecompressedSize)
+		}
+		if block == nil {
+			block = chunk
+			continue
+		}
+		if err := appendChunk(block, chunk); err != nil {
+			return err
+		}
+	}
+	if block == nil {
+		return errEmptyStream
+	}
+	_, err := es.commitBlock(stream.Context(), block)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(&pb.Empty{})
+}
+
+// appendChunk appends the txs of a follow-up chunk to the assembled block.
+// Only the first chunk carries the fields of the block, anything else set on
+// a follow-up chunk is rejected rather than silently dropped.
+func appendChunk(block, chunk *pb.ExecBlock) error {
+	rest := &pb.ExecBlock{Txs: chunk.Txs, CompressedTxs: chunk.CompressedTxs}
+	if !proto.Equal(chunk, rest) {
+		return fmt.Errorf("chunk of block %d carries block fields", block.GetSequence())
+	}
+	if len(chunk.CompressedTxs) > 0 && len(block.Txs) > 0 || len(chunk.Txs) > 0 && len(block.CompressedTxs) > 0 {
+		return fmt.Errorf("chunks of block %d mix compressed and plain txs", block.GetSequence())
+	}
+	block.Txs = append(block.Txs, chunk.Txs...)
+	block.CompressedTxs = append(block.CompressedTxs, chunk.CompressedTxs...)
+	return nil
+}
@@ -0,0 +1,93 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestCommitBlockStream(t *testing.T) {
+	e := &executor{
+		config:      testConfig,
+		chainConfig: ethashChainConfig,
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq, 1),
+	}
+	server := grpc.NewServer()
+	pb.RegisterExecutorServer(server, &executorServer{executorPtr: e})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewExecutorClient(conn)
+
+	commit := func(chunks ...*pb.ExecBlock) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := client.CommitBlockStream(ctx)
+		if err != nil {
+			t.Fatalf("failed to open stream: %v", err)
+		}
+		for _, chunk := range chunks {
+			if err := stream.Send(chunk); err != nil {
+				t.Fatalf("failed to send chunk: %v", err)
+			}
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	}
+	signer := types.LatestSigner(ethashChainConfig)
+	txs := make(types.Transactions, 3)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	// Chunks are assembled in order into a single block
+	first := newTestExecBlock(t, txs[0])
+	first.Sequence = 7
+	if err := commit(first, newTestExecBlock(t, txs[1], txs[2])); err != nil {
+		t.Fatalf("failed to commit streamed block: %v", err)
+	}
+	req := <-e.execCh
+	if req.sequence != 7 {
+		t.Errorf("sequence mismatch: have %d, want %d", req.sequence, 7)
+	}
+	if len(req.txs) != len(txs) {
+		t.Fatalf("tx count mismatch: have %d, want %d", len(req.txs), len(txs))
+	}
+	for i, tx := range req.txs {
+		if tx.Hash() != txs[i].Hash() {
+			t.Errorf("tx %d mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+	// Block fields are only accepted on the first chunk
+	late := newTestExecBlock(t, txs[1])
+	late.Sequence = 8
+	if err := commit(newTestExecBlock(t, txs[0]), late); err == nil || !strings.Contains(err.Error(), "carries block fields") {
+		t.Errorf("late block fields accepted: %v", err)
+	}
+	if err := commit(); err == nil || !strings.Contains(err.Error(), errEmptyStream.Error()) {
+		t.Errorf("error mismatch: have %v, want %v", err, errEmptyStream)
+	}
+	select {
+	case req := <-e.execCh:
+		t.Errorf("rejected block queued with %d txs", len(req.txs))
+	default:
+	}
+}
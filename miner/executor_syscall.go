@@ -0,0 +1,44 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// applySystemCalls runs the system calls declared in the chain config on top
+// of the environment, before any user transaction is executed. Calls without
+// consensus supplied calldata are skipped; gas spent on them is not accounted
+// in the block, same as the EIP-4788 beacon root call.
+func (e *executor) applySystemCalls(env *executor_env, metadata map[string][]byte) {
+	if e.chainConfig.Executor == nil || len(e.chainConfig.Executor.SystemCalls) == 0 {
+		return
+	}
+	var (
+		blockCtx = core.NewEVMBlockContext(env.header, env.chain, nil)
+		vmenv    = vm.NewEVM(blockCtx, vm.TxContext{}, env.state, e.chainConfig, *e.eth.BlockChain().GetVMConfig())
+	)
+	for _, call := range e.chainConfig.Executor.SystemCalls {
+		data, ok := metadata[call.Name]
+		if !ok {
+			continue
+		}
+		msg := &core.Message{
+			From:      params.SystemAddress,
+			GasLimit:  call.Gas,
+			GasPrice:  common.Big0,
+			GasFeeCap: common.Big0,
+			GasTipCap: common.Big0,
+			To:        &call.Address,
+			Data:      data,
+		}
+		vmenv.Reset(core.NewEVMTxContext(msg), env.state)
+		env.state.AddAddressToAccessList(call.Address)
+		if _, _, err := vmenv.Call(vm.AccountRef(msg.From), *msg.To, msg.Data, call.Gas, common.U2560); err != nil {
+			log.Debug("System call failed", "name", call.Name, "address", call.Address, "err", err)
+		}
+		env.state.Finalise(e.chainConfig.DeleteEmptyAccounts(env.header.Number))
+	}
+}
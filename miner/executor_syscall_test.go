@@ -0,0 +1,50 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestApplySystemCalls(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0xbeac02")
+		config   = *params.TestChainConfig
+	)
+	config.Executor = &params.ExecutorConfig{
+		SystemCalls: []params.SystemCall{{Name: "root", Address: contract, Gas: 100_000}},
+	}
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			// PUSH1 0 CALLDATALOAD PUSH1 0 SSTORE
+			contract: {Code: common.FromHex("0x600035600055"), Balance: common.Big0},
+		},
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	e := &executor{config: testConfig, chainConfig: &config, eth: &testWorkerBackend{chain: chain}}
+	work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix())}, 0)
+	if err != nil {
+		t.Fatalf("failed to prepare work: %v", err)
+	}
+	root := common.HexToHash("0xdeadbeef")
+	e.applySystemCalls(work, map[string][]byte{"root": root.Bytes(), "unknown": {0x01}})
+
+	if have := work.state.GetState(contract, common.Hash{}); have != root {
+		t.Fatalf("system call not applied: have %x, want %x", have, root)
+	}
+	if work.header.GasUsed != 0 {
+		t.Fatalf("system call gas accounted in block: %d", work.header.GasUsed)
+	}
+}
@@ -0,0 +1,130 @@
+package miner
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	templateHitMeter  = metrics.NewRegisteredMeter("executor/template/hit", nil)
+	templateMissMeter = metrics.NewRegisteredMeter("executor/template/miss", nil)
+
+	errMissingParent = errors.New("missing parent")
+)
+
+// workTemplate is the part of a sealing header only depending on its parent and
+// the miner configuration. Both the forwarding and the execution prepare a
+// block on top of the head every time, the template spares recomputing it
+// until the head or the gas ceiling changes.
+type workTemplate struct {
+	parent  *types.Header
+	hash    common.Hash // hash of the parent
+	gasCeil uint64      // gas ceiling the gas limit was computed with
+
+	number   *big.Int
+	gasLimit uint64
+	baseFee  *big.Int // nil before London
+
+	signer types.Signer
+	cancun bool // whether the signer is the cancun one, the only fork switched by time
+}
+
+// header returns a new sealing header from the template.
+func (t *workTemplate) header(timestamp uint64, coinbase common.Address) *types.Header {
+	header := &types.Header{
+		ParentHash: t.hash,
+		Number:     new(big.Int).Set(t.number),
+		GasLimit:   t.gasLimit,
+		Time:       timestamp,
+		Coinbase:   coinbase,
+		// Blocks are decided by the consensus layer instead of sealed, the chain
+		// runs post-merge where the beacon engine only accepts zero difficulty
+		Difficulty: big.NewInt(0),
+	}
+	if t.baseFee != nil {
+		header.BaseFee = new(big.Int).Set(t.baseFee)
+	}
+	return header
+}
+
+// workTemplate returns the template of the blocks on top of the given parent,
+// the chain head if none, reusing the cached one if still current.
+func (e *executor) workTemplate(parentHash common.Hash) (*workTemplate, error) {
+	var (
+		chain  = e.eth.BlockChain()
+		cached = e.template.Load()
+		parent *types.Header
+	)
+	if cached != nil && cached.gasCeil != e.config.GasCeil {
+		cached = nil
+	}
+	if parentHash == (common.Hash{}) {
+		// The chain hands out the same header until the head changes
+		parent = chain.CurrentBlock()
+		if cached != nil && cached.parent == parent {
+			templateHitMeter.Mark(1)
+			return cached, nil
+		}
+	} else {
+		if cached != nil && cached.hash == parentHash {
+			templateHitMeter.Mark(1)
+			return cached, nil
+		}
+		block := chain.GetBlockByHash(parentHash)
+		if block == nil {
+			return nil, errMissingParent
+		}
+		parent = block.Header()
+	}
+	templateMissMeter.Mark(1)
+
+	t := e.newTemplate(parent)
+	e.template.Store(t)
+	return t, nil
+}
+
+// newTemplate computes the template of the blocks on top of the given parent.
+func (e *executor) newTemplate(parent *types.Header) *workTemplate {
+	t := &workTemplate{
+		parent:  parent,
+		hash:    parent.Hash(),
+		gasCeil: e.config.GasCeil,
+		number:  new(big.Int).Add(parent.Number, common.Big1),
+	}
+	t.gasLimit = e.gasLimit(parent, e.config.GasCeil)
+
+	// Adding EIP 1559 logic
+	if e.chainConfig.IsLondon(t.number) {
+		t.baseFee = eip1559.CalcBaseFee(e.chainConfig, parent)
+	}
+	// The signer is picked for the time of the parent, the blocks on top of it
+	// crossing the cancun fork get a fresh one
+	t.signer = types.MakeSigner(e.chainConfig, t.number, parent.Time)
+	t.cancun = e.chainConfig.IsCancun(t.number, parent.Time)
+	return t
+}
+
+// gasLimit returns the gas limit of the blocks on top of the given parent,
+// moving towards the given ceiling.
+func (e *executor) gasLimit(parent *types.Header, gasCeil uint64) uint64 {
+	parentGasLimit := parent.GasLimit
+	if number := new(big.Int).Add(parent.Number, common.Big1); e.chainConfig.IsLondon(number) && !e.chainConfig.IsLondon(parent.Number) {
+		parentGasLimit = parent.GasLimit * e.chainConfig.ElasticityMultiplier()
+	}
+	return core.CalcGasLimit(parentGasLimit, gasCeil)
+}
+
+// signerAt returns the signer of the block of the template sealed at the given
+// time.
+func (e *executor) signerAt(t *workTemplate, timestamp uint64) types.Signer {
+	if e.chainConfig.IsCancun(t.number, timestamp) != t.cancun {
+		return types.MakeSigner(e.chainConfig, t.number, timestamp)
+	}
+	return t.signer
+}
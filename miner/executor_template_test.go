@@ -0,0 +1,68 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestWorkTemplate(t *testing.T) {
+	gspec := &core.Genesis{Config: params.TestChainConfig, GasLimit: params.GenesisGasLimit}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	config := *testConfig
+	e := &executor{config: &config, chainConfig: params.TestChainConfig, eth: &testWorkerBackend{chain: chain}}
+	prepare := func(parent common.Hash) *workTemplate {
+		t.Helper()
+		work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), parentHash: parent, coinbase: testUserAddress}, 0)
+		if err != nil {
+			t.Fatalf("failed to prepare work: %v", err)
+		}
+		tmpl := e.template.Load()
+		if work.header.ParentHash != tmpl.hash || work.header.GasLimit != tmpl.gasLimit || work.header.BaseFee.Cmp(tmpl.baseFee) != 0 {
+			t.Fatalf("header mismatch: have %v, want template %+v", work.header, tmpl)
+		}
+		// The headers handed out are not shared with the template
+		work.header.Number.SetUint64(0)
+		work.header.BaseFee.SetUint64(0)
+		return tmpl
+	}
+	first := prepare(common.Hash{})
+	if second := prepare(common.Hash{}); second != first {
+		t.Errorf("template rebuilt without a head change")
+	}
+	if first.number.Uint64() != 1 || first.baseFee.Sign() == 0 {
+		t.Errorf("template corrupted by the header: number %v, base fee %v", first.number, first.baseFee)
+	}
+	// Asking for the parent explicitly reuses the template as well
+	if parent := prepare(first.hash); parent != first {
+		t.Errorf("template rebuilt for the same parent")
+	}
+	// Changing the gas ceiling refreshes the template
+	config.GasCeil = params.GenesisGasLimit * 2
+	ceiled := prepare(common.Hash{})
+	if ceiled == first || ceiled.gasLimit <= first.gasLimit {
+		t.Errorf("template not refreshed on gas ceiling change: have %d, had %d", ceiled.gasLimit, first.gasLimit)
+	}
+	// A new head refreshes the template
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, nil)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if head := prepare(common.Hash{}); head.hash != blocks[0].Hash() || head.number.Uint64() != 2 {
+		t.Errorf("template mismatch: have parent %x number %d, want %x number 2", head.hash, head.number, blocks[0].Hash())
+	}
+	if _, err := e.prepareWork(&generateParams{parentHash: common.Hash{0x01}}, 0); err != errMissingParent {
+		t.Errorf("error mismatch: have %v, want %v", err, errMissingParent)
+	}
+}
@@ -1,6 +1,9 @@
 package miner
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -20,10 +23,12 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/miner/commitment"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/proto/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -104,6 +109,12 @@ type testWorkerBackend struct {
 func (b *testWorkerBackend) BlockChain() *core.BlockChain { return b.chain }
 func (b *testWorkerBackend) TxPool() *txpool.TxPool       { return b.txPool }
 
+// close tears down the pool before the chain it's subscribed to.
+func (b *testWorkerBackend) close() {
+	b.txPool.Close()
+	b.chain.Stop()
+}
+
 func (b *testWorkerBackend) newRandomTx(creation bool) *types.Transaction {
 	var tx *types.Transaction
 	gasPrice := big.NewInt(10 * params.InitialBaseFee)
@@ -130,6 +141,15 @@ func (b *testWorkerBackend) newTx(nonce uint64) *types.Transaction {
 	return tx
 }
 
+// postMergeConfig returns a copy of the ethash test chain config merged from
+// genesis on, as the zero difficulty executor blocks only become the head
+// post-merge.
+func postMergeConfig() params.ChainConfig {
+	config := *ethashChainConfig
+	config.TerminalTotalDifficulty = common.Big0
+	return config
+}
+
 func newTestExecBackend(chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, n int) *testWorkerBackend {
 	var gspec = &core.Genesis{
 		Config: chainConfig,
@@ -173,8 +193,9 @@ func newTestExecutor(chainConfig *params.ChainConfig, engine consensus.Engine, d
 		fmt.Println(err)
 	}
 	p2pClient := pb.NewP2PClient(conn)
+	consensusClient := pb.NewConsensusClient(conn)
 
-	e := newExecutor(testConfig, chainConfig, engine, backend, new(event.TypeMux), nil, false, p2pClient)
+	e := newExecutor(testConfig, chainConfig, engine, backend, new(event.TypeMux), nil, false, p2pClient, consensusClient)
 	e.coinbase = testBankAddress
 	return e, backend
 }
@@ -195,7 +216,9 @@ func TestExecutor(t *testing.T) {
 	defer chain.Stop()
 
 	// Start mining!
-	e.start()
+	if err := e.start(); err != nil {
+		t.Fatalf("failed to start executor: %v", err)
+	}
 
 	for i := 0; i < 20; i++ {
 		// fmt.Println("add tx")
@@ -207,3 +230,214 @@ func TestExecutor(t *testing.T) {
 		time.Sleep(10 * time.Second)
 	}
 }
+
+func TestCommitBlockOversizedTx(t *testing.T) {
+	e := &executor{config: &Config{}, execCh: make(chan *execReq, 1)}
+	es := &executorServer{executorPtr: e}
+
+	encode := func(tx *types.Transaction) []byte {
+		payload, _ := tx.MarshalBinary()
+		blob, _ := proto.Marshal(&pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+		return blob
+	}
+	signer := types.LatestSigner(params.TestChainConfig)
+	oversized := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{
+		Nonce:    2,
+		To:       &testUserAddress,
+		Gas:      params.TxGas,
+		GasPrice: big.NewInt(params.InitialBaseFee),
+		Data:     make([]byte, txMaxSize),
+	})
+	_, err := es.CommitBlock(context.Background(), &pb.ExecBlock{Txs: [][]byte{encode(pendingTxs[0]), encode(oversized), encode(newTxs[0])}})
+	if err == nil {
+		t.Fatalf("oversized transaction not reported")
+	}
+	req := <-e.execCh
+	if len(req.txs) != 2 || req.txs[0].Hash() != pendingTxs[0].Hash() || req.txs[1].Hash() != newTxs[0].Hash() {
+		t.Fatalf("executed transactions mismatch: %v", req.txs)
+	}
+}
+
+func TestCommitBlockStopping(t *testing.T) {
+	e := &executor{config: &Config{}, execCh: make(chan *execReq), exitCh: make(chan struct{})}
+	close(e.exitCh)
+
+	// Blocks delivered during shutdown must be rejected instead of hanging
+	es := &executorServer{executorPtr: e}
+	if _, err := es.CommitBlock(context.Background(), newTestExecBlock(t, pendingTxs[0])); !errors.Is(err, errExecutorStopping) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errExecutorStopping)
+	}
+}
+
+func TestCommitBlockAwaitedBranch(t *testing.T) {
+	e := &executor{config: &Config{}, execCh: make(chan *execReq, 1), exitCh: make(chan struct{})}
+	es := &executorServer{executorPtr: e}
+
+	// Branch blocks have no outcome until their branch is finalized, awaiting
+	// one must be rejected instead of hanging consensus
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.Branch = []byte("fork")
+	block.AwaitResult = true
+	if _, err := es.CommitBlock(context.Background(), block); !errors.Is(err, errAwaitedBranch) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errAwaitedBranch)
+	}
+	if len(e.execCh) != 0 {
+		t.Fatalf("awaited branch block queued")
+	}
+}
+
+func TestCommitBlockConsensusMeta(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	block := newTestExecBlock(t, pendingTxs[0])
+	block.BatchId, block.Round, block.Sequence = []byte{0xba, 0x7c}, 3, 11
+
+	req, _, err := decodeExecBlock(block, nil)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	req.timestamp = time.Now().UnixNano()
+	if err := e.executeNewTxBatch(req); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	if head.Number.Uint64() != 1 {
+		t.Fatalf("block not written, head %d", head.Number)
+	}
+	meta := backend.chain.GetConsensusMeta(head.Hash())
+	if meta == nil {
+		t.Fatalf("consensus metadata not stored")
+	}
+	if !bytes.Equal(meta.BatchID, block.BatchId) || meta.Round != 3 || meta.Sequence != 11 {
+		t.Fatalf("consensus metadata mismatch: have %+v", meta)
+	}
+}
+
+func TestReceiptCommitmentExtra(t *testing.T) {
+	config := postMergeConfig()
+	config.Executor = &params.ExecutorConfig{ReceiptCommitmentBlock: common.Big2}
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+
+	// Blocks before the fork leave the extra-data empty
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{pendingTxs[0]}}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	if head := backend.chain.CurrentBlock(); len(head.Extra) != 0 {
+		t.Fatalf("extra-data mismatch: have %x, want empty", head.Extra)
+	}
+	if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{newTxs[0]}}); err != nil {
+		t.Fatalf("failed to execute block: %v", err)
+	}
+	head := backend.chain.CurrentBlock()
+	receipts := backend.chain.GetReceiptsByHash(head.Hash())
+	if len(receipts) != 1 {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(receipts), 1)
+	}
+	if want := commitment.Root(receipts); !bytes.Equal(head.Extra, want[:]) {
+		t.Errorf("extra-data mismatch: have %x, want %x", head.Extra, want)
+	}
+}
+
+func TestKeepEmptyAccounts(t *testing.T) {
+	// Calling a precompile missing from the state creates it as empty account
+	var (
+		empty  = common.BytesToAddress([]byte{0x04})
+		signer = types.LatestSigner(ethashChainConfig)
+		touch  = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{To: &empty, Gas: 2 * params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	)
+	for _, keep := range []bool{false, true} {
+		config := postMergeConfig()
+		config.Executor = &params.ExecutorConfig{KeepEmptyAccounts: keep}
+
+		backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+		defer backend.close()
+
+		e := &executor{config: testConfig, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(testConfig)}
+		if err := e.executeNewTxBatch(&execReq{timestamp: time.Now().UnixNano(), txs: types.Transactions{touch}}); err != nil {
+			t.Fatalf("keep %v: failed to execute block: %v", keep, err)
+		}
+		statedb, err := backend.chain.State()
+		if err != nil {
+			t.Fatalf("keep %v: failed to open head state: %v", keep, err)
+		}
+		if statedb.Exist(empty) != keep {
+			t.Errorf("keep %v: touched empty account existence mismatch: have %v, want %v", keep, statedb.Exist(empty), keep)
+		}
+		// The beacon root system call of Cancun blocks touches the system account
+		cancun := config
+		cancun.ShanghaiTime, cancun.CancunTime = new(uint64), new(uint64)
+		statedb.SetCode(params.BeaconRootsStorageAddress, []byte{byte(vm.STOP)})
+
+		header := &types.Header{Number: big.NewInt(2), Difficulty: common.Big0, GasLimit: params.GenesisGasLimit}
+		vmenv := vm.NewEVM(core.NewEVMBlockContext(header, backend.chain, nil), vm.TxContext{}, statedb, &cancun, vm.Config{})
+		core.ProcessBeaconBlockRoot(common.Hash{0x01}, vmenv, statedb)
+		if statedb.Exist(params.SystemAddress) != keep {
+			t.Errorf("keep %v: system account existence mismatch: have %v, want %v", keep, statedb.Exist(params.SystemAddress), keep)
+		}
+	}
+}
+
+func BenchmarkExecuteTinyBatch(b *testing.B) {
+	b.Run("prefetch", func(b *testing.B) { benchmarkExecuteTinyBatch(b, 0) })
+	b.Run("noprefetch", func(b *testing.B) { benchmarkExecuteTinyBatch(b, DefaultConfig.PrefetchThreshold) })
+}
+
+func benchmarkExecuteTinyBatch(b *testing.B, threshold int) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), db, 0)
+		config  = *testConfig
+	)
+	defer backend.close()
+
+	config.PrefetchThreshold = threshold
+	e := &executor{config: &config, chainConfig: params.TestChainConfig, engine: ethash.NewFaker(), eth: backend}
+	txs := types.Transactions{pendingTxs[0], newTxs[0]}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		work, err := e.prepareWork(&generateParams{timestamp: uint64(time.Now().Unix()), coinbase: testUserAddress}, len(txs))
+		if err != nil {
+			b.Fatalf("failed to prepare work: %v", err)
+		}
+		e.executeTransactions(work, txs)
+		work.state.StopPrefetcher()
+	}
+}
+
+func TestExecutorListen(t *testing.T) {
+	newExecutor := func(addr string) *executor {
+		config := *testConfig
+		config.ExecutorListenAddr = addr
+		return &executor{config: &config, server: grpc.NewServer(), startCh: make(chan struct{}, 2)}
+	}
+	e := newExecutor("127.0.0.1:0")
+	defer e.server.Stop()
+
+	if err := e.start(); err != nil {
+		t.Fatalf("failed to start executor: %v", err)
+	}
+	// Restarting after a stop keeps serving on the same listener
+	listener := e.listener
+	e.stop()
+	if err := e.start(); err != nil || e.listener != listener {
+		t.Fatalf("restart mismatch: have %v, %v, want listener %v", e.listener, err, listener)
+	}
+	// A taken address fails the start instead of crashing the node
+	taken := newExecutor(listener.Addr().String())
+	if err := taken.start(); err == nil {
+		t.Fatalf("started on a taken address")
+	}
+	if taken.isRunning() {
+		t.Errorf("executor running without its API")
+	}
+}
@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var errMissingCertificate = errors.New("executor TLS CA set without a certificate")
+
+// tlsConfig loads the TLS configuration of the connections between the
+// executor and consensus, nil if they run over plaintext. The executor presents
+// the same certificate as server and as client. With a CA set, the server
+// requires and verifies client certificates and the client verifies the server
+// against it rather than the system roots.
+func tlsConfig(config *Config, server bool) (*tls.Config, error) {
+	if config.ExecutorTLSCert == "" && config.ExecutorTLSCA == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.ExecutorTLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(config.ExecutorTLSCert, config.ExecutorTLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load executor TLS certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	} else if server {
+		return nil, errMissingCertificate
+	}
+	if config.ExecutorTLSCA != "" {
+		blob, err := os.ReadFile(config.ExecutorTLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read executor TLS CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(blob) {
+			return nil, fmt.Errorf("no certificates in executor TLS CA %s", config.ExecutorTLSCA)
+		}
+		if server {
+			cfg.ClientCAs, cfg.ClientAuth = pool, tls.RequireAndVerifyClientCert
+		} else {
+			cfg.RootCAs = pool
+		}
+	}
+	return cfg, nil
+}
+
+// serverOptions returns the options of the executor gRPC server securing its
+// connections, if configured.
+func serverOptions(config *Config) ([]grpc.ServerOption, error) {
+	cfg, err := tlsConfig(config, true)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(cfg))}, nil
+}
+
+// clientCredentials returns the credentials of the connections the executor
+// opens, plaintext unless configured otherwise.
+func clientCredentials(config *Config) (credentials.TransportCredentials, error) {
+	cfg, err := tlsConfig(config, false)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return insecure.NewCredentials(), nil
+	}
+	return credentials.NewTLS(cfg), nil
+}
@@ -0,0 +1,120 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+)
+
+// writeCert creates a certificate for 127.0.0.1 signed by the given parent, self
+// signed if nil, and writes it along with its key into dir.
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to encode key: %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "executor", ca, caKey)
+	writeCert(t, dir, "consensus", ca, caKey)
+	writeCert(t, dir, "rogue", nil, nil)
+
+	tlsFiles := func(name string) *Config {
+		return &Config{
+			ExecutorTLSCert: filepath.Join(dir, name+".crt"),
+			ExecutorTLSKey:  filepath.Join(dir, name+".key"),
+			ExecutorTLSCA:   filepath.Join(dir, "ca.crt"),
+		}
+	}
+	opts, err := serverOptions(tlsFiles("executor"))
+	if err != nil {
+		t.Fatalf("failed to load server credentials: %v", err)
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterExecutorServer(server, &executorServer{executorPtr: &executor{config: testConfig, chainConfig: params.TestChainConfig}})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	verify := func(config *Config) error {
+		creds, err := clientCredentials(config)
+		if err != nil {
+			t.Fatalf("failed to load client credentials: %v", err)
+		}
+		conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = pb.NewExecutorClient(conn).VerifyTx(ctx, &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: []byte{0x01}})
+		return err
+	}
+	// Consensus holding a certificate of the CA gets through
+	if err := verify(tlsFiles("consensus")); err != nil {
+		t.Errorf("authenticated client rejected: %v", err)
+	}
+	// Clients without a certificate of the CA, or without TLS, are rejected
+	if err := verify(tlsFiles("rogue")); err == nil {
+		t.Errorf("client with a foreign certificate accepted")
+	}
+	if err := verify(&Config{ExecutorTLSCA: filepath.Join(dir, "ca.crt")}); err == nil {
+		t.Errorf("client without certificate accepted")
+	}
+	if err := verify(&Config{}); err == nil {
+		t.Errorf("plaintext client accepted")
+	}
+	// Servers can't verify clients without a certificate of their own
+	if _, err := serverOptions(&Config{ExecutorTLSCA: filepath.Join(dir, "ca.crt")}); err != errMissingCertificate {
+		t.Errorf("error mismatch: have %v, want %v", err, errMissingCertificate)
+	}
+}
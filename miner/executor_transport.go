@@ -0,0 +1,51 @@
+package miner
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// unixScheme prefixes the endpoints of the executor and consensus APIs served
+// over a unix domain socket instead of TCP, for consensus running on the same
+// machine. It is the scheme gRPC dials unix sockets with.
+const unixScheme = "unix://"
+
+// maxSocketPath is the size of sun_path on Linux, see unix(7).
+const maxSocketPath = 108
+
+// listen opens the listener of an API endpoint, either a TCP address or a
+// unix:// socket path.
+func listen(endpoint string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(endpoint, unixScheme)
+	if !ok {
+		return net.Listen("tcp", endpoint)
+	}
+	// account for null-terminator too
+	if len(path)+1 > maxSocketPath {
+		log.Warn(fmt.Sprintf("The executor socket path is longer than %d characters", maxSocketPath-1), "path", path)
+	}
+	// Ensure the socket directory exists and remove any previous leftover
+	if err := os.MkdirAll(filepath.Dir(path), 0751); err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	os.Chmod(path, 0600)
+	return listener, nil
+}
+
+// dialTarget returns the gRPC target reaching the endpoint a listener serves.
+func dialTarget(listener net.Listener) string {
+	if addr := listener.Addr(); addr.Network() == "unix" {
+		return unixScheme + addr.String()
+	}
+	return listener.Addr().String()
+}
@@ -0,0 +1,57 @@
+package miner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "executor", "executor.sock")
+
+	// A leftover socket of a previous run doesn't prevent the start
+	os.MkdirAll(filepath.Dir(path), 0751)
+	os.WriteFile(path, nil, 0600)
+
+	config := *testConfig
+	config.ExecutorListenAddr = unixScheme + path
+	e := &executor{config: &config, chainConfig: params.TestChainConfig, server: grpc.NewServer(), startCh: make(chan struct{}, 1)}
+	pb.RegisterExecutorServer(e.server, &executorServer{executorPtr: e})
+	if err := e.start(); err != nil {
+		t.Fatalf("failed to start executor: %v", err)
+	}
+	defer e.server.Stop()
+
+	if target := dialTarget(e.listener); target != config.ExecutorListenAddr {
+		t.Errorf("dial target mismatch: have %s, want %s", target, config.ExecutorListenAddr)
+	}
+	conn, err := grpc.Dial(dialTarget(e.listener), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := pb.NewExecutorClient(conn).VerifyTx(ctx, &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: []byte{0x01}})
+	if err != nil {
+		t.Fatalf("failed to call executor over the socket: %v", err)
+	}
+	if res.Success {
+		t.Errorf("malformed tx accepted")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("socket missing: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket permissions mismatch: have %v, want %v", info.Mode().Perm(), os.FileMode(0600))
+	}
+}
@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	txCacheHitMeter  = metrics.NewRegisteredMeter("executor/txcache/hit", nil)
+	txCacheMissMeter = metrics.NewRegisteredMeter("executor/txcache/miss", nil)
+)
+
+// verifiedTx is a tx consensus asked the executor to verify. The decoded tx
+// caches the sender recovered by the verification, so neither is paid for again
+// once consensus delivers the tx in a block.
+type verifiedTx struct {
+	tx  *types.Transaction
+	env *verifyEnv // context the tx was verified in, the outcome is stale once replaced
+	err error      // outcome of the verification
+}
+
+// txCache remembers the txs verified for consensus by the hash of their payload.
+// A nil cache remembers nothing.
+type txCache struct {
+	lru *lru.Cache[common.Hash, *verifiedTx]
+}
+
+// newTxCache creates a cache of the given number of verified txs, nil if zero.
+func newTxCache(size int) *txCache {
+	if size <= 0 {
+		return nil
+	}
+	return &txCache{lru: lru.NewCache[common.Hash, *verifiedTx](size)}
+}
+
+// get returns the verified tx with the given payload hash, nil if unknown.
+func (c *txCache) get(hash common.Hash) *verifiedTx {
+	if c == nil {
+		return nil
+	}
+	v, _ := c.lru.Get(hash)
+	return v
+}
+
+// add remembers a verified tx by the hash of its payload.
+func (c *txCache) add(hash common.Hash, v *verifiedTx) {
+	if c != nil {
+		c.lru.Add(hash, v)
+	}
+}
+
+// decode decodes a tx payload of a consensus block, reusing the tx decoded when
+// it was verified if any.
+func (c *txCache) decode(payload []byte) (*types.Transaction, error) {
+	if c != nil {
+		if v := c.get(crypto.Keccak256Hash(payload)); v != nil {
+			txCacheHitMeter.Mark(1)
+			return v.tx, nil
+		}
+		txCacheMissMeter.Mark(1)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(payload); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,81 @@
+package miner
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestVerifiedTxCache(t *testing.T) {
+	var (
+		config = params.TestChainConfig
+		signer = types.LatestSigner(config)
+		head   = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.GWei), GasLimit: 10 * params.TxGas}
+	)
+	e := &executor{
+		config:      testConfig,
+		chainConfig: config,
+		verifyOpts:  newValidationOptions(config, big.NewInt(params.GWei)),
+		verified:    newTxCache(16),
+	}
+	e.env.Store(&verifyEnv{signer: signer, header: head})
+	es := &executorServer{executorPtr: e}
+
+	// The tx exceeds the gas limit of the first block, not of the second
+	tx := types.MustSignNewTx(testBankKey, signer, &types.DynamicFeeTx{
+		ChainID:   config.ChainID,
+		To:        &testUserAddress,
+		Gas:       20 * params.TxGas,
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		GasTipCap: big.NewInt(params.GWei),
+	})
+	payload, _ := tx.MarshalBinary()
+	hash := crypto.Keccak256Hash(payload)
+	verify := func() bool {
+		res, err := es.VerifyTx(context.Background(), &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+		if err != nil {
+			t.Fatalf("failed to verify tx: %v", err)
+		}
+		return res.Success
+	}
+	if verify() {
+		t.Fatalf("tx exceeding the block gas limit accepted")
+	}
+	cached := e.verified.get(hash)
+	if cached == nil || cached.err == nil {
+		t.Fatalf("rejection not cached: %+v", cached)
+	}
+	// Asking again is answered from the cache
+	if verify() || e.verified.get(hash) != cached {
+		t.Fatalf("cached rejection not reused")
+	}
+	// The outcome is stale once the next block is written
+	e.env.Store(&verifyEnv{signer: signer, header: &types.Header{Number: big.NewInt(2), BaseFee: big.NewInt(params.GWei), GasLimit: 30 * params.TxGas}})
+	if !verify() {
+		t.Fatalf("tx rejected after raising the gas limit")
+	}
+	decoded := cached.tx
+	cached = e.verified.get(hash)
+	if cached.err != nil {
+		t.Fatalf("stale rejection cached: %v", cached.err)
+	}
+	if cached.tx != decoded {
+		t.Errorf("cached tx decoded again")
+	}
+	// The execution takes the decoded tx, along with its recovered sender
+	req, _, err := decodeExecBlock(newTestExecBlock(t, tx), e.verified)
+	if err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	if req.txs[0] != cached.tx {
+		t.Errorf("verified tx decoded again")
+	}
+	if from, err := types.Sender(signer, req.txs[0]); err != nil || from != testBankAddress {
+		t.Errorf("sender mismatch: have %x, want %x", from, testBankAddress)
+	}
+}
@@ -0,0 +1,154 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/proto/pb"
+)
+
+func TestValidationSplit(t *testing.T) {
+	var (
+		config = params.TestChainConfig
+		signer = types.LatestSigner(config)
+		head   = &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(params.GWei), GasLimit: 10 * params.TxGas}
+	)
+	transfer := func(key *ecdsa.PrivateKey, tip int64) *types.Transaction {
+		return types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   config.ChainID,
+			To:        &testUserAddress,
+			Gas:       params.TxGas,
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			GasTipCap: big.NewInt(tip),
+		})
+	}
+	// Consensus may order txs paying a lower tip than the forwarded ones
+	e := &executor{
+		config:      testConfig,
+		chainConfig: config,
+		verifyOpts:  newValidationOptions(config, big.NewInt(params.GWei)),
+		forwardOpts: newValidationOptions(config, big.NewInt(3*params.GWei)),
+	}
+	e.env.Store(&verifyEnv{signer: signer, header: head})
+	e.minTip.Store(big.NewInt(3 * params.GWei))
+
+	key, _ := crypto.GenerateKey()
+	cheap, pricey := transfer(key, params.GWei), transfer(testBankKey, 3*params.GWei)
+	es := &executorServer{executorPtr: e}
+	for _, tx := range []*types.Transaction{cheap, pricey} {
+		payload, _ := tx.MarshalBinary()
+		res, err := es.VerifyTx(context.Background(), &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+		if err != nil || !res.Success {
+			t.Errorf("tx %x rejected: %v %v", tx.Hash(), res, err)
+		}
+	}
+	// Forwarding applies its own, stricter floor
+	forward := func() (forwarded []common.Hash) {
+		pending := make(map[common.Address][]*txpool.LazyTransaction)
+		for _, tx := range []*types.Transaction{cheap, pricey} {
+			from, _ := types.Sender(signer, tx)
+			pending[from] = []*txpool.LazyTransaction{{Hash: tx.Hash(), Tx: tx, GasFeeCap: tx.GasFeeCap(), GasTipCap: tx.GasTipCap(), Gas: tx.Gas()}}
+		}
+		env := &executor_env{signer: signer, header: types.CopyHeader(head)}
+		e.forwardTransactions(nil, env, nil, pending, func(ltx *txpool.LazyTransaction, tx *types.Transaction, local bool) error {
+			forwarded = append(forwarded, ltx.Hash)
+			return nil
+		})
+		return forwarded
+	}
+	if forwarded := forward(); len(forwarded) != 1 || forwarded[0] != pricey.Hash() {
+		t.Errorf("forwarded txs mismatch: have %x, want [%x]", forwarded, pricey.Hash())
+	}
+	// Lowering the forwarding floor at runtime is honored by the validation
+	e.setMinTip(big.NewInt(params.GWei))
+	if forwarded := forward(); len(forwarded) != 2 {
+		t.Errorf("forwarded txs mismatch after lowering the floor: have %d, want %d", len(forwarded), 2)
+	}
+}
+
+// Tests that verifying txs while consensus blocks are written is safe, run with
+// the race detector.
+func TestConcurrentVerify(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	e := &executor{
+		config:      testConfig,
+		chainConfig: &config,
+		engine:      ethash.NewFaker(),
+		eth:         backend,
+		verifyOpts:  newValidationOptions(&config, common.Big0),
+		exitCh:      make(chan struct{}),
+		execCh:      make(chan *execReq),
+		alerter:     newAlerter(testConfig),
+	}
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+
+	var (
+		es     = &executorServer{executorPtr: e}
+		signer = types.LatestSigner(&config)
+		blocks = 5
+		txs    = make(types.Transactions, blocks)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+	}
+	payload, _ := txs[0].MarshalBinary()
+
+	var (
+		done = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			res, err := es.VerifyTx(context.Background(), &pb.Transaction{Type: pb.TransactionType_NORMAL, Payload: payload})
+			if err != nil || !res.Success {
+				t.Errorf("tx rejected: %v %v", res, err)
+				return
+			}
+		}
+	}()
+	for _, tx := range txs {
+		if _, err := es.CommitBlock(context.Background(), newTestExecBlock(t, tx)); err != nil {
+			t.Fatalf("failed to commit block: %v", err)
+		}
+	}
+	// The execution is asynchronous, wait for the last block to be written
+	for start := time.Now(); backend.chain.CurrentBlock().Number.Uint64() < uint64(blocks); {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("blocks not written, head %d", backend.chain.CurrentBlock().Number)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+
+	if env := e.verifyEnv(); env.header.Number.Uint64() != uint64(blocks) {
+		t.Errorf("verification context mismatch: have block %d, want %d", env.header.Number, blocks)
+	}
+}
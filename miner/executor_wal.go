@@ -0,0 +1,157 @@
+package miner
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/proto/pb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	walReplayedMeter = metrics.NewRegisteredMeter("executor/wal/replayed", nil)
+	walSkippedMeter  = metrics.NewRegisteredMeter("executor/wal/skipped", nil)
+)
+
+// walEntry is a consensus block logged before acknowledging it to consensus.
+type walEntry struct {
+	Received uint64 // time the block was received in unix nanoseconds, its timestamp
+	Block    []byte // protobuf encoding of the consensus block
+}
+
+// logBlock persists a consensus block to the write-ahead log before it's
+// acknowledged, so it's executed on the next startup if the node goes down
+// before the block landed. Blocks of competing branches aren't logged, they
+// are only written once consensus decided about them and delivers them again.
+func (e *executor) logBlock(req *execReq, pbBlock *pb.ExecBlock) error {
+	if !e.config.BlockWAL || req.branch != "" {
+		return nil
+	}
+	block, err := proto.Marshal(pbBlock)
+	if err != nil {
+		return err
+	}
+	blob, err := rlp.EncodeToBytes(&walEntry{Received: uint64(req.timestamp), Block: block})
+	if err != nil {
+		return err
+	}
+	index := e.walNext.Add(1) - 1
+	e.eth.BlockChain().WriteExecutorWAL(index, blob)
+	req.wal = []uint64{index}
+	return nil
+}
+
+// releaseWAL drops the logged consensus blocks of a request once executed,
+// whatever the outcome the failure policy settled on. The blocks are kept if
+// the execution halted, to be tried again on the next startup. The post state
+// of a block committing to a deferred state root is only safe once committed,
+// the blocks are dropped from the log along with the commit.
+func (e *executor) releaseWAL(req *execReq, err error) {
+	if len(req.wal) == 0 || e.halted.Load() {
+		return
+	}
+	if err == nil && e.chainConfig.Executor.DefersRoots() {
+		if n := len(e.commits.pending); n > 0 {
+			c := e.commits.pending[n-1]
+			c.wal = append(c.wal, req.wal...)
+			req.wal = nil
+			return
+		}
+	}
+	e.deleteWAL(req.wal)
+	req.wal = nil
+}
+
+// deleteWAL drops consensus blocks from the write-ahead log.
+func (e *executor) deleteWAL(indices []uint64) {
+	chain := e.eth.BlockChain()
+	for _, index := range indices {
+		chain.DeleteExecutorWAL(index)
+	}
+}
+
+// replayWAL queues the consensus blocks acknowledged before the last shutdown
+// which didn't land for execution, in the order they were received. Blocks
+// written already, as told by the consensus sequence of the head or their txs
+// being included, are dropped from the log. It returns once the blocks were
+// queued, not executed, but before consensus can deliver any further ones, so
+// those are ordered behind the replayed blocks.
+func (e *executor) replayWAL() {
+	if e.config.Stateless {
+		return
+	}
+	chain := e.eth.BlockChain()
+	indices, blobs := chain.GetExecutorWAL()
+	if len(indices) == 0 {
+		return
+	}
+	e.walNext.Store(indices[len(indices)-1] + 1)
+
+	var head uint64
+	if meta := chain.GetConsensusMeta(chain.CurrentBlock().Hash()); meta != nil {
+		head = meta.Sequence
+	}
+	var replayed, skipped int
+	for i, index := range indices {
+		req, pbBlock, err := decodeWALEntry(blobs[i], e.verified)
+		if err == nil {
+			// Consensus may retry the block after the restart, it's not to be
+			// executed again
+			if sub, dup := e.submissions.claim(blockID(pbBlock)); sub != nil && !dup {
+				sub.settle(&pb.BlockResult{}, nil)
+			}
+		}
+		switch {
+		case err != nil:
+			log.Warn("Discarding invalid WAL entry", "index", index, "err", err)
+		case req == nil:
+		case req.sequence != 0 && req.sequence <= head, e.executedTxs(req.txs):
+			log.Debug("Skipping executed consensus block", "index", index, "sequence", req.sequence)
+		default:
+			req.wal = []uint64{index}
+			if err := e.queueBlock(req.sequence, req); err != nil {
+				log.Warn("Failed to replay consensus block", "index", index, "sequence", req.sequence, "err", err)
+				return
+			}
+			replayed++
+			walReplayedMeter.Mark(1)
+			continue
+		}
+		skipped++
+		walSkippedMeter.Mark(1)
+		chain.DeleteExecutorWAL(index)
+	}
+	log.Info("Replayed consensus blocks from the WAL", "replayed", replayed, "skipped", skipped)
+}
+
+// decodeWALEntry decodes a logged consensus block into an execution request,
+// which is nil if there's nothing to execute.
+func decodeWALEntry(blob []byte, verified *txCache) (*execReq, *pb.ExecBlock, error) {
+	var entry walEntry
+	if err := rlp.DecodeBytes(blob, &entry); err != nil {
+		return nil, nil, err
+	}
+	pbBlock := new(pb.ExecBlock)
+	if err := proto.Unmarshal(entry.Block, pbBlock); err != nil {
+		return nil, nil, err
+	}
+	req, _, err := decodeExecBlock(pbBlock, verified)
+	if err != nil || req == nil {
+		return nil, pbBlock, err
+	}
+	req.timestamp = int64(entry.Received)
+	req.raw = []*pb.ExecBlock{pbBlock}
+	return req, pbBlock, nil
+}
+
+// executedTxs reports whether all the txs of a consensus block are included in
+// the chain already.
+func (e *executor) executedTxs(txs types.Transactions) bool {
+	for _, tx := range txs {
+		if lookup, _, _ := e.eth.BlockChain().GetTransactionLookup(tx.Hash()); lookup == nil {
+			return false
+		}
+	}
+	return true
+}
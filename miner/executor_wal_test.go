@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestReplayWAL(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.BlockWAL = true
+	newExec := func() *executor {
+		return &executor{
+			config:      &cfg,
+			chainConfig: &config,
+			engine:      ethash.NewFaker(),
+			eth:         backend,
+			exitCh:      make(chan struct{}),
+			execCh:      make(chan *execReq),
+			confirmCh:   make(chan *confirmReq),
+			alerter:     newAlerter(&cfg),
+		}
+	}
+	// The node goes down after writing the first consensus block but before
+	// dropping it from the log, and before executing the second one
+	var (
+		crashed = newExec()
+		signer  = types.LatestSigner(&config)
+		txs     = make(types.Transactions, 2)
+	)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: uint64(i), To: &testUserAddress, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+
+		block := newTestExecBlock(t, txs[i])
+		block.Sequence = uint64(i + 1)
+		req, _, err := decodeExecBlock(block, nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		if err := crashed.logBlock(req, block); err != nil {
+			t.Fatalf("failed to log block: %v", err)
+		}
+		if i == 0 {
+			req.wal = nil
+			if err := crashed.executeNewTxBatch(req); err != nil {
+				t.Fatalf("failed to execute block: %v", err)
+			}
+		}
+	}
+	chain := backend.chain
+	if indices, _ := chain.GetExecutorWAL(); len(indices) != 2 {
+		t.Fatalf("logged blocks mismatch: have %d, want 2", len(indices))
+	}
+	// On restart the written block is dropped from the log, the lost one is
+	// executed before consensus can deliver further blocks
+	e := newExec()
+	e.wg.Add(1)
+	go e.executionLoop()
+	defer func() {
+		close(e.exitCh)
+		e.wg.Wait()
+	}()
+	e.replayWAL()
+
+	for start := time.Now(); chain.CurrentBlock().Number.Uint64() < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("lost block not executed, head %d", chain.CurrentBlock().Number)
+		}
+	}
+	for i, tx := range txs {
+		block := chain.GetBlockByNumber(uint64(i + 1))
+		if len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != tx.Hash() {
+			t.Errorf("block %d txs mismatch: have %d txs", i+1, len(block.Transactions()))
+		}
+	}
+	if head := chain.CurrentBlock().Number.Uint64(); head != 2 {
+		t.Errorf("head mismatch: have %d, want 2", head)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if indices, _ := chain.GetExecutorWAL(); len(indices) == 0 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("executed blocks left in the log")
+		}
+	}
+	// Further blocks are logged after the replayed ones
+	if next := e.walNext.Load(); next != 2 {
+		t.Errorf("next index mismatch: have %d, want 2", next)
+	}
+}
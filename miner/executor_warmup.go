@@ -0,0 +1,77 @@
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	warmupHitMeter  = metrics.NewRegisteredMeter("executor/warmup/hit", nil)
+	warmupMissMeter = metrics.NewRegisteredMeter("executor/warmup/miss", nil)
+)
+
+// batchWarmer pre-loads the state accessed by a consensus block while it waits
+// to be executed, so the execution finds its working set in the caches.
+type batchWarmer struct {
+	total  int          // number of txs in the block
+	warmed atomic.Int32 // number of txs whose state was loaded
+	abort  atomic.Bool  // set when the execution starts
+}
+
+// warmUp starts pre-loading the senders, recipients and access lists of the
+// queued block from the head state.
+func (e *executor) warmUp(req *execReq) {
+	if !e.config.WarmUp {
+		return
+	}
+	chain := e.eth.BlockChain()
+	head := chain.CurrentBlock()
+	statedb, err := chain.StateAt(chain.PostStateRoot(head))
+	if err != nil {
+		return
+	}
+	signer := types.MakeSigner(e.chainConfig, new(big.Int).Add(head.Number, common.Big1), head.Time)
+
+	req.warmer = &batchWarmer{total: len(req.txs)}
+	go req.warmer.run(statedb, signer, req.txs)
+}
+
+// run loads the state accessed by the txs until done or aborted. The statedb
+// must not be used by anyone else.
+func (w *batchWarmer) run(statedb *state.StateDB, signer types.Signer, txs types.Transactions) {
+	for _, tx := range txs {
+		if w.abort.Load() {
+			return
+		}
+		// Recovering the sender caches it in the tx for the execution too
+		if from, err := types.Sender(signer, tx); err == nil {
+			statedb.GetNonce(from)
+		}
+		if to := tx.To(); to != nil {
+			statedb.GetCodeSize(*to)
+		}
+		for _, tuple := range tx.AccessList() {
+			for _, key := range tuple.StorageKeys {
+				statedb.GetState(tuple.Address, key)
+			}
+		}
+		w.warmed.Add(1)
+	}
+}
+
+// stop aborts the warm-up when the execution starts, accounting the txs that
+// were warmed in time as hits and the rest as misses. The number of hits is
+// returned.
+func (w *batchWarmer) stop() int {
+	w.abort.Store(true)
+
+	hits := int(w.warmed.Load())
+	warmupHitMeter.Mark(int64(hits))
+	warmupMissMeter.Mark(int64(w.total - hits))
+	return hits
+}
@@ -0,0 +1,41 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestWarmUp(t *testing.T) {
+	backend := newTestExecBackend(params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	config := &Config{}
+	e := &executor{config: config, chainConfig: params.TestChainConfig, eth: backend}
+
+	// Disabled warm-up must not touch the request
+	req := &execReq{txs: types.Transactions{pendingTxs[0], newTxs[0]}}
+	if e.warmUp(req); req.warmer != nil {
+		t.Fatalf("warm-up started while disabled")
+	}
+	// Enabled warm-up must load every tx before the execution starts
+	config.WarmUp = true
+	e.warmUp(req)
+	if req.warmer == nil {
+		t.Fatalf("warm-up not started")
+	}
+	for deadline := time.Now().Add(time.Second); req.warmer.warmed.Load() < 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("warmed mismatch: have %d, want %d", req.warmer.warmed.Load(), 2)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	req.warmer.stop()
+	if !req.warmer.abort.Load() {
+		t.Fatalf("warm-up not aborted")
+	}
+}
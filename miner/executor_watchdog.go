@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	slowWriteMeter = metrics.NewRegisteredMeter("executor/write/slow", nil)
+	slowDiskGauge  = metrics.NewRegisteredGauge("executor/write/slowdisk", nil)
+)
+
+// watchWrite arms the watchdog of a block write, the returned function disarms
+// it once the write is done. A write exceeding the deadline is reported along
+// with the database stats, and with PauseOnSlowDisk set the executor stops
+// forwarding txs until a write completes within the deadline again. Consensus
+// blocks keep being queued meanwhile.
+func (e *executor) watchWrite(block *types.Block) func() {
+	deadline := e.config.WriteDeadline
+	if deadline == 0 {
+		return func() {}
+	}
+	start := time.Now()
+	slow := func() {
+		slowWriteMeter.Mark(1)
+		log.Warn("Block write exceeding deadline", append([]interface{}{"number", block.Number(), "hash", block.Hash(), "deadline", deadline}, e.diskStats()...)...)
+
+		if e.settings().PauseOnSlowDisk && e.slowDisk.CompareAndSwap(false, true) {
+			slowDiskGauge.Update(1)
+			e.alerter.raise(AlertSlowDisk, fmt.Sprintf("block %d write exceeding %v, tx forwarding paused", block.NumberU64(), deadline))
+			log.Warn("Pausing tx forwarding on slow disk")
+		}
+	}
+	timer := time.AfterFunc(deadline, slow)
+	return func() {
+		elapsed := time.Since(start)
+		if stopped := timer.Stop(); elapsed > deadline {
+			// The timer may not have gotten to fire before the write returned
+			if stopped {
+				slow()
+			}
+			log.Warn("Slow block write completed", "number", block.Number(), "elapsed", common.PrettyDuration(elapsed), "deadline", deadline)
+			return
+		}
+		if e.slowDisk.CompareAndSwap(true, false) {
+			slowDiskGauge.Update(0)
+			e.alerter.resolve(AlertSlowDisk)
+			log.Info("Resuming tx forwarding, disk caught up", "number", block.Number(), "elapsed", common.PrettyDuration(elapsed))
+		}
+	}
+}
+
+// diskStats returns the compaction and write stall stats of the database as
+// log context. Pebble only has a single set of stats.
+func (e *executor) diskStats() []interface{} {
+	db := e.eth.BlockChain().StateCache().DiskDB()
+	stats, err := db.Stat("")
+	if err != nil {
+		return []interface{}{"staterr", err}
+	}
+	ctx := []interface{}{"dbstats", stats}
+	if delay, err := db.Stat("writedelay"); err == nil && delay != stats {
+		ctx = append(ctx, "writedelay", delay)
+	}
+	return ctx
+}
@@ -0,0 +1,59 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSlowDiskPause(t *testing.T) {
+	config := postMergeConfig()
+
+	backend := newTestExecBackend(&config, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer backend.close()
+
+	cfg := *testConfig
+	cfg.WriteDeadline = time.Nanosecond
+	cfg.PauseOnSlowDisk = true
+	e := &executor{config: &cfg, chainConfig: &config, engine: ethash.NewFaker(), eth: backend, alerter: newAlerter(&cfg)}
+
+	signer := types.LatestSigner(&config)
+	execute := func(nonce uint64) {
+		tx := types.MustSignNewTx(testBankKey, signer, &types.LegacyTx{Nonce: nonce, To: &testUserAddress, Gas: params.TxGas, GasPrice: big.NewInt(params.InitialBaseFee)})
+		req, _, err := decodeExecBlock(newTestExecBlock(t, tx), nil)
+		if err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		req.timestamp = time.Now().UnixNano()
+		if err := e.executeNewTxBatch(req); err != nil {
+			t.Fatalf("failed to execute block: %v", err)
+		}
+	}
+	// Writes over the deadline pause the forwarding, but still go through
+	execute(0)
+	for start := time.Now(); !e.slowDisk.Load(); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("slow write didn't pause the forwarding")
+		}
+	}
+	if head := backend.chain.CurrentBlock().Number.Uint64(); head != 1 {
+		t.Fatalf("head mismatch: have %d, want %d", head, 1)
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 1 || alerts[0].Kind != AlertSlowDisk {
+		t.Errorf("alerts mismatch: have %v, want %s", alerts, AlertSlowDisk)
+	}
+	// A write within the deadline resumes it
+	cfg.WriteDeadline = time.Minute
+	execute(1)
+	if e.slowDisk.Load() {
+		t.Errorf("forwarding still paused after a timely write")
+	}
+	if alerts := e.alerter.alerts(); len(alerts) != 0 {
+		t.Errorf("alerts not resolved: %v", alerts)
+	}
+}
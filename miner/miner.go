@@ -35,8 +35,6 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/proto/pb"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Backend wraps all methods required for mining. Only full node is capable
@@ -53,9 +51,103 @@ type Config struct {
 	GasFloor  uint64         // Target gas floor for mined blocks.
 	GasCeil   uint64         // Target gas ceiling for mined blocks.
 	GasPrice  *big.Int       // Minimum gas price for mining a transaction
+	VerifyTip *big.Int       `toml:",omitempty"` // Minimum gas tip of the txs consensus asks to verify (nil = GasPrice)
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
+
+	StakingContract common.Address `toml:",omitempty"` // Staking contract whose deposit/withdraw events are forwarded to consensus
+
+	PrefetchThreshold int  // Minimum number of txs in a consensus block to run the state prefetcher for
+	WarmUp            bool // Pre-load the state accessed by consensus blocks while they wait to be executed
+	VerifyCacheSize   int  // Number of txs verified for consensus kept decoded for their execution (0 = disabled)
+
+	HotSetWindow int // Number of recent blocks to learn the hot contracts from (0 = disabled)
+	HotSetSize   int // Maximum number of hot contracts kept warm before each block
+
+	AlertWebhook       string        `toml:",omitempty"` // URL critical executor conditions are POSTed to
+	AlertCommand       string        `toml:",omitempty"` // Command executed on critical executor conditions
+	AlertInterval      time.Duration // Minimum time between two alerts of the same kind
+	AlertWriteFailures int           // Number of consecutive failed block writes to alert on
+	AlertLinkTimeout   time.Duration // Time the consensus layer may be unreachable before alerting
+
+	ReconnectBackoff time.Duration // Initial delay of the attempts to reach consensus again, doubling up to 30s
+	ResendQueue      int           // Number of txs failing to reach consensus kept for sending again once it's back (0 = disabled)
+
+	AdmissionMaxPending     int  // Maximum number of pending txs before rejecting submissions (0 = unlimited)
+	AdmissionRejectLinkDown bool // Reject submissions while the consensus layer is unreachable
+
+	MaxHeadLag    uint64 // Number of consensus blocks the execution may lag behind before alerting (0 = unlimited)
+	ThrottleOnLag bool   // Pause tx forwarding while the execution lags behind more than MaxHeadLag
+	SyncLag       uint64 // Number of consensus blocks the execution may lag behind before the node reports itself as syncing
+
+	PrioritySenders []common.Address `toml:",omitempty"` // Senders whose inclusion latency is reported separately
+
+	ShadowEIPs []int `toml:",omitempty"` // Extra EIPs of the VM config committed blocks are replayed with for comparison (empty = disabled)
+
+	CoalesceWindow time.Duration // Time to wait for further consensus blocks to execute as one block (0 = disabled)
+
+	SequenceTimeout time.Duration // Time to wait for the consensus blocks missing from the sequence before executing the later ones (0 = wait indefinitely)
+
+	DrainTimeout time.Duration // Time to wait on shutdown for the consensus blocks being delivered to execute (0 = drop them)
+
+	AllowUnprotectedTxs bool // Forward and verify txs without replay protection (txs of other chains are always rejected)
+
+	ForwardExclude []common.Address `toml:",omitempty"` // Contracts whose txs aren't forwarded to consensus, nor the later txs of their senders
+
+	FairForwarding bool             // Share the forwarded batches among the submitters of the txs instead of by price alone
+	Submitters     []SubmitterQuota `toml:",omitempty"` // Weights of the submitters sharing the forwarded batches, unlisted ones share a single queue weighing 1
+
+	FailurePolicy  FailurePolicy // Reaction to consensus blocks failing to be written (retry with backoff by default, empty = drop the block)
+	FailureRetries int           // Number of times a failed block is retried before halting
+	FailureBackoff time.Duration // Delay before the first retry, doubled on every further one
+
+	UnsetEtherbase EtherbasePolicy // Reaction to consensus blocks delivered while the etherbase is unset (empty = burn the fees)
+
+	PostProcessWorkers int // Maximum number of goroutines encoding the receipts and blooms of a block (0 = number of CPUs)
+	HashWorkers        int // Maximum number of goroutines updating and hashing the storage tries of a block (0 = number of CPUs)
+	ForwardShare       int // Percentage of the time tx forwarding rounds may run, they are paced to leave the rest to the execution (0 = unpaced)
+
+	ShedCPU   int // CPU usage in percent of all cores pausing tx forwarding (0 = disabled)
+	ShedQueue int // Number of consensus blocks waiting to be executed pausing tx forwarding (0 = disabled)
+
+	WriteDeadline   time.Duration // Time a block write may take before it's reported as slow (0 = unchecked)
+	PauseOnSlowDisk bool          // Pause tx forwarding while block writes exceed WriteDeadline, consensus blocks are still queued
+	PipelineWrites  bool          // Execute the next consensus block while the previous one is written to the chain, unless the chain defers its state roots
+
+	Stateless bool // Verify consensus blocks with their witnesses instead of executing them on the local state
+
+	InFlightSnapshot time.Duration // Interval between two snapshots of the forwarded txs awaiting inclusion (0 = disabled)
+	InFlightRetry    time.Duration // Time a forwarded tx is awaited before forwarding it again (0 = forward on every recommit)
+
+	NonceGapTimeout time.Duration // Time the nonce of a sender may stall below its forwarded txs before it's reported as a gap (0 = disabled)
+	NonceGapRepair  bool          // Forward the missing tx of a nonce gap again from the pool
+
+	ReceiptExport      string `toml:",omitempty"` // URL of the sink written blocks and receipts are published to (file, http(s) or a registered scheme)
+	ReceiptExportQueue int    // Number of blocks buffered while the receipt sink is behind, dropped beyond with an ExportDropped alert
+
+	ExecutorListenAddr string   `toml:",omitempty"` // Listening address of the executor gRPC API served to consensus, TCP or unix:///path/to.sock
+	ExecutorEndpoint   string   `toml:",omitempty"` // Node endpoint the executor gRPC API is served on instead of ExecutorListenAddr, only "auth" (empty = own listener)
+	ConsensusAddr      string   `toml:",omitempty"` // Address of the consensus gRPC API, TCP or unix:///path/to.sock
+	ConsensusAddrs     []string `toml:",omitempty"` // Further consensus replicas failed over to while the ones before are down
+	ConsensusBalance   bool     // Spread the forwarded txs round-robin over the healthy consensus replicas
+	ExecutorTLSCert    string   `toml:",omitempty"` // PEM certificate the executor presents to consensus, as server and client (empty = plaintext)
+	ExecutorTLSKey     string   `toml:",omitempty"` // PEM private key of the executor certificate
+	ExecutorTLSCA      string   `toml:",omitempty"` // PEM CA bundle consensus is verified against, requiring client certificates (empty = no mutual auth)
+	StandbyPrimary     string   `toml:",omitempty"` // Executor gRPC API of the primary a standby keeps the state of warm, TCP or unix:///path/to.sock (empty = not a standby)
+	GatewayAddr        string   `toml:",omitempty"` // Listening address of the HTTP/JSON gateway in front of the executor gRPC API, secured like the API (empty = disabled)
+
+	EpochLength   uint64           // Number of blocks of a settlement epoch, the state summary is exported at its final block (0 = disabled)
+	EpochAccounts []common.Address `toml:",omitempty"` // Accounts whose balances are exported at the end of every epoch
+	EpochStorage  []StorageSlot    `toml:",omitempty"` // Contract storage slots exported at the end of every epoch
+	EpochExport   string           `toml:",omitempty"` // File the epoch summaries are appended to as JSON lines, besides executor_subscribe (empty = RPC only)
+
+	BlockWAL   bool // Log consensus blocks before acknowledging them, executing the ones which didn't land again on startup
+	Quarantine bool // Keep the consensus blocks failing irrecoverably for the operator to retry, see executor_listQuarantine
+
+	PoolLookup bool // Meter the consensus txs unknown to the local pool and prefetch their senders (never required for the execution)
+
+	DeterminismCheck bool // Refuse to start with VM settings altering the execution of the consensus blocks locally
 }
 
 // DefaultConfig contains default settings for miner.
@@ -69,6 +161,49 @@ var DefaultConfig = Config{
 	// run 3 rounds.
 	Recommit:          2 * time.Second,
 	NewPayloadTimeout: 2 * time.Second,
+
+	// Blocks of a handful of txs are executed faster than the prefetcher
+	// can be spun up and torn down.
+	PrefetchThreshold: 8,
+	WarmUp:            true,
+	VerifyCacheSize:   16384,
+
+	AlertInterval:      5 * time.Minute,
+	AlertWriteFailures: 3,
+	AlertLinkTimeout:   30 * time.Second,
+
+	ReconnectBackoff: time.Second,
+	ResendQueue:      4096,
+
+	FailurePolicy:  FailureRetry,
+	FailureRetries: 3,
+	FailureBackoff: time.Second,
+
+	SequenceTimeout: 30 * time.Second,
+	DrainTimeout:    10 * time.Second,
+
+	// Writes normally complete in tens of milliseconds, seconds mean the disk
+	// is stalled by compaction or failing
+	WriteDeadline: 5 * time.Second,
+
+	// Consensus blocks are delivered while the previous one is executed
+	SyncLag: 2,
+
+	InFlightSnapshot: 10 * time.Second,
+	InFlightRetry:    30 * time.Second,
+
+	// Lost txs are normally forwarded again after InFlightRetry
+	NonceGapTimeout: 2 * time.Minute,
+
+	ReceiptExportQueue: 1024,
+
+	BlockWAL:   true,
+	Quarantine: true,
+
+	ExecutorListenAddr: "127.0.0.1:9876",
+	ConsensusAddr:      "127.0.0.1:9080",
+
+	PoolLookup: true,
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -87,11 +222,17 @@ type Miner struct {
 
 func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, isLocalBlock func(header *types.Header) bool) *Miner {
 	// 实例化共识客户端
-	conn, err := grpc.Dial("127.0.0.1:9080", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := clientCredentials(config)
 	if err != nil {
-		fmt.Println(err)
+		log.Crit("Failed to set up executor TLS", "err", err)
 	}
-	p2pClient := pb.NewP2PClient(conn)
+	addr := config.ConsensusAddr
+	if addr == "" {
+		addr = DefaultConfig.ConsensusAddr
+	}
+	endpoints := newEndpointSet(dialEndpoints(append([]string{addr}, config.ConsensusAddrs...), creds, config), config.ConsensusBalance)
+	p2pClient := pb.NewP2PClient(endpoints)
+	consensusClient := pb.NewConsensusClient(endpoints)
 
 	miner := &Miner{
 		mux:      mux,
@@ -101,8 +242,9 @@ func New(eth Backend, config *Config, chainConfig *params.ChainConfig, mux *even
 		startCh:  make(chan struct{}),
 		stopCh:   make(chan struct{}),
 		worker:   newWorker(config, chainConfig, engine, eth, mux, isLocalBlock, true),
-		executor: newExecutor(config, chainConfig, engine, eth, mux, isLocalBlock, true, p2pClient),
+		executor: newExecutor(config, chainConfig, engine, eth, mux, isLocalBlock, true, p2pClient, consensusClient),
 	}
+	miner.executor.watchEndpoints(endpoints)
 	miner.wg.Add(1)
 	go miner.update()
 	return miner
@@ -150,7 +292,7 @@ func (miner *Miner) update() {
 				canStart = true
 				if shouldStart {
 					// miner.worker.start()
-					miner.executor.start()
+					miner.startExecutor()
 				}
 				// miner.worker.syncing.Store(false)
 
@@ -158,7 +300,7 @@ func (miner *Miner) update() {
 				canStart = true
 				if shouldStart {
 					// miner.worker.start()
-					miner.executor.start()
+					miner.startExecutor()
 				}
 				// miner.worker.syncing.Store(false)
 
@@ -168,7 +310,7 @@ func (miner *Miner) update() {
 		case <-miner.startCh:
 			if canStart {
 				// miner.worker.start()
-				miner.executor.start()
+				miner.startExecutor()
 			}
 			shouldStart = true
 		case <-miner.stopCh:
@@ -183,6 +325,14 @@ func (miner *Miner) update() {
 	}
 }
 
+// startExecutor starts the executor, which stays stopped if its API couldn't
+// be served.
+func (miner *Miner) startExecutor() {
+	if err := miner.executor.start(); err != nil {
+		log.Error("Failed to start executor", "err", err)
+	}
+}
+
 func (miner *Miner) Start() {
 	miner.startCh <- struct{}{}
 }
@@ -216,9 +366,11 @@ func (miner *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
-// SetRecommitInterval sets the interval for sealing work resubmitting.
+// SetRecommitInterval sets the interval for sealing work resubmitting, and the
+// one the executor forwards pending txs to consensus at.
 func (miner *Miner) SetRecommitInterval(interval time.Duration) {
 	miner.worker.setRecommitInterval(interval)
+	miner.executor.setRecommitInterval(interval)
 }
 
 // Pending returns the currently pending block and associated state. The returned
@@ -253,6 +405,98 @@ func (miner *Miner) SetGasCeil(ceil uint64) {
 	miner.worker.setGasCeil(ceil)
 }
 
+// MinTip returns the minimum effective tip a transaction must pay to be
+// forwarded to the consensus layer.
+func (miner *Miner) MinTip() *big.Int {
+	return miner.executor.getMinTip()
+}
+
+// SetMinTip updates the minimum effective tip a transaction must pay to be
+// forwarded to the consensus layer.
+func (miner *Miner) SetMinTip(tip *big.Int) {
+	miner.executor.setMinTip(tip)
+}
+
+// Admit checks whether a new transaction submission may be accepted, returning
+// an *AdmissionError if the executor can't currently forward it to consensus.
+func (miner *Miner) Admit() error {
+	return miner.executor.admit()
+}
+
+// Alerts returns the critical executor conditions which are currently active.
+func (miner *Miner) Alerts() []Alert {
+	return miner.executor.alerter.alerts()
+}
+
+// HeadLag returns the progress of the execution relative to consensus.
+func (miner *Miner) HeadLag() HeadLag {
+	return miner.executor.lagStatus()
+}
+
+// InclusionReports returns the time it took the forwarded txs to be included,
+// per sender class.
+func (miner *Miner) InclusionReports() []InclusionReport {
+	return miner.executor.inclusion.reports()
+}
+
+// OriginReports returns the forwarding and inclusion stats of the txs per
+// origin they were first received from.
+func (miner *Miner) OriginReports() []OriginReport {
+	return miner.executor.inclusion.originReports()
+}
+
+// Stats returns a snapshot of the executor internals.
+func (miner *Miner) Stats() Stats {
+	return miner.executor.stats()
+}
+
+// NonceGaps returns the senders whose forwarded txs are stalled by a missing
+// nonce.
+func (miner *Miner) NonceGaps() []NonceGap {
+	return miner.executor.stalledNonceGaps()
+}
+
+// ExecutorHandler returns the executor gRPC API to be mounted on the node, see
+// Config.ExecutorEndpoint.
+func (miner *Miner) ExecutorHandler() (*NodeHandler, error) {
+	return miner.executor.nodeHandler()
+}
+
+// ExecutorConfig returns the effective configuration of the executor.
+func (miner *Miner) ExecutorConfig() ConfigSnapshot {
+	return miner.executor.configSnapshot()
+}
+
+// UpdateExecutorConfig changes the executor settings which can be changed while
+// running, returning the resulting configuration.
+func (miner *Miner) UpdateExecutorConfig(update LiveConfig) (ConfigSnapshot, error) {
+	return miner.executor.updateConfig(update)
+}
+
+// Reexecute re-executes a historical block with the given overrides, returning
+// the differences they make.
+func (miner *Miner) Reexecute(hash common.Hash, overrides ReexecOverrides) (*ReexecResult, error) {
+	return miner.executor.reexecute(hash, overrides)
+}
+
+// ListQuarantine returns the consensus blocks the executor failed to execute
+// irrecoverably.
+func (miner *Miner) ListQuarantine() []QuarantinedBlock {
+	return miner.executor.listQuarantine()
+}
+
+// RetryQuarantined executes a quarantined consensus block again on top of the
+// chain head.
+func (miner *Miner) RetryQuarantined(id uint64) (*RetryResult, error) {
+	return miner.executor.retryQuarantined(id)
+}
+
+// SubscribeEpochSummaries starts delivering the state summaries of the
+// settlement epochs ended by the written blocks to the given channel.
+func (miner *Miner) SubscribeEpochSummaries(ch chan<- *EpochSummary) event.Subscription {
+	return miner.executor.epochFeed.Subscribe(ch)
+}
+
 // SubscribePendingLogs starts delivering logs from pending transactions
 // to the given channel.
 func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscription {
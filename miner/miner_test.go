@@ -90,6 +90,10 @@ func (bc *testBlockChain) StateAt(common.Hash) (*state.StateDB, error) {
 	return bc.statedb, nil
 }
 
+func (bc *testBlockChain) PostStateRoot(header *types.Header) common.Hash {
+	return header.Root
+}
+
 func (bc *testBlockChain) HasState(root common.Hash) bool {
 	return bc.root == root
 }
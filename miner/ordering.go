@@ -145,3 +145,15 @@ func (t *transactionsByPriceAndNonce) Shift() {
 func (t *transactionsByPriceAndNonce) Pop() {
 	heap.Pop(&t.heads)
 }
+
+// Remaining returns the hashes of the transactions not shifted or popped yet.
+func (t *transactionsByPriceAndNonce) Remaining() []common.Hash {
+	var hashes []common.Hash
+	for _, head := range t.heads {
+		hashes = append(hashes, head.tx.Hash)
+		for _, tx := range t.txs[head.from] {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,62 @@
+// Package provenance implements the consensus provenance executor blocks record
+// in their extra-data, tying every chain block to the consensus decision it was
+// executed from without access to the executor's database.
+//
+// The record is a version byte followed by the consensus id of the proposer,
+// the consensus epoch and the consensus round the block was decided in, each
+// as a big-endian uint64. Blocks executed from several coalesced consensus
+// blocks record the decision of the last one. The record may be followed by the
+// extra-data supplied by consensus, if it fits in the remaining bytes.
+package provenance
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Version is the version of the record encoding.
+	Version = 0x01
+
+	// Size is the length of an encoded record.
+	Size = 1 + 3*8
+)
+
+var (
+	errInvalidSize    = errors.New("invalid provenance record size")
+	errUnknownVersion = errors.New("unknown provenance record version")
+)
+
+// Provenance is the consensus decision a block was executed from.
+type Provenance struct {
+	Proposer uint64 // consensus id of the node which proposed the block
+	Epoch    uint64 // consensus epoch the block was decided in
+	Round    uint64 // consensus round the block was decided in
+}
+
+// Encode returns the record of the decision, to be used as extra-data.
+func (p Provenance) Encode() []byte {
+	blob := make([]byte, Size)
+	blob[0] = Version
+	binary.BigEndian.PutUint64(blob[1:], p.Proposer)
+	binary.BigEndian.PutUint64(blob[9:], p.Epoch)
+	binary.BigEndian.PutUint64(blob[17:], p.Round)
+	return blob
+}
+
+// Decode parses the record in the extra-data of a block, returning the bytes
+// supplied by consensus following it.
+func Decode(extra []byte) (Provenance, []byte, error) {
+	if len(extra) < Size {
+		return Provenance{}, nil, fmt.Errorf("%w: have %d, want at least %d", errInvalidSize, len(extra), Size)
+	}
+	if extra[0] != Version {
+		return Provenance{}, nil, fmt.Errorf("%w: %d", errUnknownVersion, extra[0])
+	}
+	return Provenance{
+		Proposer: binary.BigEndian.Uint64(extra[1:]),
+		Epoch:    binary.BigEndian.Uint64(extra[9:]),
+		Round:    binary.BigEndian.Uint64(extra[17:]),
+	}, extra[Size:], nil
+}
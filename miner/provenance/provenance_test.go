@@ -0,0 +1,38 @@
+package provenance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestEncoding(t *testing.T) {
+	p := Provenance{Proposer: 3, Epoch: 1 << 40, Round: 7}
+	extra := p.Encode()
+	if len(extra) != Size || len(extra) > int(params.MaximumExtraDataSize) {
+		t.Fatalf("record size mismatch: have %d, want %d", len(extra), Size)
+	}
+	have, rest, err := Decode(extra)
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	if have != p || len(rest) != 0 {
+		t.Errorf("record mismatch: have %+v, %x, want %+v", have, rest, p)
+	}
+	// Consensus supplied extra-data following the record is returned as is
+	have, rest, err = Decode(append(extra, "app"...))
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	if have != p || string(rest) != "app" {
+		t.Errorf("record mismatch: have %+v, %q, want %+v, %q", have, rest, p, "app")
+	}
+	if _, _, err := Decode(extra[:Size-1]); !errors.Is(err, errInvalidSize) {
+		t.Errorf("truncated record error mismatch: have %v, want %v", err, errInvalidSize)
+	}
+	extra[0] = Version + 1
+	if _, _, err := Decode(extra); !errors.Is(err, errUnknownVersion) {
+		t.Errorf("unknown version error mismatch: have %v, want %v", err, errUnknownVersion)
+	}
+}
@@ -77,6 +77,10 @@ var (
 	errBlockInterruptedByNewHead  = errors.New("new head arrived while building block")
 	errBlockInterruptedByRecommit = errors.New("recommit interrupt while building block")
 	errBlockInterruptedByTimeout  = errors.New("timeout while building block")
+
+	// errBlockInterruptedByPreemption is returned if the forwarding round is
+	// aborted for the execution of a consensus block.
+	errBlockInterruptedByPreemption = errors.New("consensus block preempted building block")
 )
 
 // environment is the worker's current environment and holds all
@@ -141,6 +145,7 @@ const (
 	commitInterruptNewHead
 	commitInterruptResubmit
 	commitInterruptTimeout
+	commitInterruptPreempted
 )
 
 // newWorkReq represents a request for new sealing work submitting with relative interrupt notifier.
@@ -896,6 +901,8 @@ type generateParams struct {
 	withdrawals types.Withdrawals // List of withdrawals to include in block.
 	beaconRoot  *common.Hash      // The beacon root (cancun field).
 	noTxs       bool              // Flag whether an empty block without any transaction is expected
+	state       *state.StateDB    // State to build on top of, the one of the parent if nil
+	forward     bool              // Flag whether the task only selects the txs to forward, apart from the execution
 }
 
 // prepareWork constructs the sealing task according to the given parameters,
@@ -1192,6 +1199,10 @@ func copyReceipts(receipts []*types.Receipt) []*types.Receipt {
 func totalFees(block *types.Block, receipts []*types.Receipt) *big.Int {
 	feesWei := new(big.Int)
 	for i, tx := range block.Transactions() {
+		// Deposits pay no fees, their zero fee cap would make the tip negative
+		if tx.Type() == types.DepositTxType {
+			continue
+		}
 		minerFee, _ := tx.EffectiveGasTip(block.BaseFee())
 		feesWei.Add(feesWei, new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), minerFee))
 	}
@@ -1208,6 +1219,8 @@ func signalToErr(signal int32) error {
 		return errBlockInterruptedByRecommit
 	case commitInterruptTimeout:
 		return errBlockInterruptedByTimeout
+	case commitInterruptPreempted:
+		return errBlockInterruptedByPreemption
 	default:
 		panic(fmt.Errorf("undefined signal %d", signal))
 	}
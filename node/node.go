@@ -291,6 +291,9 @@ func containsLifecycle(lfs []Lifecycle, l Lifecycle) bool {
 // stopServices terminates running services, RPC and p2p networking.
 // It is the inverse of Start.
 func (n *Node) stopServices(running []Lifecycle) error {
+	// The gRPC calls finish while the servers they're mounted on are still up
+	n.http.drainGRPC()
+	n.httpAuth.drainGRPC()
 	n.stopRPC()
 
 	// Stop running lifecycles in reverse order.
@@ -497,7 +500,7 @@ func (n *Node) startRPC() error {
 		}
 	}
 	// Configure authenticated API
-	if len(openAPIs) != len(allAPIs) {
+	if len(openAPIs) != len(allAPIs) || n.httpAuth.grpcHandler != nil {
 		jwtSecret, err := n.obtainJWTSecret(n.config.JWTSecret)
 		if err != nil {
 			return err
@@ -618,6 +621,37 @@ func (n *Node) RegisterHandler(name, path string, handler http.Handler) {
 	n.http.handlerNames[path] = name
 }
 
+// GRPCHandler is a gRPC server mounted on an HTTP server of the node. Servers
+// mounted this way can't be stopped gracefully by gRPC itself.
+type GRPCHandler interface {
+	http.Handler
+
+	// Drain stops the server from taking further calls and finishes the ones in
+	// progress. It's called before the HTTP servers of the node shut down.
+	Drain()
+}
+
+// RegisterGRPC mounts a gRPC server on the canonical HTTP server or on the
+// authenticated one, next to JSON-RPC. The calls are served over
+// cleartext HTTP/2 and are subject to the virtual hosts of the server, and to
+// its JWT secret if authenticated.
+//
+// The name of the handler is shown in a log message when the HTTP server starts
+// and should be a descriptive term for the service provided by the handler.
+func (n *Node) RegisterGRPC(name string, handler GRPCHandler, authenticated bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.state != initializingState {
+		panic("can't register gRPC handler on running/stopped node")
+	}
+	server := n.http
+	if authenticated {
+		server = n.httpAuth
+	}
+	server.grpcName, server.grpcHandler = name, handler
+}
+
 // Attach creates an RPC client attached to an in-process API handler.
 func (n *Node) Attach() *rpc.Client {
 	return rpc.DialInProc(n.inprocHandler)
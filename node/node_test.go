@@ -415,6 +415,37 @@ func TestRegisterHandler_Successful(t *testing.T) {
 	assert.Equal(t, "success", string(buf))
 }
 
+// drainRecorder is a gRPC handler recording whether the HTTP server still served
+// requests when drained.
+type drainRecorder struct {
+	http.Handler
+	url    string
+	served bool
+}
+
+func (d *drainRecorder) Drain() {
+	if resp, err := http.Get(d.url); err == nil {
+		resp.Body.Close()
+		d.served = true
+	}
+}
+
+// Tests that mounted gRPC servers are drained before the HTTP servers stop.
+func TestRegisterGRPC_Drain(t *testing.T) {
+	node := createNode(t, 7880, 7981)
+	handler := &drainRecorder{Handler: http.NotFoundHandler()}
+	node.RegisterGRPC("test", handler, false)
+
+	if err := node.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	handler.url = node.HTTPEndpoint()
+	node.Close()
+	if !handler.served {
+		t.Fatalf("HTTP server stopped before draining gRPC")
+	}
+}
+
 // Tests that the given handler will not be successfully mounted since no HTTP server
 // is enabled for RPC
 func TestRegisterHandler_Unsuccessful(t *testing.T) {
@@ -33,6 +33,8 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // httpConfig is the JSON-RPC/HTTP configuration.
@@ -61,6 +63,7 @@ type rpcEndpointConfig struct {
 type rpcHandler struct {
 	http.Handler
 	server *rpc.Server
+	grpc   http.Handler // gRPC services behind the vhost and JWT checks, nil if none mounted
 }
 
 type httpServer struct {
@@ -81,6 +84,10 @@ type httpServer struct {
 	wsConfig  wsConfig
 	wsHandler atomic.Value // *rpcHandler
 
+	// gRPC handler things, served over cleartext HTTP/2 along with JSON-RPC.
+	grpcName    string
+	grpcHandler GRPCHandler // set by Node.RegisterGRPC, nil if none mounted
+
 	// These are set by setListenAddr.
 	endpoint string
 	host     string
@@ -145,6 +152,15 @@ func (h *httpServer) start() error {
 		h.server.WriteTimeout = h.timeouts.WriteTimeout
 		h.server.IdleTimeout = h.timeouts.IdleTimeout
 	}
+	if h.grpcHandler != nil {
+		// gRPC needs HTTP/2, spoken in cleartext. The HTTP/2 connections are
+		// told to go away on shutdown.
+		h2s := new(http2.Server)
+		if err := http2.ConfigureServer(h.server, h2s); err != nil {
+			return err
+		}
+		h.server.Handler = h2c.NewHandler(h, h2s)
+	}
 
 	// Start the server.
 	listener, err := net.Listen("tcp", h.endpoint)
@@ -176,6 +192,9 @@ func (h *httpServer) start() error {
 		"cors", strings.Join(h.httpConfig.CorsAllowedOrigins, ","),
 		"vhosts", strings.Join(h.httpConfig.Vhosts, ","),
 	)
+	if h.grpcHandler != nil {
+		log.Info(h.grpcName+" enabled", "endpoint", listener.Addr(), "protocol", "gRPC")
+	}
 
 	// Log all handlers mounted on server.
 	var paths []string
@@ -207,6 +226,11 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// if http-rpc is enabled, try to serve request
 	rpc := h.httpHandler.Load().(*rpcHandler)
 	if rpc != nil {
+		// gRPC calls are told apart by their content type, whatever the path
+		if rpc.grpc != nil && isGRPC(r) {
+			rpc.grpc.ServeHTTP(w, r)
+			return
+		}
 		// First try to route in the mux.
 		// Requests to a path below root are handled by the mux,
 		// which has all the handlers registered via Node.RegisterHandler.
@@ -225,6 +249,19 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
+// drainGRPC drains the gRPC services mounted on the server, if any, before the
+// server shuts down.
+func (h *httpServer) drainGRPC() {
+	if h.grpcHandler != nil {
+		h.grpcHandler.Drain()
+	}
+}
+
+// isGRPC checks whether a given request is a gRPC call.
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
 // checkPath checks whether a given request URL matches a given path prefix.
 func checkPath(r *http.Request, path string) bool {
 	// if no prefix has been specified, request URL must be on root
@@ -308,10 +345,14 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 		return err
 	}
 	h.httpConfig = config
-	h.httpHandler.Store(&rpcHandler{
+	handler := &rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
 		server:  srv,
-	})
+	}
+	if h.grpcHandler != nil {
+		handler.grpc = newGRPCHandlerStack(h.grpcHandler, config.Vhosts, config.jwtSecret)
+	}
+	h.httpHandler.Store(handler)
 	return nil
 }
 
@@ -396,6 +437,16 @@ func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, jwtSe
 	return newGzipHandler(handler)
 }
 
+// newGRPCHandlerStack returns a wrapped gRPC handler. Browsers don't speak gRPC
+// and its messages are compressed by gRPC itself, leaving out CORS and gzip.
+func newGRPCHandlerStack(srv http.Handler, vhosts []string, jwtSecret []byte) http.Handler {
+	handler := newVHostHandler(vhosts, srv)
+	if len(jwtSecret) != 0 {
+		handler = newJWTHandler(jwtSecret, handler)
+	}
+	return handler
+}
+
 // NewWSHandlerStack returns a wrapped ws-related handler.
 func NewWSHandlerStack(srv http.Handler, jwtSecret []byte) http.Handler {
 	if len(jwtSecret) != 0 {
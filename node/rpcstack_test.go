@@ -18,6 +18,7 @@ package node
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -34,6 +35,13 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const testMethod = "rpc_modules"
@@ -449,6 +457,69 @@ func TestJWT(t *testing.T) {
 	srv.stop()
 }
 
+// testGRPC is a gRPC server mountable on the node.
+type testGRPC struct {
+	*grpc.Server
+}
+
+func (s *testGRPC) Drain() { s.Stop() }
+
+// TestGRPC makes sure gRPC services are served along with JSON-RPC, subject to
+// the vhosts and the JWT secret of the server.
+func TestGRPC(t *testing.T) {
+	var (
+		secret = []byte("secret")
+		gs     = grpc.NewServer()
+		srv    = newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+	)
+	healthpb.RegisterHealthServer(gs, health.NewServer())
+	srv.grpcName, srv.grpcHandler = "test", &testGRPC{gs}
+
+	assert.NoError(t, srv.enableRPC(apis(), httpConfig{Vhosts: []string{"localhost"}, rpcEndpointConfig: rpcEndpointConfig{jwtSecret: secret}}))
+	assert.NoError(t, srv.setListenAddr("localhost", 0))
+	assert.NoError(t, srv.start())
+	defer srv.stop()
+
+	token := func() string {
+		ss, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaim{"iat": time.Now().Unix()}).SignedString(secret)
+		return "Bearer " + ss
+	}
+	check := func(authority string, auth string) codes.Code {
+		conn, err := grpc.Dial(srv.listenAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithAuthority(authority))
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+
+		ctx := context.Background()
+		if auth != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", auth)
+		}
+		_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return status.Code(err)
+	}
+	tests := []struct {
+		authority string
+		auth      string
+		code      codes.Code
+	}{
+		{"localhost", token(), codes.OK},
+		{"localhost", "", codes.Unauthenticated},
+		{"localhost", "Bearer invalid", codes.Unauthenticated},
+		{"evil.com", token(), codes.PermissionDenied},
+	}
+	for i, tt := range tests {
+		if have := check(tt.authority, tt.auth); have != tt.code {
+			t.Errorf("tc %d: code mismatch: have %v, want %v", i, have, tt.code)
+		}
+	}
+	// JSON-RPC is still served on the same port
+	resp := rpcRequest(t, fmt.Sprintf("http://%v", srv.listenAddr()), testMethod, "Authorization", token())
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("JSON-RPC status mismatch: have %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+}
+
 func TestGzipHandler(t *testing.T) {
 	type gzipTest struct {
 		name    string
@@ -21,6 +21,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params/forks"
 )
 
@@ -364,6 +365,10 @@ type ChainConfig struct {
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+
+	// Executor holds the rules of chains executed on behalf of an external
+	// consensus layer.
+	Executor *ExecutorConfig `json:"executor,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -385,6 +390,282 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// ExecutorConfig is the set of execution rules of chains whose blocks are
+// ordered by an external consensus layer and executed by the miner's executor.
+// All the replicas of a chain must agree on it.
+type ExecutorConfig struct {
+	SystemCalls []SystemCall `json:"systemCalls,omitempty"` // Calls made before the user txs of every block
+
+	MaxCallDepth uint64 `json:"maxCallDepth,omitempty"` // Maximum depth of the call/create stack, at most CallCreateDepth (0 = CallCreateDepth)
+	MaxTxMemory  uint64 `json:"maxTxMemory,omitempty"`  // Maximum EVM memory in bytes a tx may expand across its call frames (0 = unlimited)
+
+	MaxBlockMemory uint64 `json:"maxBlockMemory,omitempty"` // Maximum memory in bytes a block may account to state changes and logs (0 = unlimited)
+
+	TxOrdering TxOrdering `json:"txOrdering,omitempty"` // Order the txs of a consensus block are executed in (empty = consensus order)
+
+	ReservedGasPercent uint64 `json:"reservedGasPercent,omitempty"` // Share of the block gas limit only governance txs may use (0 = none)
+
+	FeeSplitter *FeeSplitter `json:"feeSplitter,omitempty"` // Contract collecting the fees of every block instead of an etherbase
+
+	CalldataFee *CalldataFee `json:"calldataFee,omitempty"` // Fee market pricing the calldata of txs per byte (nil = calldata only pays gas)
+
+	KeepEmptyAccounts bool `json:"keepEmptyAccounts,omitempty"` // Keep the empty accounts touched by txs instead of deleting them per EIP158
+
+	DeferredRootDepth uint64   `json:"deferredRootDepth,omitempty"` // Number of blocks the state root in the headers lags behind, at most MaxDeferredRootDepth (0 = own post state)
+	DeferredRootBlock *big.Int `json:"deferredRootBlock,omitempty"` // Block from which the state root in the headers lags behind (nil = genesis)
+
+	GasGovernor *GasGovernor `json:"gasGovernor,omitempty"` // Contract the block gas ceiling is read from every epoch (nil = the miner gas ceiling)
+
+	Deposits      *Deposits `json:"deposits,omitempty"`      // Deposit txs consensus mints bridged funds with (nil = deposit txs are invalid)
+	DepositsBlock *big.Int  `json:"depositsBlock,omitempty"` // Block from which deposit txs are valid (nil = genesis)
+
+	ReceiptCommitmentBlock *big.Int `json:"receiptCommitmentBlock,omitempty"` // Block from which headers embed the receipt commitment in their extra-data (nil = never)
+	ProvenanceBlock        *big.Int `json:"provenanceBlock,omitempty"`        // Block from which headers embed the consensus provenance in their extra-data (nil = never)
+}
+
+// MaxDeferredRootDepth is the maximum number of blocks the state root committed
+// to by a header may lag behind. The post states of the blocks in between are
+// kept in memory, which must not outlive the tries retained by the chain.
+const MaxDeferredRootDepth = 64
+
+// RootDepth returns the number of blocks the state root committed to by the
+// header of the block with the given number lags behind, zero if the header
+// commits to its own post state. A header at height N commits to the post state
+// of block N-depth, the genesis state if there's no such block.
+func (c *ExecutorConfig) RootDepth(num *big.Int) uint64 {
+	if !isBlockForked(c.deferredRootBlock(), num) {
+		return 0
+	}
+	if c.DeferredRootDepth > MaxDeferredRootDepth {
+		return MaxDeferredRootDepth
+	}
+	return c.DeferredRootDepth
+}
+
+// DefersRoots reports whether the headers of the chain defer their state roots
+// from some block on.
+func (c *ExecutorConfig) DefersRoots() bool {
+	return c.deferredRootBlock() != nil
+}
+
+func (c *ExecutorConfig) deferredRootBlock() *big.Int {
+	if c == nil || c.DeferredRootDepth == 0 {
+		return nil
+	}
+	if c.DeferredRootBlock == nil {
+		return common.Big0
+	}
+	return c.DeferredRootBlock
+}
+
+// CallDepthLimit returns the maximum depth of the call/create stack. The
+// limit can only be tightened compared to mainnet.
+func (c *ExecutorConfig) CallDepthLimit() uint64 {
+	if c == nil || c.MaxCallDepth == 0 || c.MaxCallDepth > CallCreateDepth {
+		return CallCreateDepth
+	}
+	return c.MaxCallDepth
+}
+
+// TxMemoryLimit returns the maximum EVM memory in bytes a tx may expand across
+// its active call frames, zero if only gas bounds the memory.
+func (c *ExecutorConfig) TxMemoryLimit() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.MaxTxMemory
+}
+
+// BlockMemoryLimit returns the maximum memory in bytes the execution of a block
+// may account to its state changes and logs, zero if unlimited. Blocks over the
+// limit are rejected by every executor alike.
+func (c *ExecutorConfig) BlockMemoryLimit() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.MaxBlockMemory
+}
+
+// ReservedGas returns the gas of a block with the given gas limit which only
+// governance txs may use.
+func (c *ExecutorConfig) ReservedGas(gasLimit uint64) uint64 {
+	if c == nil || c.ReservedGasPercent == 0 {
+		return 0
+	}
+	if c.ReservedGasPercent >= 100 {
+		return gasLimit
+	}
+	return gasLimit / 100 * c.ReservedGasPercent
+}
+
+// KeepsEmptyAccounts reports whether empty accounts survive being touched, as
+// on legacy private chains migrated from clients never deleting them.
+func (c *ExecutorConfig) KeepsEmptyAccounts() bool {
+	return c != nil && c.KeepEmptyAccounts
+}
+
+// CalldataFeeMarket returns the fee market pricing the calldata of txs, nil if
+// calldata only pays gas.
+func (c *ExecutorConfig) CalldataFeeMarket() *CalldataFee {
+	if c == nil {
+		return nil
+	}
+	return c.CalldataFee
+}
+
+// GasGovernance returns the contract the block gas ceiling is read from, nil if
+// the ceiling is configured per node.
+func (c *ExecutorConfig) GasGovernance() *GasGovernor {
+	if c == nil {
+		return nil
+	}
+	return c.GasGovernor
+}
+
+// DepositConfig returns the settings of the deposit txs in the block with the
+// given number, nil if deposit txs are invalid in it.
+func (c *ExecutorConfig) DepositConfig(num *big.Int) *Deposits {
+	if !isBlockForked(c.depositsBlock(), num) {
+		return nil
+	}
+	return c.Deposits
+}
+
+func (c *ExecutorConfig) depositsBlock() *big.Int {
+	if c == nil || c.Deposits == nil {
+		return nil
+	}
+	if c.DepositsBlock == nil {
+		return common.Big0
+	}
+	return c.DepositsBlock
+}
+
+// IsReceiptCommitment reports whether the header of the block with the given
+// number embeds the commitment to its receipts in its extra-data.
+func (c *ExecutorConfig) IsReceiptCommitment(num *big.Int) bool {
+	return isBlockForked(c.receiptCommitmentBlock(), num)
+}
+
+func (c *ExecutorConfig) receiptCommitmentBlock() *big.Int {
+	if c == nil {
+		return nil
+	}
+	return c.ReceiptCommitmentBlock
+}
+
+// IsProvenance reports whether the header of the block with the given number
+// embeds the consensus decision the block was executed from in its extra-data.
+func (c *ExecutorConfig) IsProvenance(num *big.Int) bool {
+	return isBlockForked(c.provenanceBlock(), num)
+}
+
+func (c *ExecutorConfig) provenanceBlock() *big.Int {
+	if c == nil {
+		return nil
+	}
+	return c.ProvenanceBlock
+}
+
+// Ordering returns the order the txs of a consensus block are executed in.
+func (c *ExecutorConfig) Ordering() TxOrdering {
+	if c == nil || c.TxOrdering == "" {
+		return TxOrderingConsensus
+	}
+	return c.TxOrdering
+}
+
+// TxOrdering is the order the executor executes the txs of a consensus block
+// in. The txs of every sender are always executed in nonce order.
+type TxOrdering string
+
+const (
+	// TxOrderingConsensus executes the txs exactly in the order consensus
+	// delivered them.
+	TxOrderingConsensus TxOrdering = "consensus"
+
+	// TxOrderingNonce groups the txs by sender in nonce order, the senders in
+	// the order of their first tx.
+	TxOrderingNonce TxOrdering = "nonce"
+
+	// TxOrderingTip executes the txs by effective tip, highest first, ties in
+	// the order consensus delivered them.
+	TxOrderingTip TxOrdering = "tip"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, rejecting unknown orderings.
+func (o *TxOrdering) UnmarshalText(input []byte) error {
+	switch ordering := TxOrdering(input); ordering {
+	case "", TxOrderingConsensus, TxOrderingNonce, TxOrderingTip:
+		*o = ordering
+		return nil
+	default:
+		return fmt.Errorf("unknown tx ordering %q, want %q, %q or %q", input, TxOrderingConsensus, TxOrderingNonce, TxOrderingTip)
+	}
+}
+
+// SystemCall is a call made from the system address at the start of every
+// block, before any user transaction, e.g. to store a consensus provided root
+// in a contract (like EIP-4788). The calldata is supplied by the consensus
+// layer along with the block under the name of the call.
+type SystemCall struct {
+	Name    string         `json:"name"`    // Key of the calldata in the consensus block metadata
+	Address common.Address `json:"address"` // Contract being called
+	Gas     uint64         `json:"gas"`     // Gas allowance of the call, not accounted in the block
+}
+
+// GasGovernor is a governance contract holding the block gas ceiling, allowing
+// the block capacity to be changed on-chain without restarting the nodes. At
+// every epoch boundary, the contract is called from the system address on the
+// post state of the boundary block and returns the ceiling of the blocks of
+// the next epoch as a uint256. Nodes fall back to their own gas ceiling if the
+// call fails or returns zero.
+type GasGovernor struct {
+	Address common.Address `json:"address"`         // Contract holding the gas ceiling
+	Gas     uint64         `json:"gas"`             // Gas allowance of the call
+	Input   hexutil.Bytes  `json:"input,omitempty"` // Calldata of the call
+	Epoch   uint64         `json:"epoch"`           // Number of blocks of an epoch, the ceiling is read at multiples of it (0 = every block)
+}
+
+// Boundary returns the number of the block the ceiling of the block after the
+// given parent is read at, the last epoch boundary up to the parent.
+func (g *GasGovernor) Boundary(parent uint64) uint64 {
+	if g.Epoch <= 1 {
+		return parent
+	}
+	return parent - parent%g.Epoch
+}
+
+// Deposits enables the deposit txs consensus mints funds bridged from another
+// chain with, once it attested to the deposit. The source hash of every minted
+// deposit is recorded in the storage of an account, so a deposit delivered again
+// is rejected instead of minting the funds twice.
+type Deposits struct {
+	Address common.Address `json:"address"` // Account recording the minted deposits in its storage
+}
+
+// FeeSplitter is a contract used as the coinbase of every block, allowing
+// multiple operators to share the fees on-chain instead of trusting a single
+// etherbase key. After the txs of a block are executed, the contract is called
+// from the system address to distribute the fees it collected.
+type FeeSplitter struct {
+	Address common.Address `json:"address"`         // Contract collecting the fees
+	Gas     uint64         `json:"gas"`             // Gas allowance of the distribution call, not accounted in the block
+	Input   hexutil.Bytes  `json:"input,omitempty"` // Calldata of the distribution call
+}
+
+// CalldataFee prices the calldata of txs per byte on top of their gas, like
+// EIP-4844 prices blobs: the fee rises exponentially while blocks carry more
+// calldata than the target and decays while they carry less. The excess
+// calldata is kept in the storage of an account, so every executor derives the
+// same fee from the parent state. The fee is burned.
+type CalldataFee struct {
+	Address        common.Address `json:"address"`          // Account keeping the excess calldata in its storage
+	Target         uint64         `json:"target"`           // Calldata bytes per block the fee is stable at
+	MinFee         *big.Int       `json:"minFee,omitempty"` // Fee per byte without excess calldata, in wei (nil = 1 wei)
+	UpdateFraction uint64         `json:"updateFraction"`   // Excess calldata bytes raising the fee by a factor of e
+}
+
 // Description returns a human-readable description of ChainConfig.
 func (c *ChainConfig) Description() string {
 	var banner string
@@ -502,6 +783,13 @@ func (c *ChainConfig) IsEIP158(num *big.Int) bool {
 	return isBlockForked(c.EIP158Block, num)
 }
 
+// DeleteEmptyAccounts returns whether the empty accounts touched at block num are
+// deleted, which is the case from EIP158 on unless the executor config keeps
+// them.
+func (c *ChainConfig) DeleteEmptyAccounts(num *big.Int) bool {
+	return c.IsEIP158(num) && !c.Executor.KeepsEmptyAccounts()
+}
+
 // IsByzantium returns whether num is either equal to the Byzantium fork block or greater.
 func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isBlockForked(c.ByzantiumBlock, num)
@@ -667,6 +955,11 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	// Both records take up the extra-data, headers can't embed them along
+	if c.Executor.receiptCommitmentBlock() != nil && c.Executor.provenanceBlock() != nil {
+		return fmt.Errorf("unsupported executor config: receiptCommitmentBlock enabled at block %v, but provenanceBlock enabled at block %v",
+			c.Executor.receiptCommitmentBlock(), c.Executor.provenanceBlock())
+	}
 	return nil
 }
 
@@ -738,6 +1031,24 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headNumber *big.Int,
 	if isForkTimestampIncompatible(c.VerkleTime, newcfg.VerkleTime, headTimestamp) {
 		return newTimestampCompatError("Verkle fork timestamp", c.VerkleTime, newcfg.VerkleTime)
 	}
+	if isForkBlockIncompatible(c.Executor.deferredRootBlock(), newcfg.Executor.deferredRootBlock(), headNumber) {
+		return newBlockCompatError("Deferred root fork block", c.Executor.deferredRootBlock(), newcfg.Executor.deferredRootBlock())
+	}
+	if isBlockForked(c.Executor.deferredRootBlock(), headNumber) && c.Executor.RootDepth(headNumber) != newcfg.Executor.RootDepth(headNumber) {
+		return newBlockCompatError("Deferred root depth", c.Executor.deferredRootBlock(), newcfg.Executor.deferredRootBlock())
+	}
+	if isForkBlockIncompatible(c.Executor.depositsBlock(), newcfg.Executor.depositsBlock(), headNumber) {
+		return newBlockCompatError("Deposits fork block", c.Executor.depositsBlock(), newcfg.Executor.depositsBlock())
+	}
+	if isBlockForked(c.Executor.depositsBlock(), headNumber) && *c.Executor.Deposits != *newcfg.Executor.Deposits {
+		return newBlockCompatError("Deposits address", c.Executor.depositsBlock(), newcfg.Executor.depositsBlock())
+	}
+	if isForkBlockIncompatible(c.Executor.receiptCommitmentBlock(), newcfg.Executor.receiptCommitmentBlock(), headNumber) {
+		return newBlockCompatError("Receipt commitment fork block", c.Executor.receiptCommitmentBlock(), newcfg.Executor.receiptCommitmentBlock())
+	}
+	if isForkBlockIncompatible(c.Executor.provenanceBlock(), newcfg.Executor.provenanceBlock(), headNumber) {
+		return newBlockCompatError("Provenance fork block", c.Executor.provenanceBlock(), newcfg.Executor.provenanceBlock())
+	}
 	return nil
 }
 
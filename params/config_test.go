@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 )
 
@@ -109,6 +110,90 @@ func TestCheckCompatible(t *testing.T) {
 				RewindToTime: 9,
 			},
 		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{ReceiptCommitmentBlock: big.NewInt(10)}},
+			new:       &ChainConfig{},
+			headBlock: 5,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{ReceiptCommitmentBlock: big.NewInt(10)}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{}},
+			headBlock: 15,
+			wantErr: &ConfigCompatError{
+				What:          "Receipt commitment fork block",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      nil,
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{ProvenanceBlock: big.NewInt(10)}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{ProvenanceBlock: big.NewInt(20)}},
+			headBlock: 15,
+			wantErr: &ConfigCompatError{
+				What:          "Provenance fork block",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(20),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 2}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 2, DeferredRootBlock: big.NewInt(10)}},
+			headBlock: 5,
+			wantErr: &ConfigCompatError{
+				What:          "Deferred root fork block",
+				StoredBlock:   big.NewInt(0),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 0,
+			},
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 2, DeferredRootBlock: big.NewInt(10)}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 4, DeferredRootBlock: big.NewInt(10)}},
+			headBlock: 5,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 2, DeferredRootBlock: big.NewInt(10)}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{DeferredRootDepth: 4, DeferredRootBlock: big.NewInt(10)}},
+			headBlock: 15,
+			wantErr: &ConfigCompatError{
+				What:          "Deferred root depth",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{Deposits: &Deposits{}}},
+			headBlock: 5,
+			wantErr: &ConfigCompatError{
+				What:          "Deposits fork block",
+				StoredBlock:   nil,
+				NewBlock:      big.NewInt(0),
+				RewindToBlock: 0,
+			},
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{Deposits: &Deposits{}, DepositsBlock: big.NewInt(10)}},
+			headBlock: 5,
+			wantErr:   nil,
+		},
+		{
+			stored:    &ChainConfig{Executor: &ExecutorConfig{Deposits: &Deposits{}, DepositsBlock: big.NewInt(10)}},
+			new:       &ChainConfig{Executor: &ExecutorConfig{Deposits: &Deposits{Address: common.Address{0x01}}, DepositsBlock: big.NewInt(10)}},
+			headBlock: 15,
+			wantErr: &ConfigCompatError{
+				What:          "Deposits address",
+				StoredBlock:   big.NewInt(10),
+				NewBlock:      big.NewInt(10),
+				RewindToBlock: 9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -119,6 +204,17 @@ func TestCheckCompatible(t *testing.T) {
 	}
 }
 
+func TestCheckExecutorExtra(t *testing.T) {
+	c := &ChainConfig{Executor: &ExecutorConfig{ReceiptCommitmentBlock: big.NewInt(0)}}
+	if err := c.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("receipt commitment rejected: %v", err)
+	}
+	c.Executor.ProvenanceBlock = big.NewInt(10)
+	if err := c.CheckConfigForkOrder(); err == nil {
+		t.Error("receipt commitment and provenance accepted along")
+	}
+}
+
 func TestConfigRules(t *testing.T) {
 	c := &ChainConfig{
 		LondonBlock:  new(big.Int),
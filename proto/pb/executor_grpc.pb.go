@@ -19,16 +19,51 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Executor_CommitBlock_FullMethodName = "/pb.Executor/CommitBlock"
-	Executor_VerifyTx_FullMethodName    = "/pb.Executor/VerifyTx"
+	Executor_CommitBlock_FullMethodName       = "/pb.Executor/CommitBlock"
+	Executor_CommitBlockStream_FullMethodName = "/pb.Executor/CommitBlockStream"
+	Executor_VerifyTx_FullMethodName          = "/pb.Executor/VerifyTx"
+	Executor_StakingEvents_FullMethodName     = "/pb.Executor/StakingEvents"
+	Executor_Handshake_FullMethodName         = "/pb.Executor/Handshake"
+	Executor_PrepareBlock_FullMethodName      = "/pb.Executor/PrepareBlock"
+	Executor_ConfirmCommit_FullMethodName     = "/pb.Executor/ConfirmCommit"
+	Executor_Heartbeat_FullMethodName         = "/pb.Executor/Heartbeat"
+	Executor_VerifyBlock_FullMethodName       = "/pb.Executor/VerifyBlock"
+	Executor_StateDiffs_FullMethodName        = "/pb.Executor/StateDiffs"
+	Executor_FinalizeBranch_FullMethodName    = "/pb.Executor/FinalizeBranch"
+	Executor_SetHead_FullMethodName           = "/pb.Executor/SetHead"
+	Executor_Channel_FullMethodName           = "/pb.Executor/Channel"
 )
 
 // ExecutorClient is the client API for Executor service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ExecutorClient interface {
-	CommitBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*Empty, error)
+	// CommitBlock queues a block for execution. The result is empty unless the
+	// block asks to await it, in which case the call returns once the block was
+	// executed.
+	CommitBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*BlockResult, error)
+	// CommitBlockStream delivers a block too large for a single message in
+	// chunks. The first chunk carries the fields of the block along with the
+	// first txs, the following ones only carry further txs or compressedTxs
+	// bytes, which are appended in order.
+	CommitBlockStream(ctx context.Context, opts ...grpc.CallOption) (Executor_CommitBlockStreamClient, error)
 	VerifyTx(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Result, error)
+	StakingEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Executor_StakingEventsClient, error)
+	Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error)
+	PrepareBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*TentativeBlock, error)
+	ConfirmCommit(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*Empty, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	VerifyBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*Verification, error)
+	StateDiffs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Executor_StateDiffsClient, error)
+	FinalizeBranch(ctx context.Context, in *BranchRequest, opts ...grpc.CallOption) (*Empty, error)
+	// SetHead rewinds the chain to the given block, returning the new head. The
+	// txs of the rewound blocks are returned to the pool.
+	SetHead(ctx context.Context, in *SetHeadRequest, opts ...grpc.CallOption) (*Head, error)
+	// Channel is a long-lived stream consensus opens to push the ordered blocks,
+	// processed like CommitBlock, while the executor pushes back the txs it
+	// forwards in the packets otherwise sent via P2P.Send. Blocks failing the
+	// way CommitBlock would have are reported as REFUSED faults.
+	Channel(ctx context.Context, opts ...grpc.CallOption) (Executor_ChannelClient, error)
 }
 
 type executorClient struct {
@@ -39,8 +74,8 @@ func NewExecutorClient(cc grpc.ClientConnInterface) ExecutorClient {
 	return &executorClient{cc}
 }
 
-func (c *executorClient) CommitBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
+func (c *executorClient) CommitBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*BlockResult, error) {
+	out := new(BlockResult)
 	err := c.cc.Invoke(ctx, Executor_CommitBlock_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -48,6 +83,40 @@ func (c *executorClient) CommitBlock(ctx context.Context, in *ExecBlock, opts ..
 	return out, nil
 }
 
+func (c *executorClient) CommitBlockStream(ctx context.Context, opts ...grpc.CallOption) (Executor_CommitBlockStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[0], Executor_CommitBlockStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorCommitBlockStreamClient{stream}
+	return x, nil
+}
+
+type Executor_CommitBlockStreamClient interface {
+	Send(*ExecBlock) error
+	CloseAndRecv() (*Empty, error)
+	grpc.ClientStream
+}
+
+type executorCommitBlockStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorCommitBlockStreamClient) Send(m *ExecBlock) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *executorCommitBlockStreamClient) CloseAndRecv() (*Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *executorClient) VerifyTx(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Result, error) {
 	out := new(Result)
 	err := c.cc.Invoke(ctx, Executor_VerifyTx_FullMethodName, in, out, opts...)
@@ -57,12 +126,194 @@ func (c *executorClient) VerifyTx(ctx context.Context, in *Transaction, opts ...
 	return out, nil
 }
 
+func (c *executorClient) StakingEvents(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Executor_StakingEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[1], Executor_StakingEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorStakingEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Executor_StakingEventsClient interface {
+	Recv() (*StakingEvent, error)
+	grpc.ClientStream
+}
+
+type executorStakingEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorStakingEventsClient) Recv() (*StakingEvent, error) {
+	m := new(StakingEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *executorClient) Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error) {
+	out := new(HandshakeResponse)
+	err := c.cc.Invoke(ctx, Executor_Handshake_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) PrepareBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*TentativeBlock, error) {
+	out := new(TentativeBlock)
+	err := c.cc.Invoke(ctx, Executor_PrepareBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) ConfirmCommit(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Executor_ConfirmCommit_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, Executor_Heartbeat_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) VerifyBlock(ctx context.Context, in *ExecBlock, opts ...grpc.CallOption) (*Verification, error) {
+	out := new(Verification)
+	err := c.cc.Invoke(ctx, Executor_VerifyBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) StateDiffs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Executor_StateDiffsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[2], Executor_StateDiffs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorStateDiffsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Executor_StateDiffsClient interface {
+	Recv() (*StateDiff, error)
+	grpc.ClientStream
+}
+
+type executorStateDiffsClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorStateDiffsClient) Recv() (*StateDiff, error) {
+	m := new(StateDiff)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *executorClient) FinalizeBranch(ctx context.Context, in *BranchRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Executor_FinalizeBranch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) SetHead(ctx context.Context, in *SetHeadRequest, opts ...grpc.CallOption) (*Head, error) {
+	out := new(Head)
+	err := c.cc.Invoke(ctx, Executor_SetHead_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) Channel(ctx context.Context, opts ...grpc.CallOption) (Executor_ChannelClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[3], Executor_Channel_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorChannelClient{stream}
+	return x, nil
+}
+
+type Executor_ChannelClient interface {
+	Send(*ExecBlock) error
+	Recv() (*Packet, error)
+	grpc.ClientStream
+}
+
+type executorChannelClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorChannelClient) Send(m *ExecBlock) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *executorChannelClient) Recv() (*Packet, error) {
+	m := new(Packet)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ExecutorServer is the server API for Executor service.
 // All implementations must embed UnimplementedExecutorServer
 // for forward compatibility
 type ExecutorServer interface {
-	CommitBlock(context.Context, *ExecBlock) (*Empty, error)
+	// CommitBlock queues a block for execution. The result is empty unless the
+	// block asks to await it, in which case the call returns once the block was
+	// executed.
+	CommitBlock(context.Context, *ExecBlock) (*BlockResult, error)
+	// CommitBlockStream delivers a block too large for a single message in
+	// chunks. The first chunk carries the fields of the block along with the
+	// first txs, the following ones only carry further txs or compressedTxs
+	// bytes, which are appended in order.
+	CommitBlockStream(Executor_CommitBlockStreamServer) error
 	VerifyTx(context.Context, *Transaction) (*Result, error)
+	StakingEvents(*Empty, Executor_StakingEventsServer) error
+	Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error)
+	PrepareBlock(context.Context, *ExecBlock) (*TentativeBlock, error)
+	ConfirmCommit(context.Context, *ConfirmRequest) (*Empty, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	VerifyBlock(context.Context, *ExecBlock) (*Verification, error)
+	StateDiffs(*Empty, Executor_StateDiffsServer) error
+	FinalizeBranch(context.Context, *BranchRequest) (*Empty, error)
+	// SetHead rewinds the chain to the given block, returning the new head. The
+	// txs of the rewound blocks are returned to the pool.
+	SetHead(context.Context, *SetHeadRequest) (*Head, error)
+	// Channel is a long-lived stream consensus opens to push the ordered blocks,
+	// processed like CommitBlock, while the executor pushes back the txs it
+	// forwards in the packets otherwise sent via P2P.Send. Blocks failing the
+	// way CommitBlock would have are reported as REFUSED faults.
+	Channel(Executor_ChannelServer) error
 	mustEmbedUnimplementedExecutorServer()
 }
 
@@ -70,12 +321,45 @@ type ExecutorServer interface {
 type UnimplementedExecutorServer struct {
 }
 
-func (UnimplementedExecutorServer) CommitBlock(context.Context, *ExecBlock) (*Empty, error) {
+func (UnimplementedExecutorServer) CommitBlock(context.Context, *ExecBlock) (*BlockResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CommitBlock not implemented")
 }
+func (UnimplementedExecutorServer) CommitBlockStream(Executor_CommitBlockStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CommitBlockStream not implemented")
+}
 func (UnimplementedExecutorServer) VerifyTx(context.Context, *Transaction) (*Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifyTx not implemented")
 }
+func (UnimplementedExecutorServer) StakingEvents(*Empty, Executor_StakingEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StakingEvents not implemented")
+}
+func (UnimplementedExecutorServer) Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
+}
+func (UnimplementedExecutorServer) PrepareBlock(context.Context, *ExecBlock) (*TentativeBlock, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareBlock not implemented")
+}
+func (UnimplementedExecutorServer) ConfirmCommit(context.Context, *ConfirmRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmCommit not implemented")
+}
+func (UnimplementedExecutorServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedExecutorServer) VerifyBlock(context.Context, *ExecBlock) (*Verification, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyBlock not implemented")
+}
+func (UnimplementedExecutorServer) StateDiffs(*Empty, Executor_StateDiffsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StateDiffs not implemented")
+}
+func (UnimplementedExecutorServer) FinalizeBranch(context.Context, *BranchRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinalizeBranch not implemented")
+}
+func (UnimplementedExecutorServer) SetHead(context.Context, *SetHeadRequest) (*Head, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetHead not implemented")
+}
+func (UnimplementedExecutorServer) Channel(Executor_ChannelServer) error {
+	return status.Errorf(codes.Unimplemented, "method Channel not implemented")
+}
 func (UnimplementedExecutorServer) mustEmbedUnimplementedExecutorServer() {}
 
 // UnsafeExecutorServer may be embedded to opt out of forward compatibility for this service.
@@ -107,6 +391,32 @@ func _Executor_CommitBlock_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Executor_CommitBlockStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ExecutorServer).CommitBlockStream(&executorCommitBlockStreamServer{stream})
+}
+
+type Executor_CommitBlockStreamServer interface {
+	SendAndClose(*Empty) error
+	Recv() (*ExecBlock, error)
+	grpc.ServerStream
+}
+
+type executorCommitBlockStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorCommitBlockStreamServer) SendAndClose(m *Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *executorCommitBlockStreamServer) Recv() (*ExecBlock, error) {
+	m := new(ExecBlock)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Executor_VerifyTx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Transaction)
 	if err := dec(in); err != nil {
@@ -125,20 +435,666 @@ func _Executor_VerifyTx_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
-// Executor_ServiceDesc is the grpc.ServiceDesc for Executor service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Executor_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "pb.Executor",
-	HandlerType: (*ExecutorServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CommitBlock",
-			Handler:    _Executor_CommitBlock_Handler,
-		},
-		{
-			MethodName: "VerifyTx",
-			Handler:    _Executor_VerifyTx_Handler,
+func _Executor_StakingEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExecutorServer).StakingEvents(m, &executorStakingEventsServer{stream})
+}
+
+type Executor_StakingEventsServer interface {
+	Send(*StakingEvent) error
+	grpc.ServerStream
+}
+
+type executorStakingEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorStakingEventsServer) Send(m *StakingEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Executor_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_Handshake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).Handshake(ctx, req.(*HandshakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_PrepareBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecBlock)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).PrepareBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_PrepareBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).PrepareBlock(ctx, req.(*ExecBlock))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_ConfirmCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).ConfirmCommit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_ConfirmCommit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).ConfirmCommit(ctx, req.(*ConfirmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_Heartbeat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_VerifyBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecBlock)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).VerifyBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_VerifyBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).VerifyBlock(ctx, req.(*ExecBlock))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_StateDiffs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExecutorServer).StateDiffs(m, &executorStateDiffsServer{stream})
+}
+
+type Executor_StateDiffsServer interface {
+	Send(*StateDiff) error
+	grpc.ServerStream
+}
+
+type executorStateDiffsServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorStateDiffsServer) Send(m *StateDiff) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Executor_FinalizeBranch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BranchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).FinalizeBranch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_FinalizeBranch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).FinalizeBranch(ctx, req.(*BranchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_SetHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetHeadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).SetHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_SetHead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).SetHead(ctx, req.(*SetHeadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_Channel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ExecutorServer).Channel(&executorChannelServer{stream})
+}
+
+type Executor_ChannelServer interface {
+	Send(*Packet) error
+	Recv() (*ExecBlock, error)
+	grpc.ServerStream
+}
+
+type executorChannelServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorChannelServer) Send(m *Packet) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *executorChannelServer) Recv() (*ExecBlock, error) {
+	m := new(ExecBlock)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Executor_ServiceDesc is the grpc.ServiceDesc for Executor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Executor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Executor",
+	HandlerType: (*ExecutorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CommitBlock",
+			Handler:    _Executor_CommitBlock_Handler,
+		},
+		{
+			MethodName: "VerifyTx",
+			Handler:    _Executor_VerifyTx_Handler,
+		},
+		{
+			MethodName: "Handshake",
+			Handler:    _Executor_Handshake_Handler,
+		},
+		{
+			MethodName: "PrepareBlock",
+			Handler:    _Executor_PrepareBlock_Handler,
+		},
+		{
+			MethodName: "ConfirmCommit",
+			Handler:    _Executor_ConfirmCommit_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _Executor_Heartbeat_Handler,
+		},
+		{
+			MethodName: "VerifyBlock",
+			Handler:    _Executor_VerifyBlock_Handler,
+		},
+		{
+			MethodName: "FinalizeBranch",
+			Handler:    _Executor_FinalizeBranch_Handler,
+		},
+		{
+			MethodName: "SetHead",
+			Handler:    _Executor_SetHead_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CommitBlockStream",
+			Handler:       _Executor_CommitBlockStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StakingEvents",
+			Handler:       _Executor_StakingEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StateDiffs",
+			Handler:       _Executor_StateDiffs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Channel",
+			Handler:       _Executor_Channel_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pb/executor.proto",
+}
+
+const (
+	Query_GetBalance_FullMethodName = "/pb.Query/GetBalance"
+	Query_GetNonce_FullMethodName   = "/pb.Query/GetNonce"
+	Query_Call_FullMethodName       = "/pb.Query/Call"
+	Query_GetReceipt_FullMethodName = "/pb.Query/GetReceipt"
+)
+
+// QueryClient is the client API for Query service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QueryClient interface {
+	GetBalance(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	GetNonce(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*NonceResponse, error)
+	Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*CallResponse, error)
+	GetReceipt(ctx context.Context, in *ReceiptRequest, opts ...grpc.CallOption) (*ReceiptResponse, error)
+}
+
+type queryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueryClient(cc grpc.ClientConnInterface) QueryClient {
+	return &queryClient{cc}
+}
+
+func (c *queryClient) GetBalance(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*BalanceResponse, error) {
+	out := new(BalanceResponse)
+	err := c.cc.Invoke(ctx, Query_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetNonce(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*NonceResponse, error) {
+	out := new(NonceResponse)
+	err := c.cc.Invoke(ctx, Query_GetNonce_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*CallResponse, error) {
+	out := new(CallResponse)
+	err := c.cc.Invoke(ctx, Query_Call_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryClient) GetReceipt(ctx context.Context, in *ReceiptRequest, opts ...grpc.CallOption) (*ReceiptResponse, error) {
+	out := new(ReceiptResponse)
+	err := c.cc.Invoke(ctx, Query_GetReceipt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryServer is the server API for Query service.
+// All implementations must embed UnimplementedQueryServer
+// for forward compatibility
+type QueryServer interface {
+	GetBalance(context.Context, *AccountRequest) (*BalanceResponse, error)
+	GetNonce(context.Context, *AccountRequest) (*NonceResponse, error)
+	Call(context.Context, *CallRequest) (*CallResponse, error)
+	GetReceipt(context.Context, *ReceiptRequest) (*ReceiptResponse, error)
+	mustEmbedUnimplementedQueryServer()
+}
+
+// UnimplementedQueryServer must be embedded to have forward compatible implementations.
+type UnimplementedQueryServer struct {
+}
+
+func (UnimplementedQueryServer) GetBalance(context.Context, *AccountRequest) (*BalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedQueryServer) GetNonce(context.Context, *AccountRequest) (*NonceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNonce not implemented")
+}
+func (UnimplementedQueryServer) Call(context.Context, *CallRequest) (*CallResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Call not implemented")
+}
+func (UnimplementedQueryServer) GetReceipt(context.Context, *ReceiptRequest) (*ReceiptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedQueryServer) mustEmbedUnimplementedQueryServer() {}
+
+// UnsafeQueryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QueryServer will
+// result in compilation errors.
+type UnsafeQueryServer interface {
+	mustEmbedUnimplementedQueryServer()
+}
+
+func RegisterQueryServer(s grpc.ServiceRegistrar, srv QueryServer) {
+	s.RegisterService(&Query_ServiceDesc, srv)
+}
+
+func _Query_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetBalance(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetNonce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetNonce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetNonce_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetNonce(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_Call_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).Call(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_Call_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).Call(ctx, req.(*CallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Query_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Query_GetReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServer).GetReceipt(ctx, req.(*ReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Query_ServiceDesc is the grpc.ServiceDesc for Query service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Query_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Query",
+	HandlerType: (*QueryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBalance",
+			Handler:    _Query_GetBalance_Handler,
+		},
+		{
+			MethodName: "GetNonce",
+			Handler:    _Query_GetNonce_Handler,
+		},
+		{
+			MethodName: "Call",
+			Handler:    _Query_Call_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _Query_GetReceipt_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pb/executor.proto",
+}
+
+const (
+	Consensus_ReportFault_FullMethodName       = "/pb.Consensus/ReportFault"
+	Consensus_NotifyHead_FullMethodName        = "/pb.Consensus/NotifyHead"
+	Consensus_ReportInterrupt_FullMethodName   = "/pb.Consensus/ReportInterrupt"
+	Consensus_ReportBlockResult_FullMethodName = "/pb.Consensus/ReportBlockResult"
+)
+
+// ConsensusClient is the client API for Consensus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ConsensusClient interface {
+	ReportFault(ctx context.Context, in *Fault, opts ...grpc.CallOption) (*Empty, error)
+	NotifyHead(ctx context.Context, in *Head, opts ...grpc.CallOption) (*Empty, error)
+	ReportInterrupt(ctx context.Context, in *Interrupt, opts ...grpc.CallOption) (*Empty, error)
+	ReportBlockResult(ctx context.Context, in *ExecutionResult, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type consensusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConsensusClient(cc grpc.ClientConnInterface) ConsensusClient {
+	return &consensusClient{cc}
+}
+
+func (c *consensusClient) ReportFault(ctx context.Context, in *Fault, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Consensus_ReportFault_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusClient) NotifyHead(ctx context.Context, in *Head, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Consensus_NotifyHead_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusClient) ReportInterrupt(ctx context.Context, in *Interrupt, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Consensus_ReportInterrupt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *consensusClient) ReportBlockResult(ctx context.Context, in *ExecutionResult, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Consensus_ReportBlockResult_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConsensusServer is the server API for Consensus service.
+// All implementations must embed UnimplementedConsensusServer
+// for forward compatibility
+type ConsensusServer interface {
+	ReportFault(context.Context, *Fault) (*Empty, error)
+	NotifyHead(context.Context, *Head) (*Empty, error)
+	ReportInterrupt(context.Context, *Interrupt) (*Empty, error)
+	ReportBlockResult(context.Context, *ExecutionResult) (*Empty, error)
+	mustEmbedUnimplementedConsensusServer()
+}
+
+// UnimplementedConsensusServer must be embedded to have forward compatible implementations.
+type UnimplementedConsensusServer struct {
+}
+
+func (UnimplementedConsensusServer) ReportFault(context.Context, *Fault) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportFault not implemented")
+}
+func (UnimplementedConsensusServer) NotifyHead(context.Context, *Head) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NotifyHead not implemented")
+}
+func (UnimplementedConsensusServer) ReportInterrupt(context.Context, *Interrupt) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportInterrupt not implemented")
+}
+func (UnimplementedConsensusServer) ReportBlockResult(context.Context, *ExecutionResult) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportBlockResult not implemented")
+}
+func (UnimplementedConsensusServer) mustEmbedUnimplementedConsensusServer() {}
+
+// UnsafeConsensusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConsensusServer will
+// result in compilation errors.
+type UnsafeConsensusServer interface {
+	mustEmbedUnimplementedConsensusServer()
+}
+
+func RegisterConsensusServer(s grpc.ServiceRegistrar, srv ConsensusServer) {
+	s.RegisterService(&Consensus_ServiceDesc, srv)
+}
+
+func _Consensus_ReportFault_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Fault)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServer).ReportFault(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Consensus_ReportFault_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServer).ReportFault(ctx, req.(*Fault))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Consensus_NotifyHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Head)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServer).NotifyHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Consensus_NotifyHead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServer).NotifyHead(ctx, req.(*Head))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Consensus_ReportInterrupt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Interrupt)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServer).ReportInterrupt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Consensus_ReportInterrupt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServer).ReportInterrupt(ctx, req.(*Interrupt))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Consensus_ReportBlockResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecutionResult)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConsensusServer).ReportBlockResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Consensus_ReportBlockResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConsensusServer).ReportBlockResult(ctx, req.(*ExecutionResult))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Consensus_ServiceDesc is the grpc.ServiceDesc for Consensus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Consensus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Consensus",
+	HandlerType: (*ConsensusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportFault",
+			Handler:    _Consensus_ReportFault_Handler,
+		},
+		{
+			MethodName: "NotifyHead",
+			Handler:    _Consensus_NotifyHead_Handler,
+		},
+		{
+			MethodName: "ReportInterrupt",
+			Handler:    _Consensus_ReportInterrupt_Handler,
+		},
+		{
+			MethodName: "ReportBlockResult",
+			Handler:    _Consensus_ReportBlockResult_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
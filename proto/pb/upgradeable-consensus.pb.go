@@ -69,10 +69,11 @@ func (PacketType) EnumDescriptor() ([]byte, []int) {
 type TransactionType int32
 
 const (
-	TransactionType_NORMAL   TransactionType = 0
-	TransactionType_UPGRADE  TransactionType = 1
-	TransactionType_TIMEVOTE TransactionType = 2
-	TransactionType_LOCK     TransactionType = 3
+	TransactionType_NORMAL         TransactionType = 0
+	TransactionType_UPGRADE        TransactionType = 1
+	TransactionType_TIMEVOTE       TransactionType = 2
+	TransactionType_LOCK           TransactionType = 3
+	TransactionType_BRIDGE_DEPOSIT TransactionType = 4 // funds bridged from another chain, minted once consensus attested to them
 )
 
 // Enum value maps for TransactionType.
@@ -82,12 +83,14 @@ var (
 		1: "UPGRADE",
 		2: "TIMEVOTE",
 		3: "LOCK",
+		4: "BRIDGE_DEPOSIT",
 	}
 	TransactionType_value = map[string]int32{
-		"NORMAL":   0,
-		"UPGRADE":  1,
-		"TIMEVOTE": 2,
-		"LOCK":     3,
+		"NORMAL":         0,
+		"UPGRADE":        1,
+		"TIMEVOTE":       2,
+		"LOCK":           3,
+		"BRIDGE_DEPOSIT": 4,
 	}
 )
 
@@ -296,15 +299,16 @@ var file_pb_upgradeable_consensus_proto_rawDesc = []byte{
 	0x05, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x44, 0x2a, 0x2d, 0x0a, 0x0a, 0x50, 0x61,
 	0x63, 0x6b, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0d, 0x0a, 0x09, 0x50, 0x32, 0x50, 0x50,
 	0x41, 0x43, 0x4b, 0x45, 0x54, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x4c, 0x49, 0x45, 0x4e,
-	0x54, 0x50, 0x41, 0x43, 0x4b, 0x45, 0x54, 0x10, 0x01, 0x2a, 0x42, 0x0a, 0x0f, 0x54, 0x72, 0x61,
+	0x54, 0x50, 0x41, 0x43, 0x4b, 0x45, 0x54, 0x10, 0x01, 0x2a, 0x56, 0x0a, 0x0f, 0x54, 0x72, 0x61,
 	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0a, 0x0a, 0x06,
 	0x4e, 0x4f, 0x52, 0x4d, 0x41, 0x4c, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x50, 0x47, 0x52,
 	0x41, 0x44, 0x45, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x54, 0x49, 0x4d, 0x45, 0x56, 0x4f, 0x54,
-	0x45, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x43, 0x4b, 0x10, 0x03, 0x32, 0x26, 0x0a,
-	0x03, 0x50, 0x32, 0x50, 0x12, 0x1f, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x0a, 0x2e, 0x70,
-	0x62, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x1a, 0x09, 0x2e, 0x70, 0x62, 0x2e, 0x45, 0x6d,
-	0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x06, 0x5a, 0x04, 0x2e, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x45, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x43, 0x4b, 0x10, 0x03, 0x12, 0x12, 0x0a,
+	0x0e, 0x42, 0x52, 0x49, 0x44, 0x47, 0x45, 0x5f, 0x44, 0x45, 0x50, 0x4f, 0x53, 0x49, 0x54, 0x10,
+	0x04, 0x32, 0x26, 0x0a, 0x03, 0x50, 0x32, 0x50, 0x12, 0x1f, 0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64,
+	0x12, 0x0a, 0x2e, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x1a, 0x09, 0x2e, 0x70,
+	0x62, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x06, 0x5a, 0x04, 0x2e, 0x2f, 0x70,
+	0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
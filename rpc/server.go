@@ -209,6 +209,7 @@ type PeerInfo struct {
 		UserAgent string
 		Origin    string
 		Host      string
+		APIKey    string // X-Api-Key header identifying the integrator, if any
 	}
 }
 